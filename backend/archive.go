@@ -0,0 +1,260 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/mszalewicz/frosk/helpers"
+	"golang.org/x/crypto/argon2"
+)
+
+// Archive is self-contained encrypted copy of all password entries, used for backups and moving entries between
+// vaults. It does not depend on master password or user secret key of the vault it was exported from:
+//
+//	magic "FROSKARC" | version uint16 | Argon2id time uint32 | memory uint32 (KB) | threads uint8 |
+//	salt (16 bytes) | initial vector (12 bytes) | AES-256-GCM sealed JSON payload
+//
+// Integers are big endian. Key is derived from archive passphrase with Argon2id and whole header is additional data
+// of GCM, so tampering with key derivation parameters fails authentication like tampering with payload.
+
+const (
+	archiveMagic = "FROSKARC"
+
+	// Version of archive layout and payload written by this build - older versions stay readable
+	ArchiveVersion = 1
+)
+
+var InvalidArchive = errors.New("File is not a frosk archive.")
+var UnsupportedArchiveVersion = errors.New("Archive was created by newer version of frosk.")
+var ArchivePassphraseIncorrect = errors.New("Archive passphrase is incorrect or archive is damaged.")
+var EmptyArchivePassphrase = errors.New("Archive passphrase can not be empty.")
+
+type archiveHeader struct {
+	Magic         [8]byte
+	Version       uint16
+	Time          uint32
+	Memory        uint32
+	Threads       uint8
+	Salt          [16]byte
+	InitialVector [12]byte
+}
+
+type archivePayload struct {
+	CreatedAt string         `json:"created_at"`
+	Entries   []archiveEntry `json:"entries"`
+}
+
+// Entry of payload - kept apart from PasswordEntry, so archive format does not change with it by accident
+type archiveEntry struct {
+	ServiceName  string        `json:"service_name"`
+	Username     string        `json:"username"`
+	Password     string        `json:"password"`
+	URL          string        `json:"url,omitempty"`
+	Notes        string        `json:"notes,omitempty"`
+	CustomFields []CustomField `json:"custom_fields,omitempty"`
+	TOTP         string        `json:"totp,omitempty"`
+	Tags         []string      `json:"tags,omitempty"`
+}
+
+// Decrypted content of archive
+type Archive struct {
+	Version         int
+	CreatedAt       string // local time of export, ISO 8601
+	PasswordEntries []PasswordEntry
+}
+
+// Writes encrypted archive of all password entries and returns number of archived entries. Key of archive is
+// derived with key derivation parameters of the vault, so opening archive takes about as long as unlocking vault.
+func (session *Session) WriteArchive(writer io.Writer, passphrase string) (int, error) {
+	if len(passphrase) == 0 {
+		return 0, EmptyArchivePassphrase
+	}
+
+	config, err := session.backend.GetArgonConfig()
+
+	if err != nil {
+		return 0, err
+	}
+
+	passwordEntries, err := session.ExportPasswordEntries()
+
+	if err != nil {
+		return 0, err
+	}
+
+	payload := archivePayload{CreatedAt: helpers.TimeTo8601String(time.Now()), Entries: make([]archiveEntry, 0, len(passwordEntries))}
+
+	for _, passwordEntry := range passwordEntries {
+		payload.Entries = append(payload.Entries, archiveEntry{
+			ServiceName:  passwordEntry.ServiceName,
+			Username:     passwordEntry.Username,
+			Password:     passwordEntry.Password,
+			URL:          passwordEntry.URL,
+			Notes:        passwordEntry.Notes,
+			CustomFields: passwordEntry.CustomFields,
+			TOTP:         passwordEntry.TOTP,
+			Tags:         passwordEntry.Tags,
+		})
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during encoding archive payload: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	defer clear(payloadJSON)
+
+	header := archiveHeader{
+		Version: ArchiveVersion,
+		Time:    config.time,
+		Memory:  config.memory,
+		Threads: config.threads,
+	}
+	copy(header.Magic[:], archiveMagic)
+
+	_, err = rand.Read(header.Salt[:])
+
+	if err == nil {
+		_, err = rand.Read(header.InitialVector[:])
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Can't create random salt and initial vector of archive: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	var headerBytes bytes.Buffer
+	err = binary.Write(&headerBytes, binary.BigEndian, header)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during encoding archive header: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	key := argon2.IDKey([]byte(passphrase), header.Salt[:], config.time, config.memory, config.threads, 32)
+	gcm, err := InitGCM(key)
+	clear(key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	sealed := gcm.Seal(nil, header.InitialVector[:], payloadJSON, headerBytes.Bytes())
+
+	_, err = writer.Write(append(headerBytes.Bytes(), sealed...))
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during writing archive: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	slog.Info("Exported password entries to archive.", "entries", len(payload.Entries), "kdf", config.String())
+
+	return len(payload.Entries), nil
+}
+
+// Reads and decrypts archive written by WriteArchive
+func ReadArchive(reader io.Reader, passphrase string) (Archive, error) {
+	if len(passphrase) == 0 {
+		return Archive{}, EmptyArchivePassphrase
+	}
+
+	data, err := io.ReadAll(reader)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading archive: %w", err)
+		slog.Error(errWrapped.Error())
+		return Archive{}, errWrapped
+	}
+
+	var header archiveHeader
+	headerLength := binary.Size(header)
+
+	if len(data) < headerLength || string(data[:len(archiveMagic)]) != archiveMagic {
+		return Archive{}, InvalidArchive
+	}
+
+	err = binary.Read(bytes.NewReader(data[:headerLength]), binary.BigEndian, &header)
+
+	if err != nil {
+		return Archive{}, fmt.Errorf("%w %v", InvalidArchive, err)
+	}
+
+	if header.Version == 0 {
+		return Archive{}, InvalidArchive
+	}
+
+	if header.Version > ArchiveVersion {
+		return Archive{}, fmt.Errorf("%w Archive version %d, supported up to %d.", UnsupportedArchiveVersion, header.Version, ArchiveVersion)
+	}
+
+	config := ArgonConfig{time: header.Time, memory: header.Memory, threads: header.Threads}
+
+	// Archive may come from another machine, memory cost taken from its header is capped by validate
+	if config.validate() != nil {
+		return Archive{}, fmt.Errorf("%w Key derivation parameters are out of allowed range: %s.", InvalidArchive, config)
+	}
+
+	key := argon2.IDKey([]byte(passphrase), header.Salt[:], config.time, config.memory, config.threads, 32)
+	gcm, err := InitGCM(key)
+	clear(key)
+
+	if err != nil {
+		return Archive{}, err
+	}
+
+	payloadJSON, err := gcm.Open(nil, header.InitialVector[:], data[headerLength:], data[:headerLength])
+
+	if err != nil {
+		return Archive{}, ArchivePassphraseIncorrect
+	}
+
+	defer clear(payloadJSON)
+
+	var payload archivePayload
+	err = json.Unmarshal(payloadJSON, &payload)
+
+	if err != nil {
+		return Archive{}, fmt.Errorf("%w %v", InvalidArchive, err)
+	}
+
+	archive := Archive{
+		Version:         int(header.Version),
+		CreatedAt:       payload.CreatedAt,
+		PasswordEntries: make([]PasswordEntry, 0, len(payload.Entries)),
+	}
+
+	for _, entry := range payload.Entries {
+		customFields := entry.CustomFields
+
+		if customFields == nil {
+			customFields = []CustomField{}
+		}
+
+		archive.PasswordEntries = append(archive.PasswordEntries, PasswordEntry{
+			ServiceName:  entry.ServiceName,
+			Username:     entry.Username,
+			Password:     entry.Password,
+			URL:          entry.URL,
+			Notes:        entry.Notes,
+			CustomFields: customFields,
+			TOTP:         entry.TOTP,
+			Tags:         entry.Tags,
+		})
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,253 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testArchivePassphrase = "archive passphrase"
+
+// Offsets of header fields, see layout in archive.go
+const (
+	archiveVersionOffset       = 8
+	archiveTimeOffset          = 10
+	archiveMemoryOffset        = 14
+	archiveThreadsOffset       = 18
+	archiveSaltOffset          = 19
+	archiveInitialVectorOffset = 35
+)
+
+// Returns vault with two tagged entries, one of them with all optional fields
+func newArchivedTestVault(t *testing.T) *Session {
+	t.Helper()
+
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	passwordEntries := []PasswordEntry{
+		{
+			ServiceName: "github", Username: "octocat", Password: "hunter2", URL: "https://github.com", Notes: "first line\nsecond line",
+			TOTP: "JBSWY3DPEHPK3PXP", Tags: []string{"code", "work"}, CustomFields: []CustomField{{Name: "PIN", Value: "1234", Secret: true}},
+		},
+		{ServiceName: "gitlab", Username: "user", Password: "password of gitlab", Tags: []string{"work"}, CustomFields: []CustomField{}},
+	}
+
+	for _, passwordEntry := range passwordEntries {
+		if err := session.EncryptPasswordEntry(passwordEntry); err != nil {
+			t.Fatalf("EncryptPasswordEntry %s: %v", passwordEntry.ServiceName, err)
+		}
+	}
+
+	return session
+}
+
+func writeTestArchive(t *testing.T, session *Session) []byte {
+	t.Helper()
+
+	var archive bytes.Buffer
+	count, err := session.WriteArchive(&archive, testArchivePassphrase)
+
+	if err != nil {
+		t.Fatalf("WriteArchive: %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("WriteArchive() = %d entries, want 2", count)
+	}
+
+	return archive.Bytes()
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	session := newArchivedTestVault(t)
+	data := writeTestArchive(t, session)
+
+	archive, err := ReadArchive(bytes.NewReader(data), testArchivePassphrase)
+
+	if err != nil {
+		t.Fatalf("ReadArchive: %v", err)
+	}
+
+	want, err := session.ExportPasswordEntries()
+
+	if err != nil {
+		t.Fatalf("ExportPasswordEntries: %v", err)
+	}
+
+	if archive.Version != ArchiveVersion || archive.CreatedAt == "" {
+		t.Errorf("ReadArchive() version and time = %d, %q, want %d and time of export", archive.Version, archive.CreatedAt, ArchiveVersion)
+	}
+
+	if !reflect.DeepEqual(archive.PasswordEntries, want) {
+		t.Fatalf("ReadArchive() entries = %+v, want %+v", archive.PasswordEntries, want)
+	}
+
+	// Every archive gets its own salt and initial vector
+	if bytes.Equal(writeTestArchive(t, session)[:archiveInitialVectorOffset+12], data[:archiveInitialVectorOffset+12]) {
+		t.Fatalf("WriteArchive() wrote the same header twice")
+	}
+}
+
+func TestReadArchiveRejectsWrongPassphrase(t *testing.T) {
+	data := writeTestArchive(t, newArchivedTestVault(t))
+
+	if _, err := ReadArchive(bytes.NewReader(data), "wrong "+testArchivePassphrase); !errors.Is(err, ArchivePassphraseIncorrect) {
+		t.Fatalf("ReadArchive() with wrong passphrase = %v, want ArchivePassphraseIncorrect", err)
+	}
+
+	if _, err := ReadArchive(bytes.NewReader(data), ""); !errors.Is(err, EmptyArchivePassphrase) {
+		t.Fatalf("ReadArchive() with empty passphrase = %v, want EmptyArchivePassphrase", err)
+	}
+}
+
+func TestReadArchiveRejectsTamperedHeader(t *testing.T) {
+	data := writeTestArchive(t, newArchivedTestVault(t))
+
+	// Changed values stay within allowed range, so only authentication can catch them
+	tests := []struct {
+		name   string
+		offset int
+		flip   byte
+	}{
+		{"time", archiveTimeOffset + 3, 0x02},
+		{"memory", archiveMemoryOffset + 3, 0x01},
+		{"threads", archiveThreadsOffset, 0x02},
+		{"salt", archiveSaltOffset, 0x01},
+		{"initial vector", archiveInitialVectorOffset, 0x01},
+	}
+
+	for _, test := range tests {
+		tampered := bytes.Clone(data)
+		tampered[test.offset] ^= test.flip
+
+		if _, err := ReadArchive(bytes.NewReader(tampered), testArchivePassphrase); !errors.Is(err, ArchivePassphraseIncorrect) {
+			t.Errorf("ReadArchive() with tampered %s = %v, want ArchivePassphraseIncorrect", test.name, err)
+		}
+	}
+
+	tampered := bytes.Clone(data)
+	tampered[len(tampered)-1] ^= 0x01
+
+	if _, err := ReadArchive(bytes.NewReader(tampered), testArchivePassphrase); !errors.Is(err, ArchivePassphraseIncorrect) {
+		t.Errorf("ReadArchive() with tampered payload = %v, want ArchivePassphraseIncorrect", err)
+	}
+}
+
+func TestReadArchiveRejectsTruncatedFile(t *testing.T) {
+	data := writeTestArchive(t, newArchivedTestVault(t))
+
+	tests := []struct {
+		length int
+		want   error
+	}{
+		{0, InvalidArchive},
+		{len(archiveMagic), InvalidArchive},
+		{archiveInitialVectorOffset, InvalidArchive},
+		{archiveInitialVectorOffset + 12, ArchivePassphraseIncorrect},
+		{len(data) - 1, ArchivePassphraseIncorrect},
+	}
+
+	for _, test := range tests {
+		if _, err := ReadArchive(bytes.NewReader(data[:test.length]), testArchivePassphrase); !errors.Is(err, test.want) {
+			t.Errorf("ReadArchive() of first %d bytes = %v, want %v", test.length, err, test.want)
+		}
+	}
+
+	notArchive := bytes.Clone(data)
+	notArchive[0] = 'X'
+
+	if _, err := ReadArchive(bytes.NewReader(notArchive), testArchivePassphrase); !errors.Is(err, InvalidArchive) {
+		t.Errorf("ReadArchive() without magic = %v, want InvalidArchive", err)
+	}
+}
+
+func TestReadArchiveRejectsOutOfRangeHeader(t *testing.T) {
+	data := writeTestArchive(t, newArchivedTestVault(t))
+
+	tests := []struct {
+		name   string
+		change func(header []byte)
+		want   error
+	}{
+		{"zero time", func(header []byte) { binary.BigEndian.PutUint32(header[archiveTimeOffset:], 0) }, InvalidArchive},
+		{"too many passes", func(header []byte) { binary.BigEndian.PutUint32(header[archiveTimeOffset:], maxArgonTime+1) }, InvalidArchive},
+		{"too little memory", func(header []byte) { binary.BigEndian.PutUint32(header[archiveMemoryOffset:], minArgonMemory-1) }, InvalidArchive},
+		{"too much memory", func(header []byte) { binary.BigEndian.PutUint32(header[archiveMemoryOffset:], 0xffffffff) }, InvalidArchive},
+		{"zero threads", func(header []byte) { header[archiveThreadsOffset] = 0 }, InvalidArchive},
+		{"zero version", func(header []byte) { binary.BigEndian.PutUint16(header[archiveVersionOffset:], 0) }, InvalidArchive},
+		{"newer version", func(header []byte) { binary.BigEndian.PutUint16(header[archiveVersionOffset:], ArchiveVersion+1) }, UnsupportedArchiveVersion},
+	}
+
+	for _, test := range tests {
+		changed := bytes.Clone(data)
+		test.change(changed)
+
+		if _, err := ReadArchive(bytes.NewReader(changed), testArchivePassphrase); !errors.Is(err, test.want) {
+			t.Errorf("ReadArchive() with %s = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestRestoreArchiveWithCollisionPolicies(t *testing.T) {
+	archive, err := ReadArchive(bytes.NewReader(writeTestArchive(t, newArchivedTestVault(t))), testArchivePassphrase)
+
+	if err != nil {
+		t.Fatalf("ReadArchive: %v", err)
+	}
+
+	tests := []struct {
+		policy       CollisionPolicy
+		want         ImportSummary
+		wantPassword string // of "github" after restore
+	}{
+		{CollisionSkip, ImportSummary{Imported: 1, Skipped: 1}, "password of github"},
+		{CollisionOverwrite, ImportSummary{Imported: 1, Overwritten: 1}, "hunter2"},
+		{CollisionRename, ImportSummary{Imported: 1, Renamed: 1}, "password of github"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			// Existing vault with other password under the same service name
+			backend, _ := newTestVault(t, nil)
+			session := unlockTestVault(t, backend)
+			addTestEntry(t, session, "github")
+
+			summary, err := session.ImportPasswordEntries(archive.PasswordEntries, test.policy)
+
+			if err != nil {
+				t.Fatalf("ImportPasswordEntries: %v", err)
+			}
+
+			summary.Entries = nil
+
+			if !reflect.DeepEqual(summary, test.want) {
+				t.Fatalf("ImportPasswordEntries() = %+v, want %+v", summary, test.want)
+			}
+
+			if entry, err := session.DecryptPasswordEntry("github"); err != nil || entry.Password != test.wantPassword {
+				t.Fatalf("DecryptPasswordEntry() = %+v, %v, want password %q", entry, err, test.wantPassword)
+			}
+
+			if entry, err := session.DecryptPasswordEntry("gitlab"); err != nil || !reflect.DeepEqual(entry.Tags, []string{"work"}) {
+				t.Fatalf("DecryptPasswordEntry() of restored entry = %+v, %v, want its tags", entry, err)
+			}
+		})
+	}
+
+	// New vault gets every entry as it was archived
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	if _, err := session.ImportPasswordEntries(archive.PasswordEntries, CollisionSkip); err != nil {
+		t.Fatalf("ImportPasswordEntries into new vault: %v", err)
+	}
+
+	restored, err := session.ExportPasswordEntries()
+
+	if err != nil || !reflect.DeepEqual(restored, archive.PasswordEntries) {
+		t.Fatalf("ExportPasswordEntries() after restore = %+v, %v, want %+v", restored, err, archive.PasswordEntries)
+	}
+}
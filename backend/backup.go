@@ -0,0 +1,176 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var DatabaseFileDamaged = errors.New("Database file failed integrity check.")
+
+// Writes consistent copy of the vault into new file. Copy is compacted and can be opened like vault itself.
+func (backend *Backend) BackupInto(path string) error {
+	_, err := backend.DB.Exec("VACUUM INTO ?", path)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during writing copy of vault to %s: %w", path, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Opens database file read only
+func openReadOnly(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not open database file %s: %w", path, err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	return db, nil
+}
+
+// Runs sqlite integrity check of vault copy written by BackupInto and returns number of its password entries
+func InspectBackup(path string) (int, error) {
+	db, err := openReadOnly(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer db.Close()
+
+	var result string
+	err = db.QueryRow("PRAGMA integrity_check").Scan(&result)
+
+	if err != nil {
+		return 0, fmt.Errorf("%w %v", DatabaseFileDamaged, err)
+	}
+
+	if result != "ok" {
+		slog.Error("Integrity check of database file failed.", "path", path, "result", result)
+		return 0, fmt.Errorf("%w %s", DatabaseFileDamaged, result)
+	}
+
+	var passwordEntries int
+	err = db.QueryRow("SELECT COUNT(*) FROM passwords").Scan(&passwordEntries)
+
+	if err != nil {
+		return 0, fmt.Errorf("%w %v", DatabaseFileDamaged, err)
+	}
+
+	return passwordEntries, nil
+}
+
+// Returns whether vault copy written by BackupInto holds service names in plain text, as stored by versions before
+// schema migration 7 until first unlock
+func HoldsPlainTextServiceNames(path string) (bool, error) {
+	db, err := openReadOnly(path)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer db.Close()
+
+	var indexed bool
+	err = db.QueryRow("SELECT COUNT(*) > 0 FROM pragma_table_info('passwords') WHERE name = 'service_name_index'").Scan(&indexed)
+
+	query := "SELECT COUNT(*) FROM passwords WHERE service_name_index IS NULL"
+
+	if err == nil && !indexed {
+		query = "SELECT COUNT(*) FROM passwords"
+	}
+
+	var plainServiceNames int
+
+	if err == nil {
+		err = db.QueryRow(query).Scan(&plainServiceNames)
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not read service names of database file %s: %w", path, err)
+		slog.Error(errWrapped.Error())
+		return false, errWrapped
+	}
+
+	return plainServiceNames > 0, nil
+}
+
+// Replaces content of the vault with vault copy using sqlite online backup API, so open connections stay usable.
+// Copy written by older version is migrated to the latest schema afterwards.
+func (backend *Backend) RestoreFrom(path string) error {
+	source, err := openReadOnly(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer source.Close()
+
+	ctx := context.Background()
+
+	sourceConnection, err := source.Conn(ctx)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not connect to restored database file: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer sourceConnection.Close()
+
+	vaultConnection, err := backend.DB.Conn(ctx)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not connect to vault for restore: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer vaultConnection.Close()
+
+	err = vaultConnection.Raw(func(vaultDriverConnection any) error {
+		return sourceConnection.Raw(func(sourceDriverConnection any) error {
+			vaultSQLite, vaultOK := vaultDriverConnection.(*sqlite3.SQLiteConn)
+			sourceSQLite, sourceOK := sourceDriverConnection.(*sqlite3.SQLiteConn)
+
+			if !vaultOK || !sourceOK {
+				return errors.New("database connection is not sqlite connection")
+			}
+
+			backup, err := vaultSQLite.Backup("main", sourceSQLite, "main")
+
+			if err != nil {
+				return err
+			}
+
+			_, err = backup.Step(-1)
+			finishErr := backup.Finish()
+
+			if err != nil {
+				return err
+			}
+
+			return finishErr
+		})
+	})
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during restoring vault from %s: %w", path, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	slog.Info("Restored vault from backup.", "path", path)
+
+	return backend.Migrate()
+}
@@ -0,0 +1,166 @@
+package backend
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupIntoWritesOpenableCopy(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+	addTestEntry(t, session, "gitlab")
+
+	backupPath := filepath.Join(t.TempDir(), "backup.sqlite")
+	err := backend.BackupInto(backupPath)
+
+	if err != nil {
+		t.Fatalf("BackupInto: %v", err)
+	}
+
+	count, err := InspectBackup(backupPath)
+
+	if err != nil || count != 2 {
+		t.Fatalf("InspectBackup() = %d, %v, want 2 entries", count, err)
+	}
+
+	copySession := unlockTestVault(t, openTestBackend(t, backupPath, nil))
+
+	if entry, err := copySession.DecryptPasswordEntry("github"); err != nil || entry.Password != "password of github" {
+		t.Fatalf("DecryptPasswordEntry() in copy = %+v, %v, want entry", entry, err)
+	}
+
+	// Existing file is not overwritten
+	if err := backend.BackupInto(backupPath); err == nil {
+		t.Fatalf("BackupInto() over existing file = nil, want error")
+	}
+}
+
+func TestInspectBackupRejectsDamagedFile(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	addTestEntry(t, unlockTestVault(t, backend), "github")
+
+	directory := t.TempDir()
+	backupPath := filepath.Join(directory, "backup.sqlite")
+
+	if err := backend.BackupInto(backupPath); err != nil {
+		t.Fatalf("BackupInto: %v", err)
+	}
+
+	data, err := os.ReadFile(backupPath)
+
+	if err != nil {
+		t.Fatalf("reading backup: %v", err)
+	}
+
+	// Second page holds first table, its header is overwritten
+	damaged := filepath.Join(directory, "damaged.sqlite")
+	copy(data[4096:], make([]byte, 64))
+
+	notDatabase := filepath.Join(directory, "text.sqlite")
+
+	for path, content := range map[string][]byte{damaged: data, notDatabase: []byte("not a database")} {
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+
+		if _, err := InspectBackup(path); !errors.Is(err, DatabaseFileDamaged) {
+			t.Errorf("InspectBackup(%s) = %v, want DatabaseFileDamaged", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestRestoreFromReplacesContent(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+
+	backupPath := filepath.Join(t.TempDir(), "backup.sqlite")
+
+	if err := backend.BackupInto(backupPath); err != nil {
+		t.Fatalf("BackupInto: %v", err)
+	}
+
+	addTestEntry(t, session, "gitlab")
+
+	err := backend.RestoreFrom(backupPath)
+
+	if err != nil {
+		t.Fatalf("RestoreFrom: %v", err)
+	}
+
+	// Open connection sees restored content
+	serviceNames, err := unlockTestVault(t, backend).GetPasswordEntriesList()
+
+	if err != nil || len(serviceNames) != 1 || serviceNames[0] != "github" {
+		t.Fatalf("GetPasswordEntriesList() after restore = %q, %v, want [github]", serviceNames, err)
+	}
+
+	if err := unlockTestVault(t, backend).Integrity(); err != nil {
+		t.Fatalf("Integrity() after restore = %v, want nil", err)
+	}
+
+	if err := backend.RestoreFrom(filepath.Join(t.TempDir(), "missing.sqlite")); err == nil {
+		t.Fatalf("RestoreFrom() of missing file = nil, want error")
+	}
+}
+
+func TestRestoreFromMigratesOlderCopy(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	baselinePath := newBaselineVault(t, "github")
+
+	err := backend.RestoreFrom(baselinePath)
+
+	if err != nil {
+		t.Fatalf("RestoreFrom: %v", err)
+	}
+
+	version, err := backend.SchemaVersion()
+
+	if err != nil || version != len(migrations) {
+		t.Fatalf("SchemaVersion() after restore = %d, %v, want %d", version, err, len(migrations))
+	}
+}
+
+func TestHoldsPlainTextServiceNamesUntilUnlock(t *testing.T) {
+	baselinePath := newBaselineVault(t, "github")
+
+	if holds, err := HoldsPlainTextServiceNames(baselinePath); err != nil || !holds {
+		t.Fatalf("HoldsPlainTextServiceNames() of baseline vault = %v, %v, want true", holds, err)
+	}
+
+	directory := t.TempDir()
+	backend := migrateBaselineVault(t, baselinePath)
+
+	// Migrated schema keeps plain text service names until first unlock
+	migratedPath := filepath.Join(directory, "migrated.sqlite")
+
+	if err := backend.BackupInto(migratedPath); err != nil {
+		t.Fatalf("BackupInto: %v", err)
+	}
+
+	if holds, err := HoldsPlainTextServiceNames(migratedPath); err != nil || !holds {
+		t.Fatalf("HoldsPlainTextServiceNames() of migrated vault = %v, %v, want true", holds, err)
+	}
+
+	encrypted := 0
+	backend.OnServiceNamesEncrypted(func() { encrypted++ })
+	unlockTestVault(t, backend)
+	unlockTestVault(t, backend)
+
+	if encrypted != 1 {
+		t.Fatalf("OnServiceNamesEncrypted called %d times, want once", encrypted)
+	}
+
+	unlockedPath := filepath.Join(directory, "unlocked.sqlite")
+
+	if err := backend.BackupInto(unlockedPath); err != nil {
+		t.Fatalf("BackupInto: %v", err)
+	}
+
+	if holds, err := HoldsPlainTextServiceNames(unlockedPath); err != nil || holds {
+		t.Fatalf("HoldsPlainTextServiceNames() after unlock = %v, %v, want false", holds, err)
+	}
+}
@@ -7,17 +7,16 @@ import (
 	"crypto/rand"
 	"crypto/subtle"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"sort"
-	"strings"
+	"sync"
 	"time"
 
 	b64 "encoding/base64"
 
 	"github.com/mszalewicz/frosk/helpers"
-	"golang.org/x/crypto/argon2"
 )
 
 var EmptyPassword = errors.New("No password given to insert.")
@@ -29,15 +28,35 @@ var ServiceNameAlreadyTaken = errors.New("Provided service name is already prese
 var MasterPasswordDoNotMatch = errors.New("Provided master password do not match database.")
 var NoRowsDeleted = errors.New("Query did not delete any rows.")
 var DeletedMoreRowsThenExpected = errors.New("Query deleted more rows then expected.")
+var EmptyCustomFieldName = errors.New("Custom field has no name.")
 
 type Backend struct {
 	DB *sql.DB
+
+	onChange                func()         // see OnChange
+	onServiceNamesEncrypted func()         // see OnServiceNamesEncrypted
+	witness                 VersionWitness // see SetVersionWitness
+
+	integrityMutex sync.Mutex
+	integrityErr   error // failed integrity check, vault is not sealed until its content is accepted - see sealVault
 }
 
 type PasswordEntry struct {
-	Username    string
-	Password    string
-	ServiceName string
+	Username     string
+	Password     string
+	ServiceName  string
+	URL          string
+	Notes        string
+	CustomFields []CustomField
+	TOTP         string   // otpauth:// URI or base32 key of two-factor authentication, see ParseTOTP
+	Tags         []string // see MatchesTag
+}
+
+// Additional named value of password entry, e.g. recovery codes or security question
+type CustomField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret"` // value is masked until user asks to show it
 }
 
 type ArgonConfig struct {
@@ -119,14 +138,19 @@ func (backend *Backend) GetUserSecretKey(masterPasswordGUI string) ([]byte, erro
 		return userSecretKey, errorWrapped
 	}
 
-	argonSettings := GetDefaultArgonConfig()
-	argonOutput := argon2.IDKey([]byte(masterPasswordGUI), salt, argonSettings.time, argonSettings.memory, argonSettings.threads, 64)
+	argonSettings, err := backend.GetArgonConfig()
+
+	if err != nil {
+		return userSecretKey, err
+	}
+
+	argonOutput := argonSettings.deriveKeys(masterPasswordGUI, salt)
 
 	masterPasswordComputedHash := argonOutput[0:32]
 	secretKey := argonOutput[32:64]
 
 	if subtle.ConstantTimeCompare(masterPasswordHashed, masterPasswordComputedHash) != 1 {
-		errorWrapped := fmt.Errorf("Master password from GUI input do not match databse signature: %w", err)
+		errorWrapped := fmt.Errorf("Master password from GUI input do not match databse signature: %w", MasterPasswordDoNotMatch)
 		slog.Error(errorWrapped.Error())
 		return userSecretKey, errorWrapped
 	}
@@ -164,45 +188,33 @@ func Initialize(applicationDB string) (*Backend, error) {
 	return &backend, nil
 }
 
-// Create db from schema
-func (backend *Backend) CreateStructure() error {
+// Registers function called after entries or master password were changed, e.g. to schedule backup of the vault.
+// Has to be called before backend is used.
+func (backend *Backend) OnChange(onChange func()) {
+	backend.onChange = onChange
+}
 
-	const create_passwords_table = `
-		CREATE TABLE IF NOT EXISTS passwords (
-	       id INTEGER PRIMARY KEY AUTOINCREMENT,
-	       service_name TEXT UNIQUE NOT NULL,
-		   username TEXT NOT NULL,
-	       password TEXT NOT NULL,
-		   initial_vector TEXT UNIQUE NOT NULL,
-	       created_at TEXT NULL,
-	       updated_at TEXT NULL
-	   ) STRICT;
-	`
+// Registers function called after service names stored in plain text by older versions were encrypted, e.g. to remove
+// backups still holding them. Has to be called before backend is used.
+func (backend *Backend) OnServiceNamesEncrypted(onServiceNamesEncrypted func()) {
+	backend.onServiceNamesEncrypted = onServiceNamesEncrypted
+}
 
-	_, err := backend.DB.Exec(create_passwords_table)
+// Called after change of given vault version was committed
+func (backend *Backend) changed(version int64) {
+	backend.witnessVersion(version)
 
-	if err != nil {
-		errWrapped := fmt.Errorf("Error during creating passwords table: %w", err)
-		slog.Error(errWrapped.Error())
-		return err
+	if backend.onChange != nil {
+		backend.onChange()
 	}
+}
 
-	const create_master_table = `
-		CREATE TABLE IF NOT EXISTS master (
-		   	id INTEGER PRIMARY KEY AUTOINCREMENT,
-		    password TEXT UNIQUE NOT NULL,
-		    secret_key TEXT UNIQUE NOT NULL,
-		    salt TEXT UNIQUE NOT NULL,
-			initial_vector TEXT UNIQUE NOT NULL,
-		    created_at TEXT NULL,
-		    updated_at TEXT NULL
-		) STRICT;
-	`
-
-	_, err = backend.DB.Exec(create_master_table)
+// Create db from schema, migrating existing vault to the latest schema version
+func (backend *Backend) CreateStructure() error {
+	err := backend.Migrate()
 
 	if err != nil {
-		errWrapped := fmt.Errorf("Error during creating passwords table: %w", err)
+		errWrapped := fmt.Errorf("Error during migrating vault schema: %w", err)
 		slog.Error(errWrapped.Error())
 		return errWrapped
 	}
@@ -225,19 +237,63 @@ func (backend *Backend) CountMasterEntries() (int, error) {
 	return numberOfEntriesInMaster, nil
 }
 
-func (backend *Backend) CountServiceNameOccurences(serviceName string) (int, error) {
-	var numberOfServiceNameOccurences int
-	row := backend.DB.QueryRow("SELECT COUNT(service_name) FROM passwords WHERE service_name = ?", serviceName)
+// Master table record - all values except key derivation parameters are base64 encoded and ready to be stored
+type wrappedUserSecretKey struct {
+	masterPasswordHash string
+	userSecretKey      string
+	salt               string
+	initialVector      string
+	argonConfig        ArgonConfig
+}
+
+// Derives master password hash and key encryption key with fresh salt, then encrypts user secret key with it
+func wrapUserSecretKey(masterPassword string, userSecretKey []byte, argonSettings ArgonConfig) (wrappedUserSecretKey, error) {
+	var wrapped wrappedUserSecretKey
 
-	err := row.Scan(&numberOfServiceNameOccurences)
+	helpers.Assert(len(userSecretKey), 32)
+
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
 
 	if err != nil {
-		errWrapped := fmt.Errorf("Query counting number of entries in master table: %w", err)
-		slog.Error(errWrapped.Error())
-		return 0, errWrapped
+		errorWrapped := fmt.Errorf("Error during randomizing salt: %w", err)
+		slog.Error(errorWrapped.Error())
+		return wrapped, errorWrapped
 	}
 
-	return numberOfServiceNameOccurences, nil
+	argonOutput := argonSettings.deriveKeys(masterPassword, salt)
+
+	masterPasswordHash := argonOutput[0:32]
+	secretKey := argonOutput[32:64]
+
+	helpers.Assert(len(secretKey), 32)
+
+	gcm, err := InitGCM(secretKey)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during initialization of GCM cipher block: %w", err)
+		slog.Error(errorWrapped.Error())
+		return wrapped, errorWrapped
+	}
+
+	initialVector := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(initialVector)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during randomizing initial vector: %w", err)
+		slog.Error(errorWrapped.Error())
+		return wrapped, errorWrapped
+	}
+
+	userSecretKeyEncrypted := gcm.Seal(nil, initialVector, userSecretKey, nil)
+
+	wrapped.masterPasswordHash = b64.StdEncoding.EncodeToString(masterPasswordHash)
+	wrapped.userSecretKey = b64.StdEncoding.EncodeToString(userSecretKeyEncrypted)
+	wrapped.salt = b64.StdEncoding.EncodeToString(salt)
+	wrapped.initialVector = b64.StdEncoding.EncodeToString(initialVector)
+	wrapped.argonConfig = argonSettings
+
+	return wrapped, nil
 }
 
 // Create all necessary crypto primitives and insert them with master password to db
@@ -251,86 +307,187 @@ func (backend *Backend) InitMaster(masterPassword string) error {
 	//     master password secret key  -> derived from master password with PKBDF2, using salt
 	//     user secret key             -> used in encryption of user stored passwords
 
+	return backend.initMaster(masterPassword, GetDefaultArgonConfig())
+}
+
+// Initializes master record with given key derivation parameters
+func (backend *Backend) initMaster(masterPassword string, argonSettings ArgonConfig) error {
 	if len(masterPassword) == 0 {
 		return EmptyMasterPassword
 	}
 
 	helpers.AssertBigger(len(masterPassword), 0)
 
-	salt := make([]byte, 16)
-	_, err := rand.Read(salt)
+	userSecretKey := make([]byte, 32)
+	_, err := rand.Read(userSecretKey)
 
 	if err != nil {
-		errorWrapped := fmt.Errorf("Error during randomizing salt: %w", err)
+		errorWrapped := fmt.Errorf("Error during randomizing userSecretKey: %w", err)
 		slog.Error(errorWrapped.Error())
 		return errorWrapped
 	}
 
-	saltBase64 := b64.StdEncoding.EncodeToString(salt)
+	helpers.Assert(len(userSecretKey), 32)
 
-	argonSettings := GetDefaultArgonConfig()
-	argonOutput := argon2.IDKey([]byte(masterPassword), salt, argonSettings.time, argonSettings.memory, argonSettings.threads, 64)
+	wrapped, err := wrapUserSecretKey(masterPassword, userSecretKey, argonSettings)
 
-	masterPasswordHash := argonOutput[0:32]
-	secretKey := argonOutput[32:64]
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during encryption of user secret key: %w", err)
+		slog.Error(errorWrapped.Error())
+		return errorWrapped
+	}
 
-	masterPasswordHashBase64 := b64.StdEncoding.EncodeToString(masterPasswordHash)
+	now := helpers.TimeTo8601String(time.Now())
 
-	helpers.Assert(len(secretKey), 32)
+	tx, err := backend.DB.Begin()
 
-	gcm, err := InitGCM(secretKey)
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during starting transaction for master insert: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer tx.Rollback()
+
+	queryResult, err := tx.Exec(
+		"INSERT INTO master (password, secret_key, salt, initial_vector, kdf_algorithm, kdf_time, kdf_memory, kdf_threads, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		wrapped.masterPasswordHash, wrapped.userSecretKey, wrapped.salt, wrapped.initialVector,
+		KDFArgon2id, wrapped.argonConfig.time, wrapped.argonConfig.memory, wrapped.argonConfig.threads, now, now)
 
 	if err != nil {
-		errorWrapped := fmt.Errorf("Error during initialization of GCM cipher block: %w", err)
-		slog.Error(errorWrapped.Error())
-		return errorWrapped
+		err := fmt.Errorf("Error during insert into master execution: %w", err)
+		slog.Error(err.Error())
+		return err
 	}
 
-	initialVector := make([]byte, gcm.NonceSize())
-	_, err = rand.Read(initialVector)
+	rowsAffected, err := queryResult.RowsAffected()
+
+	if rowsAffected != 1 {
+		err := fmt.Errorf("Expected to insert exactly 1 row into master table. Inserted 0 / multiple rows")
+		slog.Error(err.Error())
+		return err
+	}
+
+	// New vault has no content to check yet, also when it replaced other vault with witnessed version
+	version, err := backend.storeVaultMAC(tx, newIntegrityKey(userSecretKey))
 
 	if err != nil {
-		errorWrapped := fmt.Errorf("Error during randomizing initial vector: %w", err)
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during commiting master insert: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	backend.changed(version)
+
+	return nil
+}
+
+// Replaces master password. User secret key stays the same - it is only re-encrypted with key derived from new master password,
+// so password entries do not have to be touched.
+func (backend *Backend) ChangeMasterPassword(oldMasterPassword string, newMasterPassword string) error {
+	argonSettings, err := backend.GetArgonConfig()
+
+	if err != nil {
+		return err
+	}
+
+	return backend.rewrapUserSecretKey(oldMasterPassword, newMasterPassword, argonSettings)
+}
+
+// Verifies old master password and stores user secret key encrypted with key derived from new master password using given parameters
+func (backend *Backend) rewrapUserSecretKey(oldMasterPassword string, newMasterPassword string, argonSettings ArgonConfig) error {
+
+	if len(oldMasterPassword) == 0 || len(newMasterPassword) == 0 {
+		return EmptyMasterPassword
+	}
+
+	// Fails with MasterPasswordDoNotMatch for wrong old password, no need to derive keys twice
+	userSecretKey, err := backend.GetUserSecretKey(oldMasterPassword)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during decryption of user secret key: %w", err)
 		slog.Error(errorWrapped.Error())
 		return errorWrapped
 	}
 
-	initialVectorBase64 := b64.StdEncoding.EncodeToString(initialVector)
+	// Master record is sealed together with entries, vault which failed integrity check is left as it is
+	_, err = backend.verifyVault(newIntegrityKey(userSecretKey))
 
-	userSecretKey := make([]byte, 32)
-	_, err = rand.Read(userSecretKey)
+	if errors.Is(err, VaultModified) || errors.Is(err, VaultRolledBack) {
+		return fmt.Errorf("%w %w", VaultNotAccepted, err)
+	}
 
 	if err != nil {
-		errorWrapped := fmt.Errorf("Error during randomizing userSecretKey: %w", err)
+		return err
+	}
+
+	wrapped, err := wrapUserSecretKey(newMasterPassword, userSecretKey, argonSettings)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during re-encryption of user secret key: %w", err)
 		slog.Error(errorWrapped.Error())
 		return errorWrapped
 	}
 
-	helpers.Assert(len(userSecretKey), 32)
+	now := helpers.TimeTo8601String(time.Now())
 
-	userSecretKeyEncrypted := gcm.Seal(nil, initialVector, userSecretKey, nil)
-	userSecretKeyEncryptedBase64 := b64.StdEncoding.EncodeToString(userSecretKeyEncrypted)
+	tx, err := backend.DB.Begin()
 
-	now := helpers.TimeTo8601String(time.Now())
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during starting transaction for master update: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer tx.Rollback()
 
-	queryResult, err := backend.DB.Exec(
-		"INSERT INTO master (password, secret_key, salt, initial_vector, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
-		masterPasswordHashBase64, userSecretKeyEncryptedBase64, saltBase64, initialVectorBase64, now, now)
+	queryResult, err := tx.Exec(
+		"UPDATE master SET password = ?, secret_key = ?, salt = ?, initial_vector = ?, kdf_algorithm = ?, kdf_time = ?, kdf_memory = ?, kdf_threads = ?, updated_at = ?",
+		wrapped.masterPasswordHash, wrapped.userSecretKey, wrapped.salt, wrapped.initialVector,
+		KDFArgon2id, wrapped.argonConfig.time, wrapped.argonConfig.memory, wrapped.argonConfig.threads, now)
 
 	if err != nil {
-		err := fmt.Errorf("Error during insert into master execution: %w", err)
-		slog.Error(err.Error())
-		return err
+		errWrapped := fmt.Errorf("Error during update of master table: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
 	}
 
 	rowsAffected, err := queryResult.RowsAffected()
 
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading number of updated master entries: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
 	if rowsAffected != 1 {
-		err := fmt.Errorf("Expected to insert exactly 1 row into master table. Inserted 0 / multiple rows")
+		err := fmt.Errorf("Expected to update exactly 1 row in master table. Updated %d rows", rowsAffected)
 		slog.Error(err.Error())
 		return err
 	}
 
+	version, err := backend.sealVault(tx, newIntegrityKey(userSecretKey))
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during commiting master update: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	backend.changed(version)
+
 	return nil
 }
 
@@ -366,8 +523,13 @@ func (backend *Backend) CmpMasterPassword(masterPasswordGUI string) (bool, error
 		return false, errWrapped
 	}
 
-	argonSettings := GetDefaultArgonConfig()
-	argonOutput := argon2.IDKey([]byte(masterPasswordGUI), salt, argonSettings.time, argonSettings.memory, argonSettings.threads, 64)
+	argonSettings, err := backend.GetArgonConfig()
+
+	if err != nil {
+		return false, err
+	}
+
+	argonOutput := argonSettings.deriveKeys(masterPasswordGUI, salt)
 
 	masterPasswordComputedHash := argonOutput[0:32]
 
@@ -378,206 +540,155 @@ func (backend *Backend) CmpMasterPassword(masterPasswordGUI string) (bool, error
 	}
 }
 
-// Inserts encrypted password and username for given service name
-func (backend *Backend) EncryptPasswordEntry(serviceName string, password string, username string, masterPasswordGUI string) error {
-
-	if len(serviceName) == 0 {
-		return EmptyServiceName
-	}
-
-	if len(password) == 0 {
-		return EmptyPassword
-	}
+// Inserts encrypted password entry. Derives user secret key from master password for this single action - use Session
+// to insert multiple entries after one unlock.
+func (backend *Backend) EncryptPasswordEntry(passwordEntry PasswordEntry, masterPasswordGUI string) error {
 
 	if len(masterPasswordGUI) == 0 {
 		return EmptyMasterPassword
 	}
 
-	if len(username) == 0 {
-		return EmptyUsername
-	}
-
-	serviceNameOccurences, err := backend.CountServiceNameOccurences(serviceName)
+	session, err := backend.Unlock(masterPasswordGUI)
 
 	if err != nil {
-		errorWrapped := fmt.Errorf("Problem quering count of service name occurences in passwords table: %v", err)
-		slog.Error(errorWrapped.Error())
-		return errorWrapped
+		return err
 	}
 
-	if serviceNameOccurences != 0 {
-		return ServiceNameAlreadyTaken
-	}
+	defer session.Lock()
 
-	userSecretKey, err := backend.GetUserSecretKey(masterPasswordGUI)
+	return session.EncryptPasswordEntry(passwordEntry)
+}
 
-	if err != nil {
-		errorWrapped := fmt.Errorf("Error during decryption of user secret key: %w", err)
-		slog.Error(errorWrapped.Error())
-		return errorWrapped
+// Checks fields required in every password entry
+func validatePasswordEntry(passwordEntry PasswordEntry) error {
+	if len(passwordEntry.ServiceName) == 0 {
+		return EmptyServiceName
 	}
 
-	gcmPasswordEntry, err := InitGCM(userSecretKey)
-
-	if err != nil {
-		errorWrapped := fmt.Errorf("Error during initialization of GCM cipher block: %w", err)
-		slog.Error(errorWrapped.Error())
-		return errorWrapped
+	if len(passwordEntry.Password) == 0 {
+		return EmptyPassword
 	}
 
-	initialVectorPasswordEntry := make([]byte, gcmPasswordEntry.NonceSize())
-	_, err = rand.Read(initialVectorPasswordEntry)
+	if len(passwordEntry.Username) == 0 {
+		return EmptyUsername
+	}
 
-	if err != nil {
-		errorWrapped := fmt.Errorf("Can't create random salt: %w", err)
-		slog.Error(errorWrapped.Error())
-		return errorWrapped
+	for _, customField := range passwordEntry.CustomFields {
+		if len(customField.Name) == 0 {
+			return EmptyCustomFieldName
+		}
 	}
 
-	helpers.Assert(len(initialVectorPasswordEntry), gcmPasswordEntry.NonceSize())
+	if len(passwordEntry.TOTP) != 0 {
+		_, err := ParseTOTP(passwordEntry.TOTP)
 
-	initialVectorPasswordEntryBase64 := b64.StdEncoding.EncodeToString(initialVectorPasswordEntry)
+		if err != nil {
+			return err
+		}
+	}
 
-	passwordEncrypted := gcmPasswordEntry.Seal(nil, initialVectorPasswordEntry, []byte(password), nil)
-	passwordEncryptedBase64 := b64.StdEncoding.EncodeToString(passwordEncrypted)
+	_, err := normalizeTags(passwordEntry.Tags)
 
-	usernameEncrypted := gcmPasswordEntry.Seal(nil, initialVectorPasswordEntry, []byte(username), nil)
-	usernameEncryptedBase64 := b64.StdEncoding.EncodeToString(usernameEncrypted)
+	return err
+}
 
-	now := helpers.TimeTo8601String(time.Now())
+// Encrypts fields of password entry with given id and stores them
+func sealPasswordEntryFields(tx *sql.Tx, gcm cipher.AEAD, id int64, passwordEntry PasswordEntry) error {
+	customFields := passwordEntry.CustomFields
 
-	insertPasswordEntryQuery := `INSERT INTO passwords (service_name, username, password, initial_vector, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`
+	if customFields == nil {
+		customFields = []CustomField{}
+	}
 
-	_, err = backend.DB.Exec(insertPasswordEntryQuery, serviceName, usernameEncryptedBase64, passwordEncryptedBase64, initialVectorPasswordEntryBase64, now, now)
+	customFieldsJSON, err := json.Marshal(customFields)
 
 	if err != nil {
-		errWrapped := fmt.Errorf("Error inserting password entry into passwords: %w", err)
+		errWrapped := fmt.Errorf("Error encoding custom fields of password entry: %w", err)
 		slog.Error(errWrapped.Error())
 		return errWrapped
 	}
 
-	return nil
-}
-
-// Finds and decrypts password and username for given service name
-func (backend *Backend) DecryptPasswordEntry(serviceName string, masterPasswordGUI string) (PasswordEntry, error) {
-	var (
-		passwordEntry           PasswordEntry
-		initialVectorBase64     string
-		initialVector           []byte
-		passwordEncryptedBase64 string
-		passwordEncrypted       []byte
-		usernameEncryptedBase64 string
-		usernameEncrypted       []byte
-		password                []byte
-		username                []byte
-	)
-
-	row := backend.DB.QueryRow("SELECT service_name, username, \"password\", initial_vector FROM passwords WHERE	service_name = ?", serviceName)
-	err := row.Scan(&passwordEntry.ServiceName, &usernameEncryptedBase64, &passwordEncryptedBase64, &initialVectorBase64)
-
-	if err != nil {
-		errorWrapped := fmt.Errorf("Error during count query on passwords table - looking for service name = %s: %w", serviceName, err)
-		slog.Error(errorWrapped.Error())
-		return passwordEntry, errorWrapped
+	fields := []struct {
+		name  string
+		value []byte
+	}{
+		{fieldUsername, []byte(passwordEntry.Username)},
+		{fieldPassword, []byte(passwordEntry.Password)},
+		{fieldURL, []byte(passwordEntry.URL)},
+		{fieldNotes, []byte(passwordEntry.Notes)},
+		{fieldCustomFields, customFieldsJSON},
+		{fieldTOTP, []byte(passwordEntry.TOTP)},
 	}
 
-	initialVector, errDecodeInitialVectorBase64 := b64.StdEncoding.DecodeString(initialVectorBase64)
-	passwordEncrypted, errDecodePasswordEncryptedBas64 := b64.StdEncoding.DecodeString(passwordEncryptedBase64)
-	usernameEncrypted, errDecodeUsernameEncryptedBase64 := b64.StdEncoding.DecodeString(usernameEncryptedBase64)
+	sealed := make([]any, 0, len(fields)+1)
 
-	if errDecodeInitialVectorBase64 != nil || errDecodePasswordEncryptedBas64 != nil || errDecodeUsernameEncryptedBase64 != nil {
-		errorWrapped := fmt.Errorf("Error during coversion from base 64 - initial vector: %w | password: %w | username: %w", errDecodeInitialVectorBase64, errDecodePasswordEncryptedBas64, errDecodeUsernameEncryptedBase64)
-		slog.Error(errorWrapped.Error())
-		return passwordEntry, errorWrapped
-	}
+	// Empty fields are sealed as well, so stored entry does not reveal which of them are filled in
+	for _, field := range fields {
+		fieldSealed, err := sealField(gcm, field.value, passwordEntryAdditionalData(id, field.name, passwordEntry.ServiceName))
 
-	userSecretKey, err := backend.GetUserSecretKey(masterPasswordGUI)
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		errorWrapped := fmt.Errorf("Error during decrytion of user secret key: %w", err)
-		slog.Error(errorWrapped.Error())
-		return passwordEntry, errorWrapped
+		sealed = append(sealed, fieldSealed)
 	}
 
-	gcm, err := InitGCM(userSecretKey)
-
-	if err != nil {
-		errorWrapped := fmt.Errorf("Error during creation of gcm cipher block: %w", err)
-		slog.Error(errorWrapped.Error())
-		return passwordEntry, errorWrapped
-	}
+	sealed = append(sealed, id)
 
-	password, err = gcm.Open(nil, initialVector, passwordEncrypted, nil)
+	result, err := tx.Exec(`UPDATE passwords SET username = ?, "password" = ?, url = ?, notes = ?, custom_fields = ?, totp = ? WHERE id = ?`, sealed...)
 
 	if err != nil {
-		errorWrapped := fmt.Errorf("Error during password decryption: %w", err)
-		slog.Error(errorWrapped.Error())
-		return passwordEntry, errorWrapped
+		errWrapped := fmt.Errorf("Error storing encrypted fields of password entry: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
 	}
 
-	username, err = gcm.Open(nil, initialVector, usernameEncrypted, nil)
+	affectedRows, err := result.RowsAffected()
 
 	if err != nil {
-		errorWrapped := fmt.Errorf("Error during username decryption: %w", err)
-		slog.Error(errorWrapped.Error())
-		return passwordEntry, errorWrapped
+		errWrapped := fmt.Errorf("Error during reading number of updated password entries: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
 	}
 
-	passwordEntry.Password = string(password)
-	passwordEntry.Username = string(username)
+	if affectedRows != 1 {
+		err := fmt.Errorf("Expected to update exactly 1 row in passwords table. Updated %d rows", affectedRows)
+		slog.Error(err.Error())
+		return err
+	}
 
-	return passwordEntry, nil
+	return nil
 }
 
-func (backend *Backend) GetPasswordEntriesList() ([]string, error) {
-	services := make([]string, 0)
-	service := ""
+// Finds and decrypts password entry for given service name. Derives user secret key from master password
+// for this single action - use Session to decrypt multiple entries after one unlock.
+func (backend *Backend) DecryptPasswordEntry(serviceName string, masterPasswordGUI string) (PasswordEntry, error) {
+	session, err := backend.Unlock(masterPasswordGUI)
 
-	query := "SELECT service_name FROM passwords"
-	rows, err := backend.DB.Query(query)
 	if err != nil {
-		errWrapped := fmt.Errorf("Error during getting service names for passwords: %w", err)
-		slog.Error(errWrapped.Error())
-		return nil, errWrapped
+		return PasswordEntry{}, err
 	}
 
-	for rows.Next() {
-		rows.Scan(&service)
-		if len(service) > 0 {
-			services = append(services, service)
-		}
-	}
-
-	sort.Slice(services, func(i, j int) bool { return strings.ToLower(services[i]) < strings.ToLower(services[j]) })
+	defer session.Lock()
 
-	return services, nil
+	return session.DecryptPasswordEntry(serviceName)
 }
 
-func (backend *Backend) DeletePasswordEntry(serviceName string) error {
-	result, err := backend.DB.Exec("DELETE FROM passwords WHERE service_name = ?", serviceName)
+// Re-encrypts password entry stored under given service name. Derives user secret key from master password
+// for this single action - use Session to update multiple entries after one unlock.
+func (backend *Backend) UpdatePasswordEntry(serviceName string, passwordEntry PasswordEntry, masterPasswordGUI string) error {
 
-	if err != nil {
-		errWrapped := fmt.Errorf("Error during deletion of given password entry in db: %w", err)
-		slog.Error(errWrapped.Error())
-		return errWrapped
+	if len(masterPasswordGUI) == 0 {
+		return EmptyMasterPassword
 	}
 
-	affectedRows, err := result.RowsAffected()
+	session, err := backend.Unlock(masterPasswordGUI)
 
 	if err != nil {
-		errWrapped := fmt.Errorf("Error during deletion of given password entry in db: %w", err)
-		slog.Error(errWrapped.Error())
-		return errWrapped
-	}
-
-	if affectedRows == 0 {
-		return NoRowsDeleted
+		return err
 	}
 
-	if affectedRows > 1 {
-		return DeletedMoreRowsThenExpected
-	}
+	defer session.Lock()
 
-	return nil
+	return session.UpdatePasswordEntry(serviceName, passwordEntry)
 }
@@ -0,0 +1,154 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"errors"
+	"testing"
+)
+
+func TestChangeMasterPasswordRejectsWrongOldPassword(t *testing.T) {
+	backend, _ := newTestVault(t, &memoryWitness{})
+
+	err := backend.ChangeMasterPassword("wrong "+testMasterPassword, "new "+testMasterPassword)
+
+	if !errors.Is(err, MasterPasswordDoNotMatch) {
+		t.Fatalf("ChangeMasterPassword() = %v, want MasterPasswordDoNotMatch", err)
+	}
+
+	// Master record is left untouched
+	unlockTestVault(t, backend)
+}
+
+func TestChangeMasterPasswordKeepsEntries(t *testing.T) {
+	backend, _ := newTestVault(t, &memoryWitness{})
+	addTestEntry(t, unlockTestVault(t, backend), "github")
+
+	err := backend.ChangeMasterPassword(testMasterPassword, "new "+testMasterPassword)
+
+	if err != nil {
+		t.Fatalf("ChangeMasterPassword() = %v", err)
+	}
+
+	_, err = backend.Unlock(testMasterPassword)
+
+	if !errors.Is(err, MasterPasswordDoNotMatch) {
+		t.Fatalf("Unlock() with old password = %v, want MasterPasswordDoNotMatch", err)
+	}
+
+	session, err := backend.Unlock("new " + testMasterPassword)
+
+	if err != nil {
+		t.Fatalf("Unlock() with new password = %v", err)
+	}
+
+	defer session.Lock()
+
+	entry, err := session.DecryptPasswordEntry("github")
+
+	if err != nil || entry.Password != "password of github" {
+		t.Fatalf("DecryptPasswordEntry() = %+v, %v, want entry kept", entry, err)
+	}
+}
+
+func TestUpdatePasswordEntryRejectsTakenServiceName(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+	addTestEntry(t, session, "gitlab")
+
+	err := session.UpdatePasswordEntry("github", PasswordEntry{ServiceName: "gitlab", Username: "user", Password: "new password"})
+
+	if !errors.Is(err, ServiceNameAlreadyTaken) {
+		t.Fatalf("UpdatePasswordEntry() renaming to taken service name = %v, want ServiceNameAlreadyTaken", err)
+	}
+
+	// Both entries are left as they were
+	for _, serviceName := range []string{"github", "gitlab"} {
+		entry, err := session.DecryptPasswordEntry(serviceName)
+
+		if err != nil || entry.Password != "password of "+serviceName {
+			t.Errorf("DecryptPasswordEntry(%q) = %+v, %v, want entry unchanged", serviceName, entry, err)
+		}
+	}
+}
+
+func TestUpdatePasswordEntryKeepsCreatedAt(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+
+	// Timestamps have one second resolution, so entry is made older instead of waiting
+	const createdAt = "2020-01-02 03:04:05"
+	_, err := backend.DB.Exec("UPDATE passwords SET created_at = ?, updated_at = ?", createdAt, createdAt)
+
+	if err != nil {
+		t.Fatalf("setting created_at: %v", err)
+	}
+
+	updateTestPassword(t, session, "github", "new password")
+
+	var storedCreatedAt, storedUpdatedAt string
+	err = backend.DB.QueryRow("SELECT created_at, updated_at FROM passwords").Scan(&storedCreatedAt, &storedUpdatedAt)
+
+	if err != nil {
+		t.Fatalf("reading timestamps: %v", err)
+	}
+
+	if storedCreatedAt != createdAt {
+		t.Errorf("created_at after update = %q, want %q", storedCreatedAt, createdAt)
+	}
+
+	if storedUpdatedAt == createdAt {
+		t.Errorf("updated_at after update = %q, want time of update", storedUpdatedAt)
+	}
+}
+
+func TestUpdatePasswordEntryRenameBindsFieldsToNewServiceName(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+
+	var id int64
+	var oldPasswordSealedBase64 string
+	err := backend.DB.QueryRow(`SELECT id, "password" FROM passwords`).Scan(&id, &oldPasswordSealedBase64)
+
+	if err != nil {
+		t.Fatalf("reading password entry: %v", err)
+	}
+
+	err = session.UpdatePasswordEntry("github", PasswordEntry{ServiceName: "github.com", Username: "user", Password: "password of github"})
+
+	if err != nil {
+		t.Fatalf("UpdatePasswordEntry: %v", err)
+	}
+
+	var passwordSealedBase64 string
+	err = backend.DB.QueryRow(`SELECT "password" FROM passwords WHERE id = ?`, id).Scan(&passwordSealedBase64)
+
+	if err != nil {
+		t.Fatalf("reading renamed password entry: %v", err)
+	}
+
+	session.withCipher(func(gcm cipher.AEAD) error {
+		if _, err := openField(gcm, passwordSealedBase64, passwordEntryAdditionalData(id, fieldPassword, "github.com")); err != nil {
+			t.Errorf("opening password with new service name = %v, want nil", err)
+		}
+
+		if _, err := openField(gcm, passwordSealedBase64, passwordEntryAdditionalData(id, fieldPassword, "github")); err == nil {
+			t.Errorf("opening password with old service name succeeded, want error")
+		}
+
+		return nil
+	})
+
+	// Ciphertext sealed under old service name no longer fits the renamed row
+	_, err = backend.DB.Exec(`UPDATE passwords SET "password" = ? WHERE id = ?`, oldPasswordSealedBase64, id)
+
+	if err != nil {
+		t.Fatalf("moving old ciphertext back: %v", err)
+	}
+
+	if _, err := session.DecryptPasswordEntry("github.com"); err == nil {
+		t.Fatalf("DecryptPasswordEntry() with ciphertext of old service name = nil, want error")
+	}
+}
@@ -0,0 +1,458 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	b64 "encoding/base64"
+
+	"github.com/mszalewicz/frosk/helpers"
+)
+
+// Encrypted password entry fields are stored as base64(initial vector || ciphertext).
+// Every field is sealed with its own random initial vector. Additional data binds the ciphertext to the row id,
+// column and service name, so ciphertexts moved between rows or columns fail authentication.
+//
+// Service name is sealed as well, bound to row id and column only. Entries are looked up by service_name_index -
+// keyed HMAC of the service name, which keeps service names unique without revealing them.
+
+var MalformedEncryptedField = errors.New("Encrypted field is too short to contain initial vector.")
+
+const (
+	fieldServiceName  = "service_name"
+	fieldUsername     = "username"
+	fieldPassword     = "password"
+	fieldURL          = "url"
+	fieldNotes        = "notes"
+	fieldCustomFields = "custom_fields"
+	fieldTOTP         = "totp"
+)
+
+// Builds GCM additional data binding encrypted field to its place in passwords table
+func passwordEntryAdditionalData(id int64, field string, serviceName string) []byte {
+	return []byte(fmt.Sprintf("frosk|passwords|%d|%s|%s", id, field, serviceName))
+}
+
+// Key of blind index of service names, derived from user secret key so it is not used for two purposes
+type serviceNameIndex []byte
+
+func newServiceNameIndex(userSecretKey []byte) serviceNameIndex {
+	mac := hmac.New(sha256.New, userSecretKey)
+	mac.Write([]byte("frosk|passwords|service_name_index"))
+	return mac.Sum(nil)
+}
+
+// Returns value of service_name_index column for service name
+func (index serviceNameIndex) of(serviceName string) string {
+	mac := hmac.New(sha256.New, index)
+	mac.Write([]byte(serviceName))
+	return b64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Encrypts value with fresh initial vector and returns it in storable form
+func sealField(gcm cipher.AEAD, value []byte, additionalData []byte) (string, error) {
+	initialVector := make([]byte, gcm.NonceSize())
+	_, err := rand.Read(initialVector)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Can't create random initial vector: %w", err)
+		slog.Error(errorWrapped.Error())
+		return "", errorWrapped
+	}
+
+	helpers.Assert(len(initialVector), gcm.NonceSize())
+
+	sealed := gcm.Seal(initialVector, initialVector, value, additionalData)
+
+	return b64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypts value stored by sealField
+func openField(gcm cipher.AEAD, sealedBase64 string, additionalData []byte) ([]byte, error) {
+	sealed, err := b64.StdEncoding.DecodeString(sealedBase64)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during conversion of encrypted field from base 64: %w", err)
+		slog.Error(errorWrapped.Error())
+		return nil, errorWrapped
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, MalformedEncryptedField
+	}
+
+	value, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during decryption of encrypted field: %w", err)
+		slog.Error(errorWrapped.Error())
+		return nil, errorWrapped
+	}
+
+	return value, nil
+}
+
+// Decrypts field which may be empty in entries saved before the field was added to passwords table
+func openOptionalField(gcm cipher.AEAD, sealedBase64 string, additionalData []byte) ([]byte, error) {
+	if sealedBase64 == "" {
+		return []byte{}, nil
+	}
+
+	return openField(gcm, sealedBase64, additionalData)
+}
+
+// Columns of passwords row read into sealedPasswordEntry, in order of scan
+const sealedPasswordEntryColumns = `id, service_name, username, "password", url, notes, custom_fields, totp`
+
+// Password entry as stored in passwords table, with encrypted fields in base 64
+type sealedPasswordEntry struct {
+	id                       int64
+	serviceNameSealedBase64  string
+	usernameSealedBase64     string
+	passwordSealedBase64     string
+	urlSealedBase64          string
+	notesSealedBase64        string
+	customFieldsSealedBase64 string
+	totpSealedBase64         string
+}
+
+func (sealed *sealedPasswordEntry) scan(row interface{ Scan(...any) error }) error {
+	return row.Scan(&sealed.id, &sealed.serviceNameSealedBase64, &sealed.usernameSealedBase64, &sealed.passwordSealedBase64, &sealed.urlSealedBase64, &sealed.notesSealedBase64, &sealed.customFieldsSealedBase64, &sealed.totpSealedBase64)
+}
+
+// Decrypts service name of stored password entry
+func openServiceName(gcm cipher.AEAD, id int64, serviceNameSealedBase64 string) (string, error) {
+	serviceName, err := openField(gcm, serviceNameSealedBase64, passwordEntryAdditionalData(id, fieldServiceName, ""))
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during service name decryption: %w", err)
+		slog.Error(errorWrapped.Error())
+		return "", errorWrapped
+	}
+
+	return string(serviceName), nil
+}
+
+// Decrypts all fields of stored password entry
+func (sealed sealedPasswordEntry) open(gcm cipher.AEAD) (PasswordEntry, error) {
+	serviceName, err := openServiceName(gcm, sealed.id, sealed.serviceNameSealedBase64)
+
+	if err != nil {
+		return PasswordEntry{}, err
+	}
+
+	password, err := openField(gcm, sealed.passwordSealedBase64, passwordEntryAdditionalData(sealed.id, fieldPassword, serviceName))
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during password decryption: %w", err)
+		slog.Error(errorWrapped.Error())
+		return PasswordEntry{}, errorWrapped
+	}
+
+	username, err := openField(gcm, sealed.usernameSealedBase64, passwordEntryAdditionalData(sealed.id, fieldUsername, serviceName))
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during username decryption: %w", err)
+		slog.Error(errorWrapped.Error())
+		return PasswordEntry{}, errorWrapped
+	}
+
+	url, err := openOptionalField(gcm, sealed.urlSealedBase64, passwordEntryAdditionalData(sealed.id, fieldURL, serviceName))
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during url decryption: %w", err)
+		slog.Error(errorWrapped.Error())
+		return PasswordEntry{}, errorWrapped
+	}
+
+	notes, err := openOptionalField(gcm, sealed.notesSealedBase64, passwordEntryAdditionalData(sealed.id, fieldNotes, serviceName))
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during notes decryption: %w", err)
+		slog.Error(errorWrapped.Error())
+		return PasswordEntry{}, errorWrapped
+	}
+
+	customFieldsJSON, err := openOptionalField(gcm, sealed.customFieldsSealedBase64, passwordEntryAdditionalData(sealed.id, fieldCustomFields, serviceName))
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during custom fields decryption: %w", err)
+		slog.Error(errorWrapped.Error())
+		return PasswordEntry{}, errorWrapped
+	}
+
+	totp, err := openOptionalField(gcm, sealed.totpSealedBase64, passwordEntryAdditionalData(sealed.id, fieldTOTP, serviceName))
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during totp secret decryption: %w", err)
+		slog.Error(errorWrapped.Error())
+		return PasswordEntry{}, errorWrapped
+	}
+
+	passwordEntry := PasswordEntry{
+		ServiceName:  serviceName,
+		Username:     string(username),
+		Password:     string(password),
+		URL:          string(url),
+		Notes:        string(notes),
+		CustomFields: []CustomField{},
+		TOTP:         string(totp),
+	}
+
+	if len(customFieldsJSON) > 0 {
+		err = json.Unmarshal(customFieldsJSON, &passwordEntry.CustomFields)
+
+		if err != nil {
+			errorWrapped := fmt.Errorf("Error during decoding custom fields of %s: %w", serviceName, err)
+			slog.Error(errorWrapped.Error())
+			return PasswordEntry{}, errorWrapped
+		}
+	}
+
+	return passwordEntry, nil
+}
+
+// One-shot re-encryption of entries created with the first on-disk format, where username and password shared one
+// initial vector and were not bound to their row. Initial vector of such entries is kept in legacy_initial_vector
+// by schema migration 2. All of them are re-encrypted in single transaction once user secret key is known.
+func (backend *Backend) migrateLegacyPasswordEntries(gcm cipher.AEAD, integrity integrityKey) error {
+	type legacyPasswordEntry struct {
+		id                  int64
+		serviceName         string
+		usernameBase64      string
+		passwordBase64      string
+		initialVectorBase64 string
+	}
+
+	tx, err := backend.DB.Begin()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during starting transaction for legacy entries migration: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, service_name, username, "password", legacy_initial_vector FROM passwords WHERE legacy_initial_vector IS NOT NULL`)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading legacy password entries: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	legacyEntries := make([]legacyPasswordEntry, 0)
+
+	for rows.Next() {
+		var entry legacyPasswordEntry
+		err = rows.Scan(&entry.id, &entry.serviceName, &entry.usernameBase64, &entry.passwordBase64, &entry.initialVectorBase64)
+
+		if err != nil {
+			rows.Close()
+			errWrapped := fmt.Errorf("Error during scanning legacy password entry: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		legacyEntries = append(legacyEntries, entry)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		errWrapped := fmt.Errorf("Error during iterating legacy password entries: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	if len(legacyEntries) == 0 {
+		return nil
+	}
+
+	for _, entry := range legacyEntries {
+		initialVector, errDecodeInitialVector := b64.StdEncoding.DecodeString(entry.initialVectorBase64)
+		usernameEncrypted, errDecodeUsername := b64.StdEncoding.DecodeString(entry.usernameBase64)
+		passwordEncrypted, errDecodePassword := b64.StdEncoding.DecodeString(entry.passwordBase64)
+
+		if errDecodeInitialVector != nil || errDecodeUsername != nil || errDecodePassword != nil {
+			errWrapped := fmt.Errorf("Error during conversion of legacy entry %s from base 64 - initial vector: %w | username: %w | password: %w",
+				entry.serviceName, errDecodeInitialVector, errDecodeUsername, errDecodePassword)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		username, err := gcm.Open(nil, initialVector, usernameEncrypted, nil)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during decryption of legacy username for %s: %w", entry.serviceName, err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		password, err := gcm.Open(nil, initialVector, passwordEncrypted, nil)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during decryption of legacy password for %s: %w", entry.serviceName, err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		_, err = tx.Exec(`UPDATE passwords SET legacy_initial_vector = NULL WHERE id = ?`, entry.id)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during clearing legacy initial vector of %s: %w", entry.serviceName, err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		err = sealPasswordEntryFields(tx, gcm, entry.id, PasswordEntry{ServiceName: entry.serviceName, Username: string(username), Password: string(password)})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	version, err := backend.sealVault(tx, integrity)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during commiting legacy entries migration: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	backend.changed(version)
+
+	slog.Info("Migrated password entries to per-field encryption format.", "entries", len(legacyEntries))
+
+	return nil
+}
+
+// Seals service name of password entry with given id and stores it together with its blind index
+func sealServiceName(tx *sql.Tx, gcm cipher.AEAD, index serviceNameIndex, id int64, serviceName string) error {
+	serviceNameSealed, err := sealField(gcm, []byte(serviceName), passwordEntryAdditionalData(id, fieldServiceName, ""))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE passwords SET service_name = ?, service_name_index = ? WHERE id = ?`, serviceNameSealed, index.of(serviceName), id)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error storing encrypted service name of password entry: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// One-shot encryption of service names stored in plain text by versions before schema migration 7, marked by missing
+// service_name_index. Plain text is wiped from the vault file by vacuum afterwards, copies of the vault are left
+// to OnServiceNamesEncrypted.
+func (backend *Backend) migrateServiceNames(gcm cipher.AEAD, index serviceNameIndex, integrity integrityKey) error {
+	tx, err := backend.DB.Begin()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during starting transaction for service names migration: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, service_name FROM passwords WHERE service_name_index IS NULL`)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading plain text service names: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	type plainServiceName struct {
+		id          int64
+		serviceName string
+	}
+
+	plainServiceNames := make([]plainServiceName, 0)
+
+	for rows.Next() {
+		var plain plainServiceName
+		err = rows.Scan(&plain.id, &plain.serviceName)
+
+		if err != nil {
+			rows.Close()
+			errWrapped := fmt.Errorf("Error during scanning plain text service name: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		plainServiceNames = append(plainServiceNames, plain)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		errWrapped := fmt.Errorf("Error during iterating plain text service names: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	if len(plainServiceNames) == 0 {
+		return nil
+	}
+
+	for _, plain := range plainServiceNames {
+		err = sealServiceName(tx, gcm, index, plain.id, plain.serviceName)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	version, err := backend.sealVault(tx, integrity)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during commiting service names migration: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	// Pages freed by the update may still hold plain text service names
+	_, err = backend.DB.Exec("VACUUM")
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during vacuum after service names migration: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	slog.Info("Encrypted service names of password entries.", "entries", len(plainServiceNames))
+
+	if backend.onServiceNamesEncrypted != nil {
+		backend.onServiceNamesEncrypted()
+	}
+
+	backend.changed(version)
+
+	return nil
+}
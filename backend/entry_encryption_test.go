@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"bytes"
+	"testing"
+
+	b64 "encoding/base64"
+)
+
+// Returns initial vectors of sealed username and password of entry with given id
+func fieldInitialVectors(t *testing.T, backend *Backend, id int64) ([]byte, []byte) {
+	t.Helper()
+
+	var usernameBase64, passwordBase64 string
+	err := backend.DB.QueryRow(`SELECT username, "password" FROM passwords WHERE id = ?`, id).Scan(&usernameBase64, &passwordBase64)
+
+	if err != nil {
+		t.Fatalf("reading entry %d: %v", id, err)
+	}
+
+	username, _ := b64.StdEncoding.DecodeString(usernameBase64)
+	password, _ := b64.StdEncoding.DecodeString(passwordBase64)
+
+	return username[:12], password[:12]
+}
+
+func TestLegacyEntriesGetOwnInitialVectors(t *testing.T) {
+	backend := migrateBaselineVault(t, newBaselineVault(t, "github"))
+	unlockTestVault(t, backend)
+
+	username, password := fieldInitialVectors(t, backend, 1)
+
+	if bytes.Equal(username, password) {
+		t.Fatalf("username and password of migrated entry share initial vector")
+	}
+}
+
+func TestFieldsHaveOwnInitialVectors(t *testing.T) {
+	backend, _ := newTestVault(t, &memoryWitness{})
+	addTestEntry(t, unlockTestVault(t, backend), "github")
+
+	username, password := fieldInitialVectors(t, backend, 1)
+
+	if bytes.Equal(username, password) {
+		t.Fatalf("username and password share initial vector")
+	}
+}
+
+func TestCiphertextMovedToOtherPlaceFailsToDecrypt(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"between rows", `UPDATE passwords SET "password" = (SELECT "password" FROM passwords WHERE id = 2) WHERE id = 1`},
+		{"between columns", `UPDATE passwords SET "password" = username WHERE id = 1`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend, _ := newTestVault(t, &memoryWitness{})
+			session := unlockTestVault(t, backend)
+			addTestEntry(t, session, "github")
+			addTestEntry(t, session, "gitlab")
+
+			_, err := backend.DB.Exec(test.query)
+
+			if err != nil {
+				t.Fatalf("moving ciphertext: %v", err)
+			}
+
+			entry, err := session.DecryptPasswordEntry("github")
+
+			if err == nil {
+				t.Fatalf("DecryptPasswordEntry() = %+v, want error", entry)
+			}
+		})
+	}
+}
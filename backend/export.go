@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// Decrypts all password entries, ordered by service name. User secret key of the session is used for every row,
+// so whole vault is read without deriving key from master password again.
+func (session *Session) ExportPasswordEntries() ([]PasswordEntry, error) {
+	passwordEntries := make([]PasswordEntry, 0)
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		rows, err := session.backend.DB.Query("SELECT " + sealedPasswordEntryColumns + " FROM passwords WHERE deleted_at IS NULL")
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during reading password entries for export: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var sealed sealedPasswordEntry
+			err = sealed.scan(rows)
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Error during scanning password entry for export: %w", err)
+				slog.Error(errWrapped.Error())
+				return errWrapped
+			}
+
+			passwordEntry, err := sealed.open(gcm)
+
+			if err != nil {
+				return err
+			}
+
+			passwordEntry.Tags, err = readPasswordEntryTags(session.backend.DB, gcm, sealed.id)
+
+			if err != nil {
+				return err
+			}
+
+			passwordEntries = append(passwordEntries, passwordEntry)
+		}
+
+		if err = rows.Err(); err != nil {
+			errWrapped := fmt.Errorf("Error during iterating password entries for export: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Service names are encrypted, so they can't be ordered by query
+	sort.Slice(passwordEntries, func(i, j int) bool { return passwordEntries[i].ServiceName < passwordEntries[j].ServiceName })
+
+	return passwordEntries, nil
+}
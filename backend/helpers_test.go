@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const testMasterPassword = "correct horse battery staple"
+
+// Cheapest parameters accepted by ArgonConfig.validate, so tests do not spend seconds in key derivation
+var testArgonConfig = ArgonConfig{time: 1, memory: minArgonMemory, threads: 1}
+
+// Keeps witnessed versions in memory instead of data directory
+type memoryWitness struct {
+	version int64
+}
+
+func (witness *memoryWitness) LastVersion() (int64, error) {
+	return witness.version, nil
+}
+
+func (witness *memoryWitness) SawVersion(version int64) error {
+	witness.version = max(witness.version, version)
+	return nil
+}
+
+// Opens vault file at path, migrated to the latest schema. Connection is closed when test ends.
+func openTestBackend(t *testing.T, path string, witness VersionWitness) *Backend {
+	t.Helper()
+
+	backend, err := Initialize(path)
+
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	t.Cleanup(func() { backend.DB.Close() })
+
+	if witness != nil {
+		backend.SetVersionWitness(witness)
+	}
+
+	err = backend.CreateStructure()
+
+	if err != nil {
+		t.Fatalf("CreateStructure: %v", err)
+	}
+
+	return backend
+}
+
+// Creates vault with testMasterPassword in temporary directory and returns it with path of its file
+func newTestVault(t *testing.T, witness VersionWitness) (*Backend, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "vault.sqlite")
+	backend := openTestBackend(t, path, witness)
+
+	err := backend.initMaster(testMasterPassword, testArgonConfig)
+
+	if err != nil {
+		t.Fatalf("initMaster: %v", err)
+	}
+
+	return backend, path
+}
+
+func unlockTestVault(t *testing.T, backend *Backend) *Session {
+	t.Helper()
+
+	session, err := backend.Unlock(testMasterPassword)
+
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	t.Cleanup(session.Lock)
+
+	return session
+}
+
+func addTestEntry(t *testing.T, session *Session, serviceName string) {
+	t.Helper()
+
+	err := session.EncryptPasswordEntry(PasswordEntry{ServiceName: serviceName, Username: "user", Password: "password of " + serviceName})
+
+	if err != nil {
+		t.Fatalf("EncryptPasswordEntry %s: %v", serviceName, err)
+	}
+}
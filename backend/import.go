@@ -0,0 +1,304 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mszalewicz/frosk/helpers"
+)
+
+var UnknownCollisionPolicy = errors.New("Collision policy has to be skip, overwrite or rename.")
+
+// What to do with imported entry whose service name is already present in vault - service names are unique
+type CollisionPolicy int
+
+const (
+	CollisionSkip      CollisionPolicy = iota // keep stored entry, leave imported one out
+	CollisionOverwrite                        // replace stored entry with imported one
+	CollisionRename                           // store imported entry as "name (2)", "name (3)", ...
+)
+
+func (policy CollisionPolicy) String() string {
+	switch policy {
+	case CollisionOverwrite:
+		return "overwrite"
+	case CollisionRename:
+		return "rename"
+	default:
+		return "skip"
+	}
+}
+
+// Parses name of policy as returned by String
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	for _, policy := range []CollisionPolicy{CollisionSkip, CollisionOverwrite, CollisionRename} {
+		if policy.String() == name {
+			return policy, nil
+		}
+	}
+
+	return CollisionSkip, fmt.Errorf("%w %q", UnknownCollisionPolicy, name)
+}
+
+// What happened to imported entry
+type ImportOutcome int
+
+const (
+	ImportStored      ImportOutcome = iota // stored under its own service name
+	ImportRenamed                          // stored under new service name because of collision
+	ImportOverwritten                      // replaced stored entry with the same service name
+	ImportDuplicate                        // the same service name, username and password is already present
+	ImportSkipped                          // invalid or colliding with skip policy, see Reason
+)
+
+func (outcome ImportOutcome) String() string {
+	switch outcome {
+	case ImportRenamed:
+		return "renamed"
+	case ImportOverwritten:
+		return "overwritten"
+	case ImportDuplicate:
+		return "duplicate"
+	case ImportSkipped:
+		return "skipped"
+	default:
+		return "imported"
+	}
+}
+
+type ImportedEntry struct {
+	ServiceName string // service name given in import
+	StoredAs    string // service name in vault, differs from ServiceName when renamed
+	Outcome     ImportOutcome
+	Reason      string // why entry was skipped
+}
+
+// Outcome of import, entries are counted once and listed in import order
+type ImportSummary struct {
+	Imported    int
+	Renamed     int
+	Overwritten int
+	Duplicates  int
+	Skipped     int
+	Entries     []ImportedEntry
+	DryRun      bool // nothing was stored - summary shows what import would do
+}
+
+func (summary *ImportSummary) add(importedEntry ImportedEntry) {
+	switch importedEntry.Outcome {
+	case ImportStored:
+		summary.Imported++
+	case ImportRenamed:
+		summary.Renamed++
+	case ImportOverwritten:
+		summary.Overwritten++
+	case ImportDuplicate:
+		summary.Duplicates++
+	case ImportSkipped:
+		summary.Skipped++
+	}
+
+	summary.Entries = append(summary.Entries, importedEntry)
+}
+
+// Stores imported password entries in single transaction - either all of them are stored or none when error occurs.
+// Entries which fail validation, e.g. without username, are skipped with reason in summary. Entries with the same
+// service name, username and password as stored ones or earlier imported ones are left out as duplicates.
+// Other collisions of service names are resolved with given policy.
+func (session *Session) ImportPasswordEntries(passwordEntries []PasswordEntry, policy CollisionPolicy) (ImportSummary, error) {
+	return session.importPasswordEntries(passwordEntries, policy, false)
+}
+
+// Runs import like ImportPasswordEntries and rolls it back, so summary previews import without changing vault
+func (session *Session) PreviewPasswordEntriesImport(passwordEntries []PasswordEntry, policy CollisionPolicy) (ImportSummary, error) {
+	return session.importPasswordEntries(passwordEntries, policy, true)
+}
+
+func (session *Session) importPasswordEntries(passwordEntries []PasswordEntry, policy CollisionPolicy, dryRun bool) (ImportSummary, error) {
+	summary := ImportSummary{Entries: []ImportedEntry{}, DryRun: dryRun}
+
+	// Falls back to default when it can't be read, error is logged by backend
+	historyLength, _ := session.backend.GetPasswordHistoryLength()
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		tx, err := session.backend.DB.Begin()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during starting transaction for password entries import: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer tx.Rollback()
+
+		for _, passwordEntry := range passwordEntries {
+			err = validatePasswordEntry(passwordEntry)
+
+			if err != nil {
+				summary.add(ImportedEntry{ServiceName: passwordEntry.ServiceName, Outcome: ImportSkipped, Reason: err.Error()})
+				continue
+			}
+
+			importedEntry, err := importPasswordEntry(tx, gcm, session.index, passwordEntry, policy, historyLength)
+
+			if err != nil {
+				return err
+			}
+
+			summary.add(importedEntry)
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		version, err := session.backend.sealVault(tx, session.integrity)
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during commiting password entries import: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		session.backend.changed(version)
+
+		return nil
+	})
+
+	if err != nil {
+		return ImportSummary{}, err
+	}
+
+	if !dryRun {
+		slog.Info("Imported password entries.", "imported", summary.Imported, "renamed", summary.Renamed, "overwritten", summary.Overwritten, "duplicates", summary.Duplicates, "skipped", summary.Skipped)
+	}
+
+	return summary, nil
+}
+
+func importPasswordEntry(tx *sql.Tx, gcm cipher.AEAD, index serviceNameIndex, passwordEntry PasswordEntry, policy CollisionPolicy, historyLength int) (ImportedEntry, error) {
+	importedEntry := ImportedEntry{ServiceName: passwordEntry.ServiceName, StoredAs: passwordEntry.ServiceName}
+
+	id, found, duplicate, err := findImportedServiceName(tx, gcm, index, passwordEntry.ServiceName, passwordEntry)
+
+	if err != nil {
+		return ImportedEntry{}, err
+	}
+
+	switch {
+	case !found:
+		importedEntry.Outcome = ImportStored
+		return importedEntry, insertPasswordEntry(tx, gcm, index, passwordEntry)
+
+	case duplicate:
+		importedEntry.Outcome = ImportDuplicate
+		return importedEntry, nil
+
+	case policy == CollisionOverwrite:
+		err = recordPasswordHistory(tx, gcm, id, passwordEntry.ServiceName, passwordEntry.Password, historyLength)
+
+		if err != nil {
+			return ImportedEntry{}, err
+		}
+
+		_, err = tx.Exec(`UPDATE passwords SET updated_at = ? WHERE id = ?`, helpers.TimeTo8601String(time.Now()), id)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error updating overwritten password entry: %w", err)
+			slog.Error(errWrapped.Error())
+			return ImportedEntry{}, errWrapped
+		}
+
+		err = sealPasswordEntryFields(tx, gcm, id, passwordEntry)
+
+		if err != nil {
+			return ImportedEntry{}, err
+		}
+
+		importedEntry.Outcome = ImportOverwritten
+
+		// Export without tags keeps tags given to the entry in frosk
+		if len(passwordEntry.Tags) == 0 {
+			return importedEntry, nil
+		}
+
+		return importedEntry, setPasswordEntryTags(tx, gcm, index, id, passwordEntry.Tags)
+
+	case policy == CollisionRename:
+		// Entry renamed by earlier import of the same export is found as duplicate instead of being renamed again
+		for suffix := 2; ; suffix++ {
+			serviceName := fmt.Sprintf("%s (%d)", passwordEntry.ServiceName, suffix)
+			_, found, duplicate, err := findImportedServiceName(tx, gcm, index, serviceName, passwordEntry)
+
+			if err != nil {
+				return ImportedEntry{}, err
+			}
+
+			if duplicate {
+				importedEntry.StoredAs = serviceName
+				importedEntry.Outcome = ImportDuplicate
+				return importedEntry, nil
+			}
+
+			if !found {
+				passwordEntry.ServiceName = serviceName
+				importedEntry.StoredAs = serviceName
+				importedEntry.Outcome = ImportRenamed
+				return importedEntry, insertPasswordEntry(tx, gcm, index, passwordEntry)
+			}
+		}
+	}
+
+	importedEntry.Outcome = ImportSkipped
+	importedEntry.Reason = ServiceNameAlreadyTaken.Error()
+
+	return importedEntry, nil
+}
+
+// Looks for stored entry under given service name and checks whether it holds the same credentials as imported entry
+func findImportedServiceName(tx *sql.Tx, gcm cipher.AEAD, index serviceNameIndex, serviceName string, passwordEntry PasswordEntry) (int64, bool, bool, error) {
+	var (
+		id                   int64
+		usernameSealedBase64 string
+		passwordSealedBase64 string
+	)
+
+	err := tx.QueryRow(`SELECT id, username, "password" FROM passwords WHERE service_name_index = ? AND deleted_at IS NULL`, index.of(serviceName)).Scan(&id, &usernameSealedBase64, &passwordSealedBase64)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, false, nil
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Query looking for imported service name: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, false, false, errWrapped
+	}
+
+	username, err := openField(gcm, usernameSealedBase64, passwordEntryAdditionalData(id, fieldUsername, serviceName))
+
+	if err != nil {
+		return 0, false, false, err
+	}
+
+	password, err := openField(gcm, passwordSealedBase64, passwordEntryAdditionalData(id, fieldPassword, serviceName))
+
+	if err != nil {
+		return 0, false, false, err
+	}
+
+	duplicate := string(username) == passwordEntry.Username && string(password) == passwordEntry.Password
+	clear(password)
+
+	return id, true, duplicate, nil
+}
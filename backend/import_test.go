@@ -0,0 +1,178 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportCollisionPolicies(t *testing.T) {
+	tests := []struct {
+		policy       CollisionPolicy
+		wantOutcome  ImportOutcome
+		wantStoredAs string
+		wantPassword string // of entry stored under "github"
+	}{
+		{CollisionSkip, ImportSkipped, "github", "password of github"},
+		{CollisionOverwrite, ImportOverwritten, "github", "imported"},
+		{CollisionRename, ImportRenamed, "github (2)", "password of github"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			backend, _ := newTestVault(t, nil)
+			session := unlockTestVault(t, backend)
+
+			err := session.EncryptPasswordEntry(PasswordEntry{ServiceName: "github", Username: "user", Password: "password of github", Tags: []string{"work"}})
+
+			if err != nil {
+				t.Fatalf("EncryptPasswordEntry: %v", err)
+			}
+
+			summary, err := session.ImportPasswordEntries([]PasswordEntry{{ServiceName: "github", Username: "user", Password: "imported"}}, test.policy)
+
+			if err != nil {
+				t.Fatalf("ImportPasswordEntries: %v", err)
+			}
+
+			if len(summary.Entries) != 1 || summary.Entries[0].Outcome != test.wantOutcome || summary.Entries[0].StoredAs != test.wantStoredAs {
+				t.Fatalf("ImportPasswordEntries() entries = %+v, want %v stored as %q", summary.Entries, test.wantOutcome, test.wantStoredAs)
+			}
+
+			entry, err := session.DecryptPasswordEntry("github")
+
+			if err != nil || entry.Password != test.wantPassword {
+				t.Fatalf("DecryptPasswordEntry() = %+v, %v, want password %q", entry, err, test.wantPassword)
+			}
+
+			// Imported entry without tags keeps tags given in frosk
+			if !reflect.DeepEqual(entry.Tags, []string{"work"}) {
+				t.Errorf("Tags after import = %q, want [work]", entry.Tags)
+			}
+
+			if test.policy == CollisionOverwrite {
+				history, err := session.GetPasswordHistory("github")
+
+				if err != nil || len(history) != 1 || history[0].Password != "password of github" {
+					t.Errorf("GetPasswordHistory() = %v, %v, want overwritten password", history, err)
+				}
+			}
+
+			if test.policy == CollisionRename {
+				if renamed, err := session.DecryptPasswordEntry("github (2)"); err != nil || renamed.Password != "imported" {
+					t.Errorf("DecryptPasswordEntry(renamed) = %+v, %v, want imported entry", renamed, err)
+				}
+			}
+
+			if err := unlockTestVault(t, backend).Integrity(); err != nil {
+				t.Fatalf("Integrity() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestImportLeavesOutDuplicates(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+
+	passwordEntries := []PasswordEntry{
+		{ServiceName: "github", Username: "user", Password: "password of github"}, // already stored
+		{ServiceName: "gitlab", Username: "user", Password: "password of gitlab"},
+		{ServiceName: "gitlab", Username: "user", Password: "password of gitlab"}, // repeated in export
+		{ServiceName: "github", Username: "user", Password: "other"},
+	}
+
+	summary, err := session.ImportPasswordEntries(passwordEntries, CollisionRename)
+
+	if err != nil {
+		t.Fatalf("ImportPasswordEntries: %v", err)
+	}
+
+	if summary.Imported != 1 || summary.Duplicates != 2 || summary.Renamed != 1 {
+		t.Fatalf("ImportPasswordEntries() = %+v, want 1 imported, 2 duplicates and 1 renamed", summary)
+	}
+
+	// Entry renamed by first import is found as duplicate, not renamed again
+	summary, err = session.ImportPasswordEntries(passwordEntries, CollisionRename)
+
+	if err != nil {
+		t.Fatalf("ImportPasswordEntries again: %v", err)
+	}
+
+	if summary.Duplicates != len(passwordEntries) || summary.Entries[3].StoredAs != "github (2)" {
+		t.Fatalf("ImportPasswordEntries() again = %+v, want only duplicates", summary)
+	}
+
+	serviceNames, err := session.GetPasswordEntriesList()
+
+	if err != nil || len(serviceNames) != 3 {
+		t.Fatalf("GetPasswordEntriesList() = %q, %v, want 3 entries", serviceNames, err)
+	}
+}
+
+func TestImportSkipsInvalidEntries(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	summary, err := session.ImportPasswordEntries([]PasswordEntry{{ServiceName: "note", Password: "no username"}, {ServiceName: "github", Username: "user", Password: "secret"}}, CollisionSkip)
+
+	if err != nil {
+		t.Fatalf("ImportPasswordEntries: %v", err)
+	}
+
+	if summary.Skipped != 1 || summary.Imported != 1 || summary.Entries[0].Reason == "" {
+		t.Fatalf("ImportPasswordEntries() = %+v, want invalid entry skipped with reason", summary)
+	}
+}
+
+func TestPreviewPasswordEntriesImportChangesNothing(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+
+	version, _, err := backend.VaultVersion()
+
+	if err != nil {
+		t.Fatalf("VaultVersion: %v", err)
+	}
+
+	passwordEntries := []PasswordEntry{
+		{ServiceName: "github", Username: "user", Password: "imported"},
+		{ServiceName: "gitlab", Username: "user", Password: "secret"},
+	}
+
+	preview, err := session.PreviewPasswordEntriesImport(passwordEntries, CollisionOverwrite)
+
+	if err != nil {
+		t.Fatalf("PreviewPasswordEntriesImport: %v", err)
+	}
+
+	if !preview.DryRun || preview.Overwritten != 1 || preview.Imported != 1 {
+		t.Fatalf("PreviewPasswordEntriesImport() = %+v, want dry run with 1 overwritten and 1 imported", preview)
+	}
+
+	if entry, err := session.DecryptPasswordEntry("github"); err != nil || entry.Password != "password of github" {
+		t.Fatalf("DecryptPasswordEntry() after preview = %+v, %v, want entry unchanged", entry, err)
+	}
+
+	if _, err := session.DecryptPasswordEntry("gitlab"); err == nil {
+		t.Fatalf("DecryptPasswordEntry() of previewed entry = nil, want ServiceNameNotFound")
+	}
+
+	if versionAfter, _, _ := backend.VaultVersion(); versionAfter != version {
+		t.Fatalf("vault version after preview = %d, want %d", versionAfter, version)
+	}
+
+	// Import does what preview showed
+	summary, err := session.ImportPasswordEntries(passwordEntries, CollisionOverwrite)
+
+	if err != nil {
+		t.Fatalf("ImportPasswordEntries: %v", err)
+	}
+
+	preview.DryRun = false
+
+	if !reflect.DeepEqual(summary, preview) {
+		t.Fatalf("ImportPasswordEntries() = %+v, want %+v", summary, preview)
+	}
+}
@@ -0,0 +1,404 @@
+package backend
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"log/slog"
+	"time"
+
+	b64 "encoding/base64"
+
+	"github.com/mszalewicz/frosk/helpers"
+)
+
+// Vault-wide integrity protection. Single row of integrity table holds version of the vault, incremented by every change
+// of entries or master password, and HMAC over the version, master record, all rows of passwords table and settings
+// deciding what gets purged. MAC is keyed with key derived from user secret key, so rows changed, removed or
+// duplicated outside frosk are detected at unlock.
+// Highest version seen is also kept outside the vault by VersionWitness - older copy of the vault file put in place
+// of the current one carries valid MAC, but lower version.
+
+var VaultModified = errors.New("Vault was modified outside frosk.")
+var VaultRolledBack = errors.New("Vault was replaced with its older copy.")
+var VaultNotAccepted = errors.New("Vault content has to be accepted before it can be changed.")
+
+// Keeps highest version of the vault seen by frosk outside of the vault file
+type VersionWitness interface {
+	LastVersion() (int64, error)
+	SawVersion(version int64) error // records version when it is higher than the last one
+}
+
+// Registers witness of vault versions. Has to be called before backend is used.
+func (backend *Backend) SetVersionWitness(witness VersionWitness) {
+	backend.witness = witness
+}
+
+func (backend *Backend) lastWitnessedVersion() int64 {
+	if backend.witness == nil {
+		return 0
+	}
+
+	// Unreadable witness only weakens rollback detection, error is logged by witness
+	version, _ := backend.witness.LastVersion()
+	return version
+}
+
+// Key of vault MAC, derived from user secret key so it is not used for two purposes
+type integrityKey []byte
+
+func newIntegrityKey(userSecretKey []byte) integrityKey {
+	mac := hmac.New(sha256.New, userSecretKey)
+	mac.Write([]byte("frosk|integrity"))
+	return mac.Sum(nil)
+}
+
+// Read access shared by sql.DB and sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Writes length prefixed values, so boundaries between them can't be shifted
+func writeMACValues(mac hash.Hash, values ...sql.NullString) {
+	for _, value := range values {
+		if !value.Valid {
+			binary.Write(mac, binary.BigEndian, int64(-1))
+			continue
+		}
+
+		binary.Write(mac, binary.BigEndian, int64(len(value.String)))
+		mac.Write([]byte(value.String))
+	}
+}
+
+// Adds all rows returned by query to MAC, every column read as text
+func writeMACRows(mac hash.Hash, database querier, table string, query string) error {
+	rows, err := database.Query(query)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading %s table for vault MAC: %w", table, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading columns of %s table for vault MAC: %w", table, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	values := make([]sql.NullString, len(columns))
+	destinations := make([]any, len(columns))
+
+	for i := range values {
+		destinations[i] = &values[i]
+	}
+
+	mac.Write([]byte(table))
+
+	for rows.Next() {
+		err = rows.Scan(destinations...)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during scanning %s row for vault MAC: %w", table, err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		mac.Write([]byte{1}) // row marker, so removed row can't be mistaken for empty values
+		writeMACValues(mac, values...)
+	}
+
+	if err = rows.Err(); err != nil {
+		errWrapped := fmt.Errorf("Error during iterating %s table for vault MAC: %w", table, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Adds rows returned by query to MAC only when countQuery finds any
+func writeOptionalMACRows(mac hash.Hash, database querier, table string, countQuery string, query string) error {
+	var count int
+	err := database.QueryRow(countQuery).Scan(&count)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during counting rows of %s for vault MAC: %w", table, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return writeMACRows(mac, database, table, query)
+}
+
+// Computes MAC of the vault at given version. Timestamps are left out - they do not protect any secret, except time
+// of moving entry to trash, which decides when the entry gets purged. Of settings only those deciding what gets purged
+// are covered, see sealedSettingsCondition.
+func (key integrityKey) vaultMAC(database querier, version int64) (string, error) {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("frosk|vault"))
+	binary.Write(mac, binary.BigEndian, version)
+
+	err := writeMACRows(mac, database, "master",
+		`SELECT id, "password", secret_key, salt, initial_vector, kdf_algorithm, kdf_time, kdf_memory, kdf_threads FROM master ORDER BY id`)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = writeMACRows(mac, database, "passwords",
+		`SELECT id, service_name, service_name_index, username, "password", url, notes, custom_fields, totp FROM passwords ORDER BY id`)
+
+	if err != nil {
+		return "", err
+	}
+
+	// Trash, password history and tags are covered only when they are not empty, so vaults sealed before they existed
+	// keep their MAC
+	err = writeOptionalMACRows(mac, database, "trash",
+		`SELECT COUNT(*) FROM passwords WHERE deleted_at IS NOT NULL`,
+		`SELECT id, deleted_at FROM passwords WHERE deleted_at IS NOT NULL ORDER BY id`)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = writeOptionalMACRows(mac, database, "password_history",
+		`SELECT COUNT(*) FROM password_history`,
+		`SELECT id, password_id, "password" FROM password_history ORDER BY id`)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = writeOptionalMACRows(mac, database, "tags",
+		`SELECT COUNT(*) FROM tags`,
+		`SELECT id, name, name_index FROM tags ORDER BY id`)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = writeOptionalMACRows(mac, database, "password_tags",
+		`SELECT COUNT(*) FROM password_tags`,
+		`SELECT password_id, tag_id FROM password_tags ORDER BY password_id, tag_id`)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = writeOptionalMACRows(mac, database, "settings",
+		`SELECT COUNT(*) FROM settings WHERE `+sealedSettingsCondition,
+		`SELECT key, value FROM settings WHERE `+sealedSettingsCondition+` ORDER BY key`)
+
+	if err != nil {
+		return "", err
+	}
+
+	return b64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Returns version of the vault and whether the vault was sealed at all. Does not need user secret key.
+func (backend *Backend) VaultVersion() (int64, bool, error) {
+	var version int64
+	err := backend.DB.QueryRow("SELECT version FROM integrity").Scan(&version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading vault version: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, false, errWrapped
+	}
+
+	return version, true, nil
+}
+
+// Increments version of the vault and stores MAC of its content as changed within transaction. Vault which failed
+// integrity check is not sealed - sealing it would make content changed outside frosk pass the check on next unlock.
+// Fails with VaultNotAccepted until the content is accepted by acceptVault.
+func (backend *Backend) sealVault(tx *sql.Tx, key integrityKey) (int64, error) {
+	backend.integrityMutex.Lock()
+	integrityErr := backend.integrityErr
+	backend.integrityMutex.Unlock()
+
+	if integrityErr != nil {
+		errWrapped := fmt.Errorf("%w %w", VaultNotAccepted, integrityErr)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	return backend.storeVaultMAC(tx, key)
+}
+
+// Stores MAC of the vault content under version above both stored and witnessed one, so vault accepted after rollback
+// warning does not keep triggering it
+func (backend *Backend) storeVaultMAC(tx *sql.Tx, key integrityKey) (int64, error) {
+	var version int64
+	err := tx.QueryRow("SELECT version FROM integrity").Scan(&version)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		errWrapped := fmt.Errorf("Error during reading vault version: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	version = max(version, backend.lastWitnessedVersion()) + 1
+
+	vaultMAC, err := key.vaultMAC(tx, version)
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO integrity (id, version, mac, updated_at) VALUES (1, ?, ?, ?) ON CONFLICT (id) DO UPDATE SET version = excluded.version, mac = excluded.mac, updated_at = excluded.updated_at`,
+		version, vaultMAC, helpers.TimeTo8601String(time.Now()),
+	)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during storing vault MAC: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	return version, nil
+}
+
+// Seals vault in its own transaction
+func (backend *Backend) resealVault(key integrityKey) error {
+	return backend.sealInTransaction(key, backend.sealVault)
+}
+
+// Seals vault which failed integrity check, so its current content passes the check from now on
+func (backend *Backend) acceptVault(key integrityKey) error {
+	err := backend.sealInTransaction(key, backend.storeVaultMAC)
+
+	if err != nil {
+		return err
+	}
+
+	backend.integrityMutex.Lock()
+	backend.integrityErr = nil
+	backend.integrityMutex.Unlock()
+
+	return nil
+}
+
+func (backend *Backend) sealInTransaction(key integrityKey, seal func(tx *sql.Tx, key integrityKey) (int64, error)) error {
+	tx, err := backend.DB.Begin()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during starting transaction for sealing vault: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer tx.Rollback()
+
+	version, err := seal(tx, key)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during commiting vault MAC: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	backend.changed(version)
+
+	return nil
+}
+
+// Checks MAC and version of the vault. Returns whether vault is sealed and VaultModified or VaultRolledBack when
+// check fails - other errors mean check could not be done. Failed check is remembered, so the vault is not sealed
+// again until its content is accepted.
+func (backend *Backend) verifyVault(key integrityKey) (bool, error) {
+	sealed, version, err := backend.checkVault(key)
+
+	if err == nil || errors.Is(err, VaultModified) || errors.Is(err, VaultRolledBack) {
+		backend.integrityMutex.Lock()
+		backend.integrityErr = err
+		backend.integrityMutex.Unlock()
+	}
+
+	if err == nil && sealed {
+		backend.witnessVersion(version)
+	}
+
+	return sealed, err
+}
+
+// Compares stored MAC with content of the vault and stored version with witnessed one. Returns whether vault is
+// sealed and its version.
+func (backend *Backend) checkVault(key integrityKey) (bool, int64, error) {
+	var (
+		version   int64
+		storedMAC string
+	)
+
+	err := backend.DB.QueryRow("SELECT version, mac FROM integrity").Scan(&version, &storedMAC)
+	witnessed := backend.lastWitnessedVersion()
+
+	// Vault created before integrity protection, or never unlocked since. Witnessed version means MAC was removed.
+	if errors.Is(err, sql.ErrNoRows) {
+		if witnessed > 0 {
+			slog.Error("Vault MAC is missing.", "witnessed version", witnessed)
+			return false, 0, fmt.Errorf("%w MAC of the vault is missing.", VaultModified)
+		}
+
+		return false, 0, nil
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading vault MAC: %w", err)
+		slog.Error(errWrapped.Error())
+		return false, 0, errWrapped
+	}
+
+	vaultMAC, err := key.vaultMAC(backend.DB, version)
+
+	if err != nil {
+		return true, version, err
+	}
+
+	if !hmac.Equal([]byte(vaultMAC), []byte(storedMAC)) {
+		slog.Error("Vault MAC does not match its content.", "version", version)
+		return true, version, VaultModified
+	}
+
+	if version < witnessed {
+		slog.Error("Vault version is older than witnessed one.", "version", version, "witnessed version", witnessed)
+		return true, version, fmt.Errorf("%w Vault version %d, last seen version %d.", VaultRolledBack, version, witnessed)
+	}
+
+	return true, version, nil
+}
+
+func (backend *Backend) witnessVersion(version int64) {
+	if backend.witness != nil {
+		// Error is logged by witness, vault itself is already sealed
+		backend.witness.SawVersion(version)
+	}
+}
@@ -0,0 +1,146 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Key derivation parameters are stored per vault in master table, so defaults can change without locking out
+// existing vaults and every vault can be rehashed with parameters fitting machine it is used on.
+
+const KDFArgon2id = "argon2id"
+
+var UnsupportedKDF = errors.New("Vault uses unsupported key derivation function.")
+var InvalidArgonConfig = errors.New("Argon2id parameters are out of allowed range.")
+var WeakerArgonConfig = errors.New("New key derivation parameters are weaker than current ones.")
+
+const (
+	minArgonMemory = 64 * 1024 // 64MB
+	maxArgonTime   = 64
+
+	// Parameters are read from vault file or archive, which may come from another machine - too much memory would
+	// exhaust this one instead of protecting the key
+	maxArgonMemory = 4 * 1024 * 1024 // 4GB
+)
+
+// Derives 64 bytes from master password - first half is stored master password hash, second half key encrypting user secret key
+func (config ArgonConfig) deriveKeys(masterPassword string, salt []byte) []byte {
+	return argon2.IDKey([]byte(masterPassword), salt, config.time, config.memory, config.threads, 64)
+}
+
+func (config ArgonConfig) validate() error {
+	if config.time == 0 || config.time > maxArgonTime || config.memory < minArgonMemory || config.memory > maxArgonMemory || config.threads == 0 {
+		return InvalidArgonConfig
+	}
+
+	return nil
+}
+
+// Compares cost of brute forcing master password - number of passes times memory
+func (config ArgonConfig) IsWeakerThan(other ArgonConfig) bool {
+	return uint64(config.time)*uint64(config.memory) < uint64(other.time)*uint64(other.memory)
+}
+
+// Human readable form of parameters, memory in MB
+func (config ArgonConfig) String() string {
+	return fmt.Sprintf("%s t=%d m=%dMB p=%d", KDFArgon2id, config.time, config.memory/1024, config.threads)
+}
+
+// Returns key derivation parameters stored for the vault. Parameters out of allowed range fail with InvalidArgonConfig
+// before any key is derived with them.
+func (backend *Backend) GetArgonConfig() (ArgonConfig, error) {
+	var (
+		config    ArgonConfig
+		algorithm string
+	)
+
+	row := backend.DB.QueryRow("SELECT kdf_algorithm, kdf_time, kdf_memory, kdf_threads FROM master")
+	err := row.Scan(&algorithm, &config.time, &config.memory, &config.threads)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading key derivation parameters from master table: %w", err)
+		slog.Error(errWrapped.Error())
+		return config, errWrapped
+	}
+
+	if algorithm != KDFArgon2id {
+		errWrapped := fmt.Errorf("%w: %s", UnsupportedKDF, algorithm)
+		slog.Error(errWrapped.Error())
+		return config, errWrapped
+	}
+
+	err = config.validate()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("%w Stored parameters: %s.", err, config)
+		slog.Error(errWrapped.Error())
+		return config, errWrapped
+	}
+
+	return config, nil
+}
+
+// Picks Argon2id parameters so that single derivation takes around targetDuration on current machine.
+// Memory stays at default value, number of passes is adjusted to measured speed.
+func CalibrateArgonConfig(targetDuration time.Duration) ArgonConfig {
+	config := GetDefaultArgonConfig()
+	config.time = 1
+
+	threads := runtime.NumCPU()
+	if threads > 255 {
+		threads = 255
+	}
+	config.threads = uint8(threads)
+
+	salt := make([]byte, 16)
+
+	start := time.Now()
+	config.deriveKeys("calibration", salt)
+	singlePassDuration := time.Since(start)
+
+	if singlePassDuration <= 0 {
+		singlePassDuration = time.Millisecond
+	}
+
+	passes := int64(targetDuration / singlePassDuration)
+
+	switch {
+	case passes < 1:
+		passes = 1
+	case passes > maxArgonTime:
+		passes = maxArgonTime
+	}
+
+	config.time = uint32(passes)
+
+	slog.Info("Calibrated key derivation parameters.", "target", targetDuration, "single_pass", singlePassDuration, "config", config.String())
+
+	return config
+}
+
+// Re-encrypts user secret key with key derived from the same master password using stronger parameters and fresh salt.
+// Password entries stay untouched.
+func (backend *Backend) RehashMasterPassword(masterPassword string, config ArgonConfig) error {
+	err := config.validate()
+
+	if err != nil {
+		return err
+	}
+
+	currentConfig, err := backend.GetArgonConfig()
+
+	if err != nil {
+		return err
+	}
+
+	if config.IsWeakerThan(currentConfig) {
+		return WeakerArgonConfig
+	}
+
+	return backend.rewrapUserSecretKey(masterPassword, masterPassword, config)
+}
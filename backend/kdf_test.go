@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestArgonConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ArgonConfig
+		valid  bool
+	}{
+		{"default", GetDefaultArgonConfig(), true},
+		{"minimal", ArgonConfig{time: 1, memory: minArgonMemory, threads: 1}, true},
+		{"maximal", ArgonConfig{time: maxArgonTime, memory: maxArgonMemory, threads: 255}, true},
+		{"no passes", ArgonConfig{time: 0, memory: minArgonMemory, threads: 1}, false},
+		{"too many passes", ArgonConfig{time: maxArgonTime + 1, memory: minArgonMemory, threads: 1}, false},
+		{"too little memory", ArgonConfig{time: 1, memory: minArgonMemory - 1, threads: 1}, false},
+		{"too much memory", ArgonConfig{time: 1, memory: maxArgonMemory + 1, threads: 1}, false},
+		{"no threads", ArgonConfig{time: 1, memory: minArgonMemory, threads: 0}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.validate()
+
+			if test.valid && err != nil {
+				t.Errorf("validate() = %v, want nil", err)
+			}
+
+			if !test.valid && !errors.Is(err, InvalidArgonConfig) {
+				t.Errorf("validate() = %v, want InvalidArgonConfig", err)
+			}
+		})
+	}
+}
+
+// Stored parameters out of range would make key derivation panic, hang or run out of memory
+func TestUnlockRejectsStoredArgonConfigOutOfRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		update string
+	}{
+		{"no passes", "UPDATE master SET kdf_time = 0"},
+		{"no threads", "UPDATE master SET kdf_threads = 0"},
+		{"too much memory", "UPDATE master SET kdf_memory = 4294967295"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend, _ := newTestVault(t, nil)
+
+			_, err := backend.DB.Exec(test.update)
+
+			if err != nil {
+				t.Fatalf("changing parameters: %v", err)
+			}
+
+			_, err = backend.Unlock(testMasterPassword)
+
+			if !errors.Is(err, InvalidArgonConfig) {
+				t.Fatalf("Unlock() = %v, want InvalidArgonConfig", err)
+			}
+		})
+	}
+}
+
+func TestRehashMasterPasswordRejectsWeakerConfig(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	stronger := ArgonConfig{time: 2, memory: minArgonMemory, threads: 1}
+
+	err := backend.RehashMasterPassword(testMasterPassword, stronger)
+
+	if err != nil {
+		t.Fatalf("RehashMasterPassword() = %v", err)
+	}
+
+	err = backend.RehashMasterPassword(testMasterPassword, testArgonConfig)
+
+	if !errors.Is(err, WeakerArgonConfig) {
+		t.Fatalf("RehashMasterPassword() with weaker config = %v, want WeakerArgonConfig", err)
+	}
+
+	config, err := backend.GetArgonConfig()
+
+	if err != nil || config != stronger {
+		t.Fatalf("GetArgonConfig() = %v, %v, want %v", config, err, stronger)
+	}
+
+	unlockTestVault(t, backend)
+}
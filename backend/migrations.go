@@ -0,0 +1,365 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Schema version of the vault is kept in sqlite PRAGMA user_version. Every migration runs in its own transaction
+// together with the version bump, so vault is never left between two versions.
+//
+// Never edit or reorder migrations which were already released - append new ones at the end instead.
+// After adding migration regenerate sql/schema.sql with `make schema`.
+
+type migration struct {
+	version     int
+	description string
+	apply       func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create passwords and master tables",
+		apply: execStatements(
+			`CREATE TABLE IF NOT EXISTS passwords (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				service_name TEXT UNIQUE NOT NULL,
+				username TEXT NOT NULL,
+				password TEXT NOT NULL,
+				initial_vector TEXT UNIQUE NOT NULL,
+				created_at TEXT NULL,
+				updated_at TEXT NULL
+			) STRICT`,
+			`CREATE TABLE IF NOT EXISTS master (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				password TEXT UNIQUE NOT NULL,
+				secret_key TEXT UNIQUE NOT NULL,
+				salt TEXT UNIQUE NOT NULL,
+				initial_vector TEXT UNIQUE NOT NULL,
+				created_at TEXT NULL,
+				updated_at TEXT NULL
+			) STRICT`,
+		),
+	},
+	{
+		version:     2,
+		description: "drop shared initial vector of password entries in favour of per-field initial vectors",
+		apply:       migratePasswordsToPerFieldInitialVectors,
+	},
+	{
+		version:     3,
+		description: "store key derivation parameters per vault",
+		// Defaults are parameters hard-coded before they were stored, so existing vaults keep unlocking
+		apply: execStatements(
+			`ALTER TABLE master ADD COLUMN kdf_algorithm TEXT NOT NULL DEFAULT 'argon2id'`,
+			`ALTER TABLE master ADD COLUMN kdf_time INTEGER NOT NULL DEFAULT 3`,
+			`ALTER TABLE master ADD COLUMN kdf_memory INTEGER NOT NULL DEFAULT 524288`,
+			`ALTER TABLE master ADD COLUMN kdf_threads INTEGER NOT NULL DEFAULT 8`,
+		),
+	},
+	{
+		version:     4,
+		description: "create settings table",
+		apply: execStatements(
+			`CREATE TABLE IF NOT EXISTS settings (
+				key TEXT PRIMARY KEY,
+				value TEXT NOT NULL,
+				updated_at TEXT NULL
+			) STRICT`,
+		),
+	},
+	{
+		version:     5,
+		description: "add encrypted url, notes and custom fields of password entries",
+		// Empty value marks entry saved before the fields existed - it is sealed on its next update
+		apply: execStatements(
+			`ALTER TABLE passwords ADD COLUMN url TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE passwords ADD COLUMN notes TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE passwords ADD COLUMN custom_fields TEXT NOT NULL DEFAULT ''`,
+		),
+	},
+	{
+		version:     6,
+		description: "add encrypted totp secret of password entries",
+		apply: execStatements(
+			`ALTER TABLE passwords ADD COLUMN totp TEXT NOT NULL DEFAULT ''`,
+		),
+	},
+	{
+		version:     7,
+		description: "add blind index of encrypted service names",
+		// Missing index marks service name still stored in plain text - it is encrypted on next unlock
+		apply: execStatements(
+			`ALTER TABLE passwords ADD COLUMN service_name_index TEXT NULL`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS passwords_service_name_index ON passwords (service_name_index)`,
+		),
+	},
+	{
+		version:     8,
+		description: "create integrity table holding version and MAC of the vault",
+		// Vault is sealed on its next unlock
+		apply: execStatements(
+			`CREATE TABLE IF NOT EXISTS integrity (
+				id INTEGER PRIMARY KEY CHECK (id = 1),
+				version INTEGER NOT NULL,
+				mac TEXT NOT NULL,
+				updated_at TEXT NULL
+			) STRICT`,
+		),
+	},
+	{
+		version:     9,
+		description: "add trash of password entries",
+		// Entry in trash keeps its service name index, name can be taken again only by entry outside of trash
+		apply: execStatements(
+			`ALTER TABLE passwords ADD COLUMN deleted_at TEXT NULL`,
+			`DROP INDEX IF EXISTS passwords_service_name_index`,
+			`CREATE UNIQUE INDEX passwords_service_name_index ON passwords (service_name_index) WHERE deleted_at IS NULL`,
+		),
+	},
+	{
+		version:     10,
+		description: "create password history table",
+		apply: execStatements(
+			`CREATE TABLE IF NOT EXISTS password_history (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				password_id INTEGER NOT NULL REFERENCES passwords (id),
+				password TEXT NOT NULL,
+				replaced_at TEXT NOT NULL
+			) STRICT`,
+			`CREATE INDEX IF NOT EXISTS password_history_password_id ON password_history (password_id)`,
+		),
+	},
+	{
+		version:     11,
+		description: "create tags of password entries",
+		apply: execStatements(
+			`CREATE TABLE IF NOT EXISTS tags (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				name_index TEXT UNIQUE NOT NULL
+			) STRICT`,
+			`CREATE TABLE IF NOT EXISTS password_tags (
+				password_id INTEGER NOT NULL REFERENCES passwords (id),
+				tag_id INTEGER NOT NULL REFERENCES tags (id),
+				PRIMARY KEY (password_id, tag_id)
+			) STRICT`,
+			`CREATE INDEX IF NOT EXISTS password_tags_tag_id ON password_tags (tag_id)`,
+		),
+	},
+}
+
+// Returns migration applying given sql statements in order
+func execStatements(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			_, err := tx.Exec(statement)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Checks whether table has column with given name
+func hasColumn(tx *sql.Tx, table string, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count)
+
+	return count > 0, err
+}
+
+// Rebuilds passwords table without UNIQUE shared initial_vector column. Ciphertexts can't be re-encrypted without
+// user secret key, so initial vector of not yet migrated entries is kept in legacy_initial_vector until first unlock.
+func migratePasswordsToPerFieldInitialVectors(tx *sql.Tx) error {
+	isLegacy, err := hasColumn(tx, "passwords", "initial_vector")
+
+	if err != nil {
+		return err
+	}
+
+	if !isLegacy {
+		_, err = tx.Exec("ALTER TABLE passwords ADD COLUMN legacy_initial_vector TEXT NULL")
+		return err
+	}
+
+	return execStatements(
+		`CREATE TABLE passwords_migrated (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_name TEXT UNIQUE NOT NULL,
+			username TEXT NOT NULL,
+			password TEXT NOT NULL,
+			created_at TEXT NULL,
+			updated_at TEXT NULL,
+			legacy_initial_vector TEXT NULL
+		) STRICT`,
+		`INSERT INTO passwords_migrated (id, service_name, username, password, created_at, updated_at, legacy_initial_vector)
+			SELECT id, service_name, username, password, created_at, updated_at, initial_vector FROM passwords`,
+		`DROP TABLE passwords`,
+		`ALTER TABLE passwords_migrated RENAME TO passwords`,
+	)(tx)
+}
+
+// Returns current schema version of the vault
+func (backend *Backend) SchemaVersion() (int, error) {
+	var version int
+	err := backend.DB.QueryRow("PRAGMA user_version").Scan(&version)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading schema version: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	return version, nil
+}
+
+// Applies all migrations newer than current schema version of the vault
+func (backend *Backend) Migrate() error {
+	currentVersion, err := backend.SchemaVersion()
+
+	if err != nil {
+		return err
+	}
+
+	latestVersion := migrations[len(migrations)-1].version
+
+	if currentVersion > latestVersion {
+		errWrapped := fmt.Errorf("Vault schema version %d is newer than supported version %d", currentVersion, latestVersion)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	for _, migration := range migrations {
+		if migration.version <= currentVersion {
+			continue
+		}
+
+		err = backend.applyMigration(migration)
+
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Applied vault schema migration.", "version", migration.version, "description", migration.description)
+	}
+
+	return nil
+}
+
+func (backend *Backend) applyMigration(migration migration) error {
+	tx, err := backend.DB.Begin()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during starting transaction for migration %d: %w", migration.version, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer tx.Rollback()
+
+	err = migration.apply(tx)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during migration %d (%s): %w", migration.version, migration.description, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	// PRAGMA does not accept bound parameters, version comes from migrations list
+	_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", migration.version))
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during setting schema version %d: %w", migration.version, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during commiting migration %d: %w", migration.version, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Returns sql creating the schema of the vault in its current version - used to generate sql/schema.sql
+func (backend *Backend) DumpSchema() (string, error) {
+	version, err := backend.SchemaVersion()
+
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := backend.DB.Query("SELECT sql FROM sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY type DESC, name")
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading vault schema: %w", err)
+		slog.Error(errWrapped.Error())
+		return "", errWrapped
+	}
+
+	defer rows.Close()
+
+	var schema strings.Builder
+	fmt.Fprintf(&schema, "-- Generated by `make schema` from migrations in backend/migrations.go. Do not edit by hand.\n")
+	fmt.Fprintf(&schema, "-- Schema version: %d\n", version)
+
+	for rows.Next() {
+		var statement string
+
+		err = rows.Scan(&statement)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during scanning vault schema: %w", err)
+			slog.Error(errWrapped.Error())
+			return "", errWrapped
+		}
+
+		fmt.Fprintf(&schema, "\n%s;\n", formatCreateStatement(statement))
+	}
+
+	return schema.String(), rows.Err()
+}
+
+// Statements are stored by sqlite exactly as written in migrations, with columns added by ALTER TABLE appended
+// at the end - print every column definition in its own line for readability
+func formatCreateStatement(statement string) string {
+	openIdx := strings.Index(statement, "(")
+	closeIdx := strings.LastIndex(statement, ")")
+
+	if openIdx == -1 || closeIdx < openIdx || !strings.HasPrefix(statement, "CREATE TABLE") {
+		return statement
+	}
+
+	definitions := make([]string, 0)
+	depth := 0
+	last := openIdx + 1
+
+	for i := openIdx + 1; i < closeIdx; i++ {
+		switch statement[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, strings.Join(strings.Fields(statement[last:i]), " "))
+				last = i + 1
+			}
+		}
+	}
+
+	definitions = append(definitions, strings.Join(strings.Fields(statement[last:closeIdx]), " "))
+
+	return strings.TrimSpace(statement[:openIdx]) + " (\n    " + strings.Join(definitions, ",\n    ") + "\n" + statement[closeIdx:]
+}
@@ -0,0 +1,252 @@
+package backend
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	b64 "encoding/base64"
+)
+
+// Tables exactly as created by the first release, before schema versioning
+const baselineSchema = `
+	CREATE TABLE IF NOT EXISTS passwords (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		service_name TEXT UNIQUE NOT NULL,
+		username TEXT NOT NULL,
+		password TEXT NOT NULL,
+		initial_vector TEXT UNIQUE NOT NULL,
+		created_at TEXT NULL,
+		updated_at TEXT NULL
+	) STRICT;
+
+	CREATE TABLE IF NOT EXISTS master (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		password TEXT UNIQUE NOT NULL,
+		secret_key TEXT UNIQUE NOT NULL,
+		salt TEXT UNIQUE NOT NULL,
+		initial_vector TEXT UNIQUE NOT NULL,
+		created_at TEXT NULL,
+		updated_at TEXT NULL
+	) STRICT;
+`
+
+// Creates vault in format of the first release holding entries made by addTestEntry and returns path of its file.
+// First release derived keys with fixed parameters - testArgonConfig is used instead, see lowerStoredArgonConfig.
+func newBaselineVault(t *testing.T, serviceNames ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "baseline.sqlite")
+	db, err := sql.Open("sqlite3", path)
+
+	if err != nil {
+		t.Fatalf("opening baseline vault: %v", err)
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec(baselineSchema)
+
+	if err != nil {
+		t.Fatalf("creating baseline tables: %v", err)
+	}
+
+	salt := randomBytes(t, 16)
+	argonOutput := testArgonConfig.deriveKeys(testMasterPassword, salt)
+	masterGCM, err := InitGCM(argonOutput[32:64])
+
+	if err != nil {
+		t.Fatalf("InitGCM: %v", err)
+	}
+
+	userSecretKey := randomBytes(t, 32)
+	masterInitialVector := randomBytes(t, masterGCM.NonceSize())
+
+	_, err = db.Exec("INSERT INTO master (password, secret_key, salt, initial_vector) VALUES (?, ?, ?, ?)",
+		b64.StdEncoding.EncodeToString(argonOutput[0:32]),
+		b64.StdEncoding.EncodeToString(masterGCM.Seal(nil, masterInitialVector, userSecretKey, nil)),
+		b64.StdEncoding.EncodeToString(salt),
+		b64.StdEncoding.EncodeToString(masterInitialVector))
+
+	if err != nil {
+		t.Fatalf("inserting baseline master: %v", err)
+	}
+
+	gcm, err := InitGCM(userSecretKey)
+
+	if err != nil {
+		t.Fatalf("InitGCM: %v", err)
+	}
+
+	// Username and password shared one initial vector and were not bound to their row
+	for _, serviceName := range serviceNames {
+		initialVector := randomBytes(t, gcm.NonceSize())
+
+		_, err = db.Exec("INSERT INTO passwords (service_name, username, password, initial_vector) VALUES (?, ?, ?, ?)",
+			serviceName,
+			b64.StdEncoding.EncodeToString(gcm.Seal(nil, initialVector, []byte("user"), nil)),
+			b64.StdEncoding.EncodeToString(gcm.Seal(nil, initialVector, []byte("password of "+serviceName), nil)),
+			b64.StdEncoding.EncodeToString(initialVector))
+
+		if err != nil {
+			t.Fatalf("inserting baseline entry %s: %v", serviceName, err)
+		}
+	}
+
+	return path
+}
+
+func randomBytes(t *testing.T, length int) []byte {
+	t.Helper()
+
+	value := make([]byte, length)
+
+	if _, err := rand.Read(value); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+
+	return value
+}
+
+// Migration 3 stores parameters the first release hard-coded - replaced with the ones baseline vault was made with
+func lowerStoredArgonConfig(t *testing.T, backend *Backend) {
+	t.Helper()
+
+	_, err := backend.DB.Exec("UPDATE master SET kdf_time = ?, kdf_memory = ?, kdf_threads = ?", testArgonConfig.time, testArgonConfig.memory, testArgonConfig.threads)
+
+	if err != nil {
+		t.Fatalf("lowering stored argon config: %v", err)
+	}
+}
+
+// Opens baseline vault and applies every migration, checking schema version after each of them
+func migrateBaselineVault(t *testing.T, path string) *Backend {
+	t.Helper()
+
+	backend, err := Initialize(path)
+
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	t.Cleanup(func() { backend.DB.Close() })
+	backend.SetVersionWitness(&memoryWitness{})
+
+	if version, err := backend.SchemaVersion(); err != nil || version != 0 {
+		t.Fatalf("SchemaVersion() of baseline vault = %d, %v, want 0", version, err)
+	}
+
+	for _, migration := range migrations {
+		err = backend.applyMigration(migration)
+
+		if err != nil {
+			t.Fatalf("applyMigration(%d): %v", migration.version, err)
+		}
+
+		if version, err := backend.SchemaVersion(); err != nil || version != migration.version {
+			t.Fatalf("SchemaVersion() after migration %d = %d, %v", migration.version, version, err)
+		}
+
+		if migration.version == 3 {
+			lowerStoredArgonConfig(t, backend)
+		}
+	}
+
+	return backend
+}
+
+func TestMigrationsAreNumberedInOrder(t *testing.T) {
+	for index, migration := range migrations {
+		if migration.version != index+1 {
+			t.Fatalf("migrations[%d].version = %d, want %d", index, migration.version, index+1)
+		}
+	}
+}
+
+func TestMigrateBaselineVault(t *testing.T) {
+	backend := migrateBaselineVault(t, newBaselineVault(t, "github", "gitlab"))
+
+	schema, err := backend.DumpSchema()
+
+	if err != nil {
+		t.Fatalf("DumpSchema() = %v", err)
+	}
+
+	generated, err := os.ReadFile("../sql/schema.sql")
+
+	if err != nil {
+		t.Fatalf("reading sql/schema.sql: %v", err)
+	}
+
+	if schema != string(generated) {
+		t.Errorf("schema of migrated baseline vault differs from sql/schema.sql, run `make schema`:\n%s", schema)
+	}
+
+	// Latest vault is left as it is
+	err = backend.Migrate()
+
+	if err != nil {
+		t.Fatalf("Migrate() of latest vault = %v", err)
+	}
+
+	session := unlockTestVault(t, backend)
+
+	if err := session.Integrity(); err != nil {
+		t.Fatalf("Integrity() of migrated vault = %v, want nil", err)
+	}
+
+	for _, serviceName := range []string{"github", "gitlab"} {
+		entry, err := session.DecryptPasswordEntry(serviceName)
+
+		if err != nil || entry.Username != "user" || entry.Password != "password of "+serviceName {
+			t.Errorf("DecryptPasswordEntry(%s) = %+v, %v", serviceName, entry, err)
+		}
+	}
+
+	var unmigrated int
+	backend.DB.QueryRow("SELECT COUNT(*) FROM passwords WHERE legacy_initial_vector IS NOT NULL OR service_name_index IS NULL").Scan(&unmigrated)
+
+	if unmigrated != 0 {
+		t.Errorf("%d entries left in legacy format after unlock", unmigrated)
+	}
+}
+
+func TestFreshVaultMatchesGeneratedSchema(t *testing.T) {
+	backend, _ := newTestVault(t, &memoryWitness{})
+
+	schema, err := backend.DumpSchema()
+
+	if err != nil {
+		t.Fatalf("DumpSchema() = %v", err)
+	}
+
+	generated, err := os.ReadFile("../sql/schema.sql")
+
+	if err != nil {
+		t.Fatalf("reading sql/schema.sql: %v", err)
+	}
+
+	if schema != string(generated) {
+		t.Errorf("schema of new vault differs from sql/schema.sql, run `make schema`:\n%s", schema)
+	}
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	backend, _ := newTestVault(t, &memoryWitness{})
+
+	_, err := backend.DB.Exec("PRAGMA user_version = 1000")
+
+	if err != nil {
+		t.Fatalf("setting user_version: %v", err)
+	}
+
+	if err := backend.Migrate(); err == nil {
+		t.Fatalf("Migrate() of vault from newer version = nil, want error")
+	}
+
+	if version, _ := backend.SchemaVersion(); version != 1000 {
+		t.Fatalf("SchemaVersion() = %d, want 1000 left untouched", version)
+	}
+}
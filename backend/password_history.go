@@ -0,0 +1,220 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mszalewicz/frosk/helpers"
+)
+
+// Passwords replaced by update or overwriting import are kept in password_history table, encrypted like password
+// entries themselves. Additional data binds them to the entry row and history row, but not to the service name,
+// so history survives renaming of the entry. Only the newest passwords up to password history length setting are kept.
+
+type PasswordHistoryEntry struct {
+	Password   string
+	ReplacedAt string // ISO 8601, as stored
+}
+
+// Builds GCM additional data binding replaced password to its entry and place in password_history table
+func passwordHistoryAdditionalData(passwordID int64, id int64) []byte {
+	return []byte(fmt.Sprintf("frosk|password_history|%d|%d", passwordID, id))
+}
+
+// Returns how many replaced passwords are kept per entry. Zero means history is not kept.
+func (backend *Backend) GetPasswordHistoryLength() (int, error) {
+	length, err := backend.getIntSetting(settingPasswordHistoryLength, DefaultPasswordHistoryLength)
+	return min(length, maxPasswordHistoryLength), err
+}
+
+// Saves password history length and removes replaced passwords above it from all entries. Both happen in one
+// transaction, so stored length always matches trimmed history.
+func (session *Session) SetPasswordHistoryLength(length int) error {
+	if length < 0 || length > maxPasswordHistoryLength {
+		return InvalidSettingValue
+	}
+
+	_, err := session.writeSealed("saving of password history length", func(tx *sql.Tx, gcm cipher.AEAD) (int64, error) {
+		err := writeSetting(tx, settingPasswordHistoryLength, strconv.Itoa(length))
+
+		if err != nil {
+			return 0, err
+		}
+
+		trimmed, err := trimPasswordHistory(tx, length)
+
+		// Saved setting counts as changed row, so transaction is committed also when nothing got trimmed
+		return trimmed + 1, err
+	})
+
+	return err
+}
+
+// Stores current password of entry in history when it differs from new password, then trims history to given length
+func recordPasswordHistory(tx *sql.Tx, gcm cipher.AEAD, id int64, serviceName string, newPassword string, length int) error {
+	if length > 0 {
+		var currentPasswordSealedBase64 string
+		err := tx.QueryRow(`SELECT "password" FROM passwords WHERE id = ?`, id).Scan(&currentPasswordSealedBase64)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Query reading replaced password: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		currentPassword, err := openField(gcm, currentPasswordSealedBase64, passwordEntryAdditionalData(id, fieldPassword, serviceName))
+
+		if err != nil {
+			return err
+		}
+
+		defer clear(currentPassword)
+
+		if string(currentPassword) == newPassword {
+			return nil
+		}
+
+		// Row id is part of additional data, so the row has to exist before sealing
+		result, err := tx.Exec(`INSERT INTO password_history (password_id, "password", replaced_at) VALUES (?, '', ?)`, id, helpers.TimeTo8601String(time.Now()))
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error inserting replaced password into password history: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		historyID, err := result.LastInsertId()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error reading id of password history row: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		passwordSealed, err := sealField(gcm, currentPassword, passwordHistoryAdditionalData(id, historyID))
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE password_history SET "password" = ? WHERE id = ?`, passwordSealed, historyID)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error storing encrypted replaced password: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+	}
+
+	_, err := trimPasswordHistory(tx, length)
+
+	return err
+}
+
+// Removes replaced passwords above given length from history of every entry, oldest first. Returns number of removed ones.
+func trimPasswordHistory(tx *sql.Tx, length int) (int64, error) {
+	result, err := tx.Exec(
+		`DELETE FROM password_history WHERE id IN (
+			SELECT id FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY password_id ORDER BY id DESC) AS position FROM password_history)
+			WHERE position > ?
+		)`,
+		length,
+	)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during trimming password history: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, errWrapped
+	}
+
+	return result.RowsAffected()
+}
+
+// Removes history of password entries which no longer exist
+func deletePasswordHistoryOfPurged(tx *sql.Tx) error {
+	_, err := tx.Exec(`DELETE FROM password_history WHERE password_id NOT IN (SELECT id FROM passwords)`)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during removing password history of purged entries: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Returns decrypted passwords replaced in entry with given service name, most recently replaced first
+func (session *Session) GetPasswordHistory(serviceName string) ([]PasswordHistoryEntry, error) {
+	history := make([]PasswordHistoryEntry, 0)
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		var passwordID int64
+		err := session.backend.DB.QueryRow("SELECT id FROM passwords WHERE service_name_index = ? AND deleted_at IS NULL", session.index.of(serviceName)).Scan(&passwordID)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return ServiceNameNotFound
+		}
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Query looking for service name of password history: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		rows, err := session.backend.DB.Query(`SELECT id, "password", replaced_at FROM password_history WHERE password_id = ? ORDER BY id DESC`, passwordID)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during reading password history: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				id                   int64
+				passwordSealedBase64 string
+				historyEntry         PasswordHistoryEntry
+			)
+
+			err = rows.Scan(&id, &passwordSealedBase64, &historyEntry.ReplacedAt)
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Error during scanning password history: %w", err)
+				slog.Error(errWrapped.Error())
+				return errWrapped
+			}
+
+			password, err := openField(gcm, passwordSealedBase64, passwordHistoryAdditionalData(passwordID, id))
+
+			if err != nil {
+				return err
+			}
+
+			historyEntry.Password = string(password)
+			clear(password)
+
+			history = append(history, historyEntry)
+		}
+
+		if err = rows.Err(); err != nil {
+			errWrapped := fmt.Errorf("Error during iterating password history: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+)
+
+func updateTestPassword(t *testing.T, session *Session, serviceName string, password string) {
+	t.Helper()
+
+	err := session.UpdatePasswordEntry(serviceName, PasswordEntry{ServiceName: serviceName, Username: "user", Password: password})
+
+	if err != nil {
+		t.Fatalf("UpdatePasswordEntry: %v", err)
+	}
+}
+
+func TestPasswordHistoryKeepsReplacedPasswordsNewestFirst(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+
+	updateTestPassword(t, session, "github", "second")
+	updateTestPassword(t, session, "github", "second") // unchanged password is not recorded
+	updateTestPassword(t, session, "github", "third")
+
+	history, err := session.GetPasswordHistory("github")
+
+	if err != nil {
+		t.Fatalf("GetPasswordHistory: %v", err)
+	}
+
+	want := []string{"second", "password of github"}
+
+	if len(history) != len(want) {
+		t.Fatalf("GetPasswordHistory() has %d entries, want %d", len(history), len(want))
+	}
+
+	for i, password := range want {
+		if history[i].Password != password {
+			t.Errorf("history[%d] = %q, want %q", i, history[i].Password, password)
+		}
+	}
+}
+
+func TestSetPasswordHistoryLengthTrimsHistory(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+
+	for _, password := range []string{"1", "2", "3", "4"} {
+		updateTestPassword(t, session, "github", password)
+	}
+
+	err := session.SetPasswordHistoryLength(2)
+
+	if err != nil {
+		t.Fatalf("SetPasswordHistoryLength: %v", err)
+	}
+
+	length, err := backend.GetPasswordHistoryLength()
+
+	if err != nil || length != 2 {
+		t.Fatalf("GetPasswordHistoryLength() = %d, %v, want 2", length, err)
+	}
+
+	history, err := session.GetPasswordHistory("github")
+
+	if err != nil {
+		t.Fatalf("GetPasswordHistory: %v", err)
+	}
+
+	if len(history) != 2 || history[0].Password != "3" || history[1].Password != "2" {
+		t.Fatalf("GetPasswordHistory() = %v, want passwords 3 and 2", history)
+	}
+
+	// Nothing left to trim, setting is saved anyway
+	err = session.SetPasswordHistoryLength(5)
+
+	if err != nil {
+		t.Fatalf("SetPasswordHistoryLength: %v", err)
+	}
+
+	if length, _ := backend.GetPasswordHistoryLength(); length != 5 {
+		t.Fatalf("GetPasswordHistoryLength() = %d, want 5", length)
+	}
+
+	if err := unlockTestVault(t, backend).Integrity(); err != nil {
+		t.Fatalf("Integrity() = %v, want nil", err)
+	}
+}
+
+func TestSetPasswordHistoryLengthRejectsOutOfRange(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	for _, length := range []int{-1, maxPasswordHistoryLength + 1} {
+		if err := session.SetPasswordHistoryLength(length); !errors.Is(err, InvalidSettingValue) {
+			t.Errorf("SetPasswordHistoryLength(%d) = %v, want InvalidSettingValue", length, err)
+		}
+	}
+}
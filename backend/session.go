@@ -0,0 +1,621 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mszalewicz/frosk/helpers"
+)
+
+// Session keeps user secret key of unlocked vault in memory, so master password key derivation runs once per unlock
+// instead of once per action. Lock wipes the key - session can't be used afterwards and vault has to be unlocked again.
+
+var SessionLocked = errors.New("Vault session is locked.")
+
+type Session struct {
+	backend       *Backend
+	mutex         sync.RWMutex
+	userSecretKey []byte
+	gcm           cipher.AEAD
+	index         serviceNameIndex
+	integrity     integrityKey
+	integrityErr  error // VaultModified or VaultRolledBack found at unlock
+}
+
+// Derives user secret key from master password, checks integrity of the vault, makes sure stored entries use current
+// format, purges expired trash and returns unlocked session. Failed integrity check does not prevent unlock, see Session.Integrity.
+func (backend *Backend) Unlock(masterPasswordGUI string) (*Session, error) {
+	if len(masterPasswordGUI) == 0 {
+		return nil, EmptyMasterPassword
+	}
+
+	userSecretKey, err := backend.GetUserSecretKey(masterPasswordGUI)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during decryption of user secret key: %w", err)
+		slog.Error(errorWrapped.Error())
+		return nil, errorWrapped
+	}
+
+	gcm, err := InitGCM(userSecretKey)
+
+	if err != nil {
+		clear(userSecretKey)
+		errorWrapped := fmt.Errorf("Error during initialization of GCM cipher block: %w", err)
+		slog.Error(errorWrapped.Error())
+		return nil, errorWrapped
+	}
+
+	session := &Session{
+		backend:       backend,
+		userSecretKey: userSecretKey,
+		gcm:           gcm,
+		index:         newServiceNameIndex(userSecretKey),
+		integrity:     newIntegrityKey(userSecretKey),
+	}
+
+	// Checked before migrations below, which seal the vault again
+	sealed, err := backend.verifyVault(session.integrity)
+
+	switch {
+	case errors.Is(err, VaultModified) || errors.Is(err, VaultRolledBack):
+		// Vault which failed integrity check is left as it is until user accepts its content, see AcceptVaultState
+		session.integrityErr = err
+		return session, nil
+	case err != nil:
+		session.Lock()
+		return nil, err
+	}
+
+	err = session.migrate()
+
+	if err != nil {
+		session.Lock()
+		return nil, err
+	}
+
+	// Vault created before integrity protection is sealed on its first unlock
+	if !sealed {
+		err = backend.resealVault(session.integrity)
+
+		if err != nil {
+			session.Lock()
+			return nil, err
+		}
+	}
+
+	// Purge error is logged, expired entries stay in trash until next unlock
+	session.PurgeExpiredTrash()
+
+	return session, nil
+}
+
+// Brings password entries stored by older versions to current format
+func (session *Session) migrate() error {
+	err := session.backend.migrateLegacyPasswordEntries(session.gcm, session.integrity)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during migration of password entries to current format: %w", err)
+		slog.Error(errorWrapped.Error())
+		return errorWrapped
+	}
+
+	err = session.backend.migrateServiceNames(session.gcm, session.index, session.integrity)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during encryption of service names: %w", err)
+		slog.Error(errorWrapped.Error())
+		return errorWrapped
+	}
+
+	return nil
+}
+
+// Returns VaultModified or VaultRolledBack when integrity check at unlock failed, nil otherwise
+func (session *Session) Integrity() error {
+	session.mutex.RLock()
+	defer session.mutex.RUnlock()
+
+	return session.integrityErr
+}
+
+// Accepts current content of the vault after failed integrity check by sealing it again. Until then the vault can't
+// be changed - every write fails with VaultNotAccepted. Migrations and purge of expired trash skipped at unlock run afterwards.
+func (session *Session) AcceptVaultState() error {
+	err := session.accept()
+
+	if err != nil {
+		return err
+	}
+
+	session.PurgeExpiredTrash()
+
+	return nil
+}
+
+func (session *Session) accept() error {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if session.gcm == nil {
+		return SessionLocked
+	}
+
+	err := session.backend.acceptVault(session.integrity)
+
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Accepted vault content after failed integrity check.", "reason", session.integrityErr)
+	session.integrityErr = nil
+
+	return session.migrate()
+}
+
+// Wipes user secret key and drops cipher built from it. Safe to call multiple times.
+func (session *Session) Lock() {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	clear(session.userSecretKey)
+	clear(session.index)
+	clear(session.integrity)
+	session.userSecretKey = nil
+	session.gcm = nil
+	session.index = nil
+	session.integrity = nil
+}
+
+func (session *Session) IsLocked() bool {
+	session.mutex.RLock()
+	defer session.mutex.RUnlock()
+
+	return session.gcm == nil
+}
+
+// Runs action with cipher for password entries, holding read lock so session can't be locked in the middle of it
+func (session *Session) withCipher(action func(gcm cipher.AEAD) error) error {
+	session.mutex.RLock()
+	defer session.mutex.RUnlock()
+
+	if session.gcm == nil {
+		return SessionLocked
+	}
+
+	return action(session.gcm)
+}
+
+// Inserts encrypted password entry under its service name
+func (session *Session) EncryptPasswordEntry(passwordEntry PasswordEntry) error {
+	err := validatePasswordEntry(passwordEntry)
+
+	if err != nil {
+		return err
+	}
+
+	return session.withCipher(func(gcm cipher.AEAD) error {
+		tx, err := session.backend.DB.Begin()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during starting transaction for password entry insert: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer tx.Rollback()
+
+		err = insertPasswordEntry(tx, gcm, session.index, passwordEntry)
+
+		if err != nil {
+			return err
+		}
+
+		version, err := session.backend.sealVault(tx, session.integrity)
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during commiting password entry insert: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		session.backend.changed(version)
+
+		return nil
+	})
+}
+
+// Inserts row of password entry within transaction and seals its fields
+func insertPasswordEntry(tx *sql.Tx, gcm cipher.AEAD, index serviceNameIndex, passwordEntry PasswordEntry) error {
+	var serviceNameOccurences int
+	err := tx.QueryRow("SELECT COUNT(*) FROM passwords WHERE service_name_index = ? AND deleted_at IS NULL", index.of(passwordEntry.ServiceName)).Scan(&serviceNameOccurences)
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Problem quering count of service name occurences in passwords table: %v", err)
+		slog.Error(errorWrapped.Error())
+		return errorWrapped
+	}
+
+	if serviceNameOccurences != 0 {
+		return ServiceNameAlreadyTaken
+	}
+
+	now := helpers.TimeTo8601String(time.Now())
+
+	// Row id is part of additional data of encrypted fields, so the row has to exist before sealing
+	insertPasswordEntryQuery := `INSERT INTO passwords (service_name, username, password, created_at, updated_at) VALUES ('', '', '', ?, ?)`
+
+	result, err := tx.Exec(insertPasswordEntryQuery, now, now)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error inserting password entry into passwords: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error reading id of inserted password entry: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	err = sealServiceName(tx, gcm, index, id, passwordEntry.ServiceName)
+
+	if err != nil {
+		return err
+	}
+
+	err = sealPasswordEntryFields(tx, gcm, id, passwordEntry)
+
+	if err != nil {
+		return err
+	}
+
+	return setPasswordEntryTags(tx, gcm, index, id, passwordEntry.Tags)
+}
+
+// Finds and decrypts password entry for given service name
+func (session *Session) DecryptPasswordEntry(serviceName string) (PasswordEntry, error) {
+	var passwordEntry PasswordEntry
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		var sealed sealedPasswordEntry
+
+		row := session.backend.DB.QueryRow("SELECT "+sealedPasswordEntryColumns+" FROM passwords WHERE service_name_index = ? AND deleted_at IS NULL", session.index.of(serviceName))
+		err := sealed.scan(row)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return ServiceNameNotFound
+		}
+
+		if err != nil {
+			errorWrapped := fmt.Errorf("Error during select query on passwords table - looking for service name = %s: %w", serviceName, err)
+			slog.Error(errorWrapped.Error())
+			return errorWrapped
+		}
+
+		passwordEntry, err = sealed.open(gcm)
+
+		if err != nil {
+			return err
+		}
+
+		passwordEntry.Tags, err = readPasswordEntryTags(session.backend.DB, gcm, sealed.id)
+
+		return err
+	})
+
+	if err != nil {
+		return PasswordEntry{}, err
+	}
+
+	return passwordEntry, nil
+}
+
+// Re-encrypts password entry stored under given service name with fresh initial vectors. Replaced password is kept
+// in password history. Service name can be changed by passing entry with different service name. Whole operation runs
+// in single transaction.
+func (session *Session) UpdatePasswordEntry(serviceName string, passwordEntry PasswordEntry) error {
+	if len(serviceName) == 0 {
+		return EmptyServiceName
+	}
+
+	err := validatePasswordEntry(passwordEntry)
+
+	if err != nil {
+		return err
+	}
+
+	// Falls back to default when it can't be read, error is logged by backend
+	historyLength, _ := session.backend.GetPasswordHistoryLength()
+
+	return session.withCipher(func(gcm cipher.AEAD) error {
+		tx, err := session.backend.DB.Begin()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during starting transaction for password entry update: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer tx.Rollback()
+
+		var id int64
+		err = tx.QueryRow("SELECT id FROM passwords WHERE service_name_index = ? AND deleted_at IS NULL", session.index.of(serviceName)).Scan(&id)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return ServiceNameNotFound
+		}
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Query looking for updated service name: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		if passwordEntry.ServiceName != serviceName {
+			var serviceNameOccurences int
+			err = tx.QueryRow("SELECT COUNT(*) FROM passwords WHERE service_name_index = ? AND deleted_at IS NULL", session.index.of(passwordEntry.ServiceName)).Scan(&serviceNameOccurences)
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Query counting occurences of new service name: %w", err)
+				slog.Error(errWrapped.Error())
+				return errWrapped
+			}
+
+			if serviceNameOccurences != 0 {
+				return ServiceNameAlreadyTaken
+			}
+		}
+
+		// Replaced password is opened with service name it was sealed with, before the entry gets renamed
+		err = recordPasswordHistory(tx, gcm, id, serviceName, passwordEntry.Password, historyLength)
+
+		if err != nil {
+			return err
+		}
+
+		now := helpers.TimeTo8601String(time.Now())
+
+		_, err = tx.Exec(`UPDATE passwords SET updated_at = ? WHERE id = ?`, now, id)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error updating password entry in passwords: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		err = sealServiceName(tx, gcm, session.index, id, passwordEntry.ServiceName)
+
+		if err != nil {
+			return err
+		}
+
+		err = sealPasswordEntryFields(tx, gcm, id, passwordEntry)
+
+		if err != nil {
+			return err
+		}
+
+		err = setPasswordEntryTags(tx, gcm, session.index, id, passwordEntry.Tags)
+
+		if err != nil {
+			return err
+		}
+
+		version, err := session.backend.sealVault(tx, session.integrity)
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during commiting password entry update: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		session.backend.changed(version)
+
+		return nil
+	})
+}
+
+// Returns decrypted service names of all password entries, sorted case insensitively
+func (session *Session) GetPasswordEntriesList() ([]string, error) {
+	services := make([]string, 0)
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		rows, err := session.backend.DB.Query("SELECT id, service_name FROM passwords WHERE deleted_at IS NULL")
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during getting service names for passwords: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				id                      int64
+				serviceNameSealedBase64 string
+			)
+
+			err = rows.Scan(&id, &serviceNameSealedBase64)
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Error during scanning service name: %w", err)
+				slog.Error(errWrapped.Error())
+				return errWrapped
+			}
+
+			serviceName, err := openServiceName(gcm, id, serviceNameSealedBase64)
+
+			if err != nil {
+				return err
+			}
+
+			services = append(services, serviceName)
+		}
+
+		if err = rows.Err(); err != nil {
+			errWrapped := fmt.Errorf("Error during iterating service names: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(services, func(i, j int) bool { return strings.ToLower(services[i]) < strings.ToLower(services[j]) })
+
+	return services, nil
+}
+
+func (session *Session) CountServiceNameOccurences(serviceName string) (int, error) {
+	var numberOfServiceNameOccurences int
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		err := session.backend.DB.QueryRow("SELECT COUNT(*) FROM passwords WHERE service_name_index = ? AND deleted_at IS NULL", session.index.of(serviceName)).Scan(&numberOfServiceNameOccurences)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during counting occurences of service name: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		return nil
+	})
+
+	return numberOfServiceNameOccurences, err
+}
+
+// Moves password entry to trash, see Session.GetTrash
+func (session *Session) DeletePasswordEntry(serviceName string) error {
+	return session.withCipher(func(gcm cipher.AEAD) error {
+		tx, err := session.backend.DB.Begin()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during starting transaction for password entry deletion: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer tx.Rollback()
+
+		result, err := tx.Exec(
+			"UPDATE passwords SET deleted_at = ? WHERE service_name_index = ? AND deleted_at IS NULL",
+			helpers.TimeTo8601String(time.Now()), session.index.of(serviceName),
+		)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during moving given password entry to trash: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		affectedRows, err := result.RowsAffected()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during moving given password entry to trash: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		if affectedRows == 0 {
+			return NoRowsDeleted
+		}
+
+		if affectedRows > 1 {
+			return DeletedMoreRowsThenExpected
+		}
+
+		version, err := session.backend.sealVault(tx, session.integrity)
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during commiting move of password entry to trash: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		session.backend.changed(version)
+
+		return nil
+	})
+}
+
+// Runs write within transaction and seals the vault, unless write did not change any row. Returns number of changed
+// rows. Errors of write are returned as they are - write logs them itself.
+func (session *Session) writeSealed(description string, write func(tx *sql.Tx, gcm cipher.AEAD) (int64, error)) (int64, error) {
+	var changedRows int64
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		tx, err := session.backend.DB.Begin()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during starting transaction for %s: %w", description, err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer tx.Rollback()
+
+		changedRows, err = write(tx, gcm)
+
+		if err != nil || changedRows == 0 {
+			return err
+		}
+
+		version, err := session.backend.sealVault(tx, session.integrity)
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.Commit()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during commiting %s: %w", description, err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		session.backend.changed(version)
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return changedRows, nil
+}
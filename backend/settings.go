@@ -0,0 +1,246 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mszalewicz/frosk/helpers"
+)
+
+// Application settings are stored per vault in settings table as key / value text pairs.
+// Missing key means default value is used.
+
+const (
+	settingAutoLockTimeout       = "auto_lock_timeout_seconds"
+	settingLockOnMinimize        = "lock_on_minimize"
+	settingClipboardClearTimeout = "clipboard_clear_timeout_seconds"
+	settingBackupKeepLast        = "backup_keep_last"
+	settingBackupKeepDaily       = "backup_keep_daily"
+	settingBackupKeepWeekly      = "backup_keep_weekly"
+	settingTrashRetention        = "trash_retention_seconds"
+	settingPasswordHistoryLength = "password_history_length"
+)
+
+// Settings deciding what gets removed from the vault are covered by vault MAC, so they can't be changed outside frosk
+// to purge trash or password history at next unlock. They are saved within sealed transaction by Session.
+// Backup retention is left out - backups are kept outside the vault file and whoever can change the vault can remove them.
+const sealedSettingsCondition = "key IN ('" + settingTrashRetention + "', '" + settingPasswordHistoryLength + "')"
+
+const (
+	DefaultAutoLockTimeout       = 5 * time.Minute
+	DefaultClipboardClearTimeout = 30 * time.Second
+	DefaultTrashRetention        = 30 * 24 * time.Hour
+	DefaultPasswordHistoryLength = 10
+)
+
+// Backups of the vault kept by pruning - all other backups are removed
+type BackupRetention struct {
+	Last   int // newest backups
+	Daily  int // newest backup of each of that many most recent days
+	Weekly int // newest backup of each of that many most recent weeks
+}
+
+var DefaultBackupRetention = BackupRetention{Last: 10, Daily: 7, Weekly: 4}
+
+const maxBackupRetention = 1000
+const maxPasswordHistoryLength = 100
+
+func (retention BackupRetention) String() string {
+	return fmt.Sprintf("last %d, %d daily, %d weekly", retention.Last, retention.Daily, retention.Weekly)
+}
+
+var InvalidSettingValue = errors.New("Setting value is out of allowed range.")
+
+// Returns stored value of setting and whether it was set at all
+func (backend *Backend) getSetting(key string) (string, bool, error) {
+	var value string
+	err := backend.DB.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading setting %s: %w", key, err)
+		slog.Error(errWrapped.Error())
+		return "", false, errWrapped
+	}
+
+	return value, true, nil
+}
+
+func (backend *Backend) setSetting(key string, value string) error {
+	return writeSetting(backend.DB, key, value)
+}
+
+// Write access shared by sql.DB and sql.Tx
+type executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Saves setting, also within transaction changing other content of the vault
+func writeSetting(database executor, key string, value string) error {
+	now := helpers.TimeTo8601String(time.Now())
+
+	_, err := database.Exec(
+		`INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		key, value, now,
+	)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during saving setting %s: %w", key, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Reads duration stored in whole seconds, falling back to default when it is not set or invalid
+func (backend *Backend) getDurationSetting(key string, defaultValue time.Duration) (time.Duration, error) {
+	value, isSet, err := backend.getSetting(key)
+
+	if err != nil || !isSet {
+		return defaultValue, err
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || seconds < 0 {
+		slog.Error("Stored setting is invalid, using default.", "key", key, "value", value)
+		return defaultValue, nil
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func (backend *Backend) setDurationSetting(key string, value time.Duration) error {
+	if value < 0 {
+		return InvalidSettingValue
+	}
+
+	return backend.setSetting(key, strconv.FormatInt(int64(value/time.Second), 10))
+}
+
+// Reads non negative integer setting, falling back to default when it is not set or invalid
+func (backend *Backend) getIntSetting(key string, defaultValue int) (int, error) {
+	value, isSet, err := backend.getSetting(key)
+
+	if err != nil || !isSet {
+		return defaultValue, err
+	}
+
+	number, err := strconv.Atoi(value)
+
+	if err != nil || number < 0 {
+		slog.Error("Stored setting is invalid, using default.", "key", key, "value", value)
+		return defaultValue, nil
+	}
+
+	return number, nil
+}
+
+// Returns time of inactivity after which unlocked vault is locked. Zero means vault is never locked automatically.
+func (backend *Backend) GetAutoLockTimeout() (time.Duration, error) {
+	return backend.getDurationSetting(settingAutoLockTimeout, DefaultAutoLockTimeout)
+}
+
+func (backend *Backend) SetAutoLockTimeout(timeout time.Duration) error {
+	return backend.setDurationSetting(settingAutoLockTimeout, timeout)
+}
+
+// Returns time after which copied username or password is removed from clipboard. Zero means clipboard is never cleared.
+func (backend *Backend) GetClipboardClearTimeout() (time.Duration, error) {
+	return backend.getDurationSetting(settingClipboardClearTimeout, DefaultClipboardClearTimeout)
+}
+
+func (backend *Backend) SetClipboardClearTimeout(timeout time.Duration) error {
+	return backend.setDurationSetting(settingClipboardClearTimeout, timeout)
+}
+
+// Returns time after which password entries in trash are purged. Zero means trash is never purged automatically.
+func (backend *Backend) GetTrashRetention() (time.Duration, error) {
+	return backend.getDurationSetting(settingTrashRetention, DefaultTrashRetention)
+}
+
+func (session *Session) SetTrashRetention(retention time.Duration) error {
+	if retention < 0 {
+		return InvalidSettingValue
+	}
+
+	_, err := session.writeSealed("saving of trash retention", func(tx *sql.Tx, gcm cipher.AEAD) (int64, error) {
+		return 1, writeSetting(tx, settingTrashRetention, strconv.FormatInt(int64(retention/time.Second), 10))
+	})
+
+	return err
+}
+
+// Returns whether vault is locked when main window gets minimized
+func (backend *Backend) GetLockOnMinimize() (bool, error) {
+	value, isSet, err := backend.getSetting(settingLockOnMinimize)
+
+	if err != nil || !isSet {
+		return true, err
+	}
+
+	lockOnMinimize, err := strconv.ParseBool(value)
+
+	if err != nil {
+		slog.Error("Stored lock on minimize setting is invalid, using default.", "value", value)
+		return true, nil
+	}
+
+	return lockOnMinimize, nil
+}
+
+func (backend *Backend) SetLockOnMinimize(lockOnMinimize bool) error {
+	return backend.setSetting(settingLockOnMinimize, strconv.FormatBool(lockOnMinimize))
+}
+
+// Returns how many backups of the vault are kept
+func (backend *Backend) GetBackupRetention() (BackupRetention, error) {
+	last, err := backend.getIntSetting(settingBackupKeepLast, DefaultBackupRetention.Last)
+
+	if err != nil {
+		return DefaultBackupRetention, err
+	}
+
+	daily, err := backend.getIntSetting(settingBackupKeepDaily, DefaultBackupRetention.Daily)
+
+	if err != nil {
+		return DefaultBackupRetention, err
+	}
+
+	weekly, err := backend.getIntSetting(settingBackupKeepWeekly, DefaultBackupRetention.Weekly)
+
+	if err != nil {
+		return DefaultBackupRetention, err
+	}
+
+	// At least the newest backup is always kept
+	return BackupRetention{Last: max(last, 1), Daily: daily, Weekly: weekly}, nil
+}
+
+func (backend *Backend) SetBackupRetention(retention BackupRetention) error {
+	if retention.Last < 1 || retention.Daily < 0 || retention.Weekly < 0 ||
+		retention.Last > maxBackupRetention || retention.Daily > maxBackupRetention || retention.Weekly > maxBackupRetention {
+		return InvalidSettingValue
+	}
+
+	err := backend.setSetting(settingBackupKeepLast, strconv.Itoa(retention.Last))
+
+	if err == nil {
+		err = backend.setSetting(settingBackupKeepDaily, strconv.Itoa(retention.Daily))
+	}
+
+	if err == nil {
+		err = backend.setSetting(settingBackupKeepWeekly, strconv.Itoa(retention.Weekly))
+	}
+
+	return err
+}
@@ -0,0 +1,338 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	b64 "encoding/base64"
+)
+
+// Password entries can carry any number of tags. Tag names are sealed like service names, bound to row id of tags
+// table, and looked up by blind index. Slash in tag name nests tags like folders - entry tagged "work/servers" is
+// found by filtering with "work" as well. Tags left without entries are removed.
+
+var EmptyTagName = errors.New("Tag name can't be empty.")
+
+// Separator of nested tags
+const TagSeparator = "/"
+
+// Builds GCM additional data binding encrypted tag name to its row in tags table
+func tagAdditionalData(id int64) []byte {
+	return []byte(fmt.Sprintf("frosk|tags|%d|name", id))
+}
+
+// Returns value of name_index column for tag name. Prefix keeps it apart from index values of service names.
+func (index serviceNameIndex) ofTag(tagName string) string {
+	mac := hmac.New(sha256.New, index)
+	mac.Write([]byte("frosk|tags|"))
+	mac.Write([]byte(tagName))
+	return b64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Trims tag names of surrounding spaces and separators, drops duplicates and sorts them
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		parts := strings.Split(tag, TagSeparator)
+
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+
+		tag = strings.Trim(strings.Join(parts, TagSeparator), TagSeparator)
+
+		if len(tag) == 0 || strings.Contains(tag, TagSeparator+TagSeparator) {
+			return nil, EmptyTagName
+		}
+
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized), nil
+}
+
+// Returns whether entry with given tags is found by filtering with tag, also through tags nested in it
+func MatchesTag(entryTags []string, tag string) bool {
+	for _, entryTag := range entryTags {
+		if entryTag == tag || strings.HasPrefix(entryTag, tag+TagSeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Replaces tags of password entry within transaction, creating tags which do not exist yet
+func setPasswordEntryTags(tx *sql.Tx, gcm cipher.AEAD, index serviceNameIndex, id int64, tags []string) error {
+	tags, err := normalizeTags(tags)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM password_tags WHERE password_id = ?", id)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during removing tags of password entry: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	for _, tag := range tags {
+		var tagID int64
+		err = tx.QueryRow("SELECT id FROM tags WHERE name_index = ?", index.ofTag(tag)).Scan(&tagID)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			tagID, err = insertTag(tx, gcm, index, tag)
+		}
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during looking up tag of password entry: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		_, err = tx.Exec("INSERT INTO password_tags (password_id, tag_id) VALUES (?, ?)", id, tagID)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during tagging password entry: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+	}
+
+	return deleteUnusedTags(tx)
+}
+
+func insertTag(tx *sql.Tx, gcm cipher.AEAD, index serviceNameIndex, tag string) (int64, error) {
+	// Row id is part of additional data, so the row has to exist before sealing
+	result, err := tx.Exec("INSERT INTO tags (name, name_index) VALUES ('', ?)", index.ofTag(tag))
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return 0, err
+	}
+
+	nameSealed, err := sealField(gcm, []byte(tag), tagAdditionalData(id))
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("UPDATE tags SET name = ? WHERE id = ?", nameSealed, id)
+
+	return id, err
+}
+
+// Removes tags without password entries, including entries in trash
+func deleteUnusedTags(tx *sql.Tx) error {
+	_, err := tx.Exec("DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM password_tags)")
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during removing unused tags: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Removes tags of password entries which no longer exist
+func deleteTagsOfPurged(tx *sql.Tx) error {
+	_, err := tx.Exec("DELETE FROM password_tags WHERE password_id NOT IN (SELECT id FROM passwords)")
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during removing tags of purged entries: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return deleteUnusedTags(tx)
+}
+
+func openTagName(gcm cipher.AEAD, id int64, nameSealedBase64 string) (string, error) {
+	name, err := openField(gcm, nameSealedBase64, tagAdditionalData(id))
+
+	if err != nil {
+		errorWrapped := fmt.Errorf("Error during tag name decryption: %w", err)
+		slog.Error(errorWrapped.Error())
+		return "", errorWrapped
+	}
+
+	return string(name), nil
+}
+
+// Returns decrypted tags of password entry with given id, sorted
+func readPasswordEntryTags(database querier, gcm cipher.AEAD, id int64) ([]string, error) {
+	rows, err := database.Query("SELECT tags.id, tags.name FROM tags JOIN password_tags ON password_tags.tag_id = tags.id WHERE password_tags.password_id = ?", id)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading tags of password entry: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	defer rows.Close()
+
+	tags := []string{}
+
+	for rows.Next() {
+		var (
+			tagID            int64
+			nameSealedBase64 string
+		)
+
+		err = rows.Scan(&tagID, &nameSealedBase64)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during scanning tag of password entry: %w", err)
+			slog.Error(errWrapped.Error())
+			return nil, errWrapped
+		}
+
+		tag, err := openTagName(gcm, tagID, nameSealedBase64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		errWrapped := fmt.Errorf("Error during iterating tags of password entry: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	slices.Sort(tags)
+
+	return tags, nil
+}
+
+// Returns tags of every tagged password entry outside of trash, keyed by service name
+func (session *Session) GetPasswordEntriesTags() (map[string][]string, error) {
+	entriesTags := map[string][]string{}
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		rows, err := session.backend.DB.Query(
+			`SELECT passwords.id, passwords.service_name, tags.id, tags.name FROM passwords
+			JOIN password_tags ON password_tags.password_id = passwords.id
+			JOIN tags ON tags.id = password_tags.tag_id
+			WHERE passwords.deleted_at IS NULL`,
+		)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during reading tags of password entries: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer rows.Close()
+
+		// Every service name and tag name is decrypted once
+		serviceNames := map[int64]string{}
+		tagNames := map[int64]string{}
+
+		for rows.Next() {
+			var (
+				id                      int64
+				serviceNameSealedBase64 string
+				tagID                   int64
+				tagNameSealedBase64     string
+			)
+
+			err = rows.Scan(&id, &serviceNameSealedBase64, &tagID, &tagNameSealedBase64)
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Error during scanning tag of password entries: %w", err)
+				slog.Error(errWrapped.Error())
+				return errWrapped
+			}
+
+			serviceName, found := serviceNames[id]
+
+			if !found {
+				serviceName, err = openServiceName(gcm, id, serviceNameSealedBase64)
+
+				if err != nil {
+					return err
+				}
+
+				serviceNames[id] = serviceName
+			}
+
+			tagName, found := tagNames[tagID]
+
+			if !found {
+				tagName, err = openTagName(gcm, tagID, tagNameSealedBase64)
+
+				if err != nil {
+					return err
+				}
+
+				tagNames[tagID] = tagName
+			}
+
+			entriesTags[serviceName] = append(entriesTags[serviceName], tagName)
+		}
+
+		if err = rows.Err(); err != nil {
+			errWrapped := fmt.Errorf("Error during iterating tags of password entries: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tags := range entriesTags {
+		slices.Sort(tags)
+	}
+
+	return entriesTags, nil
+}
+
+// Returns tags used by password entries together with tags they are nested in, sorted case insensitively
+func TagsWithParents(entriesTags map[string][]string) []string {
+	found := map[string]bool{}
+
+	for _, tags := range entriesTags {
+		for _, tag := range tags {
+			parts := strings.Split(tag, TagSeparator)
+
+			for i := range parts {
+				found[strings.Join(parts[:i+1], TagSeparator)] = true
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(found))
+
+	for tag := range found {
+		tags = append(tags, tag)
+	}
+
+	slices.SortFunc(tags, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+
+	return tags
+}
@@ -0,0 +1,252 @@
+package backend
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func countRows(t *testing.T, backend *Backend, table string) int {
+	t.Helper()
+
+	var count int
+	err := backend.DB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count)
+
+	if err != nil {
+		t.Fatalf("counting rows of %s: %v", table, err)
+	}
+
+	return count
+}
+
+func decryptTestTags(t *testing.T, session *Session, serviceName string) []string {
+	t.Helper()
+
+	passwordEntry, err := session.DecryptPasswordEntry(serviceName)
+
+	if err != nil {
+		t.Fatalf("DecryptPasswordEntry %s: %v", serviceName, err)
+	}
+
+	return passwordEntry.Tags
+}
+
+func TestPasswordEntryTagsAreAddedAndRemoved(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	err := session.EncryptPasswordEntry(PasswordEntry{ServiceName: "github", Username: "user", Password: "secret", Tags: []string{" work / servers ", "code", "code"}})
+
+	if err != nil {
+		t.Fatalf("EncryptPasswordEntry: %v", err)
+	}
+
+	if tags := decryptTestTags(t, session, "github"); !reflect.DeepEqual(tags, []string{"code", "work/servers"}) {
+		t.Fatalf("Tags = %q, want normalized [code work/servers]", tags)
+	}
+
+	err = session.UpdatePasswordEntry("github", PasswordEntry{ServiceName: "github", Username: "user", Password: "secret", Tags: []string{"code"}})
+
+	if err != nil {
+		t.Fatalf("UpdatePasswordEntry: %v", err)
+	}
+
+	if tags := decryptTestTags(t, session, "github"); !reflect.DeepEqual(tags, []string{"code"}) {
+		t.Fatalf("Tags after removing one = %q, want [code]", tags)
+	}
+
+	// Tag left without entries is removed
+	if count := countRows(t, backend, "tags"); count != 1 {
+		t.Fatalf("tags table has %d rows, want 1", count)
+	}
+
+	err = session.UpdatePasswordEntry("github", PasswordEntry{ServiceName: "github", Username: "user", Password: "secret"})
+
+	if err != nil {
+		t.Fatalf("UpdatePasswordEntry: %v", err)
+	}
+
+	if tags := decryptTestTags(t, session, "github"); len(tags) != 0 {
+		t.Fatalf("Tags after removing all = %q, want none", tags)
+	}
+
+	if count := countRows(t, backend, "tags"); count != 0 {
+		t.Fatalf("tags table has %d rows, want 0", count)
+	}
+}
+
+func TestEmptyTagNameIsRejected(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	for _, tags := range [][]string{{""}, {" / "}, {"work//servers"}} {
+		err := session.EncryptPasswordEntry(PasswordEntry{ServiceName: "github", Username: "user", Password: "secret", Tags: tags})
+
+		if !errors.Is(err, EmptyTagName) {
+			t.Errorf("EncryptPasswordEntry() with tags %q = %v, want EmptyTagName", tags, err)
+		}
+	}
+}
+
+func TestTagIsSharedByPasswordEntries(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	for _, serviceName := range []string{"github", "gitlab"} {
+		err := session.EncryptPasswordEntry(PasswordEntry{ServiceName: serviceName, Username: "user", Password: "secret", Tags: []string{"work"}})
+
+		if err != nil {
+			t.Fatalf("EncryptPasswordEntry %s: %v", serviceName, err)
+		}
+	}
+
+	addTestEntry(t, session, "bank")
+
+	if tags, links := countRows(t, backend, "tags"), countRows(t, backend, "password_tags"); tags != 1 || links != 2 {
+		t.Fatalf("tags and password_tags have %d and %d rows, want 1 and 2", tags, links)
+	}
+
+	entriesTags, err := session.GetPasswordEntriesTags()
+
+	if err != nil {
+		t.Fatalf("GetPasswordEntriesTags: %v", err)
+	}
+
+	want := map[string][]string{"github": {"work"}, "gitlab": {"work"}}
+
+	if !reflect.DeepEqual(entriesTags, want) {
+		t.Fatalf("GetPasswordEntriesTags() = %v, want %v", entriesTags, want)
+	}
+
+	// Removing tag from one entry keeps it on the other
+	err = session.UpdatePasswordEntry("github", PasswordEntry{ServiceName: "github", Username: "user", Password: "secret"})
+
+	if err != nil {
+		t.Fatalf("UpdatePasswordEntry: %v", err)
+	}
+
+	if tags := decryptTestTags(t, session, "gitlab"); !reflect.DeepEqual(tags, []string{"work"}) {
+		t.Fatalf("Tags of other entry = %q, want [work]", tags)
+	}
+
+	if err := unlockTestVault(t, backend).Integrity(); err != nil {
+		t.Fatalf("Integrity() = %v, want nil", err)
+	}
+}
+
+func TestTagsSurviveUpdate(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	err := session.EncryptPasswordEntry(PasswordEntry{ServiceName: "github", Username: "user", Password: "secret", Tags: []string{"work", "code"}})
+
+	if err != nil {
+		t.Fatalf("EncryptPasswordEntry: %v", err)
+	}
+
+	passwordEntry, err := session.DecryptPasswordEntry("github")
+
+	if err != nil {
+		t.Fatalf("DecryptPasswordEntry: %v", err)
+	}
+
+	// Entry is edited as read, with new password and service name
+	passwordEntry.ServiceName = "github.com"
+	passwordEntry.Password = "new secret"
+
+	err = session.UpdatePasswordEntry("github", passwordEntry)
+
+	if err != nil {
+		t.Fatalf("UpdatePasswordEntry: %v", err)
+	}
+
+	if tags := decryptTestTags(t, session, "github.com"); !reflect.DeepEqual(tags, []string{"code", "work"}) {
+		t.Fatalf("Tags after update = %q, want [code work]", tags)
+	}
+
+	entriesTags, err := session.GetPasswordEntriesTags()
+
+	if err != nil || !reflect.DeepEqual(entriesTags, map[string][]string{"github.com": {"code", "work"}}) {
+		t.Fatalf("GetPasswordEntriesTags() = %v, %v, want tags under new service name", entriesTags, err)
+	}
+}
+
+func TestTagsOfPurgedPasswordEntryAreRemoved(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	err := session.EncryptPasswordEntry(PasswordEntry{ServiceName: "github", Username: "user", Password: "secret", Tags: []string{"work"}})
+
+	if err != nil {
+		t.Fatalf("EncryptPasswordEntry: %v", err)
+	}
+
+	err = session.DeletePasswordEntry("github")
+
+	if err != nil {
+		t.Fatalf("DeletePasswordEntry: %v", err)
+	}
+
+	entriesTags, err := session.GetPasswordEntriesTags()
+
+	if err != nil || len(entriesTags) != 0 {
+		t.Fatalf("GetPasswordEntriesTags() = %v, %v, want entry in trash left out", entriesTags, err)
+	}
+
+	// Entry in trash keeps its tags, so they come back when it is restored
+	if count := countRows(t, backend, "password_tags"); count != 1 {
+		t.Fatalf("password_tags has %d rows with entry in trash, want 1", count)
+	}
+
+	trash, err := session.GetTrash()
+
+	if err != nil || len(trash) != 1 {
+		t.Fatalf("GetTrash() = %v, %v, want one entry", trash, err)
+	}
+
+	err = session.PurgePasswordEntry(trash[0].ID)
+
+	if err != nil {
+		t.Fatalf("PurgePasswordEntry: %v", err)
+	}
+
+	if tags, links := countRows(t, backend, "tags"), countRows(t, backend, "password_tags"); tags != 0 || links != 0 {
+		t.Fatalf("tags and password_tags have %d and %d rows after purge, want none", tags, links)
+	}
+}
+
+func TestMatchesTag(t *testing.T) {
+	entryTags := []string{"personal", "work/servers"}
+
+	tests := []struct {
+		tag  string
+		want bool
+	}{
+		{"personal", true},
+		{"work", true},
+		{"work/servers", true},
+		{"servers", false},
+		{"work/serv", false},
+		{"pers", false},
+	}
+
+	for _, test := range tests {
+		if got := MatchesTag(entryTags, test.tag); got != test.want {
+			t.Errorf("MatchesTag(%q, %q) = %v, want %v", entryTags, test.tag, got, test.want)
+		}
+	}
+}
+
+func TestTagsWithParents(t *testing.T) {
+	entriesTags := map[string][]string{
+		"github": {"work/servers/eu", "Code"},
+		"gitlab": {"work"},
+		"bank":   {"personal"},
+	}
+
+	want := []string{"Code", "personal", "work", "work/servers", "work/servers/eu"}
+
+	if got := TagsWithParents(entriesTags); !reflect.DeepEqual(got, want) {
+		t.Fatalf("TagsWithParents() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var InvalidTOTP = errors.New("TOTP secret has to be otpauth://totp/ URI or base32 encoded key.")
+
+const (
+	defaultTOTPAlgorithm = "SHA1"
+	defaultTOTPDigits    = 6
+	defaultTOTPPeriod    = 30
+
+	// Longer periods are not used by any service and could overflow when converted to time.Duration
+	maxTOTPPeriod = 24 * 60 * 60
+)
+
+// Time-based one-time password generator (RFC 6238) of two-factor authentication, as configured by the service
+type TOTP struct {
+	Secret    []byte
+	Algorithm string        // SHA1, SHA256 or SHA512
+	Digits    int           // 6 to 8
+	Period    time.Duration // whole seconds, 30 seconds when shorter than one
+
+	Issuer  string
+	Account string
+}
+
+// Parses TOTP secret of password entry. Accepts otpauth://totp/ URI, as encoded in QR codes, or bare base32 key
+// which services show for manual setup - the latter uses default SHA1, 6 digits and 30 second period.
+func ParseTOTP(secret string) (TOTP, error) {
+	secret = strings.TrimSpace(secret)
+
+	totp := TOTP{Algorithm: defaultTOTPAlgorithm, Digits: defaultTOTPDigits, Period: defaultTOTPPeriod * time.Second}
+
+	if !strings.HasPrefix(strings.ToLower(secret), "otpauth:") {
+		key, err := decodeTOTPKey(secret)
+
+		if err != nil {
+			return TOTP{}, err
+		}
+
+		totp.Secret = key
+
+		return totp, nil
+	}
+
+	uri, err := url.Parse(secret)
+
+	if err != nil {
+		return TOTP{}, fmt.Errorf("%w %v", InvalidTOTP, err)
+	}
+
+	if !strings.EqualFold(uri.Host, "totp") {
+		return TOTP{}, fmt.Errorf("%w Type %q is not supported.", InvalidTOTP, uri.Host)
+	}
+
+	// Label is "issuer:account" or just "account"
+	label := strings.TrimPrefix(uri.Path, "/")
+
+	if issuer, account, found := strings.Cut(label, ":"); found {
+		totp.Issuer = strings.TrimSpace(issuer)
+		totp.Account = strings.TrimSpace(account)
+	} else {
+		totp.Account = strings.TrimSpace(label)
+	}
+
+	query := uri.Query()
+
+	if issuer := query.Get("issuer"); issuer != "" {
+		totp.Issuer = issuer
+	}
+
+	totp.Secret, err = decodeTOTPKey(query.Get("secret"))
+
+	if err != nil {
+		return TOTP{}, err
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		totp.Algorithm = strings.ToUpper(algorithm)
+
+		if totp.Algorithm != "SHA1" && totp.Algorithm != "SHA256" && totp.Algorithm != "SHA512" {
+			return TOTP{}, fmt.Errorf("%w Algorithm %q is not supported.", InvalidTOTP, algorithm)
+		}
+	}
+
+	if digits := query.Get("digits"); digits != "" {
+		totp.Digits, err = strconv.Atoi(digits)
+
+		if err != nil || totp.Digits < 6 || totp.Digits > 8 {
+			return TOTP{}, fmt.Errorf("%w Number of digits has to be between 6 and 8, got %q.", InvalidTOTP, digits)
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+
+		if err != nil || seconds < 1 || seconds > maxTOTPPeriod {
+			return TOTP{}, fmt.Errorf("%w Period has to be between 1 and %d seconds, got %q.", InvalidTOTP, maxTOTPPeriod, period)
+		}
+
+		totp.Period = time.Duration(seconds) * time.Second
+	}
+
+	return totp, nil
+}
+
+// Decodes base32 key - case, spaces and padding are ignored as apps print keys in many ways
+func decodeTOTPKey(key string) ([]byte, error) {
+	key = strings.ToUpper(strings.Join(strings.Fields(key), ""))
+	key = strings.TrimRight(key, "=")
+
+	if key == "" {
+		return nil, fmt.Errorf("%w Secret key is empty.", InvalidTOTP)
+	}
+
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w Secret key is not valid base32.", InvalidTOTP)
+	}
+
+	return decoded, nil
+}
+
+// Returns one-time code valid at given time
+func (totp TOTP) Code(at time.Time) string {
+	counter := uint64(at.Unix() / int64(totp.period()/time.Second))
+
+	var newHash func() hash.Hash
+
+	switch totp.Algorithm {
+	case "SHA256":
+		newHash = sha256.New
+	case "SHA512":
+		newHash = sha512.New
+	default:
+		newHash = sha1.New
+	}
+
+	mac := hmac.New(newHash, totp.Secret)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+
+	for range totp.Digits {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totp.Digits, truncated%modulo)
+}
+
+// Returns how long code valid at given time stays valid
+func (totp TOTP) Remaining(at time.Time) time.Duration {
+	period := totp.period()
+	return period - time.Duration(at.UnixNano()%int64(period))
+}
+
+// TOTP built without ParseTOTP may have no period - default one is used instead of dividing by zero
+func (totp TOTP) period() time.Duration {
+	if totp.Period < time.Second {
+		return defaultTOTPPeriod * time.Second
+	}
+
+	return totp.Period.Truncate(time.Second)
+}
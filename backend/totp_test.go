@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"encoding/base32"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Test vectors of RFC 6238 appendix B - 8 digits, 30 second period, key length matching the hash
+func TestTOTPCodeRFC6238(t *testing.T) {
+	secrets := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+
+	tests := []struct {
+		unix  int64
+		codes map[string]string
+	}{
+		{59, map[string]string{"SHA1": "94287082", "SHA256": "46119246", "SHA512": "90693936"}},
+		{1111111109, map[string]string{"SHA1": "07081804", "SHA256": "68084774", "SHA512": "25091201"}},
+		{1111111111, map[string]string{"SHA1": "14050471", "SHA256": "67062674", "SHA512": "99943326"}},
+		{1234567890, map[string]string{"SHA1": "89005924", "SHA256": "91819424", "SHA512": "93441116"}},
+		{2000000000, map[string]string{"SHA1": "69279037", "SHA256": "90698825", "SHA512": "38618901"}},
+		{20000000000, map[string]string{"SHA1": "65353130", "SHA256": "77737706", "SHA512": "47863826"}},
+	}
+
+	for _, test := range tests {
+		for algorithm, want := range test.codes {
+			totp := TOTP{Secret: secrets[algorithm], Algorithm: algorithm, Digits: 8, Period: 30 * time.Second}
+
+			if got := totp.Code(time.Unix(test.unix, 0)); got != want {
+				t.Errorf("%s code at %d = %s, want %s", algorithm, test.unix, got, want)
+			}
+		}
+	}
+}
+
+func TestParseTOTPURI(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890123456789012"))
+
+	totp, err := ParseTOTP("otpauth://totp/Example:alice@example.com?secret=" + secret + "&issuer=Example&algorithm=sha256&digits=8&period=60")
+
+	if err != nil {
+		t.Fatalf("ParseTOTP() = %v", err)
+	}
+
+	if totp.Issuer != "Example" || totp.Account != "alice@example.com" || totp.Algorithm != "SHA256" || totp.Digits != 8 || totp.Period != time.Minute {
+		t.Fatalf("ParseTOTP() = %+v", totp)
+	}
+
+	// Counter of RFC 6238 vector at 1111111111 s with 30 second period, reached with 60 second period at twice the time
+	if got := totp.Code(time.Unix(2222222222, 0)); got != "67062674" {
+		t.Errorf("Code() = %s, want 67062674", got)
+	}
+
+	if got := totp.Remaining(time.Unix(2222222222, 0)); got != 58*time.Second {
+		t.Errorf("Remaining() = %v, want 58s", got)
+	}
+}
+
+func TestParseTOTPBareKeyUsesDefaults(t *testing.T) {
+	totp, err := ParseTOTP(" gezd gnbv gy3t qojq gezd gnbv gy3t qojq ")
+
+	if err != nil {
+		t.Fatalf("ParseTOTP() = %v", err)
+	}
+
+	if string(totp.Secret) != "12345678901234567890" || totp.Algorithm != "SHA1" || totp.Digits != 6 || totp.Period != 30*time.Second {
+		t.Fatalf("ParseTOTP() = %+v", totp)
+	}
+
+	if got := totp.Code(time.Unix(59, 0)); got != "287082" {
+		t.Errorf("Code() = %s, want 287082", got)
+	}
+}
+
+func TestParseTOTPRejectsInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"not base32!",
+		"otpauth://hotp/Example?secret=GEZDGNBV",
+		"otpauth://totp/Example",
+		"otpauth://totp/Example?secret=GEZDGNBV&algorithm=MD5",
+		"otpauth://totp/Example?secret=GEZDGNBV&digits=5",
+		"otpauth://totp/Example?secret=GEZDGNBV&digits=9",
+		"otpauth://totp/Example?secret=GEZDGNBV&period=0",
+		"otpauth://totp/Example?secret=GEZDGNBV&period=86401",
+		// Would overflow time.Duration and make Code divide by zero
+		"otpauth://totp/Example?secret=GEZDGNBV&period=9223372036",
+		"otpauth://totp/Example?secret=GEZDGNBV&period=9223372036854775807",
+	}
+
+	for _, secret := range tests {
+		_, err := ParseTOTP(secret)
+
+		if !errors.Is(err, InvalidTOTP) {
+			t.Errorf("ParseTOTP(%q) = %v, want InvalidTOTP", secret, err)
+		}
+	}
+}
+
+func TestTOTPWithoutPeriodUsesDefault(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	at := time.Unix(59, 0)
+
+	for _, period := range []time.Duration{0, 500 * time.Millisecond} {
+		totp := TOTP{Secret: secret, Algorithm: "SHA1", Digits: 8, Period: period}
+
+		if got := totp.Code(at); got != "94287082" {
+			t.Errorf("Code() with period %v = %s, want 94287082", period, got)
+		}
+
+		if got := totp.Remaining(at); got != time.Second {
+			t.Errorf("Remaining() with period %v = %v, want 1s", period, got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package backend
+
+import (
+	"crypto/cipher"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mszalewicz/frosk/helpers"
+)
+
+// Deleted password entries are moved to trash - row stays encrypted as it was, only deleted_at is set. Entries in trash
+// are left out of listing, lookups, export and import, until user restores or purges them. Trash older than
+// trash retention setting is purged at unlock.
+
+var PasswordEntryNotInTrash = errors.New("Password entry is not in trash.")
+
+type TrashedPasswordEntry struct {
+	ID          int64 // identifies entry, trash can hold several entries with the same service name
+	ServiceName string
+	DeletedAt   string // ISO 8601, as stored
+}
+
+// Returns decrypted service names of entries in trash, most recently deleted first
+func (session *Session) GetTrash() ([]TrashedPasswordEntry, error) {
+	trash := make([]TrashedPasswordEntry, 0)
+
+	err := session.withCipher(func(gcm cipher.AEAD) error {
+		rows, err := session.backend.DB.Query("SELECT id, service_name, deleted_at FROM passwords WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC")
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during reading trash: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				trashed                 TrashedPasswordEntry
+				serviceNameSealedBase64 string
+			)
+
+			err = rows.Scan(&trashed.ID, &serviceNameSealedBase64, &trashed.DeletedAt)
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Error during scanning entry in trash: %w", err)
+				slog.Error(errWrapped.Error())
+				return errWrapped
+			}
+
+			trashed.ServiceName, err = openServiceName(gcm, trashed.ID, serviceNameSealedBase64)
+
+			if err != nil {
+				return err
+			}
+
+			trash = append(trash, trashed)
+		}
+
+		if err = rows.Err(); err != nil {
+			errWrapped := fmt.Errorf("Error during iterating trash: %w", err)
+			slog.Error(errWrapped.Error())
+			return errWrapped
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return trash, nil
+}
+
+// Moves password entry out of trash. Fails with ServiceNameAlreadyTaken when other entry got its service name since.
+func (session *Session) RestorePasswordEntry(id int64) error {
+	_, err := session.writeSealed("restore of password entry from trash", func(tx *sql.Tx, gcm cipher.AEAD) (int64, error) {
+		var serviceNameSealedBase64 string
+		err := tx.QueryRow("SELECT service_name FROM passwords WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&serviceNameSealedBase64)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, PasswordEntryNotInTrash
+		}
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Query looking for restored password entry: %w", err)
+			slog.Error(errWrapped.Error())
+			return 0, errWrapped
+		}
+
+		serviceName, err := openServiceName(gcm, id, serviceNameSealedBase64)
+
+		if err != nil {
+			return 0, err
+		}
+
+		var serviceNameOccurences int
+		err = tx.QueryRow("SELECT COUNT(*) FROM passwords WHERE service_name_index = ? AND deleted_at IS NULL", session.index.of(serviceName)).Scan(&serviceNameOccurences)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Query counting occurences of restored service name: %w", err)
+			slog.Error(errWrapped.Error())
+			return 0, errWrapped
+		}
+
+		if serviceNameOccurences != 0 {
+			return 0, ServiceNameAlreadyTaken
+		}
+
+		result, err := tx.Exec("UPDATE passwords SET deleted_at = NULL, updated_at = ? WHERE id = ?", helpers.TimeTo8601String(time.Now()), id)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during restoring password entry from trash: %w", err)
+			slog.Error(errWrapped.Error())
+			return 0, errWrapped
+		}
+
+		return result.RowsAffected()
+	})
+
+	return err
+}
+
+// Removes password entry in trash for good
+func (session *Session) PurgePasswordEntry(id int64) error {
+	_, err := session.writeSealed("purge of password entry", func(tx *sql.Tx, gcm cipher.AEAD) (int64, error) {
+		result, err := tx.Exec("DELETE FROM passwords WHERE id = ? AND deleted_at IS NOT NULL", id)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during purging password entry: %w", err)
+			slog.Error(errWrapped.Error())
+			return 0, errWrapped
+		}
+
+		purged, err := result.RowsAffected()
+
+		if err == nil && purged == 0 {
+			return 0, PasswordEntryNotInTrash
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		err = deletePasswordHistoryOfPurged(tx)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return purged, deleteTagsOfPurged(tx)
+	})
+
+	return err
+}
+
+// Removes password entries which are in trash longer than trash retention setting and returns their number
+func (session *Session) PurgeExpiredTrash() (int64, error) {
+	retention, _ := session.backend.GetTrashRetention()
+
+	if retention == 0 {
+		return 0, nil
+	}
+
+	expiredBefore := helpers.TimeTo8601String(time.Now().Add(-retention))
+
+	purged, err := session.writeSealed("purge of expired trash", func(tx *sql.Tx, gcm cipher.AEAD) (int64, error) {
+		result, err := tx.Exec("DELETE FROM passwords WHERE deleted_at IS NOT NULL AND deleted_at < ?", expiredBefore)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during purging expired trash: %w", err)
+			slog.Error(errWrapped.Error())
+			return 0, errWrapped
+		}
+
+		purged, err := result.RowsAffected()
+
+		if err != nil || purged == 0 {
+			return 0, err
+		}
+
+		err = deletePasswordHistoryOfPurged(tx)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return purged, deleteTagsOfPurged(tx)
+	})
+
+	if err == nil && purged > 0 {
+		slog.Info("Purged expired password entries from trash.", "purged", purged, "retention", retention)
+	}
+
+	return purged, err
+}
@@ -0,0 +1,183 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func trashTestEntry(t *testing.T, session *Session, serviceName string) TrashedPasswordEntry {
+	t.Helper()
+
+	err := session.DeletePasswordEntry(serviceName)
+
+	if err != nil {
+		t.Fatalf("DeletePasswordEntry %s: %v", serviceName, err)
+	}
+
+	trash, err := session.GetTrash()
+
+	if err != nil || len(trash) == 0 || trash[0].ServiceName != serviceName {
+		t.Fatalf("GetTrash() = %+v, %v, want %s deleted last", trash, err, serviceName)
+	}
+
+	return trash[0]
+}
+
+func TestDeletedPasswordEntryIsHidden(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+	addTestEntry(t, session, "gitlab")
+
+	trashTestEntry(t, session, "github")
+
+	serviceNames, err := session.GetPasswordEntriesList()
+
+	if err != nil || len(serviceNames) != 1 || serviceNames[0] != "gitlab" {
+		t.Errorf("GetPasswordEntriesList() = %q, %v, want [gitlab]", serviceNames, err)
+	}
+
+	if _, err := session.DecryptPasswordEntry("github"); !errors.Is(err, ServiceNameNotFound) {
+		t.Errorf("DecryptPasswordEntry() of deleted entry = %v, want ServiceNameNotFound", err)
+	}
+
+	if count, err := session.CountServiceNameOccurences("github"); err != nil || count != 0 {
+		t.Errorf("CountServiceNameOccurences() of deleted entry = %d, %v, want 0", count, err)
+	}
+
+	exported, err := session.ExportPasswordEntries()
+
+	if err != nil || len(exported) != 1 || exported[0].ServiceName != "gitlab" {
+		t.Errorf("ExportPasswordEntries() = %+v, %v, want gitlab only", exported, err)
+	}
+
+	// Service name of entry in trash is free again
+	addTestEntry(t, session, "github")
+
+	if err := unlockTestVault(t, backend).Integrity(); err != nil {
+		t.Fatalf("Integrity() = %v, want nil", err)
+	}
+}
+
+func TestRestorePasswordEntry(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "github")
+
+	trashed := trashTestEntry(t, session, "github")
+
+	// Other entry took the service name meanwhile
+	addTestEntry(t, session, "github")
+
+	if err := session.RestorePasswordEntry(trashed.ID); !errors.Is(err, ServiceNameAlreadyTaken) {
+		t.Fatalf("RestorePasswordEntry() with taken service name = %v, want ServiceNameAlreadyTaken", err)
+	}
+
+	err := session.DeletePasswordEntry("github")
+
+	if err != nil {
+		t.Fatalf("DeletePasswordEntry: %v", err)
+	}
+
+	err = session.RestorePasswordEntry(trashed.ID)
+
+	if err != nil {
+		t.Fatalf("RestorePasswordEntry: %v", err)
+	}
+
+	if entry, err := session.DecryptPasswordEntry("github"); err != nil || entry.Password != "password of github" {
+		t.Fatalf("DecryptPasswordEntry() of restored entry = %+v, %v, want entry", entry, err)
+	}
+
+	if err := session.RestorePasswordEntry(trashed.ID); !errors.Is(err, PasswordEntryNotInTrash) {
+		t.Fatalf("RestorePasswordEntry() of restored entry = %v, want PasswordEntryNotInTrash", err)
+	}
+}
+
+func TestPurgePasswordEntryRemovesHistoryAndTags(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+
+	err := session.EncryptPasswordEntry(PasswordEntry{ServiceName: "github", Username: "user", Password: "first", Tags: []string{"work"}})
+
+	if err != nil {
+		t.Fatalf("EncryptPasswordEntry: %v", err)
+	}
+
+	err = session.UpdatePasswordEntry("github", PasswordEntry{ServiceName: "github", Username: "user", Password: "second", Tags: []string{"work"}})
+
+	if err != nil {
+		t.Fatalf("UpdatePasswordEntry: %v", err)
+	}
+
+	trashed := trashTestEntry(t, session, "github")
+
+	err = session.PurgePasswordEntry(trashed.ID)
+
+	if err != nil {
+		t.Fatalf("PurgePasswordEntry: %v", err)
+	}
+
+	for _, table := range []string{"passwords", "password_history", "password_tags", "tags"} {
+		if count := countRows(t, backend, table); count != 0 {
+			t.Errorf("%s has %d rows after purge, want 0", table, count)
+		}
+	}
+
+	if err := session.PurgePasswordEntry(trashed.ID); !errors.Is(err, PasswordEntryNotInTrash) {
+		t.Errorf("PurgePasswordEntry() of purged entry = %v, want PasswordEntryNotInTrash", err)
+	}
+
+	if err := unlockTestVault(t, backend).Integrity(); err != nil {
+		t.Fatalf("Integrity() = %v, want nil", err)
+	}
+}
+
+func TestPurgeExpiredTrashHonoursRetention(t *testing.T) {
+	backend, _ := newTestVault(t, nil)
+	session := unlockTestVault(t, backend)
+	addTestEntry(t, session, "old")
+	addTestEntry(t, session, "recent")
+
+	old := trashTestEntry(t, session, "old")
+	trashTestEntry(t, session, "recent")
+
+	// Entry was deleted long before the retention
+	_, err := backend.DB.Exec("UPDATE passwords SET deleted_at = ? WHERE id = ?", "2020-01-02 03:04:05", old.ID)
+
+	if err != nil {
+		t.Fatalf("setting deleted_at: %v", err)
+	}
+
+	// Zero retention means trash is never purged
+	err = session.SetTrashRetention(0)
+
+	if err != nil {
+		t.Fatalf("SetTrashRetention: %v", err)
+	}
+
+	if purged, err := session.PurgeExpiredTrash(); err != nil || purged != 0 {
+		t.Fatalf("PurgeExpiredTrash() with zero retention = %d, %v, want 0", purged, err)
+	}
+
+	err = session.SetTrashRetention(30 * 24 * time.Hour)
+
+	if err != nil {
+		t.Fatalf("SetTrashRetention: %v", err)
+	}
+
+	if purged, err := session.PurgeExpiredTrash(); err != nil || purged != 1 {
+		t.Fatalf("PurgeExpiredTrash() = %d, %v, want 1", purged, err)
+	}
+
+	trash, err := session.GetTrash()
+
+	if err != nil || len(trash) != 1 || trash[0].ServiceName != "recent" {
+		t.Fatalf("GetTrash() after purge = %+v, %v, want recent entry only", trash, err)
+	}
+
+	if err := session.SetTrashRetention(-time.Second); !errors.Is(err, InvalidSettingValue) {
+		t.Fatalf("SetTrashRetention() of negative retention = %v, want InvalidSettingValue", err)
+	}
+}
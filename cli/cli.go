@@ -0,0 +1,205 @@
+// Command line mode of frosk, used from terminals and scripts
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+
+	server "github.com/mszalewicz/frosk/backend"
+	"github.com/mszalewicz/frosk/generator"
+	"github.com/mszalewicz/frosk/importer"
+	"github.com/mszalewicz/frosk/kdbx"
+	"github.com/mszalewicz/frosk/strength"
+)
+
+// Exit codes are part of the interface used by scripts - never change meaning of existing ones, only add new.
+const (
+	ExitOK                  = 0
+	ExitError               = 1 // unspecified error, details in the log
+	ExitUsage               = 2
+	ExitWrongMasterPassword = 3
+	ExitNotFound            = 4
+	ExitAlreadyExists       = 5
+	ExitNotInitialized      = 6
+	ExitAlreadyInitialized  = 7
+	ExitInvalidInput        = 8
+	ExitVaultNotAccepted    = 9 // vault failed integrity check and can't be changed until `frosk accept`
+)
+
+var UsageError = errors.New("Invalid usage.")
+var VaultNotInitialized = errors.New("Vault has no master password yet. Run `frosk init` first.")
+var VaultAlreadyInitialized = errors.New("Vault already has master password.")
+var MasterPasswordsDiffer = errors.New("Master password does not match its repetition.")
+var ArchivePassphrasesDiffer = errors.New("Archive passphrase does not match its repetition.")
+var KeePassPasswordsDiffer = errors.New("KeePass password does not match its repetition.")
+var PlaintextNotConfirmed = errors.New("Export in this format holds passwords unencrypted. Add --plaintext to confirm.")
+var FileAlreadyExists = errors.New("File already exists.")
+var FieldNotFound = errors.New("Password entry has no such field.")
+
+const usage = `Usage: frosk [--vault PATH] <command> [arguments]
+
+Commands:
+  init                      set master password of a new vault
+  ls                        list service names, optionally only those with a tag
+  get <service>             print password (or other --field) of a service
+  add <service>             store credentials of a new service
+  edit <service>            change credentials or name of a service
+  history <service>         print replaced passwords of a service, newest first
+  rm <service>              move service to trash
+  trash                     list, restore or purge entries in trash
+  accept                    trust vault content after failed integrity check
+  generate                  print random password or passphrase
+  import <path>             import entries of frosk archive or exported by other password managers
+  export <path>             write all entries to encrypted archive, KeePass database, CSV or JSON
+  help                      show this message
+
+Vault is chosen by --vault, then FROSK_VAULT environment variable, then default vault in data directory.
+Master password is read from the terminal, or from file descriptor given with --password-fd.
+Run frosk <command> -h for options of a command. Without command graphical interface is started.
+`
+
+func PrintUsage(output io.Writer) {
+	fmt.Fprint(output, usage)
+}
+
+type CLI struct {
+	backend *server.Backend
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+type command func(cli *CLI, args []string) error
+
+var commands = map[string]command{
+	"init":     (*CLI).initVault,
+	"ls":       (*CLI).list,
+	"get":      (*CLI).get,
+	"add":      (*CLI).add,
+	"edit":     (*CLI).edit,
+	"history":  (*CLI).history,
+	"rm":       (*CLI).remove,
+	"trash":    (*CLI).trash,
+	"accept":   (*CLI).accept,
+	"generate": (*CLI).generate,
+	"import":   (*CLI).importEntries,
+	"export":   (*CLI).export,
+}
+
+// Commands which never touch the vault
+var vaultFreeCommands = []string{"help", "-h", "-help", "--help", "generate"}
+
+// Reports whether command given in args (without program name) works with the vault. Otherwise it is run with nil
+// backend, so vault is not opened, migrated or backed up just to print usage or generate a password.
+func NeedsVault(args []string) bool {
+	if len(args) == 0 || slices.Contains(vaultFreeCommands, args[0]) {
+		return false
+	}
+
+	_, known := commands[args[0]]
+
+	return known
+}
+
+// Runs command given in args (without program name) and returns exit code of the process. Backend is nil when
+// NeedsVault reports false.
+func Run(backend *server.Backend, args []string, stdout io.Writer, stderr io.Writer) int {
+	cli := &CLI{backend: backend, stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return ExitOK
+	}
+
+	run, ok := commands[args[0]]
+
+	if !ok {
+		fmt.Fprintf(stderr, "frosk: unknown command %q\n\n%s", args[0], usage)
+		return ExitUsage
+	}
+
+	err := run(cli, args[1:])
+
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "frosk %s: %s\n", args[0], errorMessage(err))
+	}
+
+	return ExitCode(err)
+}
+
+// Maps backend and cli errors to exit codes
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, UsageError):
+		return ExitUsage
+	case errors.Is(err, server.MasterPasswordDoNotMatch):
+		return ExitWrongMasterPassword
+	case errors.Is(err, server.ServiceNameNotFound), errors.Is(err, server.NoRowsDeleted), errors.Is(err, FieldNotFound),
+		errors.Is(err, server.PasswordEntryNotInTrash):
+		return ExitNotFound
+	case errors.Is(err, server.ServiceNameAlreadyTaken), errors.Is(err, FileAlreadyExists):
+		return ExitAlreadyExists
+	case errors.Is(err, VaultNotInitialized):
+		return ExitNotInitialized
+	case errors.Is(err, VaultAlreadyInitialized):
+		return ExitAlreadyInitialized
+	case errors.Is(err, server.VaultNotAccepted):
+		return ExitVaultNotAccepted
+	case errors.Is(err, server.EmptyPassword), errors.Is(err, server.EmptyUsername), errors.Is(err, server.EmptyServiceName),
+		errors.Is(err, server.EmptyMasterPassword), errors.Is(err, server.EmptyCustomFieldName), errors.Is(err, server.InvalidTOTP), errors.Is(err, server.EmptyTagName), errors.Is(err, MasterPasswordsDiffer),
+		errors.Is(err, generator.InvalidLength), errors.Is(err, generator.NoCharacterClasses),
+		errors.Is(err, generator.InvalidSymbolSet), errors.Is(err, generator.PolicyUnsatisfiable),
+		errors.Is(err, generator.InvalidWordCount), errors.Is(err, generator.InvalidSeparator),
+		errors.Is(err, strength.MasterPasswordTooWeak), errors.Is(err, strength.ArchivePassphraseTooWeak),
+		errors.Is(err, server.EmptyArchivePassphrase), errors.Is(err, ArchivePassphrasesDiffer), errors.Is(err, server.InvalidArchive),
+		errors.Is(err, server.UnsupportedArchiveVersion), errors.Is(err, server.ArchivePassphraseIncorrect),
+		errors.Is(err, strength.KeePassPasswordTooWeak), errors.Is(err, KeePassPasswordsDiffer),
+		errors.Is(err, importer.UnknownFormat), errors.Is(err, importer.MalformedExport), errors.Is(err, importer.EncryptedExport),
+		errors.Is(err, importer.GPGNotFound), errors.Is(err, kdbx.InvalidSignature), errors.Is(err, kdbx.UnsupportedVersion),
+		errors.Is(err, kdbx.InvalidCredentials), errors.Is(err, kdbx.CorruptedDatabase), errors.Is(err, kdbx.UnsupportedCipher),
+		errors.Is(err, kdbx.UnsupportedKDF), errors.Is(err, kdbx.MissingCredentials), errors.Is(err, kdbx.InvalidKeyFile):
+		return ExitInvalidInput
+	default:
+		return ExitError
+	}
+}
+
+// Backend errors carry whole chain of wrapped messages meant for the log - known ones are shown without it
+func errorMessage(err error) string {
+	known := []error{
+		server.MasterPasswordDoNotMatch,
+		server.ServiceNameNotFound,
+		server.NoRowsDeleted,
+		server.PasswordEntryNotInTrash,
+		server.ServiceNameAlreadyTaken,
+		server.EmptyPassword,
+		server.EmptyUsername,
+		server.EmptyServiceName,
+		server.EmptyMasterPassword,
+		server.EmptyCustomFieldName,
+		server.EmptyTagName,
+		server.VaultNotAccepted,
+	}
+
+	for _, knownErr := range known {
+		if errors.Is(err, knownErr) {
+			return knownErr.Error()
+		}
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNeedsVault(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{}, false},
+		{[]string{"help"}, false},
+		{[]string{"--help"}, false},
+		{[]string{"generate", "--words", "5"}, false},
+		{[]string{"unknown"}, false},
+		{[]string{"ls"}, true},
+		{[]string{"get", "github"}, true},
+		{[]string{"init"}, true},
+	}
+
+	for _, test := range tests {
+		if got := NeedsVault(test.args); got != test.want {
+			t.Errorf("NeedsVault(%q) = %v, want %v", test.args, got, test.want)
+		}
+	}
+}
+
+// Commands which do not need the vault run without backend
+func TestRunWithoutVault(t *testing.T) {
+	tests := []struct {
+		args     []string
+		exitCode int
+	}{
+		{[]string{"help"}, ExitOK},
+		{[]string{"generate", "--length", "16"}, ExitOK},
+		{[]string{"generate", "--words", "4"}, ExitOK},
+		{[]string{"generate", "--length", "1"}, ExitInvalidInput},
+		{[]string{"unknown"}, ExitUsage},
+		{[]string{}, ExitUsage},
+	}
+
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+
+		if exitCode := Run(nil, test.args, &stdout, &stderr); exitCode != test.exitCode {
+			t.Errorf("Run(%q) = %d, want %d, stderr: %s", test.args, exitCode, test.exitCode, stderr.String())
+		}
+	}
+}
+
+func TestGeneratePrintsPasswordOfGivenLength(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if exitCode := Run(nil, []string{"generate", "--length", "32"}, &stdout, &stderr); exitCode != ExitOK {
+		t.Fatalf("Run(generate) = %d, stderr: %s", exitCode, stderr.String())
+	}
+
+	if password := strings.TrimSuffix(stdout.String(), "\n"); len(password) != 32 {
+		t.Fatalf("generate printed %q, want 32 characters", password)
+	}
+}
@@ -0,0 +1,1026 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	server "github.com/mszalewicz/frosk/backend"
+	"github.com/mszalewicz/frosk/exporter"
+	"github.com/mszalewicz/frosk/generator"
+	"github.com/mszalewicz/frosk/importer"
+	"github.com/mszalewicz/frosk/kdbx"
+	"github.com/mszalewicz/frosk/strength"
+)
+
+// Flag set of a command. Errors are returned instead of exiting, so Run can map them to exit codes.
+func (cli *CLI) newFlagSet(name string, arguments string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(cli.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(cli.stderr, "Usage: frosk %s %s\n", name, arguments)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+func addPasswordFdFlag(flags *flag.FlagSet) *int {
+	return flags.Int("password-fd", -1, "read master password from first line of given file descriptor instead of terminal")
+}
+
+// Parses flags placed before and after positional arguments and checks number of positional arguments
+func parseArgs(flags *flag.FlagSet, args []string, positionalCount int) ([]string, error) {
+	positional := make([]string, 0, positionalCount)
+
+	for {
+		err := flags.Parse(args)
+
+		if err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("%w %w", UsageError, err)
+		}
+
+		if flags.NArg() == 0 {
+			break
+		}
+
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(positional) != positionalCount {
+		flags.Usage()
+		return nil, fmt.Errorf("%w Expected %d positional arguments, got %d.", UsageError, positionalCount, len(positional))
+	}
+
+	return positional, nil
+}
+
+func (cli *CLI) ensureInitialized() error {
+	numberOfEntriesInMasterTable, err := cli.backend.CountMasterEntries()
+
+	if err != nil {
+		return err
+	}
+
+	if numberOfEntriesInMasterTable == 0 {
+		return VaultNotInitialized
+	}
+
+	return nil
+}
+
+// Reads master password and unlocks the vault
+func (cli *CLI) unlock(passwordFd int) (*server.Session, error) {
+	err := cli.ensureInitialized()
+
+	if err != nil {
+		return nil, err
+	}
+
+	masterPassword, err := readSecret("Master password: ", passwordFd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := cli.backend.Unlock(masterPassword)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Command still runs, but can't change the vault. Vault content can be restored from backup in the app.
+	if integrityErr := session.Integrity(); integrityErr != nil {
+		fmt.Fprintf(cli.stderr, "frosk: warning: %v Restore a backup if you did not change the vault yourself, or run `frosk accept` to trust its content.\n", integrityErr)
+	}
+
+	return session, nil
+}
+
+func (cli *CLI) initVault(args []string) error {
+	flags := cli.newFlagSet("init", "[--password-fd N]")
+	passwordFd := addPasswordFdFlag(flags)
+
+	_, err := parseArgs(flags, args, 0)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = cli.setMasterPassword(*passwordFd)
+
+	return err
+}
+
+// Reads master password of a new vault, stores it and returns it
+func (cli *CLI) setMasterPassword(passwordFd int) (string, error) {
+	numberOfEntriesInMasterTable, err := cli.backend.CountMasterEntries()
+
+	if err != nil {
+		return "", err
+	}
+
+	if numberOfEntriesInMasterTable != 0 {
+		return "", VaultAlreadyInitialized
+	}
+
+	masterPassword, err := readNewSecret(newMasterPassword, passwordFd)
+
+	if err != nil {
+		return "", err
+	}
+
+	return masterPassword, cli.backend.InitMaster(masterPassword)
+}
+
+// Secret chosen by user, checked with errors specific to it
+type newSecret struct {
+	name    string // as used in prompts
+	empty   error
+	tooWeak error
+	differ  error
+}
+
+var (
+	newMasterPassword    = newSecret{"master password", server.EmptyMasterPassword, strength.MasterPasswordTooWeak, MasterPasswordsDiffer}
+	newArchivePassphrase = newSecret{"archive passphrase", server.EmptyArchivePassphrase, strength.ArchivePassphraseTooWeak, ArchivePassphrasesDiffer}
+	newKeePassPassword   = newSecret{"KeePass password", kdbx.MissingCredentials, strength.KeePassPasswordTooWeak, KeePassPasswordsDiffer}
+)
+
+// Reads new secret and checks its strength. Secret read from terminal has to be repeated.
+func readNewSecret(secret newSecret, fd int) (string, error) {
+	value, err := readSecret("New "+secret.name+": ", fd)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(value) == 0 {
+		return "", secret.empty
+	}
+
+	result := strength.Estimate(value, "frosk")
+
+	if result.Score < strength.MinMasterPasswordScore {
+		return "", fmt.Errorf("%w %s", secret.tooWeak, strings.TrimSpace(result.Warning+" "+strings.Join(result.Suggestions, " ")))
+	}
+
+	// Typo can't be noticed in hidden input, secret given through descriptor is taken as is
+	if fd < 0 {
+		repeat, err := readSecret("Repeat "+secret.name+": ", -1)
+
+		if err != nil {
+			return "", err
+		}
+
+		if value != repeat {
+			return "", secret.differ
+		}
+	}
+
+	return value, nil
+}
+
+func (cli *CLI) list(args []string) error {
+	flags := cli.newFlagSet("ls", "[--tag TAG] [--password-fd N]")
+	tag := flags.String("tag", "", "list only services with given tag or tags nested in it")
+	passwordFd := addPasswordFdFlag(flags)
+
+	_, err := parseArgs(flags, args, 0)
+
+	if err != nil {
+		return err
+	}
+
+	// Service names are encrypted, so listing them needs master password as well
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	services, err := session.GetPasswordEntriesList()
+
+	if err != nil {
+		return err
+	}
+
+	if *tag != "" {
+		entriesTags, err := session.GetPasswordEntriesTags()
+
+		if err != nil {
+			return err
+		}
+
+		services = slices.DeleteFunc(services, func(serviceName string) bool { return !server.MatchesTag(entriesTags[serviceName], *tag) })
+	}
+
+	for _, serviceName := range services {
+		fmt.Fprintln(cli.stdout, serviceName)
+	}
+
+	return nil
+}
+
+func (cli *CLI) get(args []string) error {
+	flags := cli.newFlagSet("get", "<service> [--field password|username|url|notes|totp|totp-uri|tags|NAME] [--password-fd N]")
+	field := flags.String("field", "password", "field to print: password, username, url, notes, totp (current code), totp-uri, tags (one per line) or name of a custom field")
+	passwordFd := addPasswordFdFlag(flags)
+
+	positional, err := parseArgs(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	passwordEntry, err := session.DecryptPasswordEntry(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	switch *field {
+	case "password":
+		fmt.Fprintln(cli.stdout, passwordEntry.Password)
+	case "username":
+		fmt.Fprintln(cli.stdout, passwordEntry.Username)
+	case "url":
+		fmt.Fprintln(cli.stdout, passwordEntry.URL)
+	case "notes":
+		fmt.Fprintln(cli.stdout, passwordEntry.Notes)
+	case "tags":
+		for _, tag := range passwordEntry.Tags {
+			fmt.Fprintln(cli.stdout, tag)
+		}
+	case "totp", "totp-uri":
+		if passwordEntry.TOTP == "" {
+			return fmt.Errorf("%w %q", FieldNotFound, *field)
+		}
+
+		if *field == "totp-uri" {
+			fmt.Fprintln(cli.stdout, passwordEntry.TOTP)
+			break
+		}
+
+		totp, err := server.ParseTOTP(passwordEntry.TOTP)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cli.stdout, totp.Code(time.Now()))
+	default:
+		index := slices.IndexFunc(passwordEntry.CustomFields, func(customField server.CustomField) bool { return customField.Name == *field })
+
+		if index < 0 {
+			return fmt.Errorf("%w %q", FieldNotFound, *field)
+		}
+
+		fmt.Fprintln(cli.stdout, passwordEntry.CustomFields[index].Value)
+	}
+
+	return nil
+}
+
+// Flags of optional password entry fields shared by add and edit
+type entryFieldFlags struct {
+	url          *string
+	notes        *string
+	totp         *string
+	customFields []server.CustomField // in order given on command line
+	removed      []string             // names of custom fields to remove
+	tags         []string
+	untagged     []string // tags to remove
+}
+
+func addEntryFieldFlags(flags *flag.FlagSet, withRemove bool) *entryFieldFlags {
+	fields := &entryFieldFlags{
+		url:   flags.String("url", "", "website address of the service"),
+		notes: flags.String("notes", "", "notes about the service"),
+		totp:  flags.String("totp", "", "two-factor authentication secret given as otpauth://totp/ URI or base32 key"),
+	}
+
+	customField := func(secret bool) func(string) error {
+		return func(value string) error {
+			name, fieldValue, found := strings.Cut(value, "=")
+
+			if !found || name == "" {
+				return errors.New("custom field has to be given as NAME=VALUE")
+			}
+
+			fields.customFields = append(fields.customFields, server.CustomField{Name: name, Value: fieldValue, Secret: secret})
+			return nil
+		}
+	}
+
+	flags.Func("custom", "custom field given as NAME=VALUE, can be repeated", customField(false))
+	flags.Func("secret-custom", "custom field with masked value given as NAME=VALUE, can be repeated", customField(true))
+	flags.Func("tag", "tag of the service, nested with / like work/servers, can be repeated", func(tag string) error {
+		fields.tags = append(fields.tags, tag)
+		return nil
+	})
+
+	if withRemove {
+		flags.Func("remove-custom", "name of custom field to remove, can be repeated", func(name string) error {
+			fields.removed = append(fields.removed, name)
+			return nil
+		})
+		flags.Func("untag", "tag to remove from the service, can be repeated", func(tag string) error {
+			fields.untagged = append(fields.untagged, tag)
+			return nil
+		})
+	}
+
+	return fields
+}
+
+// Applies fields given on command line to password entry. Custom field with name already present replaces it.
+func (fields *entryFieldFlags) apply(flags *flag.FlagSet, passwordEntry *server.PasswordEntry) {
+	// Empty url, notes or totp given explicitly clear the field
+	flags.Visit(func(given *flag.Flag) {
+		switch given.Name {
+		case "url":
+			passwordEntry.URL = *fields.url
+		case "notes":
+			passwordEntry.Notes = *fields.notes
+		case "totp":
+			passwordEntry.TOTP = *fields.totp
+		}
+	})
+
+	passwordEntry.CustomFields = slices.DeleteFunc(passwordEntry.CustomFields, func(customField server.CustomField) bool {
+		return slices.Contains(fields.removed, customField.Name)
+	})
+
+	for _, customField := range fields.customFields {
+		index := slices.IndexFunc(passwordEntry.CustomFields, func(existing server.CustomField) bool { return existing.Name == customField.Name })
+
+		if index >= 0 {
+			passwordEntry.CustomFields[index] = customField
+		} else {
+			passwordEntry.CustomFields = append(passwordEntry.CustomFields, customField)
+		}
+	}
+
+	passwordEntry.Tags = slices.DeleteFunc(passwordEntry.Tags, func(tag string) bool { return slices.Contains(fields.untagged, tag) })
+	passwordEntry.Tags = append(passwordEntry.Tags, fields.tags...)
+}
+
+// Flags of generator policy shared by commands generating passwords
+type policyFlags struct {
+	noLower          *bool
+	noUpper          *bool
+	noDigits         *bool
+	noSpecial        *bool
+	symbols          *string
+	excludeAmbiguous *bool
+	anyClass         *bool
+	noRepeats        *bool
+	unique           *bool
+}
+
+func addPolicyFlags(flags *flag.FlagSet) policyFlags {
+	return policyFlags{
+		noLower:          flags.Bool("no-lower", false, "generate password without lowercase letters"),
+		noUpper:          flags.Bool("no-upper", false, "generate password without uppercase letters"),
+		noDigits:         flags.Bool("no-digits", false, "generate password without digits"),
+		noSpecial:        flags.Bool("no-special", false, "generate password without special characters"),
+		symbols:          flags.String("symbols", generator.DefaultSymbolSet, "special characters allowed in generated password"),
+		excludeAmbiguous: flags.Bool("exclude-ambiguous", false, "do not use characters easily confused with each other ("+generator.AmbiguousCharacters+")"),
+		anyClass:         flags.Bool("any-class", false, "do not require a character from every enabled class"),
+		noRepeats:        flags.Bool("no-repeats", false, "never use the same character twice in a row"),
+		unique:           flags.Bool("unique", false, "use every character at most once"),
+	}
+}
+
+func (policyFlags policyFlags) policy(length int) generator.Policy {
+	return generator.Policy{
+		Length:               length,
+		Lowercase:            !*policyFlags.noLower,
+		Uppercase:            !*policyFlags.noUpper,
+		Digits:               !*policyFlags.noDigits,
+		Symbols:              !*policyFlags.noSpecial,
+		SymbolSet:            *policyFlags.symbols,
+		RequireEveryClass:    !*policyFlags.anyClass,
+		ExcludeAmbiguous:     *policyFlags.excludeAmbiguous,
+		NoConsecutiveRepeats: *policyFlags.noRepeats,
+		NoDuplicates:         *policyFlags.unique,
+	}
+}
+
+// Flags choosing new password of a service - typed in, read from descriptor or generated
+type passwordSourceFlags struct {
+	secretFd   *int
+	generate   *int
+	policy     policyFlags
+	promptUser *bool
+}
+
+func addPasswordSourceFlags(flags *flag.FlagSet, withPrompt bool) passwordSourceFlags {
+	source := passwordSourceFlags{
+		secretFd: flags.Int("secret-fd", -1, "read password of the service from first line of given file descriptor instead of terminal"),
+		generate: flags.Int("generate", 0, "generate random password of given length instead of reading it"),
+		policy:   addPolicyFlags(flags),
+	}
+
+	if withPrompt {
+		source.promptUser = flags.Bool("new-password", false, "ask for new password of the service")
+	}
+
+	return source
+}
+
+// Returns whether new password was requested at all - always true when there is no --new-password flag
+func (source passwordSourceFlags) requested() bool {
+	return source.promptUser == nil || *source.promptUser || *source.secretFd >= 0 || *source.generate > 0
+}
+
+func (source passwordSourceFlags) read(serviceName string) (string, error) {
+	if *source.generate < 0 {
+		return "", fmt.Errorf("%w Length of generated password can't be negative.", UsageError)
+	}
+
+	if *source.generate > 0 {
+		return generator.Generate(source.policy.policy(*source.generate))
+	}
+
+	return readSecret("Password for "+serviceName+": ", *source.secretFd)
+}
+
+func (cli *CLI) add(args []string) error {
+	flags := cli.newFlagSet("add", "<service> --username U [--url URL] [--notes TEXT] [--totp URI] [--custom NAME=VALUE]... [--tag TAG]... [--generate N [policy flags] | --secret-fd N] [--password-fd N]")
+	username := flags.String("username", "", "username for the service")
+	entryFields := addEntryFieldFlags(flags, false)
+	passwordSource := addPasswordSourceFlags(flags, false)
+	passwordFd := addPasswordFdFlag(flags)
+
+	positional, err := parseArgs(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	password, err := passwordSource.read(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	passwordEntry := server.PasswordEntry{ServiceName: positional[0], Username: *username, Password: password}
+	entryFields.apply(flags, &passwordEntry)
+
+	return session.EncryptPasswordEntry(passwordEntry)
+}
+
+func (cli *CLI) edit(args []string) error {
+	flags := cli.newFlagSet("edit", "<service> [--rename NAME] [--username U] [--url URL] [--notes TEXT] [--totp URI] [--custom NAME=VALUE]... [--remove-custom NAME]... [--tag TAG]... [--untag TAG]... [--new-password | --generate N [policy flags] | --secret-fd N] [--password-fd N]")
+	rename := flags.String("rename", "", "new name of the service")
+	username := flags.String("username", "", "new username for the service")
+	entryFields := addEntryFieldFlags(flags, true)
+	passwordSource := addPasswordSourceFlags(flags, true)
+	passwordFd := addPasswordFdFlag(flags)
+
+	positional, err := parseArgs(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	serviceName := positional[0]
+	passwordEntry, err := session.DecryptPasswordEntry(serviceName)
+
+	if err != nil {
+		return err
+	}
+
+	if len(*rename) > 0 {
+		passwordEntry.ServiceName = *rename
+	}
+
+	if len(*username) > 0 {
+		passwordEntry.Username = *username
+	}
+
+	entryFields.apply(flags, &passwordEntry)
+
+	if passwordSource.requested() {
+		passwordEntry.Password, err = passwordSource.read(serviceName)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return session.UpdatePasswordEntry(serviceName, passwordEntry)
+}
+
+func (cli *CLI) remove(args []string) error {
+	flags := cli.newFlagSet("rm", "<service> [--password-fd N]")
+	passwordFd := addPasswordFdFlag(flags)
+
+	positional, err := parseArgs(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	return session.DeletePasswordEntry(positional[0])
+}
+
+// Seals current content of the vault after failed integrity check, so it can be changed again
+func (cli *CLI) accept(args []string) error {
+	flags := cli.newFlagSet("accept", "[--password-fd N]")
+	passwordFd := addPasswordFdFlag(flags)
+
+	_, err := parseArgs(flags, args, 0)
+
+	if err != nil {
+		return err
+	}
+
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	if session.Integrity() == nil {
+		fmt.Fprintln(cli.stdout, "vault passed integrity check, nothing to accept")
+		return nil
+	}
+
+	err = session.AcceptVaultState()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cli.stdout, "accepted current content of the vault")
+
+	return nil
+}
+
+func (cli *CLI) history(args []string) error {
+	flags := cli.newFlagSet("history", "<service> [--password-fd N]")
+	passwordFd := addPasswordFdFlag(flags)
+
+	positional, err := parseArgs(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	history, err := session.GetPasswordHistory(positional[0])
+
+	if err != nil {
+		return err
+	}
+
+	for _, historyEntry := range history {
+		fmt.Fprintf(cli.stdout, "%s\t%s\n", historyEntry.ReplacedAt, historyEntry.Password)
+	}
+
+	return nil
+}
+
+func (cli *CLI) trash(args []string) error {
+	flags := cli.newFlagSet("trash", "[--restore ID | --purge ID] [--password-fd N]")
+	restoreID := flags.Int64("restore", 0, "move entry with given id out of trash")
+	purgeID := flags.Int64("purge", 0, "remove entry with given id for good")
+	passwordFd := addPasswordFdFlag(flags)
+
+	_, err := parseArgs(flags, args, 0)
+
+	if err != nil {
+		return err
+	}
+
+	if *restoreID != 0 && *purgeID != 0 {
+		return fmt.Errorf("%w --restore and --purge can't be used together.", UsageError)
+	}
+
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	switch {
+	case *restoreID != 0:
+		return session.RestorePasswordEntry(*restoreID)
+	case *purgeID != 0:
+		return session.PurgePasswordEntry(*purgeID)
+	}
+
+	trash, err := session.GetTrash()
+
+	if err != nil {
+		return err
+	}
+
+	// Trash can hold several entries of the same service, so they are addressed by id
+	for _, trashed := range trash {
+		fmt.Fprintf(cli.stdout, "%d\t%s\t%s\n", trashed.ID, trashed.DeletedAt, trashed.ServiceName)
+	}
+
+	return nil
+}
+
+func (cli *CLI) generate(args []string) error {
+	defaultPassphrasePolicy := generator.DefaultPassphrasePolicy()
+
+	flags := cli.newFlagSet("generate", "[--length N] [policy flags] | --words N [passphrase flags]")
+	length := flags.Int("length", generator.DefaultPolicy().Length, "length of generated password")
+	policy := addPolicyFlags(flags)
+	words := flags.Int("words", 0, "generate passphrase of given number of words instead of random characters")
+	shortWords := flags.Bool("short-words", false, "draw passphrase from EFF short wordlist instead of the large one")
+	separator := flags.String("separator", defaultPassphrasePolicy.Separator, "separator between passphrase words")
+	capitalize := flags.Bool("capitalize", false, "capitalize passphrase words")
+	appendDigit := flags.Bool("digit", false, "append random digit to passphrase")
+
+	_, err := parseArgs(flags, args, 0)
+
+	if err != nil {
+		return err
+	}
+
+	var password string
+
+	if *words > 0 {
+		passphrasePolicy := generator.PassphrasePolicy{
+			Words:       *words,
+			Wordlist:    generator.EFFLarge,
+			Separator:   *separator,
+			Capitalize:  *capitalize,
+			AppendDigit: *appendDigit,
+		}
+
+		if *shortWords {
+			passphrasePolicy.Wordlist = generator.EFFShort
+		}
+
+		password, err = generator.GeneratePassphrase(passphrasePolicy)
+	} else {
+		password, err = generator.Generate(policy.policy(*length))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cli.stdout, password)
+
+	return nil
+}
+
+func (cli *CLI) importEntries(args []string) error {
+	formats := append([]string{archiveFormat, keePassFormat}, importer.Names()...)
+
+	flags := cli.newFlagSet("import", "<path> --format "+strings.Join(formats, "|")+" [--on-collision rename|skip|overwrite] [--dry-run] [--init] [--key-file PATH] [--file-password-fd N] [--password-fd N]")
+	format := flags.String("format", "", "format of imported file: "+strings.Join(formats, ", ")+" (frosk reads archive written by export, pass reads password store directory)")
+	onCollision := flags.String("on-collision", server.CollisionRename.String(), "what to do when service name is already present: rename, skip or overwrite")
+	dryRun := flags.Bool("dry-run", false, "show what import would do without changing the vault")
+	initialize := flags.Bool("init", false, "set master password of a new vault before import, e.g. to restore archive into it")
+	keyFile := flags.String("key-file", "", "key file of KeePass database")
+	filePasswordFd := flags.Int("file-password-fd", -1, "read passphrase of frosk archive or password of KeePass database from first line of given file descriptor instead of terminal")
+	passwordFd := addPasswordFdFlag(flags)
+
+	positional, err := parseArgs(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	policy, err := server.ParseCollisionPolicy(*onCollision)
+
+	if err != nil {
+		return fmt.Errorf("%w %w", UsageError, err)
+	}
+
+	if !slices.Contains(formats, *format) {
+		flags.Usage()
+		return fmt.Errorf("%w Format has to be one of: %s.", UsageError, strings.Join(formats, ", "))
+	}
+
+	if *initialize && *dryRun {
+		return fmt.Errorf("%w --init can't be combined with --dry-run.", UsageError)
+	}
+
+	var session *server.Session
+
+	if *initialize {
+		var masterPassword string
+		masterPassword, err = cli.setMasterPassword(*passwordFd)
+
+		if err == nil {
+			session, err = cli.backend.Unlock(masterPassword)
+		}
+	} else {
+		session, err = cli.unlock(*passwordFd)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	var passwordEntries []server.PasswordEntry
+
+	switch *format {
+	case archiveFormat:
+		passwordEntries, err = readArchive(positional[0], *filePasswordFd)
+	case keePassFormat:
+		passwordEntries, err = readKeePass(positional[0], *keyFile, *filePasswordFd)
+	default:
+		var importFormat importer.Format
+		importFormat, err = importer.Lookup(*format)
+
+		if err == nil {
+			passwordEntries, err = importFormat.Read(positional[0])
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var summary server.ImportSummary
+
+	if *dryRun {
+		summary, err = session.PreviewPasswordEntriesImport(passwordEntries, policy)
+	} else {
+		summary, err = session.ImportPasswordEntries(passwordEntries, policy)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, importedEntry := range summary.Entries {
+		line := fmt.Sprintf("%-11s %s", importedEntry.Outcome, importedEntry.ServiceName)
+
+		switch {
+		case importedEntry.Outcome == server.ImportSkipped:
+			line += " - " + importedEntry.Reason
+		case importedEntry.StoredAs != importedEntry.ServiceName:
+			line += " -> " + importedEntry.StoredAs
+		}
+
+		fmt.Fprintln(cli.stdout, line)
+	}
+
+	fmt.Fprintf(cli.stdout, "imported %d, renamed %d, overwritten %d, duplicates %d, skipped %d\n", summary.Imported, summary.Renamed, summary.Overwritten, summary.Duplicates, summary.Skipped)
+
+	if summary.DryRun {
+		fmt.Fprintln(cli.stdout, "dry run - vault was not changed")
+	}
+
+	return nil
+}
+
+// Format names of files which are encrypted with password given by user and are not handled by importer and exporter
+const (
+	archiveFormat = "frosk"   // archive written by export
+	keePassFormat = "keepass" // KeePass database read and written by kdbx package
+)
+
+// Name of exported KeePass database, shown by KeePass as name of root group
+const keePassDatabaseName = "frosk"
+
+func readArchive(path string, passphraseFd int) ([]server.PasswordEntry, error) {
+	archiveFile, err := os.Open(path)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during opening archive: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	defer archiveFile.Close()
+
+	passphrase, err := readSecret("Archive passphrase: ", passphraseFd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := server.ReadArchive(archiveFile, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return archive.PasswordEntries, nil
+}
+
+func readKeePass(path string, keyFilePath string, passwordFd int) ([]server.PasswordEntry, error) {
+	database, err := os.Open(path)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during opening KeePass database: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	defer database.Close()
+
+	var keyFile []byte
+
+	if keyFilePath != "" {
+		keyFile, err = os.ReadFile(keyFilePath)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during reading key file: %w", err)
+			slog.Error(errWrapped.Error())
+			return nil, errWrapped
+		}
+	}
+
+	password, err := readSecret("KeePass password: ", passwordFd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	opened, err := kdbx.Open(database, password, keyFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return opened.PasswordEntries(), nil
+}
+
+func (cli *CLI) export(args []string) error {
+	formats := append([]string{archiveFormat, keePassFormat}, exporter.Names()...)
+
+	flags := cli.newFlagSet("export", "<path> [--format "+strings.Join(formats, "|")+"] [--plaintext] [--force] [--file-password-fd N] [--password-fd N]")
+	format := flags.String("format", archiveFormat, "format of exported file: "+strings.Join(formats, ", ")+" ("+strings.Join(exporter.Names(), " and ")+" are not encrypted)")
+	plaintext := flags.Bool("plaintext", false, "confirm export which holds passwords unencrypted")
+	force := flags.Bool("force", false, "replace existing file")
+	filePasswordFd := flags.Int("file-password-fd", -1, "read passphrase of frosk archive or password of KeePass database from first line of given file descriptor instead of terminal")
+	passwordFd := addPasswordFdFlag(flags)
+
+	positional, err := parseArgs(flags, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(formats, *format) {
+		flags.Usage()
+		return fmt.Errorf("%w Format has to be one of: %s.", UsageError, strings.Join(formats, ", "))
+	}
+
+	// Formats other than archive and KeePass database are written by exporter without encryption
+	plaintextFormat, lookupErr := exporter.Lookup(*format)
+
+	if lookupErr == nil && !*plaintext {
+		return fmt.Errorf("%w %w", UsageError, PlaintextNotConfirmed)
+	}
+
+	path := positional[0]
+
+	_, err = os.Stat(path)
+
+	if err == nil && !*force {
+		return fmt.Errorf("%w Use --force to replace %s.", FileAlreadyExists, path)
+	}
+
+	session, err := cli.unlock(*passwordFd)
+
+	if err != nil {
+		return err
+	}
+
+	defer session.Lock()
+
+	var password string
+
+	switch *format {
+	case archiveFormat:
+		password, err = readNewSecret(newArchivePassphrase, *filePasswordFd)
+	case keePassFormat:
+		password, err = readNewSecret(newKeePassPassword, *filePasswordFd)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Export is written next to its destination and renamed, so failed export never leaves half of file behind
+	exportFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during creating export: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer os.Remove(exportFile.Name())
+	defer exportFile.Close()
+
+	var count int
+
+	if *format == archiveFormat {
+		count, err = session.WriteArchive(exportFile, password)
+	} else {
+		var passwordEntries []server.PasswordEntry
+		passwordEntries, err = session.ExportPasswordEntries()
+		count = len(passwordEntries)
+
+		switch {
+		case err != nil:
+		case *format == keePassFormat:
+			err = kdbx.Save(exportFile, kdbx.NewDatabase(keePassDatabaseName, passwordEntries), password, nil)
+		default:
+			err = plaintextFormat.Write(exportFile, passwordEntries)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = exportFile.Sync()
+
+	if err == nil {
+		err = exportFile.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(exportFile.Name(), path)
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during saving export: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	fmt.Fprintf(cli.stdout, "exported %d entries to %s\n", count, path)
+
+	if lookupErr == nil {
+		fmt.Fprintf(cli.stderr, "warning: %s holds passwords unencrypted - delete it once it is not needed\n", path)
+	}
+
+	return nil
+}
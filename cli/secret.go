@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+var TerminalNotAvailable = errors.New("Terminal is not available for hidden input. Use --password-fd to pass the password.")
+
+// Reads secret from first line of file descriptor, or with echo disabled from terminal when fd is negative
+func readSecret(prompt string, fd int) (string, error) {
+	if fd < 0 {
+		return readSecretFromTerminal(prompt)
+	}
+
+	file, opened := descriptors[fd]
+
+	if !opened {
+		file = os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+
+		if file == nil {
+			return "", fmt.Errorf("%w Invalid file descriptor %d.", UsageError, fd)
+		}
+
+		descriptors[fd] = file
+	}
+
+	return readLine(file)
+}
+
+// Files of descriptors secrets were read from. Kept referenced, so garbage collector does not close descriptor
+// which is read again, e.g. when master password and KeePass password share it, or reused by files opened later.
+var descriptors = map[int]*os.File{}
+
+// Reads byte by byte, so nothing after the line is consumed from descriptor shared with other secrets
+func readLine(reader io.Reader) (string, error) {
+	var line strings.Builder
+	buffer := make([]byte, 1)
+
+	for {
+		n, err := reader.Read(buffer)
+
+		if n == 1 {
+			if buffer[0] == '\n' {
+				break
+			}
+
+			line.WriteByte(buffer[0])
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during reading secret: %w", err)
+			slog.Error(errWrapped.Error())
+			return "", errWrapped
+		}
+	}
+
+	return strings.TrimSuffix(line.String(), "\r"), nil
+}
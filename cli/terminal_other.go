@@ -0,0 +1,7 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly || windows)
+
+package cli
+
+func readSecretFromTerminal(prompt string) (string, error) {
+	return "", TerminalNotAvailable
+}
@@ -0,0 +1,50 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Prompts on controlling terminal, so secrets can be typed even when standard streams are redirected
+func readSecretFromTerminal(prompt string) (string, error) {
+	terminal, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+
+	if err != nil {
+		return "", TerminalNotAvailable
+	}
+
+	defer terminal.Close()
+
+	fd := int(terminal.Fd())
+	state, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+
+	if err != nil {
+		return "", TerminalNotAvailable
+	}
+
+	hidden := *state
+	hidden.Lflag &^= unix.ECHO
+	hidden.Lflag |= unix.ICANON | unix.ISIG
+
+	err = unix.IoctlSetTermios(fd, ioctlSetTermios, &hidden)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during disabling terminal echo: %w", err)
+		slog.Error(errWrapped.Error())
+		return "", errWrapped
+	}
+
+	defer func() {
+		unix.IoctlSetTermios(fd, ioctlSetTermios, state)
+		fmt.Fprintln(terminal)
+	}()
+
+	fmt.Fprint(terminal, prompt)
+
+	return readLine(terminal)
+}
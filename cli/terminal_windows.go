@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Prompts on console, so secrets can be typed even when standard streams are redirected
+func readSecretFromTerminal(prompt string) (string, error) {
+	console, err := os.OpenFile("CONIN$", os.O_RDWR, 0)
+
+	if err != nil {
+		return "", TerminalNotAvailable
+	}
+
+	defer console.Close()
+
+	handle := windows.Handle(console.Fd())
+
+	var mode uint32
+	err = windows.GetConsoleMode(handle, &mode)
+
+	if err != nil {
+		return "", TerminalNotAvailable
+	}
+
+	err = windows.SetConsoleMode(handle, (mode&^windows.ENABLE_ECHO_INPUT)|windows.ENABLE_LINE_INPUT|windows.ENABLE_PROCESSED_INPUT)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during disabling console echo: %w", err)
+		slog.Error(errWrapped.Error())
+		return "", errWrapped
+	}
+
+	defer func() {
+		windows.SetConsoleMode(handle, mode)
+		fmt.Fprintln(os.Stderr)
+	}()
+
+	fmt.Fprint(os.Stderr, prompt)
+
+	return readLine(console)
+}
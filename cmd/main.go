@@ -1,60 +1,43 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
 	"os"
-	"os/user"
 	"path/filepath"
-	"runtime"
 
 	"gioui.org/app"
 	"gioui.org/op"
 	"gioui.org/unit"
 	"gioui.org/widget/material"
-	server "github.com/mszalewicz/frosk/backend"
+	"github.com/mszalewicz/frosk/cli"
 	"github.com/mszalewicz/frosk/gui"
+	"github.com/mszalewicz/frosk/vaults"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
-	//      App directory scheme under different OS:
-	// 		Mac:       ~/Library/Application\ Scripts/frosk/log
-	// 		Windows:   C:\Users\<username>\AppData\Local\frosk\log
-	// 		Linux:     /var/lib/frosk/log
+	vaultFlag := flag.String("vault", "", "path of vault file to open, "+vaults.EnvironmentVariable+" is used when not given")
+	flag.Usage = func() { cli.PrintUsage(flag.CommandLine.Output()) }
+	flag.Parse()
 
-	current_os := runtime.GOOS
-	logPath := ""
-	applicationDBPath := ""
-	appDirectory := ""
+	// Any command switches application to command line mode, errors are then reported on stderr instead of in a window
+	cliMode := flag.NArg() > 0
 
-	usr, err := user.Current()
+	// Log and list of known vaults are kept in data directory, vaults can be stored anywhere
+	appDirectory, err := vaults.DataDirectory()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	switch current_os {
-	case "darwin":
-		appDirectory = filepath.Join(usr.HomeDir, "/Library/Application Support/frosk/")
-		logPath = filepath.Join(appDirectory, "log")
-		applicationDBPath = filepath.Join(appDirectory, "application.sqlite")
-
-	case "windows":
-		appDirectory := filepath.Join(usr.HomeDir, "AppData\\Local\\frosk")
-		logPath = filepath.Join(appDirectory, "log")
-		applicationDBPath = filepath.Join(appDirectory, "application.sqlite")
-
-	case "linux":
-		appDirectory := "/var/lib/frosk"
-		logPath = filepath.Join(appDirectory, "log")
-		applicationDBPath = filepath.Join(appDirectory, "application.sqlite")
-	}
+	logPath := filepath.Join(appDirectory, "log")
 
 	_, err = os.Stat(appDirectory)
 	if os.IsNotExist(err) {
-		err := os.MkdirAll(appDirectory, os.ModePerm)
+		err := os.MkdirAll(appDirectory, 0o700)
 		if err != nil {
 			fmt.Println("Error creating directory:", err)
 			return
@@ -67,6 +50,12 @@ func main() {
 	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
 	if err != nil {
 		slog.Error("Could not create log file.", "error", err)
+
+		if cliMode {
+			fmt.Fprintln(os.Stderr, "frosk: could not create log file:", err)
+			os.Exit(cli.ExitError)
+		}
+
 		var ops op.Ops
 		theme := material.NewTheme()
 
@@ -87,29 +76,27 @@ func main() {
 	logger := slog.New(slog.NewJSONHandler(logFile, loggerArgs))
 	slog.SetDefault(logger)
 
-	backend, errToHandleInGUI := server.Initialize(applicationDBPath)
-
-	if errToHandleInGUI != nil {
-		slog.Error("Could not create local db.", "error", errToHandleInGUI)
-		var ops op.Ops
-		theme := material.NewTheme()
-
-		go func() {
-			window := new(app.Window)
-			window.Option(app.Title("frosk"))
-			window.Option(app.Size(unit.Dp(450), unit.Dp(800)))
-			window.Option(app.MinSize(unit.Dp(350), unit.Dp(350)))
-			window.Option(app.Decorated(false))
-			gui.ErrorWindow(&ops, window, theme, "Application could not create local database. Please report bug. Details can be found in the logs.")
-		}()
+	if cliMode && !cli.NeedsVault(flag.Args()) {
+		exitCode := cli.Run(nil, flag.Args(), os.Stdout, os.Stderr)
+		logFile.Close()
+		os.Exit(exitCode)
+	}
 
-		app.Main()
+	applicationDBPath, err := vaults.Resolve(*vaultFlag, appDirectory)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	errToHandleInGUI = backend.CreateStructure()
+	vault, errToHandleInGUI := vaults.Open(applicationDBPath, appDirectory)
 
 	if errToHandleInGUI != nil {
-		slog.Error("Could not bootstrap DB from schema.", "error", errToHandleInGUI)
+		slog.Error("Could not open vault.", "path", applicationDBPath, "error", errToHandleInGUI)
+
+		if cliMode {
+			fmt.Fprintln(os.Stderr, "frosk: could not open vault", applicationDBPath+", details can be found in the logs")
+			os.Exit(cli.ExitError)
+		}
+
 		var ops op.Ops
 		theme := material.NewTheme()
 
@@ -119,12 +106,21 @@ func main() {
 			window.Option(app.Size(unit.Dp(450), unit.Dp(800)))
 			window.Option(app.MinSize(unit.Dp(350), unit.Dp(350)))
 			window.Option(app.Decorated(false))
-			gui.ErrorWindow(&ops, window, theme, "Application could not create database structure. Please report bug. Details can be found in the logs.")
+			gui.ErrorWindow(&ops, window, theme, "Application could not open vault database. Please report bug. Details can be found in the logs.")
 		}()
 
 		app.Main()
 	}
 
+	// Failure only means vault is missing in vault picker, it is logged by vaults package
+	vaults.Remember(appDirectory, vault.Path)
+
+	if cliMode {
+		exitCode := cli.Run(vault.Backend, flag.Args(), os.Stdout, os.Stderr)
+		logFile.Close()
+		vault.Close()
+		os.Exit(exitCode)
+	}
 
 	go func() {
 		window := new(app.Window)
@@ -133,7 +129,7 @@ func main() {
 		window.Option(app.MinSize(unit.Dp(350), unit.Dp(350)))
 		window.Option(app.Decorated(false))
 
-		err := gui.HandleMainWindow(window, backend)
+		err := gui.HandleMainWindow(window, vault, appDirectory)
 
 		if err != nil {
 			slog.Error(err.Error())
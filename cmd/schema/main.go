@@ -0,0 +1,42 @@
+// Regenerates sql/schema.sql by applying all vault migrations to an empty in-memory database
+package main
+
+import (
+	"log"
+	"os"
+
+	server "github.com/mszalewicz/frosk/backend"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	output := "sql/schema.sql"
+
+	if len(os.Args) > 1 {
+		output = os.Args[1]
+	}
+
+	backend, err := server.Initialize(":memory:")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Every connection to :memory: opens separate database
+	backend.DB.SetMaxOpenConns(1)
+
+	err = backend.CreateStructure()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	schema, err := backend.DumpSchema()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(output, []byte(schema), 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+// CSV with header row. First columns follow Chrome export, so browsers and most password managers read the file
+// as it is. Every custom field name gets column of its own - repeated name of one entry gets "name (2)", ...
+type csvFormat struct{}
+
+func (csvFormat) Name() string        { return "csv" }
+func (csvFormat) Description() string { return "CSV" }
+
+var csvColumns = []string{"name", "url", "username", "password", "note", "totp", "tags"}
+
+func (csvFormat) Write(writer io.Writer, passwordEntries []server.PasswordEntry) error {
+	customColumns := make([]string, 0)
+	rows := make([]map[string]string, 0, len(passwordEntries))
+
+	for _, passwordEntry := range passwordEntries {
+		row := map[string]string{
+			"name":     passwordEntry.ServiceName,
+			"url":      passwordEntry.URL,
+			"username": passwordEntry.Username,
+			"password": passwordEntry.Password,
+			"note":     passwordEntry.Notes,
+			"totp":     passwordEntry.TOTP,
+			"tags":     strings.Join(passwordEntry.Tags, ", "),
+		}
+
+		for _, customField := range passwordEntry.CustomFields {
+			column := customField.Name
+
+			for suffix := 2; isTaken(row, column); suffix++ {
+				column = fmt.Sprintf("%s (%d)", customField.Name, suffix)
+			}
+
+			row[column] = customField.Value
+
+			if !slices.Contains(customColumns, column) {
+				customColumns = append(customColumns, column)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	header := append(slices.Clone(csvColumns), customColumns...)
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write(header)
+
+	for _, row := range rows {
+		record := make([]string, len(header))
+
+		for i, column := range header {
+			record[i] = row[column]
+		}
+
+		csvWriter.Write(record)
+	}
+
+	csvWriter.Flush()
+
+	if err := csvWriter.Error(); err != nil {
+		errWrapped := fmt.Errorf("Error during writing CSV export: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Custom field can't take column of standard field nor of earlier custom field of the same entry
+func isTaken(row map[string]string, column string) bool {
+	_, taken := row[column]
+	return taken || slices.Contains(csvColumns, column)
+}
@@ -0,0 +1,65 @@
+// Writers of unencrypted exports read by other tools - every password of the vault ends up in plain text, so
+// exports are written only when user explicitly asks for them. New formats are added with Register.
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+var UnknownFormat = errors.New("Export format is not known.")
+
+// Writer of one export format
+type Format interface {
+	Name() string        // short identifier used on command line, e.g. "csv"
+	Description() string // shown to user, e.g. "CSV"
+
+	// Writes all given entries, in given order
+	Write(writer io.Writer, passwordEntries []server.PasswordEntry) error
+}
+
+var formats []Format
+
+// Makes format available through Formats and Lookup. Panics when format with the same name is already registered.
+func Register(format Format) {
+	if slices.ContainsFunc(formats, func(registered Format) bool { return registered.Name() == format.Name() }) {
+		panic("exporter: format " + format.Name() + " registered twice")
+	}
+
+	formats = append(formats, format)
+}
+
+// Returns registered formats in order of registration
+func Formats() []Format {
+	return slices.Clone(formats)
+}
+
+func Lookup(name string) (Format, error) {
+	index := slices.IndexFunc(formats, func(format Format) bool { return format.Name() == name })
+
+	if index < 0 {
+		return nil, fmt.Errorf("%w %q", UnknownFormat, name)
+	}
+
+	return formats[index], nil
+}
+
+// Returns names of registered formats, e.g. for usage messages
+func Names() []string {
+	names := make([]string, 0, len(formats))
+
+	for _, format := range formats {
+		names = append(names, format.Name())
+	}
+
+	return names
+}
+
+func init() {
+	Register(csvFormat{})
+	Register(jsonFormat{})
+}
@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	server "github.com/mszalewicz/frosk/backend"
+	"github.com/mszalewicz/frosk/helpers"
+)
+
+// JSON document with all fields of every entry, meant for scripts
+type jsonFormat struct{}
+
+func (jsonFormat) Name() string        { return "json" }
+func (jsonFormat) Description() string { return "JSON" }
+
+type jsonExport struct {
+	ExportedAt string      `json:"exported_at"`
+	Entries    []jsonEntry `json:"entries"`
+}
+
+type jsonEntry struct {
+	ServiceName  string               `json:"service_name"`
+	Username     string               `json:"username"`
+	Password     string               `json:"password"`
+	URL          string               `json:"url"`
+	Notes        string               `json:"notes"`
+	TOTP         string               `json:"totp"`
+	Tags         []string             `json:"tags"`
+	CustomFields []server.CustomField `json:"custom_fields"`
+}
+
+func (jsonFormat) Write(writer io.Writer, passwordEntries []server.PasswordEntry) error {
+	export := jsonExport{ExportedAt: helpers.TimeTo8601String(time.Now()), Entries: make([]jsonEntry, 0, len(passwordEntries))}
+
+	for _, passwordEntry := range passwordEntries {
+		entry := jsonEntry{
+			ServiceName:  passwordEntry.ServiceName,
+			Username:     passwordEntry.Username,
+			Password:     passwordEntry.Password,
+			URL:          passwordEntry.URL,
+			Notes:        passwordEntry.Notes,
+			TOTP:         passwordEntry.TOTP,
+			Tags:         passwordEntry.Tags,
+			CustomFields: passwordEntry.CustomFields,
+		}
+
+		if entry.Tags == nil {
+			entry.Tags = []string{}
+		}
+
+		if entry.CustomFields == nil {
+			entry.CustomFields = []server.CustomField{}
+		}
+
+		export.Entries = append(export.Entries, entry)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(export)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during writing JSON export: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
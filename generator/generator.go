@@ -0,0 +1,260 @@
+// Random password and passphrase generation driven by policy.
+// Characters and words are drawn from crypto/rand with rejection sampling, so every character of the pool and every word of the wordlist is equally likely.
+package generator
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	LowercaseLetters = "abcdefghijklmnopqrstuvwxyz"
+	UppercaseLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	DigitCharacters  = "0123456789"
+	DefaultSymbolSet = "^!#$%&'()*+,-./:;<=>?@_|\"\\"
+
+	// Characters easily confused with each other when password is read or retyped
+	AmbiguousCharacters = "0Oo1Il|"
+
+	MinLength = 4
+	MaxLength = 256
+
+	// Bound for redrawing whole password when it misses one of required classes
+	maxAttempts = 10_000
+)
+
+var InvalidLength = fmt.Errorf("Password length has to be between %d and %d.", MinLength, MaxLength)
+var NoCharacterClasses = errors.New("At least one character class has to be enabled.")
+var InvalidSymbolSet = errors.New("Symbol set may contain only printable ASCII characters other than letters, digits and space.")
+var PolicyUnsatisfiable = errors.New("Password can't satisfy policy - enable more characters or change length.")
+
+// Rules for generated password
+type Policy struct {
+	Length int
+
+	Lowercase bool
+	Uppercase bool
+	Digits    bool
+	Symbols   bool
+	SymbolSet string // symbols used when Symbols is enabled, DefaultSymbolSet when empty
+
+	RequireEveryClass bool // at least one character from every enabled class
+	ExcludeAmbiguous  bool // AmbiguousCharacters are never used
+
+	NoConsecutiveRepeats bool // same character never appears twice in a row
+	NoDuplicates         bool // every character appears at most once
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		Length:            24,
+		Lowercase:         true,
+		Uppercase:         true,
+		Digits:            true,
+		Symbols:           true,
+		SymbolSet:         DefaultSymbolSet,
+		RequireEveryClass: true,
+	}
+}
+
+// Returns characters of every enabled class, after removing ambiguous characters and duplicates
+func (policy Policy) classes() ([][]byte, error) {
+	symbolSet := policy.SymbolSet
+	if symbolSet == "" {
+		symbolSet = DefaultSymbolSet
+	}
+
+	for _, char := range []byte(symbolSet) {
+		if char <= ' ' || char > '~' || strings.IndexByte(LowercaseLetters+UppercaseLetters+DigitCharacters, char) >= 0 {
+			return nil, InvalidSymbolSet
+		}
+	}
+
+	enabled := []struct {
+		on    bool
+		chars string
+	}{
+		{policy.Lowercase, LowercaseLetters},
+		{policy.Uppercase, UppercaseLetters},
+		{policy.Digits, DigitCharacters},
+		{policy.Symbols, symbolSet},
+	}
+
+	classes := [][]byte{}
+
+	for _, class := range enabled {
+		if !class.on {
+			continue
+		}
+
+		chars := []byte{}
+
+		for _, char := range []byte(class.chars) {
+			if policy.ExcludeAmbiguous && strings.IndexByte(AmbiguousCharacters, char) >= 0 {
+				continue
+			}
+
+			if !slices.Contains(chars, char) {
+				chars = append(chars, char)
+			}
+		}
+
+		if len(chars) == 0 {
+			return nil, PolicyUnsatisfiable
+		}
+
+		classes = append(classes, chars)
+	}
+
+	if len(classes) == 0 {
+		return nil, NoCharacterClasses
+	}
+
+	return classes, nil
+}
+
+// Checks policy and returns pool of characters password is drawn from
+func (policy Policy) pool() ([][]byte, []byte, error) {
+	if policy.Length < MinLength || policy.Length > MaxLength {
+		return nil, nil, InvalidLength
+	}
+
+	classes, err := policy.classes()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pool := slices.Concat(classes...)
+
+	switch {
+	case policy.RequireEveryClass && policy.Length < len(classes):
+		return nil, nil, PolicyUnsatisfiable
+	case policy.NoDuplicates && policy.Length > len(pool):
+		return nil, nil, PolicyUnsatisfiable
+	case policy.NoConsecutiveRepeats && len(pool) < 2:
+		return nil, nil, PolicyUnsatisfiable
+	}
+
+	return classes, pool, nil
+}
+
+// Returns number of characters password is drawn from
+func (policy Policy) PoolSize() (int, error) {
+	_, pool, err := policy.pool()
+	return len(pool), err
+}
+
+// Returns approximate strength of generated password in bits, ignoring small loss caused by class and repeat rules
+func (policy Policy) Entropy() (float64, error) {
+	poolSize, err := policy.PoolSize()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if policy.NoDuplicates {
+		bits := 0.0
+		for i := range policy.Length {
+			bits += math.Log2(float64(poolSize - i))
+		}
+		return bits, nil
+	}
+
+	return float64(policy.Length) * math.Log2(float64(poolSize)), nil
+}
+
+// Generates password satisfying policy
+func Generate(policy Policy) (string, error) {
+	classes, pool, err := policy.pool()
+
+	if err != nil {
+		return "", err
+	}
+
+	// Password missing a required class is drawn again as a whole - fixing it up in place would make some passwords more likely than others
+	for range maxAttempts {
+		password, err := draw(policy, pool)
+
+		if err != nil {
+			return "", err
+		}
+
+		if !policy.RequireEveryClass || containsEveryClass(password, classes) {
+			return string(password), nil
+		}
+	}
+
+	return "", PolicyUnsatisfiable
+}
+
+func draw(policy Policy, pool []byte) ([]byte, error) {
+	available := slices.Clone(pool)
+	password := make([]byte, 0, policy.Length)
+
+	for len(password) < policy.Length {
+		index, err := randomIndex(len(available))
+
+		if err != nil {
+			return nil, err
+		}
+
+		char := available[index]
+
+		if policy.NoConsecutiveRepeats && len(password) > 0 && password[len(password)-1] == char {
+			continue
+		}
+
+		password = append(password, char)
+
+		if policy.NoDuplicates {
+			available = slices.Delete(available, index, index+1)
+		}
+	}
+
+	return password, nil
+}
+
+func containsEveryClass(password []byte, classes [][]byte) bool {
+	for _, class := range classes {
+		if !slices.ContainsFunc(password, func(char byte) bool { return slices.Contains(class, char) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns uniformly distributed number in [0, n). Values from the incomplete last block of uint32 range are rejected,
+// as taking them modulo n would favour lower numbers.
+func randomIndex(n int) (int, error) {
+	limit := rejectionLimit(n)
+	buffer := make([]byte, 4)
+
+	for {
+		_, err := rand.Read(buffer)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Could not read from secure random source: %w", err)
+			slog.Error(errWrapped.Error())
+			return 0, errWrapped
+		}
+
+		value := binary.LittleEndian.Uint32(buffer)
+
+		if value < limit {
+			return int(value % uint32(n)), nil
+		}
+	}
+}
+
+// Returns bound below which random uint32 is accepted - number of values below it is a multiple of n
+func rejectionLimit(n int) uint32 {
+	return math.MaxUint32 - math.MaxUint32%uint32(n)
+}
@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRejectionLimitKeepsWholeBlocks(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 7, 10, 26, 62, 94, 255, 1000, 7776, 1 << 20} {
+		limit := rejectionLimit(n)
+
+		if limit%uint32(n) != 0 {
+			t.Errorf("rejectionLimit(%d) = %d, not a multiple of %d", n, limit, n)
+		}
+
+		// At most one incomplete block is rejected
+		if math.MaxUint32-uint64(limit) >= uint64(n) {
+			t.Errorf("rejectionLimit(%d) = %d rejects more than incomplete last block", n, limit)
+		}
+	}
+}
+
+func TestRandomIndexIsUniform(t *testing.T) {
+	const n, draws = 3, 60_000
+	counts := make([]int, n)
+
+	for range draws {
+		index, err := randomIndex(n)
+
+		if err != nil {
+			t.Fatalf("randomIndex() = %v", err)
+		}
+
+		if index < 0 || index >= n {
+			t.Fatalf("randomIndex(%d) = %d, out of range", n, index)
+		}
+
+		counts[index]++
+	}
+
+	// Standard deviation of every count is about 115
+	for index, count := range counts {
+		if math.Abs(float64(count)-draws/n) > 1000 {
+			t.Errorf("randomIndex(%d) returned %d %d times out of %d", n, index, count, draws)
+		}
+	}
+}
+
+func TestPolicyRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   error
+	}{
+		{"too short", Policy{Length: MinLength - 1, Lowercase: true}, InvalidLength},
+		{"too long", Policy{Length: MaxLength + 1, Lowercase: true}, InvalidLength},
+		{"no classes", Policy{Length: 20}, NoCharacterClasses},
+		{"letter in symbol set", Policy{Length: 20, Symbols: true, SymbolSet: "!a"}, InvalidSymbolSet},
+		{"space in symbol set", Policy{Length: 20, Symbols: true, SymbolSet: "! "}, InvalidSymbolSet},
+		{"longer than pool without duplicates", Policy{Length: 11, Digits: true, NoDuplicates: true}, PolicyUnsatisfiable},
+		{"single character without repeats", Policy{Length: 8, Symbols: true, SymbolSet: "!", NoConsecutiveRepeats: true}, PolicyUnsatisfiable},
+		{"only ambiguous symbols", Policy{Length: 8, Symbols: true, SymbolSet: "|", ExcludeAmbiguous: true}, PolicyUnsatisfiable},
+	}
+
+	for _, test := range tests {
+		_, err := Generate(test.policy)
+
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: Generate() = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestGenerateFollowsPolicy(t *testing.T) {
+	policies := []Policy{
+		DefaultPolicy(),
+		{Length: MinLength, Lowercase: true, Uppercase: true, Digits: true, Symbols: true, RequireEveryClass: true},
+		{Length: 40, Lowercase: true, Digits: true, ExcludeAmbiguous: true, NoConsecutiveRepeats: true},
+		{Length: 10, Digits: true, NoDuplicates: true},
+		{Length: 16, Symbols: true, SymbolSet: "!?#", RequireEveryClass: true, NoConsecutiveRepeats: true},
+	}
+
+	for _, policy := range policies {
+		classes, pool, err := policy.pool()
+
+		if err != nil {
+			t.Fatalf("pool() of %+v = %v", policy, err)
+		}
+
+		for range 200 {
+			password, err := Generate(policy)
+
+			if err != nil {
+				t.Fatalf("Generate(%+v) = %v", policy, err)
+			}
+
+			if len(password) != policy.Length {
+				t.Fatalf("Generate(%+v) = %q, want length %d", policy, password, policy.Length)
+			}
+
+			for index, char := range []byte(password) {
+				switch {
+				case strings.IndexByte(string(pool), char) < 0:
+					t.Fatalf("Generate(%+v) = %q, %q is not in pool", policy, password, char)
+				case policy.ExcludeAmbiguous && strings.IndexByte(AmbiguousCharacters, char) >= 0:
+					t.Fatalf("Generate(%+v) = %q, contains ambiguous %q", policy, password, char)
+				case policy.NoConsecutiveRepeats && index > 0 && password[index-1] == char:
+					t.Fatalf("Generate(%+v) = %q, repeats %q", policy, password, char)
+				case policy.NoDuplicates && strings.IndexByte(password[:index], char) >= 0:
+					t.Fatalf("Generate(%+v) = %q, duplicates %q", policy, password, char)
+				}
+			}
+
+			if policy.RequireEveryClass && !containsEveryClass([]byte(password), classes) {
+				t.Fatalf("Generate(%+v) = %q, misses one of classes", policy, password)
+			}
+		}
+	}
+}
+
+func TestPolicyEntropy(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		want   float64
+	}{
+		{Policy{Length: 10, Digits: true}, 10 * math.Log2(10)},
+		{Policy{Length: 20, Lowercase: true, Uppercase: true}, 20 * math.Log2(52)},
+		{Policy{Length: 4, Digits: true, NoDuplicates: true}, math.Log2(10 * 9 * 8 * 7)},
+		{Policy{Length: 8, Lowercase: true, ExcludeAmbiguous: true}, 8 * math.Log2(24)},
+	}
+
+	for _, test := range tests {
+		entropy, err := test.policy.Entropy()
+
+		if err != nil || math.Abs(entropy-test.want) > 1e-9 {
+			t.Errorf("Entropy() of %+v = %v, %v, want %v", test.policy, entropy, err, test.want)
+		}
+	}
+}
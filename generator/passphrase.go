@@ -0,0 +1,186 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Wordlists published by Electronic Frontier Foundation under CC BY 3.0 license, kept in their original
+// "dice rolls<TAB>word" format: https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+
+//go:embed wordlists/eff_large_wordlist.txt
+var effLargeWordlist []byte
+
+//go:embed wordlists/eff_short_wordlist_2_0.txt
+var effShortWordlist []byte
+
+type Wordlist int
+
+const (
+	EFFLarge Wordlist = iota // 7776 words, ~12.9 bits per word
+	EFFShort                 // 1296 shorter words, ~10.3 bits per word
+)
+
+const (
+	MinWords           = 3
+	MaxWords           = 20
+	MaxSeparatorLength = 4
+)
+
+var InvalidWordCount = fmt.Errorf("Number of words has to be between %d and %d.", MinWords, MaxWords)
+var InvalidSeparator = fmt.Errorf("Separator may contain at most %d printable ASCII characters.", MaxSeparatorLength)
+var UnknownWordlist = errors.New("Unknown wordlist.")
+
+// Rules for generated passphrase
+type PassphrasePolicy struct {
+	Words       int
+	Wordlist    Wordlist
+	Separator   string
+	Capitalize  bool // first letter of every word is uppercase
+	AppendDigit bool // random digit is appended to the passphrase
+}
+
+func DefaultPassphrasePolicy() PassphrasePolicy {
+	return PassphrasePolicy{
+		Words:     6,
+		Wordlist:  EFFLarge,
+		Separator: "-",
+	}
+}
+
+var parsedWordlists = sync.OnceValues(func() (map[Wordlist][]string, error) {
+	wordlists := map[Wordlist][]string{}
+
+	for wordlist, content := range map[Wordlist][]byte{EFFLarge: effLargeWordlist, EFFShort: effShortWordlist} {
+		words := []string{}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+
+		for scanner.Scan() {
+			_, word, found := strings.Cut(scanner.Text(), "\t")
+
+			if !found || word == "" {
+				return nil, fmt.Errorf("Malformed line in embedded wordlist: %q", scanner.Text())
+			}
+
+			words = append(words, word)
+		}
+
+		wordlists[wordlist] = words
+	}
+
+	return wordlists, nil
+})
+
+// Returns words of the wordlist in their original order
+func (wordlist Wordlist) Words() ([]string, error) {
+	wordlists, err := parsedWordlists()
+
+	if err != nil {
+		return nil, err
+	}
+
+	words, ok := wordlists[wordlist]
+
+	if !ok {
+		return nil, UnknownWordlist
+	}
+
+	return words, nil
+}
+
+func (policy PassphrasePolicy) validate() error {
+	if policy.Words < MinWords || policy.Words > MaxWords {
+		return InvalidWordCount
+	}
+
+	if len(policy.Separator) > MaxSeparatorLength {
+		return InvalidSeparator
+	}
+
+	for _, char := range []byte(policy.Separator) {
+		if char < ' ' || char > '~' {
+			return InvalidSeparator
+		}
+	}
+
+	return nil
+}
+
+// Returns strength of generated passphrase in bits. Capitalization and separator are predictable and add nothing.
+func (policy PassphrasePolicy) Entropy() (float64, error) {
+	err := policy.validate()
+
+	if err != nil {
+		return 0, err
+	}
+
+	words, err := policy.Wordlist.Words()
+
+	if err != nil {
+		return 0, err
+	}
+
+	bits := float64(policy.Words) * math.Log2(float64(len(words)))
+
+	if policy.AppendDigit {
+		bits += math.Log2(float64(len(DigitCharacters)))
+	}
+
+	return bits, nil
+}
+
+// Generates passphrase of words drawn independently from wordlist
+func GeneratePassphrase(policy PassphrasePolicy) (string, error) {
+	err := policy.validate()
+
+	if err != nil {
+		return "", err
+	}
+
+	words, err := policy.Wordlist.Words()
+
+	if err != nil {
+		return "", err
+	}
+
+	chosen := make([]string, 0, policy.Words)
+
+	for range policy.Words {
+		index, err := randomIndex(len(words))
+
+		if err != nil {
+			return "", err
+		}
+
+		word := words[index]
+
+		if policy.Capitalize {
+			first, size := utf8.DecodeRuneInString(word)
+			word = string(unicode.ToUpper(first)) + word[size:]
+		}
+
+		chosen = append(chosen, word)
+	}
+
+	passphrase := strings.Join(chosen, policy.Separator)
+
+	if policy.AppendDigit {
+		index, err := randomIndex(len(DigitCharacters))
+
+		if err != nil {
+			return "", err
+		}
+
+		passphrase += string(DigitCharacters[index])
+	}
+
+	return passphrase, nil
+}
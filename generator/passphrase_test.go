@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWordlistsHaveAllWords(t *testing.T) {
+	tests := []struct {
+		wordlist Wordlist
+		want     int
+	}{
+		{EFFLarge, 7776},
+		{EFFShort, 1296},
+	}
+
+	for _, test := range tests {
+		words, err := test.wordlist.Words()
+
+		if err != nil {
+			t.Fatalf("Words() = %v", err)
+		}
+
+		if len(words) != test.want {
+			t.Errorf("wordlist %d has %d words, want %d", test.wordlist, len(words), test.want)
+		}
+
+		sorted := slices.Clone(words)
+		slices.Sort(sorted)
+
+		if len(slices.Compact(sorted)) != test.want {
+			t.Errorf("wordlist %d has repeated words", test.wordlist)
+		}
+	}
+
+	if _, err := Wordlist(99).Words(); !errors.Is(err, UnknownWordlist) {
+		t.Errorf("Words() of unknown wordlist = %v, want UnknownWordlist", err)
+	}
+}
+
+func TestPassphrasePolicyRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy PassphrasePolicy
+		want   error
+	}{
+		{"too few words", PassphrasePolicy{Words: MinWords - 1}, InvalidWordCount},
+		{"too many words", PassphrasePolicy{Words: MaxWords + 1}, InvalidWordCount},
+		{"long separator", PassphrasePolicy{Words: 6, Separator: strings.Repeat("-", MaxSeparatorLength+1)}, InvalidSeparator},
+		{"control character", PassphrasePolicy{Words: 6, Separator: "\t"}, InvalidSeparator},
+		{"non-ASCII separator", PassphrasePolicy{Words: 6, Separator: "·"}, InvalidSeparator},
+		{"unknown wordlist", PassphrasePolicy{Words: 6, Wordlist: Wordlist(99)}, UnknownWordlist},
+	}
+
+	for _, test := range tests {
+		if _, err := GeneratePassphrase(test.policy); !errors.Is(err, test.want) {
+			t.Errorf("%s: GeneratePassphrase() = %v, want %v", test.name, err, test.want)
+		}
+
+		if _, err := test.policy.Entropy(); !errors.Is(err, test.want) {
+			t.Errorf("%s: Entropy() = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestGeneratePassphraseFollowsPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy PassphrasePolicy
+	}{
+		{"default", DefaultPassphrasePolicy()},
+		{"short wordlist", PassphrasePolicy{Words: MinWords, Wordlist: EFFShort, Separator: " "}},
+		{"capitalized with digit", PassphrasePolicy{Words: MaxWords, Wordlist: EFFLarge, Separator: ".:", Capitalize: true, AppendDigit: true}},
+	}
+
+	for _, test := range tests {
+		words, err := test.policy.Wordlist.Words()
+
+		if err != nil {
+			t.Fatalf("Words() = %v", err)
+		}
+
+		for range 50 {
+			passphrase, err := GeneratePassphrase(test.policy)
+
+			if err != nil {
+				t.Fatalf("%s: GeneratePassphrase() = %v", test.name, err)
+			}
+
+			if test.policy.AppendDigit {
+				last := passphrase[len(passphrase)-1]
+
+				if !strings.ContainsRune(DigitCharacters, rune(last)) {
+					t.Fatalf("%s: %q does not end with digit", test.name, passphrase)
+				}
+
+				passphrase = passphrase[:len(passphrase)-1]
+			}
+
+			// Separators of tested policies do not occur in words
+			chosen := strings.Split(passphrase, test.policy.Separator)
+
+			if len(chosen) != test.policy.Words {
+				t.Fatalf("%s: %q has %d words, want %d", test.name, passphrase, len(chosen), test.policy.Words)
+			}
+
+			for _, word := range chosen {
+				if test.policy.Capitalize && strings.ToUpper(word[:1]) != word[:1] {
+					t.Fatalf("%s: word %q of %q is not capitalized", test.name, word, passphrase)
+				}
+
+				if !slices.Contains(words, strings.ToLower(word[:1])+word[1:]) {
+					t.Fatalf("%s: word %q of %q is not in wordlist", test.name, word, passphrase)
+				}
+			}
+		}
+	}
+}
+
+func TestPassphraseEntropy(t *testing.T) {
+	tests := []struct {
+		policy PassphrasePolicy
+		want   float64
+	}{
+		{PassphrasePolicy{Words: 6, Wordlist: EFFLarge}, 6 * math.Log2(7776)},
+		{PassphrasePolicy{Words: 4, Wordlist: EFFShort, Capitalize: true}, 4 * math.Log2(1296)},
+		{PassphrasePolicy{Words: 4, Wordlist: EFFShort, AppendDigit: true}, 4*math.Log2(1296) + math.Log2(10)},
+	}
+
+	for _, test := range tests {
+		got, err := test.policy.Entropy()
+
+		if err != nil || math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Entropy() of %+v = %v, %v, want %v", test.policy, got, err, test.want)
+		}
+	}
+}
@@ -9,6 +9,7 @@ require golang.org/x/crypto v0.25.0
 require (
 	gioui.org v0.9.0
 	golang.org/x/exp v0.0.0-20250408133849-7e4ce0ab07d0
+	golang.org/x/sys v0.33.0
 )
 
 require (
@@ -16,6 +17,5 @@ require (
 	github.com/go-text/typesetting v0.3.0 // indirect
 	golang.org/x/exp/shiny v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
 	golang.org/x/image v0.26.0 // indirect
-	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 )
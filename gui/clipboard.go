@@ -0,0 +1,122 @@
+package gui
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"gioui.org/app"
+	"gioui.org/io/clipboard"
+	"gioui.org/io/transfer"
+	"gioui.org/layout"
+)
+
+const clipboardMimeType = "application/text"
+
+// Clears copied username or password from clipboard after configured time, unless user copied something else in the meantime.
+// Clearing is done by main window, so it happens even when window the secret was copied from is already closed.
+// Only hash of copied secret is kept to compare it with clipboard content.
+type ClipboardGuard struct {
+	mutex        sync.Mutex
+	mainWindow   *app.Window
+	clearTimeout time.Duration // zero disables clearing
+	timer        *time.Timer
+	secretHash   [sha256.Size]byte
+	due          bool // clear timeout passed, clipboard has to be checked
+	reading      bool // clipboard read was requested, waiting for its content
+}
+
+func NewClipboardGuard(mainWindow *app.Window, clearTimeout time.Duration) *ClipboardGuard {
+	return &ClipboardGuard{mainWindow: mainWindow, clearTimeout: clearTimeout}
+}
+
+func (clipboardGuard *ClipboardGuard) ClearTimeout() time.Duration {
+	clipboardGuard.mutex.Lock()
+	defer clipboardGuard.mutex.Unlock()
+
+	return clipboardGuard.clearTimeout
+}
+
+func (clipboardGuard *ClipboardGuard) SetClearTimeout(clearTimeout time.Duration) {
+	clipboardGuard.mutex.Lock()
+	defer clipboardGuard.mutex.Unlock()
+
+	clipboardGuard.clearTimeout = clearTimeout
+}
+
+// Writes secret to clipboard from any window and schedules its clearing
+func (clipboardGuard *ClipboardGuard) copy(gtx layout.Context, secret string) {
+	gtx.Execute(clipboard.WriteCmd{Type: clipboardMimeType, Data: io.NopCloser(strings.NewReader(secret))})
+
+	clipboardGuard.mutex.Lock()
+	defer clipboardGuard.mutex.Unlock()
+
+	if clipboardGuard.timer != nil {
+		clipboardGuard.timer.Stop()
+		clipboardGuard.timer = nil
+	}
+
+	clipboardGuard.due = false
+	clipboardGuard.reading = false
+
+	if clipboardGuard.clearTimeout <= 0 {
+		return
+	}
+
+	clipboardGuard.secretHash = sha256.Sum256([]byte(secret))
+	clipboardGuard.timer = time.AfterFunc(clipboardGuard.clearTimeout, func() {
+		clipboardGuard.mutex.Lock()
+		clipboardGuard.due = true
+		clipboardGuard.mutex.Unlock()
+
+		clipboardGuard.mainWindow.Invalidate()
+	})
+}
+
+// Has to be called on every frame of main window. Once clear timeout passes clipboard content is requested
+// and overwritten when it still holds copied secret.
+func (clipboardGuard *ClipboardGuard) update(gtx layout.Context) {
+	for {
+		event, ok := gtx.Event(transfer.TargetFilter{Target: clipboardGuard, Type: clipboardMimeType})
+
+		if !ok {
+			break
+		}
+
+		dataEvent, ok := event.(transfer.DataEvent)
+
+		if !ok {
+			continue
+		}
+
+		data := dataEvent.Open()
+		content, err := io.ReadAll(io.LimitReader(data, 1<<20))
+		data.Close()
+
+		clipboardGuard.mutex.Lock()
+		contentHash := sha256.Sum256(content)
+		// Another secret copied since the read was requested has its own timer
+		stillHoldsSecret := clipboardGuard.reading && err == nil && subtle.ConstantTimeCompare(contentHash[:], clipboardGuard.secretHash[:]) == 1
+		if clipboardGuard.reading {
+			clipboardGuard.secretHash = [sha256.Size]byte{}
+			clipboardGuard.reading = false
+		}
+		clipboardGuard.mutex.Unlock()
+
+		if stillHoldsSecret {
+			gtx.Execute(clipboard.WriteCmd{Type: clipboardMimeType, Data: io.NopCloser(strings.NewReader(""))})
+		}
+	}
+
+	clipboardGuard.mutex.Lock()
+	defer clipboardGuard.mutex.Unlock()
+
+	if clipboardGuard.due && !clipboardGuard.reading {
+		clipboardGuard.due = false
+		clipboardGuard.reading = true
+		gtx.Execute(clipboard.ReadCmd{Tag: clipboardGuard})
+	}
+}
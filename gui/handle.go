@@ -1,6 +1,7 @@
 package gui
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -9,13 +10,20 @@ import (
 	"unicode"
 
 	"log/slog"
-	"math/rand"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	server "github.com/mszalewicz/frosk/backend"
-	"github.com/mszalewicz/frosk/helpers"
+	"github.com/mszalewicz/frosk/exporter"
+	"github.com/mszalewicz/frosk/generator"
+	"github.com/mszalewicz/frosk/importer"
+	"github.com/mszalewicz/frosk/kdbx"
+	"github.com/mszalewicz/frosk/strength"
+	"github.com/mszalewicz/frosk/vaults"
 
 	"gioui.org/app"
 	"gioui.org/font"
@@ -34,14 +42,17 @@ import (
 
 type PasswordEntriesGUI struct {
 	serviceName     string
+	tags            []string
 	guiListElement  []layout.FlexChild
 	openBtnWidget   *widget.Clickable
+	editBtnWidget   *widget.Clickable
 	deleteBtnWidget *widget.Clickable
 }
 
 type DecryptionPackage struct {
-	err           error
-	passwordEntry server.PasswordEntry
+	err             error
+	passwordEntry   server.PasswordEntry
+	passwordHistory []server.PasswordHistoryEntry
 }
 
 func isSubsequence(needle, term string) bool {
@@ -85,8 +96,29 @@ func MatchPatternResult(stack []PasswordEntriesGUI, query string) []PasswordEntr
 	return filtered
 }
 
+// Narrows entries to those matching search query and selected tag. Empty query or tag does not filter.
+func filterPasswordEntries(entries []PasswordEntriesGUI, query string, tag string) []PasswordEntriesGUI {
+	if query != "" {
+		entries = MatchPatternResult(entries, query)
+	}
+
+	if tag == "" {
+		return entries
+	}
+
+	filtered := make([]PasswordEntriesGUI, 0, len(entries))
+
+	for _, entry := range entries {
+		if server.MatchesTag(entry.tags, tag) {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	return filtered
+}
+
 // Creates list entry components
-func createPasswordEntryListLineComponents(serviceName string, theme *material.Theme) ([]layout.FlexChild, *widget.Clickable, *widget.Clickable) {
+func createPasswordEntryListLineComponents(serviceName string, theme *material.Theme) ([]layout.FlexChild, *widget.Clickable, *widget.Clickable, *widget.Clickable) {
 	const buttonSize = 12
 
 	var openBtnWidget widget.Clickable
@@ -98,6 +130,14 @@ func createPasswordEntryListLineComponents(serviceName string, theme *material.T
 	openBtn.Font.Typeface = "Verdana, monospace"
 	// openBtn.Font.Style
 
+	var editBtnWidget widget.Clickable
+	editBtn := material.Button(theme, &editBtnWidget, "EDIT")
+	editBtn.Color = black
+	editBtn.Background = grey_light
+	editBtn.TextSize = unit.Sp(buttonSize)
+	editBtn.Font.Weight = font.Medium
+	editBtn.Font.Typeface = "Verdana, monospace"
+
 	var deleteBtnWidget widget.Clickable
 	deleteBtn := material.Button(theme, &deleteBtnWidget, "DELETE")
 	deleteBtn.Color = black
@@ -141,6 +181,20 @@ func createPasswordEntryListLineComponents(serviceName string, theme *material.T
 		},
 	)
 
+	editBtnFlexChild := layout.Rigid(
+		func(gtx layout.Context) layout.Dimensions {
+			return btnMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					border := widget.Border{Color: charcoal, CornerRadius: unit.Dp(4), Width: unit.Dp(0)}
+					return border.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return layout.UniformInset(unit.Dp(0)).Layout(gtx, editBtn.Layout)
+					})
+				},
+			)
+		},
+	)
+
 	deleteBtnFlexChild := layout.Rigid(
 		func(gtx layout.Context) layout.Dimensions {
 			return btnMargin.Layout(
@@ -155,7 +209,7 @@ func createPasswordEntryListLineComponents(serviceName string, theme *material.T
 		},
 	)
 
-	return []layout.FlexChild{serviceFlexChild, openBtnFlexChild, deleteBtnFlexChild}, &openBtnWidget, &deleteBtnWidget
+	return []layout.FlexChild{serviceFlexChild, openBtnFlexChild, editBtnFlexChild, deleteBtnFlexChild}, &openBtnWidget, &editBtnWidget, &deleteBtnWidget
 }
 
 // Creastes and populates GUI list container from password entries components
@@ -253,7 +307,28 @@ func ErrorWindow(ops *op.Ops, window *app.Window, theme *material.Theme, errorMs
 	}
 }
 
-func HandleMainWindow(window *app.Window, backend *server.Backend) error {
+// Shows vault in main window until the window gets closed. User can switch to other vault from vault picker.
+func HandleMainWindow(window *app.Window, vault *vaults.Vault, dataDirectory string) error {
+	// Guard lives as long as main window, so secret copied before switching vault is still cleared
+	clipboardGuard := NewClipboardGuard(window, server.DefaultClipboardClearTimeout)
+
+	for {
+		nextVault, err := showVault(window, vault, dataDirectory, clipboardGuard)
+
+		if err != nil || nextVault == nil {
+			vault.Close() // takes pending backup
+			return err
+		}
+
+		vault.Close()
+		vaults.Remember(dataDirectory, nextVault.Path)
+		vault = nextVault
+	}
+}
+
+// Shows vault in main window. Returns vault selected in vault picker or nil when window got closed.
+func showVault(window *app.Window, vault *vaults.Vault, dataDirectory string, clipboardGuard *ClipboardGuard) (*vaults.Vault, error) {
+	backend := vault.Backend
 	errChan := make(chan error)
 
 	theme := material.NewTheme()
@@ -265,6 +340,9 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 
 	var ops op.Ops
 	var newPasswordEntryWidget widget.Clickable
+	var changeMasterPasswordWidget widget.Clickable
+	var settingsWidget widget.Clickable
+	var lockWidget widget.Clickable
 
 	numberOfEntriesInMasterTable, errToHandleInGUI := backend.CountMasterEntries()
 
@@ -272,6 +350,22 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 		ErrorWindow(&ops, window, theme, "Fatal error when running application. Please consult logs.")
 	}
 
+	// Settings fall back to defaults when they can't be read, error is logged by backend
+	autoLockTimeout, _ := backend.GetAutoLockTimeout()
+	lockOnMinimize, _ := backend.GetLockOnMinimize()
+
+	clipboardClearTimeout, _ := backend.GetClipboardClearTimeout()
+
+	vaultSession := NewVaultSession(autoLockTimeout, lockOnMinimize)
+	defer vaultSession.Lock()
+
+	clipboardGuard.SetClearTimeout(clipboardClearTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go vaultSession.watchIdle(ctx, window)
+
 	centerWindow := true
 
 	passwordInput := new(widget.Editor)
@@ -290,11 +384,17 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 	var searchInput widget.Editor
 	searchInput.SingleLine = true
 
+	// Tag selected in filter bar, empty shows all entries
+	var selectedTag string
+
 	initialSetup := InitialSetup{
+		vaultName:           vaults.Name(vault.Path),
 		passwordInput:       passwordInput,
 		passwordInputRepeat: passwordInputRepeat,
 		confirmBtnWidget:    confirmBtnWidget,
 		showHidWidget:       showHideWidget,
+		vaultsBtnWidget:     new(widget.Clickable),
+		strengthMeter:       new(StrengthMeter),
 		borderColor:         black,
 	}
 
@@ -304,6 +404,8 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 	masterPasswordHeading := material.H6(theme, "Master password:")
 	masterPasswordRepeatHeading := material.H6(theme, "Repeat password:")
 
+	var vaultsOps op.Ops
+
 	// Get master password info during firt use of application
 	if numberOfEntriesInMasterTable == 0 {
 		ResizeWindowInitialSetup(window)
@@ -323,11 +425,27 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 		for {
 			switch e := window.Event().(type) {
 			case app.DestroyEvent:
-				return e.Err
+				return nil, e.Err
 
 			case app.FrameEvent:
 				gtx := app.NewContext(&ops, e)
 
+				clipboardGuard.update(gtx)
+
+				// New vault can be left empty in favour of other one
+				if initialSetup.vaultsBtnWidget.Clicked(gtx) {
+					selectedVault, windowOpen, err := SelectVault(window, &vaultsOps, theme, clipboardGuard, vault.Path, dataDirectory)
+
+					if !windowOpen || selectedVault != nil {
+						return selectedVault, err
+					}
+
+					ResizeWindowInitialSetup(window)
+					centerWindow = true
+					window.Invalidate()
+					continue
+				}
+
 				// Show/hide input
 				if initialSetup.showHidWidget.Clicked(gtx) {
 					switch {
@@ -344,9 +462,22 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 				if confirmBtnWidget.Clicked(gtx) {
 					switch {
 					case passwordInput.Len() > 0 && passwordInputRepeat.Len() > 0:
+						// Vault is only as safe as its master password - easily guessed one is refused
+						if initialSetup.strengthMeter.Result().Score < strength.MinMasterPasswordScore {
+							masterPasswordHeading.Color = red
+							masterPasswordHeading.Text = "Master password: (too easy to guess)"
+							break
+						}
+
 						if passwordInput.Text() == passwordInputRepeat.Text() {
 							go func() {
 								err := backend.InitMaster(passwordInput.Text())
+
+								// Master password was just typed in, vault is opened right away
+								if err == nil {
+									_, err = vaultSession.Unlock(backend, passwordInput.Text())
+								}
+
 								errChan <- err
 								return
 							}()
@@ -376,8 +507,13 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 					default:
 						break CheckInputEventMarker
 					}
+
+					masterPasswordHeading.Color = black
+					masterPasswordHeading.Text = "Master password:"
 				}
 
+				initialSetup.strengthMeter.Update(passwordInput.Text(), initialSetup.vaultName, "frosk")
+
 				InitialSetupWidget(&gtx, theme, &initialSetup, &masterPasswordHeading, &masterPasswordRepeatHeading)
 
 				if centerWindow {
@@ -398,7 +534,7 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 		for {
 			switch e := window.Event().(type) {
 			case app.DestroyEvent:
-				return e.Err
+				return nil, e.Err
 
 			case app.FrameEvent:
 				gtx := app.NewContext(&ops, e)
@@ -434,25 +570,82 @@ func HandleMainWindow(window *app.Window, backend *server.Backend) error {
 	refreshChan := make(chan bool, 1)
 	centerWindow = true
 	var passwordListOps op.Ops
+	var unlockOps op.Ops
+
+UnlockMarker:
+	if vaultSession.Get() == nil {
+		unlockResult, err := UnlockVault(window, &unlockOps, backend, vaultSession, clipboardGuard, theme, vaults.Name(vault.Path))
+
+		switch unlockResult {
+		case UnlockClosed:
+			return nil, err
+		case UnlockSwitchVault:
+			selectedVault, windowOpen, err := SelectVault(window, &vaultsOps, theme, clipboardGuard, vault.Path, dataDirectory)
+
+			if !windowOpen || selectedVault != nil {
+				return selectedVault, err
+			}
+
+			goto UnlockMarker
+		}
+
+		// Vault changed outside frosk is still unlocked, user decides whether to trust its content
+		if vaultSession.Get() != nil && vaultSession.Get().Integrity() != nil {
+			var integrityWarningOps op.Ops
+			windowOpen, err := IntegrityWarning(window, &integrityWarningOps, vaultSession, theme)
+
+			if !windowOpen {
+				return nil, err
+			}
+		}
+
+		centerWindow = true
+		focusSearchBarOnWindowLoad = true
+	}
 
 PasswordViewMarker:
 	for {
-		services, err := backend.GetPasswordEntriesList()
+		session := vaultSession.Get()
+
+		if session == nil {
+			goto UnlockMarker
+		}
+
+		// Service names are encrypted, list is decrypted in memory for display and search
+		services, err := session.GetPasswordEntriesList()
+
+		if errors.Is(err, server.SessionLocked) {
+			goto UnlockMarker
+		}
 
 		if err != nil {
 			var errorWindowOps op.Ops
 			ErrorWindow(&errorWindowOps, window, theme, "Could not load password entries.")
 		}
 
+		entriesTags, err := session.GetPasswordEntriesTags()
+
+		if err != nil {
+			var errorWindowOps op.Ops
+			ErrorWindow(&errorWindowOps, window, theme, "Could not load tags of password entries.")
+		}
+
+		tagFilter := NewTagFilter(server.TagsWithParents(entriesTags))
+
+		// Selected tag could be gone with the last entry carrying it
+		if !slices.Contains(tagFilter.tags, selectedTag) {
+			selectedTag = ""
+		}
+
 		passwordEntriesList := &layout.List{Axis: layout.Vertical}
-		passwordEntries := make([]PasswordEntriesGUI, 0, len(services))
+		fullSetOfPasswordEntries := make([]PasswordEntriesGUI, 0, len(services))
 
 		for _, serviceName := range services {
-			listElement, openBtnWidget, deleteBtnWidget := createPasswordEntryListLineComponents(serviceName, theme)
-			passwordEntries = append(passwordEntries, PasswordEntriesGUI{serviceName: serviceName, guiListElement: listElement, openBtnWidget: openBtnWidget, deleteBtnWidget: deleteBtnWidget})
+			listElement, openBtnWidget, editBtnWidget, deleteBtnWidget := createPasswordEntryListLineComponents(serviceName, theme)
+			fullSetOfPasswordEntries = append(fullSetOfPasswordEntries, PasswordEntriesGUI{serviceName: serviceName, tags: entriesTags[serviceName], guiListElement: listElement, openBtnWidget: openBtnWidget, editBtnWidget: editBtnWidget, deleteBtnWidget: deleteBtnWidget})
 		}
 
-		fullSetOfPasswordEntries := passwordEntries
+		passwordEntries := filterPasswordEntries(fullSetOfPasswordEntries, searchInput.Text(), selectedTag)
 
 		// Schedule invalidate in seperate gorotuine to redraw window after initial show after resizing + centering.
 		// For some reason gio do not paint correct layout / elements sizes on the first show after resizing + centering.
@@ -470,11 +663,29 @@ PasswordViewMarker:
 		for {
 			switch e := window.Event().(type) {
 			case app.DestroyEvent:
-				return e.Err
+				return nil, e.Err
+
+			case app.ConfigEvent:
+				if e.Config.Mode == app.Minimized && vaultSession.LockOnMinimize() {
+					vaultSession.Lock()
+				}
 
 			case app.FrameEvent:
 				gtx := app.NewContext(&passwordListOps, e)
 
+				clipboardGuard.update(gtx)
+
+				if lockWidget.Clicked(gtx) {
+					vaultSession.Lock()
+				}
+
+				// Locked by LOCK button, inactivity or minimizing
+				if vaultSession.Get() == nil {
+					searchInput.SetText("")
+					selectedTag = ""
+					goto UnlockMarker
+				}
+
 				select {
 				case shouldRefresh := <-refreshChan:
 					_ = shouldRefresh
@@ -487,33 +698,77 @@ PasswordViewMarker:
 					event, ok := searchInput.Update(gtx)
 					if ok {
 						if _, ok := event.(widget.ChangeEvent); ok {
-							if searchInput.Text() != "" {
-								validEntries := MatchPatternResult(fullSetOfPasswordEntries, searchInput.Text())
-								passwordEntries = validEntries
-							} else {
-								passwordEntries = fullSetOfPasswordEntries
-							}
+							vaultSession.Touch()
+							passwordEntries = filterPasswordEntries(fullSetOfPasswordEntries, searchInput.Text(), selectedTag)
 						}
 					}
 				}
 
+				if tag, clicked := tagFilter.Clicked(gtx); clicked {
+					vaultSession.Touch()
+					selectedTag = tag
+					passwordEntries = filterPasswordEntries(fullSetOfPasswordEntries, searchInput.Text(), selectedTag)
+				}
+
 				for _, passwordEntryInfo := range passwordEntries {
 					if passwordEntryInfo.openBtnWidget.Clicked(gtx) {
-						go authenticateAndShowPassword(backend, theme, passwordEntryInfo.serviceName)
+						go authenticateAndShowPassword(backend, vaultSession, clipboardGuard, theme, passwordEntryInfo.serviceName)
+					}
+
+					if passwordEntryInfo.editBtnWidget.Clicked(gtx) {
+						go func(serviceName string) {
+							var editPasswordEntryOps op.Ops
+							editPasswordWindow := new(app.Window)
+							ResizeWindowNewPasswordInsert(editPasswordWindow)
+							err := EditPasswordEntry(editPasswordWindow, &editPasswordEntryOps, backend, vaultSession, theme, serviceName, refreshChan)
+
+							if err != nil {
+								var errorWindowOps op.Ops
+								ErrorWindow(&errorWindowOps, editPasswordWindow, theme, "Error occured during password update. Please check logs.")
+							}
+						}(passwordEntryInfo.serviceName)
 					}
 
 					if passwordEntryInfo.deleteBtnWidget.Clicked(gtx) {
-						go confirmDeletion(backend, theme, passwordEntryInfo.serviceName, refreshChan)
+						go confirmDeletion(vaultSession, theme, passwordEntryInfo.serviceName, refreshChan)
 					}
 
 				}
 
+				if changeMasterPasswordWidget.Clicked(gtx) {
+					go func() {
+						var changeMasterPasswordOps op.Ops
+						changeMasterPasswordWindow := new(app.Window)
+						ResizeWindowChangeMasterPassword(changeMasterPasswordWindow)
+						err := ChangeMasterPassword(changeMasterPasswordWindow, &changeMasterPasswordOps, backend, vaultSession, theme)
+
+						if err != nil {
+							var errorWindowOps op.Ops
+							ErrorWindow(&errorWindowOps, changeMasterPasswordWindow, theme, "Error occured during master password change. Please check logs.")
+						}
+					}()
+				}
+
+				if settingsWidget.Clicked(gtx) {
+					go func() {
+						var settingsOps op.Ops
+						settingsWindow := new(app.Window)
+						ResizeWindowSettings(settingsWindow)
+						err := Settings(settingsWindow, &settingsOps, backend, vault.Backups, vaultSession, clipboardGuard, theme, refreshChan)
+
+						if err != nil {
+							var errorWindowOps op.Ops
+							ErrorWindow(&errorWindowOps, settingsWindow, theme, "Error occured in settings. Please check logs.")
+						}
+					}()
+				}
+
 				if newPasswordEntryWidget.Clicked(gtx) {
 					go func() {
 						var newPasswordEntryOps op.Ops
 						newPasswordWindow := new(app.Window)
 						ResizeWindowNewPasswordInsert(newPasswordWindow)
-						err = InputNewPassword(newPasswordWindow, &newPasswordEntryOps, backend, theme, refreshChan)
+						err = InputNewPassword(newPasswordWindow, &newPasswordEntryOps, backend, vaultSession, theme, refreshChan)
 
 						if err != nil {
 							var errorWindowOps op.Ops
@@ -530,7 +785,35 @@ PasswordViewMarker:
 					gtx,
 
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						return DrawSearchInput(gtx, theme, &searchInput, 130)
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+							gtx,
+							layout.Flexed(
+								1,
+								func(gtx layout.Context) layout.Dimensions {
+									return DrawSearchInput(gtx, theme, &searchInput, 130)
+								},
+							),
+							layout.Rigid(
+								func(gtx layout.Context) layout.Dimensions {
+									return layout.Inset{Top: unit.Dp(15), Right: unit.Dp(20)}.Layout(
+										gtx,
+										func(gtx layout.Context) layout.Dimensions {
+											lock := material.Button(theme, &lockWidget, "LOCK")
+											lock.Background = purple_light
+											lock.Color = black
+											lock.TextSize = unit.Sp(20)
+											lock.Font.Weight = font.SemiBold
+											lock.Font.Typeface = "Verdana, monospace"
+
+											return lock.Layout(gtx)
+										},
+									)
+								},
+							),
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return TagFilterWidget(gtx, theme, tagFilter, selectedTag)
 					}),
 
 					constructPasswordEntriesList(&passwordEntries, passwordEntriesList, margin),
@@ -538,19 +821,63 @@ PasswordViewMarker:
 						func(gtx layout.Context) layout.Dimensions {
 							return margin.Layout(gtx,
 								func(gtx layout.Context) layout.Dimensions {
-									newPasswordEntry := material.Button(theme, &newPasswordEntryWidget, "NEW")
-									newPasswordEntry.Background = charcoal
-									newPasswordEntry.TextSize = unit.Sp(25)
-									newPasswordEntry.Font.Weight = font.SemiBold
-									newPasswordEntry.Font.Typeface = "Verdana, monospace"
-
-									return newPasswordEntry.Layout(gtx)
+									return layout.Flex{Axis: layout.Horizontal}.Layout(
+										gtx,
+										layout.Flexed(
+											1,
+											func(gtx layout.Context) layout.Dimensions {
+												newPasswordEntry := material.Button(theme, &newPasswordEntryWidget, "NEW")
+												newPasswordEntry.Background = charcoal
+												newPasswordEntry.TextSize = unit.Sp(25)
+												newPasswordEntry.Font.Weight = font.SemiBold
+												newPasswordEntry.Font.Typeface = "Verdana, monospace"
+
+												return newPasswordEntry.Layout(gtx)
+											},
+										),
+										layout.Rigid(
+											func(gtx layout.Context) layout.Dimensions {
+												return layout.Inset{Left: unit.Dp(10)}.Layout(
+													gtx,
+													func(gtx layout.Context) layout.Dimensions {
+														changeMasterPassword := material.Button(theme, &changeMasterPasswordWidget, "MASTER")
+														changeMasterPassword.Background = grey
+														changeMasterPassword.Color = black
+														changeMasterPassword.TextSize = unit.Sp(25)
+														changeMasterPassword.Font.Weight = font.SemiBold
+														changeMasterPassword.Font.Typeface = "Verdana, monospace"
+
+														return changeMasterPassword.Layout(gtx)
+													},
+												)
+											},
+										),
+										layout.Rigid(
+											func(gtx layout.Context) layout.Dimensions {
+												return layout.Inset{Left: unit.Dp(10)}.Layout(
+													gtx,
+													func(gtx layout.Context) layout.Dimensions {
+														settings := material.Button(theme, &settingsWidget, "SETTINGS")
+														settings.Background = grey
+														settings.Color = black
+														settings.TextSize = unit.Sp(25)
+														settings.Font.Weight = font.SemiBold
+														settings.Font.Typeface = "Verdana, monospace"
+
+														return settings.Layout(gtx)
+													},
+												)
+											},
+										),
+									)
 								},
 							)
 						},
 					),
 				)
 
+				vaultSession.trackActivity(gtx)
+
 				if centerWindow {
 					window.Perform(system.ActionCenter)
 					centerWindow = !centerWindow
@@ -567,42 +894,40 @@ PasswordViewMarker:
 	}
 }
 
-func authenticateAndShowPassword(backend *server.Backend, theme *material.Theme, serviceName string) {
-	var (
-		centerWindow                  bool = true
-		alreadyDecrypted              bool = false
-		authenticate                  widget.Clickable
-		cancel                        widget.Clickable
-		showHideUsername              widget.Clickable
-		showHidePassword              widget.Clickable
-		masterPasswordGUI             widget.Editor
-		usernameGUI                   widget.Editor
-		passwordGUI                   widget.Editor
-		textCheckMsg                  string
-		passwordEditorBackgroundColor color.NRGBA = grey
-	)
+// Way unlock screen was left
+type UnlockResult int
 
-	masterPasswordGUI.SingleLine = true
-	masterPasswordGUI.Mask = '*'
-	masterPasswordGUI.Filter = input_filter
+const (
+	UnlockClosed UnlockResult = iota // main window got closed
+	Unlocked
+	UnlockSwitchVault // user wants to open other vault
+)
 
-	usernameGUI.ReadOnly = true
-	usernameGUI.SingleLine = true
-	usernameGUI.Mask = '*'
+// Shows unlock screen in main window until master password unlocks vault session or user chooses to switch vault
+func UnlockVault(window *app.Window, ops *op.Ops, backend *server.Backend, vaultSession *VaultSession, clipboardGuard *ClipboardGuard, theme *material.Theme, vaultName string) (UnlockResult, error) {
+	var centerWindow bool = true
 
-	passwordGUI.ReadOnly = true
-	passwordGUI.SingleLine = true
-	passwordGUI.Mask = '*'
+	masterPassword := new(widget.Editor)
+	masterPassword.SingleLine = true
+	masterPassword.Submit = true
+	masterPassword.Mask = '*'
+	masterPassword.Filter = input_filter
 
-	ops := new(op.Ops)
-	window := new(app.Window)
-	ResizeDecryptionWindow(window)
+	unlockView := UnlockView{
+		vaultName:       vaultName,
+		masterPassword:  masterPassword,
+		unlockBtnWidget: new(widget.Clickable),
+		showHidWidget:   new(widget.Clickable),
+		vaultsBtnWidget: new(widget.Clickable),
+	}
 
-	confirmDecryptionChan := make(chan DecryptionPackage, 2)
-	closeLoaderChan := make(chan bool, 2)
+	info := Information{"Provide Master Password to unlock the vault.", purple}
+	tryingToUnlock := false
+	focusMasterPassword := true
+	unlockChan := make(chan error)
+
+	ResizeWindowUnlock(window)
 
-	// Schedule invalidate in seperate gorotuine to redraw window after initial show after resizing + centering.
-	// For some reason gio do not paint correct layout / elements sizes on the first show after resizing + centering.
 	go func() {
 		for range 3 {
 			time.Sleep(time.Second / 20)
@@ -614,126 +939,305 @@ func authenticateAndShowPassword(backend *server.Backend, theme *material.Theme,
 	for {
 		switch e := window.Event().(type) {
 		case app.DestroyEvent:
-			return
+			return UnlockClosed, e.Err
+
 		case app.FrameEvent:
 			gtx := app.NewContext(ops, e)
-			select {
-			case decryptPackage := <-confirmDecryptionChan:
-				closeLoaderChan <- true
-				switch decryptErr := decryptPackage.err; {
-				case decryptErr == nil:
-					passwordEditorBackgroundColor = white
 
-					usernameGUI.ReadOnly = false
-					if usernameGUI.Text() != decryptPackage.passwordEntry.Password {
-						usernameGUI.SetText(decryptPackage.passwordEntry.Username)
-					}
+			// Secret copied before the vault got locked is still cleared in time
+			clipboardGuard.update(gtx)
 
-					passwordGUI.ReadOnly = false
-					if passwordGUI.Text() != decryptPackage.passwordEntry.Password {
-						passwordGUI.SetText(decryptPackage.passwordEntry.Password)
-					}
+			select {
+			case err := <-unlockChan:
+				if err == nil {
+					masterPassword.SetText("")
+					return Unlocked, nil
+				}
 
-					alreadyDecrypted = !alreadyDecrypted
-				case errors.Is(decryptErr, server.MasterPasswordDoNotMatch):
-					textCheckMsg = " - incorrect password."
-					passwordEditorBackgroundColor = red
-				default:
+				if errors.Is(err, server.MasterPasswordDoNotMatch) {
+					info.text = "Master Password is incorrect."
+				} else {
+					info.text = "Could not unlock the vault. Please check logs."
 				}
+
+				info.color = red
+				tryingToUnlock = false
+				ResizeWindowUnlock(window)
+				window.Perform(system.ActionCenter)
 			default:
 			}
 
-			if authenticate.Clicked(gtx) && !alreadyDecrypted {
-				if len(masterPasswordGUI.Text()) == 0 {
-					textCheckMsg = " - empty, please enter password"
-				} else {
-					opsLoading := new(op.Ops)
-					loaderWindow := new(app.Window)
-					ResizeWindowLoad(loaderWindow)
+			submitted := false
 
-					textCheckMsg = ""
+			for {
+				event, ok := masterPassword.Update(gtx)
 
-					masterPassword := masterPasswordGUI.Text()
-					go tryPasswordDecryption(backend, window, confirmDecryptionChan, &serviceName, &masterPassword)
-					go showLoading(opsLoading, loaderWindow, theme, closeLoaderChan)
+				if !ok {
+					break
 				}
-			}
 
-			if cancel.Clicked(gtx) {
-				window.Perform(system.ActionClose)
+				if _, ok := event.(widget.SubmitEvent); ok {
+					submitted = true
+				}
 			}
 
-			if showHidePassword.Clicked(gtx) {
-				if passwordGUI.ReadOnly != true {
-					switch {
-					case passwordGUI.Mask == rune(0):
-						passwordGUI.Mask = '*'
-					default:
-						passwordGUI.Mask = rune(0)
-					}
+			if unlockView.showHidWidget.Clicked(gtx) {
+				switch {
+				case masterPassword.Mask == rune(0):
+					masterPassword.Mask = '*'
+				default:
+					masterPassword.Mask = rune(0)
 				}
 			}
 
-			if showHideUsername.Clicked(gtx) {
-				if usernameGUI.ReadOnly != true {
-					switch {
-					case usernameGUI.Mask == rune(0):
-						usernameGUI.Mask = '*'
-					default:
-						usernameGUI.Mask = rune(0)
-					}
+			if unlockView.vaultsBtnWidget.Clicked(gtx) && !tryingToUnlock {
+				masterPassword.SetText("")
+				return UnlockSwitchVault, nil
+			}
+
+			if (unlockView.unlockBtnWidget.Clicked(gtx) || submitted) && !tryingToUnlock {
+				if masterPassword.Len() == 0 {
+					info.text = "Master Password is empty."
+					info.color = red
+				} else {
+					password := masterPassword.Text()
+
+					go func() {
+						_, err := vaultSession.Unlock(backend, password)
+						unlockChan <- err
+						window.Invalidate()
+					}()
+
+					tryingToUnlock = true
+					ResizeWindowLoad(window)
+					window.Perform(system.ActionCenter)
 				}
 			}
 
-			ManagePasswordDecryptionWidget(&gtx, theme, &serviceName, &textCheckMsg, &authenticate, &cancel, &showHideUsername, &showHidePassword, &masterPasswordGUI, &usernameGUI, &passwordGUI, &passwordEditorBackgroundColor)
+			if tryingToUnlock {
+				LoadWidget(&gtx, theme)
+			} else {
+				UnlockWidget(&gtx, theme, &unlockView, info)
+			}
 
 			if centerWindow {
 				window.Perform(system.ActionCenter)
 				centerWindow = !centerWindow
 			}
 
+			if focusMasterPassword {
+				gtx.Execute(key.FocusCmd{Tag: masterPassword})
+				focusMasterPassword = false
+			}
+
 			e.Frame(gtx.Ops)
 		}
 	}
 }
 
-func tryPasswordDecryption(backend *server.Backend, window *app.Window, confirmDecryptionChan chan DecryptionPackage, serviceName *string, masterPassword *string) {
-	_, err := backend.CmpMasterPassword(*masterPassword)
+// Warns in main window that vault failed integrity check after unlock. ACCEPT seals current content of the vault,
+// LATER leaves the vault read only and keeps the warning for next unlock. Returns false when window got closed.
+func IntegrityWarning(window *app.Window, ops *op.Ops, vaultSession *VaultSession, theme *material.Theme) (bool, error) {
+	session := vaultSession.Get()
 
-	if err != nil {
-		confirmDecryptionChan <- DecryptionPackage{err: err, passwordEntry: server.PasswordEntry{}}
-		window.Invalidate()
-		return
+	if session == nil {
+		return true, nil
 	}
 
-	passwordEntry, err := backend.DecryptPasswordEntry(*serviceName, *masterPassword)
+	warning := "Vault was modified outside frosk - entries could have been changed, removed or added by someone else."
 
-	if err != nil {
-		confirmDecryptionChan <- DecryptionPackage{err: err, passwordEntry: server.PasswordEntry{}}
-	} else {
-		confirmDecryptionChan <- DecryptionPackage{err: nil, passwordEntry: passwordEntry}
+	if errors.Is(session.Integrity(), server.VaultRolledBack) {
+		warning = "Vault was replaced with its older copy - recent changes are missing."
 	}
 
-	window.Invalidate()
-	return
-}
+	integrityWarningView := IntegrityWarningView{
+		warning:         warning,
+		acceptBtnWidget: new(widget.Clickable),
+		laterBtnWidget:  new(widget.Clickable),
+	}
 
-func showLoading(ops *op.Ops, window *app.Window, theme *material.Theme, closeLoadingChan chan bool) {
-	var centerWindow bool = true
+	info := Information{"If you did not do it, restore a backup in Settings -> BACKUPS. ACCEPT trusts current content of the vault, until then it can't be changed.", purple}
+	centerWindow := true
+
+	ResizeWindowIntegrityWarning(window)
+
+	go func() {
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
+		return
+	}()
 
 	for {
 		switch e := window.Event().(type) {
 		case app.DestroyEvent:
-			return
+			return false, e.Err
 
 		case app.FrameEvent:
 			gtx := app.NewContext(ops, e)
 
-			select {
-			case <-closeLoadingChan:
-				window.Perform(system.ActionClose)
-			default:
-				LoadWidget(&gtx, theme)
+			if integrityWarningView.laterBtnWidget.Clicked(gtx) {
+				return true, nil
+			}
+
+			if integrityWarningView.acceptBtnWidget.Clicked(gtx) {
+				err := session.AcceptVaultState()
+
+				if err == nil {
+					return true, nil
+				}
+
+				info = Information{"Could not accept vault content. Please check logs.", red}
+			}
+
+			IntegrityWarningWidget(&gtx, theme, &integrityWarningView, info)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+// Shows vault picker in main window. Returns vault opened by user, nil when user went back to current vault,
+// and false when window got closed.
+func SelectVault(window *app.Window, ops *op.Ops, theme *material.Theme, clipboardGuard *ClipboardGuard, currentVaultPath string, dataDirectory string) (*vaults.Vault, bool, error) {
+	var centerWindow bool = true
+
+	type openResult struct {
+		vault *vaults.Vault
+		err   error
+	}
+
+	info := Information{"Open known vault, or type name or path of vault to create or open.", purple}
+
+	knownVaults, err := vaults.Known(dataDirectory)
+
+	if err != nil {
+		info = Information{"Could not load list of known vaults. Please check logs.", red}
+	}
+
+	if !slices.Contains(knownVaults, currentVaultPath) {
+		knownVaults = slices.Insert(knownVaults, 0, currentVaultPath)
+	}
+
+	vaultName := new(widget.Editor)
+	vaultName.SingleLine = true
+	vaultName.Submit = true
+
+	vaultsView := VaultsView{
+		currentVaultPath: currentVaultPath,
+		vaultPaths:       knownVaults,
+		openBtnWidgets:   make([]widget.Clickable, len(knownVaults)),
+		list:             &widget.List{List: layout.List{Axis: layout.Vertical}},
+		vaultName:        vaultName,
+		createBtnWidget:  new(widget.Clickable),
+		openBtnWidget:    new(widget.Clickable),
+		backBtnWidget:    new(widget.Clickable),
+	}
+
+	opening := false
+	openChan := make(chan openResult)
+
+	openVault := func(vaultPath string) {
+		opening = true
+		ResizeWindowLoad(window)
+		window.Perform(system.ActionCenter)
+
+		go func() {
+			vault, err := vaults.Open(vaultPath, dataDirectory)
+			openChan <- openResult{vault, err}
+			window.Invalidate()
+		}()
+	}
+
+	ResizeWindowVaults(window)
+
+	go func() {
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
+		return
+	}()
+
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return nil, false, e.Err
+
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			clipboardGuard.update(gtx)
+
+			select {
+			case result := <-openChan:
+				if result.err == nil {
+					return result.vault, true, nil
+				}
+
+				info = Information{"Could not open vault. Is it a frosk vault file? Please check logs.", red}
+				opening = false
+				ResizeWindowVaults(window)
+				window.Perform(system.ActionCenter)
+			default:
+			}
+
+			submitted := false
+
+			for {
+				event, ok := vaultName.Update(gtx)
+
+				if !ok {
+					break
+				}
+
+				if _, ok := event.(widget.SubmitEvent); ok {
+					submitted = true
+				}
+			}
+
+			if vaultsView.backBtnWidget.Clicked(gtx) && !opening {
+				return nil, true, nil
+			}
+
+			for i, vaultPath := range vaultsView.vaultPaths {
+				if vaultsView.openBtnWidgets[i].Clicked(gtx) && !opening && vaultPath != currentVaultPath {
+					openVault(vaultPath)
+				}
+			}
+
+			createClicked := vaultsView.createBtnWidget.Clicked(gtx)
+			openClicked := vaultsView.openBtnWidget.Clicked(gtx) || submitted
+
+			if (createClicked || openClicked) && !opening {
+				vaultPath, err := vaults.PathFromName(dataDirectory, vaultName.Text())
+
+				switch {
+				case errors.Is(err, vaults.EmptyVaultName):
+					info = Information{vaults.EmptyVaultName.Error(), red}
+				case err != nil:
+					info = Information{"Invalid vault path. Please check logs.", red}
+				case vaultPath == currentVaultPath:
+					return nil, true, nil
+				case createClicked && vaults.Exists(vaultPath):
+					info = Information{vaults.VaultAlreadyExists.Error() + " Use OPEN instead.", red}
+				case openClicked && !vaults.Exists(vaultPath):
+					info = Information{vaults.VaultDoesNotExist.Error() + " Use CREATE instead.", red}
+				default:
+					openVault(vaultPath)
+				}
+			}
+
+			if opening {
+				LoadWidget(&gtx, theme)
+			} else {
+				VaultsWidget(&gtx, theme, &vaultsView, info)
 			}
 
 			if centerWindow {
@@ -746,160 +1250,2204 @@ func showLoading(ops *op.Ops, window *app.Window, theme *material.Theme, closeLo
 	}
 }
 
-func confirmDeletion(backend *server.Backend, theme *material.Theme, serviceName string, refreshChan chan bool) {
+// Shows decrypted username and password for service name. Master password is asked for only when vault session is locked.
+func authenticateAndShowPassword(backend *server.Backend, vaultSession *VaultSession, clipboardGuard *ClipboardGuard, theme *material.Theme, serviceName string) {
 	var (
-		deletePasswordEntry bool = true
-		centerWindow        bool = true
-		confirm             widget.Clickable
-		deny                widget.Clickable
-		maxW                unit.Dp = 650
-		maxH                unit.Dp = 350
+		centerWindow                  bool = true
+		alreadyDecrypted              bool = false
+		masterPasswordRequired        bool = vaultSession.Get() == nil
+		loaderShown                   bool = false
+		authenticate                  widget.Clickable
+		cancel                        widget.Clickable
+		showHideUsername              widget.Clickable
+		showHidePassword              widget.Clickable
+		copyUsername                  widget.Clickable
+		copyPassword                  widget.Clickable
+		masterPasswordGUI             widget.Editor
+		usernameGUI                   widget.Editor
+		passwordGUI                   widget.Editor
+		textCheckMsg                  string
+		passwordEditorBackgroundColor color.NRGBA   = grey
+		entryDetails                  *EntryDetails = NewEntryDetails()
 	)
 
+	masterPasswordGUI.SingleLine = true
+	masterPasswordGUI.Mask = '*'
+	masterPasswordGUI.Filter = input_filter
+
+	usernameGUI.ReadOnly = true
+	usernameGUI.SingleLine = true
+	usernameGUI.Mask = '*'
+
+	passwordGUI.ReadOnly = true
+	passwordGUI.SingleLine = true
+	passwordGUI.Mask = '*'
+
 	ops := new(op.Ops)
 	window := new(app.Window)
-	window.Option(app.Decorated(false))
-	window.Option(app.MinSize(unit.Dp(maxW), unit.Dp(maxH)))
-	window.Option(app.MaxSize(unit.Dp(maxW), unit.Dp(maxH)))
-	window.Option(app.Size(unit.Dp(maxW), unit.Dp(maxH)))
-	window.Option(app.Title("frosk"))
+	ResizeDecryptionWindow(window)
+
+	confirmDecryptionChan := make(chan DecryptionPackage, 2)
+	closeLoaderChan := make(chan bool, 2)
+
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	defer cancelCtx()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
+
+	// Unlocked session decrypts without key derivation, so there is nothing to wait for
+	if !masterPasswordRequired {
+		go tryPasswordDecryption(backend, vaultSession, window, confirmDecryptionChan, &serviceName, new(string))
+	}
+
+	// Schedule invalidate in seperate gorotuine to redraw window after initial show after resizing + centering.
+	// For some reason gio do not paint correct layout / elements sizes on the first show after resizing + centering.
+	go func() {
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
+		return
+	}()
+
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			// Decrypted credentials can't stay on screen after vault gets locked
+			select {
+			case <-locked:
+				window.Perform(system.ActionClose)
+			default:
+			}
+
+			select {
+			case decryptPackage := <-confirmDecryptionChan:
+				if loaderShown {
+					closeLoaderChan <- true
+					loaderShown = false
+				}
+				switch decryptErr := decryptPackage.err; {
+				case decryptErr == nil:
+					passwordEditorBackgroundColor = white
+
+					usernameGUI.ReadOnly = false
+					if usernameGUI.Text() != decryptPackage.passwordEntry.Password {
+						usernameGUI.SetText(decryptPackage.passwordEntry.Username)
+					}
+
+					passwordGUI.ReadOnly = false
+					if passwordGUI.Text() != decryptPackage.passwordEntry.Password {
+						passwordGUI.SetText(decryptPackage.passwordEntry.Password)
+					}
+
+					entryDetails.url.SetText(decryptPackage.passwordEntry.URL)
+					entryDetails.notes.SetText(decryptPackage.passwordEntry.Notes)
+					entryDetails.customFields = nil
+					entryDetails.totp = nil
+
+					if decryptPackage.passwordEntry.TOTP != "" {
+						totp, err := server.ParseTOTP(decryptPackage.passwordEntry.TOTP)
+
+						if err != nil {
+							errWrapped := fmt.Errorf("Stored two-factor secret of %s can't be used: %w", serviceName, err)
+							slog.Error(errWrapped.Error())
+						} else {
+							entryDetails.totp = &totp
+						}
+					}
+
+					for _, customField := range decryptPackage.passwordEntry.CustomFields {
+						entryDetails.customFields = append(entryDetails.customFields, NewCustomFieldDetails(customField.Name, customField.Value, customField.Secret))
+					}
+
+					entryDetails.passwordHistory = nil
+
+					for _, historyEntry := range decryptPackage.passwordHistory {
+						entryDetails.passwordHistory = append(entryDetails.passwordHistory, NewCustomFieldDetails("Replaced "+historyEntry.ReplacedAt, historyEntry.Password, true))
+					}
+
+					alreadyDecrypted = !alreadyDecrypted
+				case errors.Is(decryptErr, server.MasterPasswordDoNotMatch):
+					textCheckMsg = " - incorrect password."
+					passwordEditorBackgroundColor = red
+				case errors.Is(decryptErr, server.EmptyMasterPassword):
+					// Vault was locked in the meantime
+					masterPasswordRequired = true
+					textCheckMsg = " - vault is locked, please enter password"
+				default:
+				}
+			default:
+			}
+
+			if authenticate.Clicked(gtx) && !alreadyDecrypted {
+				if len(masterPasswordGUI.Text()) == 0 {
+					textCheckMsg = " - empty, please enter password"
+				} else {
+					opsLoading := new(op.Ops)
+					loaderWindow := new(app.Window)
+					ResizeWindowLoad(loaderWindow)
+
+					textCheckMsg = ""
+
+					masterPassword := masterPasswordGUI.Text()
+					go tryPasswordDecryption(backend, vaultSession, window, confirmDecryptionChan, &serviceName, &masterPassword)
+					go showLoading(opsLoading, loaderWindow, theme, closeLoaderChan)
+					loaderShown = true
+				}
+			}
+
+			if cancel.Clicked(gtx) {
+				window.Perform(system.ActionClose)
+			}
+
+			if copyUsername.Clicked(gtx) && alreadyDecrypted {
+				clipboardGuard.copy(gtx, usernameGUI.Text())
+			}
+
+			if copyPassword.Clicked(gtx) && alreadyDecrypted {
+				clipboardGuard.copy(gtx, passwordGUI.Text())
+			}
+
+			if entryDetails.copyTOTP.Clicked(gtx) && entryDetails.totp != nil {
+				clipboardGuard.copy(gtx, entryDetails.totp.Code(time.Now()))
+			}
+
+			if entryDetails.copyURL.Clicked(gtx) && alreadyDecrypted {
+				clipboardGuard.copy(gtx, entryDetails.url.Text())
+			}
+
+			for _, customField := range slices.Concat(entryDetails.customFields, entryDetails.passwordHistory) {
+				if customField.copyWidget.Clicked(gtx) {
+					clipboardGuard.copy(gtx, customField.value.Text())
+				}
+
+				if customField.showHideWidget.Clicked(gtx) {
+					switch {
+					case customField.value.Mask == rune(0):
+						customField.value.Mask = '*'
+					default:
+						customField.value.Mask = rune(0)
+					}
+				}
+			}
+
+			if showHidePassword.Clicked(gtx) {
+				if passwordGUI.ReadOnly != true {
+					switch {
+					case passwordGUI.Mask == rune(0):
+						passwordGUI.Mask = '*'
+					default:
+						passwordGUI.Mask = rune(0)
+					}
+				}
+			}
+
+			if showHideUsername.Clicked(gtx) {
+				if usernameGUI.ReadOnly != true {
+					switch {
+					case usernameGUI.Mask == rune(0):
+						usernameGUI.Mask = '*'
+					default:
+						usernameGUI.Mask = rune(0)
+					}
+				}
+			}
+
+			masterPasswordInput := &masterPasswordGUI
+			if !masterPasswordRequired {
+				masterPasswordInput = nil
+			}
+
+			ManagePasswordDecryptionWidget(&gtx, theme, &serviceName, &textCheckMsg, &authenticate, &cancel, &showHideUsername, &showHidePassword, &copyUsername, &copyPassword, masterPasswordInput, &usernameGUI, &passwordGUI, &passwordEditorBackgroundColor, entryDetails)
+			vaultSession.trackActivity(gtx)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+// Decrypts password entry with vault session, unlocking the vault with master password first when needed
+func tryPasswordDecryption(backend *server.Backend, vaultSession *VaultSession, window *app.Window, confirmDecryptionChan chan DecryptionPackage, serviceName *string, masterPassword *string) {
+	session, err := vaultSession.Unlock(backend, *masterPassword)
+
+	if err != nil {
+		confirmDecryptionChan <- DecryptionPackage{err: err, passwordEntry: server.PasswordEntry{}}
+		window.Invalidate()
+		return
+	}
+
+	passwordEntry, err := session.DecryptPasswordEntry(*serviceName)
+
+	var passwordHistory []server.PasswordHistoryEntry
+
+	if err == nil {
+		passwordHistory, err = session.GetPasswordHistory(*serviceName)
+	}
+
+	if err != nil {
+		confirmDecryptionChan <- DecryptionPackage{err: err, passwordEntry: server.PasswordEntry{}}
+	} else {
+		confirmDecryptionChan <- DecryptionPackage{err: nil, passwordEntry: passwordEntry, passwordHistory: passwordHistory}
+	}
+
+	window.Invalidate()
+	return
+}
+
+func showLoading(ops *op.Ops, window *app.Window, theme *material.Theme, closeLoadingChan chan bool) {
+	var centerWindow bool = true
+
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return
+
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			select {
+			case <-closeLoadingChan:
+				window.Perform(system.ActionClose)
+			default:
+				LoadWidget(&gtx, theme)
+			}
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+func confirmDeletion(vaultSession *VaultSession, theme *material.Theme, serviceName string, refreshChan chan bool) {
+	var (
+		deletePasswordEntry bool = true
+		centerWindow        bool = true
+		confirm             widget.Clickable
+		deny                widget.Clickable
+		maxW                unit.Dp = 650
+		maxH                unit.Dp = 350
+	)
+
+	ops := new(op.Ops)
+	window := new(app.Window)
+	window.Option(app.Decorated(false))
+	window.Option(app.MinSize(unit.Dp(maxW), unit.Dp(maxH)))
+	window.Option(app.MaxSize(unit.Dp(maxW), unit.Dp(maxH)))
+	window.Option(app.Size(unit.Dp(maxW), unit.Dp(maxH)))
+	window.Option(app.Title("frosk"))
+
+	go func() {
+		time.Sleep(time.Second / 20)
+		window.Invalidate()
+		return
+	}()
+
+	{ // Window loop
+		for {
+			switch e := window.Event().(type) {
+			case app.DestroyEvent:
+				// return e.Err
+				return
+
+			case app.FrameEvent:
+				gtx := app.NewContext(ops, e)
+
+				{ // Choice whether to delete password or not
+					if confirm.Clicked(gtx) {
+						err := server.SessionLocked
+						if session := vaultSession.Get(); session != nil {
+							err = session.DeletePasswordEntry(serviceName)
+						}
+						if err != nil {
+							errWrapped := fmt.Errorf("Error during deletion of password: %w", err)
+							slog.Error(errWrapped.Error())
+						}
+						refreshChan <- deletePasswordEntry
+						window.Perform(system.ActionClose)
+					}
+
+					if deny.Clicked(gtx) {
+						window.Perform(system.ActionClose)
+					}
+				}
+
+				{ // Paint
+					paint.Fill(ops, black)
+					ConfirmPasswordDeletionWidget(&gtx, theme, serviceName, &confirm, &deny)
+
+					if centerWindow {
+						window.Perform(system.ActionCenter)
+						centerWindow = !centerWindow
+					}
+
+					e.Frame(gtx.Ops)
+				}
+
+			}
+		}
+	}
+
+}
+
+type Information struct {
+	text  string
+	color color.NRGBA
+}
+
+func InputNewPassword(window *app.Window, ops *op.Ops, backend *server.Backend, vaultSession *VaultSession, theme *material.Theme, refreshChan chan bool) error {
+	var centerWindow bool = true
+	var inserted bool = true
+
+	masterPassword := new(widget.Editor)
+	masterPassword.SingleLine = true
+	masterPassword.Mask = '*'
+	masterPassword.Filter = input_filter
+
+	serviceName := new(widget.Editor)
+	serviceName.SingleLine = true
+	serviceName.Mask = '*'
+	serviceName.Filter = input_filter + " "
+
+	username := new(widget.Editor)
+	username.SingleLine = true
+	username.Mask = '*'
+	username.Filter = input_filter + " "
+
+	password := new(widget.Editor)
+	password.SingleLine = true
+	password.Mask = '*'
+	password.Filter = input_filter
+
+	url := new(widget.Editor)
+	url.SingleLine = true
+	url.Mask = '*'
+
+	notes := new(widget.Editor)
+	notes.Mask = '*'
+
+	totp := new(widget.Editor)
+	totp.SingleLine = true
+	totp.Mask = '*'
+
+	tags := new(widget.Editor)
+	tags.SingleLine = true
+	tags.Mask = '*'
+
+	confirmBtnWidget := new(widget.Clickable)
+	showHideWidget := new(widget.Clickable)
+
+	newPasswordView := NewPasswordView{
+		header:           "New Password",
+		masterPassword:   masterPassword,
+		serviceName:      serviceName,
+		username:         username,
+		password:         password,
+		confirmBtnWidget: confirmBtnWidget,
+		showHidWidget:    showHideWidget,
+		policyEditor:     NewPolicyEditor(),
+		strengthMeter:    new(StrengthMeter),
+		url:              url,
+		notes:            notes,
+		totp:             totp,
+		tags:             tags,
+		addFieldWidget:   new(widget.Clickable),
+		list:             &widget.List{List: layout.List{Axis: layout.Vertical}},
+		unlocked:         vaultSession.Get() != nil,
+		borderColor:      black,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
+
+	info := Information{"Provide Master Password to authenticate. Fill out form to save credentials for a service.", purple}
+	if newPasswordView.unlocked {
+		info.text = "Fill out form to save credentials for a service."
+	}
+	tryingToInsertPassword := false
+
+	type InsertPasswordEntryOperation struct {
+		error     error
+		didInsert bool
+		msg       string
+	}
+
+	insertPasswordOperationChan := make(chan InsertPasswordEntryOperation)
+
+	// Draw
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return e.Err
+
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			select {
+			case <-locked:
+				window.Perform(system.ActionClose)
+			default:
+			}
+
+			select {
+			case insertOperation := <-insertPasswordOperationChan:
+				if insertOperation.error != nil {
+					switch err := insertOperation.error; {
+					case errors.Is(err, server.ServiceNameAlreadyTaken), errors.Is(err, server.EmptyTagName), errors.Is(err, server.VaultNotAccepted), errors.Is(err, server.MasterPasswordDoNotMatch), errors.Is(err, server.EmptyMasterPassword):
+						info.text = insertOperation.msg
+						info.color = red
+						newPasswordView.unlocked = vaultSession.Get() != nil
+						tryingToInsertPassword = false
+						ResizeWindowNewPasswordInsert(window)
+						window.Perform(system.ActionCenter)
+					default:
+						return err
+					}
+				}
+				if insertOperation.didInsert {
+					refreshChan <- true
+					window.Perform(system.ActionClose)
+				}
+			default:
+			}
+
+			handlePasswordFormControls(gtx, &newPasswordView, &info)
+
+		CheckConfirmButtonClickMarker:
+			if confirmBtnWidget.Clicked(gtx) {
+				info.text = ""
+				inputProblem := false
+
+				if !newPasswordView.unlocked && len(newPasswordView.masterPassword.Text()) == 0 {
+					info.text += "Master Password is empty. "
+					info.color = red
+					inputProblem = true
+				}
+				if len(newPasswordView.username.Text()) == 0 {
+					info.text += "Username is empty. "
+					info.color = red
+					inputProblem = true
+				}
+				if len(newPasswordView.serviceName.Text()) == 0 {
+					info.text += "Service name is empty. "
+					info.color = red
+					inputProblem = true
+				}
+				if len(newPasswordView.password.Text()) == 0 {
+					info.text += "Password is empty. "
+					info.color = red
+					inputProblem = true
+				}
+				if newPasswordView.totp.Len() > 0 {
+					if _, err := server.ParseTOTP(newPasswordView.totp.Text()); err != nil {
+						info.text += "Two-factor secret is invalid. "
+						info.color = red
+						inputProblem = true
+					}
+				}
+				if newPasswordView.hasUnnamedCustomField() {
+					info.text += "Custom field name is empty. "
+					info.color = red
+					inputProblem = true
+				}
+
+				if inputProblem {
+					goto CheckConfirmButtonClickMarker
+				}
+
+				go func() {
+					session, err := vaultSession.Unlock(backend, newPasswordView.masterPassword.Text())
+
+					switch {
+					case errors.Is(err, server.MasterPasswordDoNotMatch):
+						insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Master Password is incorrect."}
+						return
+					case errors.Is(err, server.EmptyMasterPassword):
+						insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Vault was locked. Provide Master Password to authenticate."}
+						return
+					case err != nil:
+						insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Unspecified error occured. Check error description."}
+						return
+					}
+
+					err = session.EncryptPasswordEntry(newPasswordView.passwordEntry())
+
+					if err != nil {
+						if errors.Is(err, server.ServiceNameAlreadyTaken) {
+							insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Service name is already taken. Choose another name."}
+						} else if errors.Is(err, server.EmptyTagName) {
+							insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Tags can't have empty names or empty parts between slashes."}
+						} else if errors.Is(err, server.VaultNotAccepted) {
+							insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Vault failed integrity check. Accept its content or restore a backup before changing it."}
+						} else {
+							insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Unspecified error occured. Check error description."}
+						}
+						return
+					}
+
+					insertPasswordOperationChan <- InsertPasswordEntryOperation{nil, inserted, ""}
+				}()
+
+				tryingToInsertPassword = true
+				ResizeWindowLoad(window)
+				window.Perform(system.ActionCenter)
+			}
+
+			passwordLength := strconv.Itoa(newPasswordView.password.Len())
+			newPasswordView.strengthMeter.Update(newPasswordView.password.Text(), newPasswordView.serviceName.Text(), newPasswordView.username.Text())
+
+			window.Invalidate()
+
+			if tryingToInsertPassword {
+				LoadWidget(&gtx, theme)
+			} else {
+				InsertNewPasswordWidget(&gtx, theme, &newPasswordView, passwordLength, info)
+			}
+
+			vaultSession.trackActivity(gtx)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+// Handles password generator policy and show/hide buttons shared by new and edit password forms
+func handlePasswordFormControls(gtx layout.Context, passwordView *NewPasswordView, info *Information) {
+	policyEditor := passwordView.policyEditor
+
+	toggles := []struct {
+		clickable *widget.Clickable
+		value     *bool
+	}{
+		{policyEditor.lowercaseWidget, &policyEditor.policy.Lowercase},
+		{policyEditor.uppercaseWidget, &policyEditor.policy.Uppercase},
+		{policyEditor.digitsWidget, &policyEditor.policy.Digits},
+		{policyEditor.symbolsWidget, &policyEditor.policy.Symbols},
+		{policyEditor.requireEveryClassWidget, &policyEditor.policy.RequireEveryClass},
+		{policyEditor.excludeAmbiguousWidget, &policyEditor.policy.ExcludeAmbiguous},
+		{policyEditor.noConsecutiveRepeatWidget, &policyEditor.policy.NoConsecutiveRepeats},
+		{policyEditor.noDuplicatesWidget, &policyEditor.policy.NoDuplicates},
+		{policyEditor.capitalizeWidget, &policyEditor.passphrasePolicy.Capitalize},
+		{policyEditor.appendDigitWidget, &policyEditor.passphrasePolicy.AppendDigit},
+	}
+
+	for _, toggle := range toggles {
+		if toggle.clickable.Clicked(gtx) {
+			*toggle.value = !*toggle.value
+		}
+	}
+
+	if policyEditor.charactersModeWidget.Clicked(gtx) {
+		policyEditor.passphraseMode = false
+	}
+
+	if policyEditor.passphraseModeWidget.Clicked(gtx) {
+		policyEditor.passphraseMode = true
+	}
+
+	if policyEditor.shortWordlistWidget.Clicked(gtx) {
+		if policyEditor.passphrasePolicy.Wordlist == generator.EFFShort {
+			policyEditor.passphrasePolicy.Wordlist = generator.EFFLarge
+		} else {
+			policyEditor.passphrasePolicy.Wordlist = generator.EFFShort
+		}
+	}
+
+	if policyEditor.generateBtnWidget.Clicked(gtx) {
+		randomString, err := policyEditor.Generate()
+
+		if err != nil {
+			info.text = err.Error()
+			info.color = red
+		} else {
+			passwordView.password.SetText(randomString)
+		}
+	}
+
+	if passwordView.addFieldWidget.Clicked(gtx) {
+		passwordView.customFields = append(passwordView.customFields, NewCustomFieldInput("", "", false, passwordView.password.Mask))
+	}
+
+	for index := 0; index < len(passwordView.customFields); index++ {
+		customField := passwordView.customFields[index]
+
+		if customField.secretWidget.Clicked(gtx) {
+			customField.secret = !customField.secret
+		}
+
+		if customField.removeWidget.Clicked(gtx) {
+			passwordView.customFields = slices.Delete(passwordView.customFields, index, index+1)
+			index--
+		}
+	}
+
+	if passwordView.showHidWidget.Clicked(gtx) {
+		mask := rune(0)
+
+		if passwordView.masterPassword.Mask == rune(0) {
+			mask = '*'
+		}
+
+		passwordView.masterPassword.Mask = mask
+		passwordView.serviceName.Mask = mask
+		passwordView.username.Mask = mask
+		passwordView.password.Mask = mask
+		passwordView.url.Mask = mask
+		passwordView.notes.Mask = mask
+		passwordView.totp.Mask = mask
+		passwordView.tags.Mask = mask
+
+		for _, customField := range passwordView.customFields {
+			customField.value.Mask = mask
+		}
+	}
+}
+
+// Reports custom field row which has value but no name - such field can't be saved
+func (passwordView *NewPasswordView) hasUnnamedCustomField() bool {
+	for _, customField := range passwordView.customFields {
+		if strings.TrimSpace(customField.name.Text()) == "" && customField.value.Text() != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Collects entry from the form. Custom field rows left completely empty are skipped.
+func (passwordView *NewPasswordView) passwordEntry() server.PasswordEntry {
+	passwordEntry := server.PasswordEntry{
+		ServiceName:  passwordView.serviceName.Text(),
+		Username:     passwordView.username.Text(),
+		Password:     passwordView.password.Text(),
+		URL:          passwordView.url.Text(),
+		Notes:        passwordView.notes.Text(),
+		TOTP:         strings.TrimSpace(passwordView.totp.Text()),
+		CustomFields: []server.CustomField{},
+		Tags:         []string{},
+	}
+
+	for _, tag := range strings.Split(passwordView.tags.Text(), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			passwordEntry.Tags = append(passwordEntry.Tags, tag)
+		}
+	}
+
+	for _, customField := range passwordView.customFields {
+		name := strings.TrimSpace(customField.name.Text())
+
+		if name == "" && customField.value.Text() == "" {
+			continue
+		}
+
+		passwordEntry.CustomFields = append(passwordEntry.CustomFields, server.CustomField{Name: name, Value: customField.value.Text(), Secret: customField.secret})
+	}
+
+	return passwordEntry
+}
+
+// Shows form pre-filled with service name. Current username and password are loaded into the form right away when vault session
+// is unlocked, otherwise after providing master password. Saving re-encrypts the entry in place, keeping its creation date.
+func EditPasswordEntry(window *app.Window, ops *op.Ops, backend *server.Backend, vaultSession *VaultSession, theme *material.Theme, serviceNameToEdit string, refreshChan chan bool) error {
+	var centerWindow bool = true
+	var updated bool = true
+
+	masterPassword := new(widget.Editor)
+	masterPassword.SingleLine = true
+	masterPassword.Mask = '*'
+	masterPassword.Filter = input_filter
+
+	serviceName := new(widget.Editor)
+	serviceName.SingleLine = true
+	serviceName.Mask = '*'
+	serviceName.Filter = input_filter + " "
+	serviceName.SetText(serviceNameToEdit)
+
+	username := new(widget.Editor)
+	username.SingleLine = true
+	username.Mask = '*'
+	username.Filter = input_filter + " "
+
+	password := new(widget.Editor)
+	password.SingleLine = true
+	password.Mask = '*'
+	password.Filter = input_filter
+
+	url := new(widget.Editor)
+	url.SingleLine = true
+	url.Mask = '*'
+
+	notes := new(widget.Editor)
+	notes.Mask = '*'
+
+	totp := new(widget.Editor)
+	totp.SingleLine = true
+	totp.Mask = '*'
+
+	tags := new(widget.Editor)
+	tags.SingleLine = true
+	tags.Mask = '*'
+
+	editPasswordView := NewPasswordView{
+		header:           "Edit Password",
+		masterPassword:   masterPassword,
+		serviceName:      serviceName,
+		username:         username,
+		password:         password,
+		confirmBtnWidget: new(widget.Clickable),
+		showHidWidget:    new(widget.Clickable),
+		loadBtnWidget:    new(widget.Clickable),
+		policyEditor:     NewPolicyEditor(),
+		strengthMeter:    new(StrengthMeter),
+		url:              url,
+		notes:            notes,
+		totp:             totp,
+		tags:             tags,
+		addFieldWidget:   new(widget.Clickable),
+		list:             &widget.List{List: layout.List{Axis: layout.Vertical}},
+		unlocked:         vaultSession.Get() != nil,
+		borderColor:      black,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
+
+	info := Information{"Provide Master Password and press LOAD to fill out current credentials. Change the form and save to update " + serviceNameToEdit + ".", purple}
+	tryingToUpdatePassword := false
+
+	type UpdatePasswordEntryOperation struct {
+		error      error
+		didUpdate  bool
+		msg        string
+		loaded     bool
+		loadedInfo server.PasswordEntry
+	}
+
+	updatePasswordOperationChan := make(chan UpdatePasswordEntryOperation)
+
+	loadCurrentCredentials := func(masterPassword string) {
+		session, err := vaultSession.Unlock(backend, masterPassword)
+
+		switch {
+		case errors.Is(err, server.MasterPasswordDoNotMatch):
+			updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, msg: "Master Password is incorrect."}
+			return
+		case errors.Is(err, server.EmptyMasterPassword):
+			updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, msg: "Vault was locked. Provide Master Password and press LOAD."}
+			return
+		case err != nil:
+			updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, msg: "Unspecified error occured. Check error description."}
+			return
+		}
+
+		passwordEntry, err := session.DecryptPasswordEntry(serviceNameToEdit)
+
+		if err != nil {
+			updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, msg: "Unspecified error occured. Check error description."}
+			return
+		}
+
+		updatePasswordOperationChan <- UpdatePasswordEntryOperation{loaded: true, loadedInfo: passwordEntry}
+	}
+
+	if editPasswordView.unlocked {
+		info.text = "Change the form and save to update " + serviceNameToEdit + "."
+		go loadCurrentCredentials("")
+	}
+
+	// Draw
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return e.Err
+
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			select {
+			case <-locked:
+				window.Perform(system.ActionClose)
+			default:
+			}
+
+			select {
+			case updateOperation := <-updatePasswordOperationChan:
+				if updateOperation.error != nil {
+					switch err := updateOperation.error; {
+					case errors.Is(err, server.ServiceNameAlreadyTaken), errors.Is(err, server.ServiceNameNotFound), errors.Is(err, server.EmptyTagName), errors.Is(err, server.VaultNotAccepted), errors.Is(err, server.MasterPasswordDoNotMatch), errors.Is(err, server.EmptyMasterPassword):
+						info.text = updateOperation.msg
+						info.color = red
+						editPasswordView.unlocked = vaultSession.Get() != nil
+						tryingToUpdatePassword = false
+						ResizeWindowNewPasswordInsert(window)
+						window.Perform(system.ActionCenter)
+					default:
+						return err
+					}
+				}
+				if updateOperation.loaded {
+					editPasswordView.username.SetText(updateOperation.loadedInfo.Username)
+					editPasswordView.password.SetText(updateOperation.loadedInfo.Password)
+					editPasswordView.url.SetText(updateOperation.loadedInfo.URL)
+					editPasswordView.notes.SetText(updateOperation.loadedInfo.Notes)
+					editPasswordView.totp.SetText(updateOperation.loadedInfo.TOTP)
+					editPasswordView.tags.SetText(strings.Join(updateOperation.loadedInfo.Tags, ", "))
+					editPasswordView.customFields = nil
+
+					for _, customField := range updateOperation.loadedInfo.CustomFields {
+						editPasswordView.customFields = append(
+							editPasswordView.customFields,
+							NewCustomFieldInput(customField.Name, customField.Value, customField.Secret, editPasswordView.password.Mask),
+						)
+					}
+					info.text = "Current credentials loaded."
+					info.color = purple
+					tryingToUpdatePassword = false
+					ResizeWindowNewPasswordInsert(window)
+					window.Perform(system.ActionCenter)
+				}
+				if updateOperation.didUpdate {
+					refreshChan <- true
+					window.Perform(system.ActionClose)
+				}
+			default:
+			}
+
+			handlePasswordFormControls(gtx, &editPasswordView, &info)
+
+			if editPasswordView.loadBtnWidget.Clicked(gtx) {
+				if len(editPasswordView.masterPassword.Text()) == 0 {
+					info.text = "Master Password is empty. "
+					info.color = red
+				} else {
+					go loadCurrentCredentials(editPasswordView.masterPassword.Text())
+
+					tryingToUpdatePassword = true
+					ResizeWindowLoad(window)
+					window.Perform(system.ActionCenter)
+				}
+			}
+
+		CheckConfirmButtonClickMarker:
+			if editPasswordView.confirmBtnWidget.Clicked(gtx) {
+				info.text = ""
+				inputProblem := false
+
+				if !editPasswordView.unlocked && len(editPasswordView.masterPassword.Text()) == 0 {
+					info.text += "Master Password is empty. "
+					info.color = red
+					inputProblem = true
+				}
+				if len(editPasswordView.username.Text()) == 0 {
+					info.text += "Username is empty. "
+					info.color = red
+					inputProblem = true
+				}
+				if len(editPasswordView.serviceName.Text()) == 0 {
+					info.text += "Service name is empty. "
+					info.color = red
+					inputProblem = true
+				}
+				if len(editPasswordView.password.Text()) == 0 {
+					info.text += "Password is empty. "
+					info.color = red
+					inputProblem = true
+				}
+				if editPasswordView.totp.Len() > 0 {
+					if _, err := server.ParseTOTP(editPasswordView.totp.Text()); err != nil {
+						info.text += "Two-factor secret is invalid. "
+						info.color = red
+						inputProblem = true
+					}
+				}
+				if editPasswordView.hasUnnamedCustomField() {
+					info.text += "Custom field name is empty. "
+					info.color = red
+					inputProblem = true
+				}
+
+				if inputProblem {
+					goto CheckConfirmButtonClickMarker
+				}
+
+				go func() {
+					session, err := vaultSession.Unlock(backend, editPasswordView.masterPassword.Text())
+
+					switch {
+					case errors.Is(err, server.MasterPasswordDoNotMatch):
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, didUpdate: !updated, msg: "Master Password is incorrect."}
+						return
+					case errors.Is(err, server.EmptyMasterPassword):
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, didUpdate: !updated, msg: "Vault was locked. Provide Master Password to authenticate."}
+						return
+					case err != nil:
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, didUpdate: !updated, msg: "Unspecified error occured. Check error description."}
+						return
+					}
+
+					err = session.UpdatePasswordEntry(serviceNameToEdit, editPasswordView.passwordEntry())
+
+					switch {
+					case errors.Is(err, server.ServiceNameAlreadyTaken):
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, didUpdate: !updated, msg: "Service name is already taken. Choose another name."}
+					case errors.Is(err, server.ServiceNameNotFound):
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, didUpdate: !updated, msg: "Service " + serviceNameToEdit + " no longer exists."}
+					case errors.Is(err, server.EmptyTagName):
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, didUpdate: !updated, msg: "Tags can't have empty names or empty parts between slashes."}
+					case errors.Is(err, server.VaultNotAccepted):
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, didUpdate: !updated, msg: "Vault failed integrity check. Accept its content or restore a backup before changing it."}
+					case err != nil:
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: err, didUpdate: !updated, msg: "Unspecified error occured. Check error description."}
+					default:
+						updatePasswordOperationChan <- UpdatePasswordEntryOperation{error: nil, didUpdate: updated}
+					}
+				}()
+
+				tryingToUpdatePassword = true
+				ResizeWindowLoad(window)
+				window.Perform(system.ActionCenter)
+			}
+
+			passwordLength := strconv.Itoa(editPasswordView.password.Len())
+			editPasswordView.strengthMeter.Update(editPasswordView.password.Text(), editPasswordView.serviceName.Text(), editPasswordView.username.Text())
+
+			window.Invalidate()
+
+			if tryingToUpdatePassword {
+				LoadWidget(&gtx, theme)
+			} else {
+				InsertNewPasswordWidget(&gtx, theme, &editPasswordView, passwordLength, info)
+			}
+
+			vaultSession.trackActivity(gtx)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+// Lets user replace master password. Current password has to be confirmed, new one typed twice.
+func ChangeMasterPassword(window *app.Window, ops *op.Ops, backend *server.Backend, vaultSession *VaultSession, theme *material.Theme) error {
+	var centerWindow bool = true
+
+	currentMasterPassword := new(widget.Editor)
+	currentMasterPassword.SingleLine = true
+	currentMasterPassword.Mask = '*'
+	currentMasterPassword.Filter = input_filter
+
+	newMasterPassword := new(widget.Editor)
+	newMasterPassword.SingleLine = true
+	newMasterPassword.Mask = '*'
+	newMasterPassword.Filter = input_filter
+
+	newMasterPasswordRepeat := new(widget.Editor)
+	newMasterPasswordRepeat.SingleLine = true
+	newMasterPasswordRepeat.Mask = '*'
+	newMasterPasswordRepeat.Filter = input_filter
+
+	changeMasterPasswordView := ChangeMasterPasswordView{
+		currentMasterPassword:   currentMasterPassword,
+		newMasterPassword:       newMasterPassword,
+		newMasterPasswordRepeat: newMasterPasswordRepeat,
+		confirmBtnWidget:        new(widget.Clickable),
+		cancelBtnWidget:         new(widget.Clickable),
+		showHidWidget:           new(widget.Clickable),
+		rehashBtnWidget:         new(widget.Clickable),
+		unlockTimeWidget:        new(widget.Clickable),
+		strengthMeter:           new(StrengthMeter),
+		unlockTime:              2 * time.Second,
+	}
+
+	argonConfig, err := backend.GetArgonConfig()
+
+	if err != nil {
+		return err
+	}
+
+	changeMasterPasswordView.kdfInfo = argonConfig.String()
+
+	info := Information{"Provide current Master Password and type the new one twice. Stored passwords stay untouched. To only strengthen key derivation provide current Master Password and press REHASH.", purple}
+	tryingToChangeMasterPassword := false
+	changeMasterPasswordChan := make(chan error)
+
+	type RehashOperation struct {
+		error       error
+		argonConfig server.ArgonConfig
+	}
+
+	rehashChan := make(chan RehashOperation)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
+
+	go func() {
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
+		return
+	}()
+
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return e.Err
+
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			select {
+			case <-locked:
+				window.Perform(system.ActionClose)
+			default:
+			}
+
+			select {
+			case err := <-changeMasterPasswordChan:
+				switch {
+				case err == nil:
+					window.Perform(system.ActionClose)
+				case errors.Is(err, server.MasterPasswordDoNotMatch):
+					info.text = "Current Master Password is incorrect."
+					info.color = red
+					tryingToChangeMasterPassword = false
+					ResizeWindowChangeMasterPassword(window)
+					window.Perform(system.ActionCenter)
+				default:
+					return err
+				}
+			case rehashOperation := <-rehashChan:
+				tryingToChangeMasterPassword = false
+				ResizeWindowChangeMasterPassword(window)
+				window.Perform(system.ActionCenter)
+
+				switch err := rehashOperation.error; {
+				case err == nil:
+					changeMasterPasswordView.kdfInfo = rehashOperation.argonConfig.String()
+					info.text = "Key derivation parameters upgraded."
+					info.color = purple
+				case errors.Is(err, server.MasterPasswordDoNotMatch):
+					info.text = "Current Master Password is incorrect."
+					info.color = red
+				case errors.Is(err, server.WeakerArgonConfig):
+					info.text = "Parameters calibrated for " + changeMasterPasswordView.unlockTime.String() + " would be weaker than current ones. Choose longer unlock time."
+					info.color = red
+				default:
+					return err
+				}
+			default:
+			}
+
+			if changeMasterPasswordView.cancelBtnWidget.Clicked(gtx) {
+				window.Perform(system.ActionClose)
+			}
+
+			if changeMasterPasswordView.unlockTimeWidget.Clicked(gtx) {
+				switch changeMasterPasswordView.unlockTime {
+				case 1 * time.Second:
+					changeMasterPasswordView.unlockTime = 2 * time.Second
+				case 2 * time.Second:
+					changeMasterPasswordView.unlockTime = 4 * time.Second
+				default:
+					changeMasterPasswordView.unlockTime = 1 * time.Second
+				}
+			}
+
+			if changeMasterPasswordView.rehashBtnWidget.Clicked(gtx) {
+				if currentMasterPassword.Len() == 0 {
+					info.text = "Current Master Password is empty."
+					info.color = red
+				} else {
+					masterPassword := currentMasterPassword.Text()
+					unlockTime := changeMasterPasswordView.unlockTime
+
+					go func() {
+						argonConfig := server.CalibrateArgonConfig(unlockTime)
+						err := backend.RehashMasterPassword(masterPassword, argonConfig)
+						rehashChan <- RehashOperation{err, argonConfig}
+						window.Invalidate()
+					}()
+
+					tryingToChangeMasterPassword = true
+					ResizeWindowLoad(window)
+					window.Perform(system.ActionCenter)
+				}
+			}
+
+			if changeMasterPasswordView.showHidWidget.Clicked(gtx) {
+				switch {
+				case changeMasterPasswordView.currentMasterPassword.Mask == rune(0):
+					changeMasterPasswordView.currentMasterPassword.Mask = '*'
+					changeMasterPasswordView.newMasterPassword.Mask = '*'
+					changeMasterPasswordView.newMasterPasswordRepeat.Mask = '*'
+				default:
+					changeMasterPasswordView.currentMasterPassword.Mask = rune(0)
+					changeMasterPasswordView.newMasterPassword.Mask = rune(0)
+					changeMasterPasswordView.newMasterPasswordRepeat.Mask = rune(0)
+				}
+			}
+
+			if changeMasterPasswordView.confirmBtnWidget.Clicked(gtx) {
+				switch {
+				case currentMasterPassword.Len() == 0:
+					info.text = "Current Master Password is empty."
+					info.color = red
+				case newMasterPassword.Len() == 0:
+					info.text = "New Master Password is empty."
+					info.color = red
+				case newMasterPassword.Text() != newMasterPasswordRepeat.Text():
+					info.text = "New Master Password does not match its repetition."
+					info.color = red
+				case changeMasterPasswordView.strengthMeter.Result().Score < strength.MinMasterPasswordScore:
+					info.text = strength.MasterPasswordTooWeak.Error()
+					info.color = red
+				default:
+					oldPassword := currentMasterPassword.Text()
+					newPassword := newMasterPassword.Text()
+
+					go func() {
+						changeMasterPasswordChan <- backend.ChangeMasterPassword(oldPassword, newPassword)
+						window.Invalidate()
+					}()
+
+					tryingToChangeMasterPassword = true
+					ResizeWindowLoad(window)
+					window.Perform(system.ActionCenter)
+				}
+			}
+
+			changeMasterPasswordView.strengthMeter.Update(newMasterPassword.Text(), "frosk")
+
+			if tryingToChangeMasterPassword {
+				LoadWidget(&gtx, theme)
+			} else {
+				ChangeMasterPasswordWidget(&gtx, theme, &changeMasterPasswordView, info)
+			}
+
+			vaultSession.trackActivity(gtx)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+// Lets user configure automatic lock, backups and trash of the vault, and open import, export, backups and trash.
+// Every change is saved right away.
+func Settings(window *app.Window, ops *op.Ops, backend *server.Backend, backups *vaults.Backups, vaultSession *VaultSession, clipboardGuard *ClipboardGuard, theme *material.Theme, refreshChan chan bool) error {
+	var centerWindow bool = true
+
+	autoLockTimeouts := []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute, time.Hour, 0}
+	clipboardClearTimeouts := []time.Duration{10 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute, 0}
+	backupRetentions := []server.BackupRetention{
+		{Last: 5},
+		server.DefaultBackupRetention,
+		{Last: 20, Daily: 14, Weekly: 8},
+		{Last: 50, Daily: 30, Weekly: 52},
+	}
+	passwordHistoryLengths := []int{0, 5, server.DefaultPasswordHistoryLength, 20, 50}
+	trashRetentions := []time.Duration{7 * 24 * time.Hour, server.DefaultTrashRetention, 90 * 24 * time.Hour, 365 * 24 * time.Hour, 0}
+
+	// Fall back to defaults when they can't be read, error is logged by backend
+	backupRetention, _ := backend.GetBackupRetention()
+	trashRetention, _ := backend.GetTrashRetention()
+	passwordHistoryLength, _ := backend.GetPasswordHistoryLength()
+
+	settingsView := SettingsView{
+		autoLockWidget:        new(widget.Clickable),
+		lockOnMinimizeWidget:  new(widget.Clickable),
+		clipboardClearWidget:  new(widget.Clickable),
+		passwordHistoryWidget: new(widget.Clickable),
+		importWidget:          new(widget.Clickable),
+		exportWidget:          new(widget.Clickable),
+		backupRetentionWidget: new(widget.Clickable),
+		backupsWidget:         new(widget.Clickable),
+		trashRetentionWidget:  new(widget.Clickable),
+		trashWidget:           new(widget.Clickable),
+		closeBtnWidget:        new(widget.Clickable),
+		autoLockTimeout:       vaultSession.AutoLockTimeout(),
+		lockOnMinimize:        vaultSession.LockOnMinimize(),
+		clipboardClearTimeout: clipboardGuard.ClearTimeout(),
+		passwordHistoryLength: passwordHistoryLength,
+		backupRetention:       backupRetention,
+		trashRetention:        trashRetention,
+	}
+
+	info := Information{"Settings are stored in the vault and applied right away.", purple}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
+
+	go func() {
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
+		return
+	}()
+
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return e.Err
+
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			select {
+			case <-locked:
+				window.Perform(system.ActionClose)
+			default:
+			}
+
+			if settingsView.autoLockWidget.Clicked(gtx) {
+				nextTimeout := nextOption(autoLockTimeouts, settingsView.autoLockTimeout)
+				err := backend.SetAutoLockTimeout(nextTimeout)
+
+				if err != nil {
+					info.text = "Could not save setting. Please check logs."
+					info.color = red
+				} else {
+					settingsView.autoLockTimeout = nextTimeout
+					vaultSession.SetAutoLockTimeout(nextTimeout)
+				}
+			}
+
+			if settingsView.clipboardClearWidget.Clicked(gtx) {
+				nextTimeout := nextOption(clipboardClearTimeouts, settingsView.clipboardClearTimeout)
+				err := backend.SetClipboardClearTimeout(nextTimeout)
+
+				if err != nil {
+					info.text = "Could not save setting. Please check logs."
+					info.color = red
+				} else {
+					settingsView.clipboardClearTimeout = nextTimeout
+					clipboardGuard.SetClearTimeout(nextTimeout)
+				}
+			}
+
+			if settingsView.passwordHistoryWidget.Clicked(gtx) {
+				nextLength := nextOption(passwordHistoryLengths, settingsView.passwordHistoryLength)
+
+				// Shorter history removes replaced passwords above it, so vault has to be unlocked
+				err := server.SessionLocked
+				if session := vaultSession.Get(); session != nil {
+					err = session.SetPasswordHistoryLength(nextLength)
+				}
+
+				if err != nil {
+					info.text = "Could not save setting. Please check logs."
+					info.color = red
+				} else {
+					settingsView.passwordHistoryLength = nextLength
+				}
+			}
+
+			if settingsView.lockOnMinimizeWidget.Clicked(gtx) {
+				err := backend.SetLockOnMinimize(!settingsView.lockOnMinimize)
+
+				if err != nil {
+					info.text = "Could not save setting. Please check logs."
+					info.color = red
+				} else {
+					settingsView.lockOnMinimize = !settingsView.lockOnMinimize
+					vaultSession.SetLockOnMinimize(settingsView.lockOnMinimize)
+				}
+			}
+
+			if settingsView.importWidget.Clicked(gtx) {
+				go func() {
+					var importOps op.Ops
+					importWindow := new(app.Window)
+					ResizeWindowImport(importWindow)
+					err := ImportEntries(importWindow, &importOps, vaultSession, theme, refreshChan)
+
+					if err != nil {
+						var errorWindowOps op.Ops
+						ErrorWindow(&errorWindowOps, importWindow, theme, "Error occured during import. Please check logs.")
+					}
+				}()
+			}
+
+			if settingsView.exportWidget.Clicked(gtx) {
+				go func() {
+					var exportOps op.Ops
+					exportWindow := new(app.Window)
+					ResizeWindowExport(exportWindow)
+					err := ExportEntries(exportWindow, &exportOps, vaultSession, theme)
+
+					if err != nil {
+						var errorWindowOps op.Ops
+						ErrorWindow(&errorWindowOps, exportWindow, theme, "Error occured during export. Please check logs.")
+					}
+				}()
+			}
+
+			if settingsView.backupRetentionWidget.Clicked(gtx) {
+				nextRetention := nextOption(backupRetentions, settingsView.backupRetention)
+				err := backend.SetBackupRetention(nextRetention)
+
+				if err != nil {
+					info.text = "Could not save setting. Please check logs."
+					info.color = red
+				} else {
+					settingsView.backupRetention = nextRetention
+				}
+			}
+
+			if settingsView.backupsWidget.Clicked(gtx) {
+				go func() {
+					var backupsOps op.Ops
+					backupsWindow := new(app.Window)
+					ResizeWindowBackups(backupsWindow)
+					err := RestoreBackup(backupsWindow, &backupsOps, backups, vaultSession, theme)
+
+					if err != nil {
+						var errorWindowOps op.Ops
+						ErrorWindow(&errorWindowOps, backupsWindow, theme, "Error occured during restoring backup. Please check logs.")
+					}
+				}()
+			}
+
+			if settingsView.trashRetentionWidget.Clicked(gtx) {
+				nextRetention := nextOption(trashRetentions, settingsView.trashRetention)
+
+				// Retention decides what gets purged, so it is sealed with the vault and needs unlocked vault
+				err := server.SessionLocked
+				if session := vaultSession.Get(); session != nil {
+					err = session.SetTrashRetention(nextRetention)
+				}
+
+				if err != nil {
+					info.text = "Could not save setting. Please check logs."
+					info.color = red
+				} else {
+					settingsView.trashRetention = nextRetention
+				}
+			}
+
+			if settingsView.trashWidget.Clicked(gtx) {
+				go func() {
+					var trashOps op.Ops
+					trashWindow := new(app.Window)
+					ResizeWindowTrash(trashWindow)
+					err := Trash(trashWindow, &trashOps, vaultSession, theme, refreshChan)
+
+					if err != nil {
+						var errorWindowOps op.Ops
+						ErrorWindow(&errorWindowOps, trashWindow, theme, "Error occured in trash. Please check logs.")
+					}
+				}()
+			}
+
+			if settingsView.closeBtnWidget.Clicked(gtx) {
+				window.Perform(system.ActionClose)
+			}
+
+			SettingsWidget(&gtx, theme, &settingsView, info)
+			vaultSession.trackActivity(gtx)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+// Imports entries of frosk archive, KeePass database or export of other password manager into unlocked vault. Import can be
+// previewed first - summary then lists what would happen to every entry without changing the vault.
+func ImportEntries(window *app.Window, ops *op.Ops, vaultSession *VaultSession, theme *material.Theme, refreshChan chan bool) error {
+	var centerWindow bool = true
+
+	path := new(widget.Editor)
+	path.SingleLine = true
+
+	password := new(widget.Editor)
+	password.SingleLine = true
+	password.Mask = '*'
+
+	keyFilePath := new(widget.Editor)
+	keyFilePath.SingleLine = true
+
+	sources := []ImportSource{{description: "frosk archive", archive: true}, {description: "KeePass KDBX 4"}}
+
+	for _, format := range importer.Formats() {
+		sources = append(sources, ImportSource{description: format.Description(), format: format})
+	}
+
+	importView := ImportView{
+		path:                  path,
+		password:              password,
+		keyFilePath:           keyFilePath,
+		sourceWidget:          new(widget.Clickable),
+		collisionPolicyWidget: new(widget.Clickable),
+		importBtnWidget:       new(widget.Clickable),
+		previewBtnWidget:      new(widget.Clickable),
+		showHideWidget:        new(widget.Clickable),
+		closeBtnWidget:        new(widget.Clickable),
+		sources:               sources,
+		collisionPolicy:       server.CollisionRename,
+		summary:               []string{},
+		summaryList:           &widget.List{List: layout.List{Axis: layout.Vertical}},
+	}
+
+	info := Information{"Choose what to import and what to do when service name is already present in the vault. PREVIEW shows outcome without changing the vault.", purple}
+	tryingToImport := false
+
+	type ImportOperation struct {
+		error   error
+		msg     string // set when error is caused by user input and can be corrected
+		summary server.ImportSummary
+	}
+
+	importChan := make(chan ImportOperation)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
+
+	go func() {
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
+		return
+	}()
+
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return e.Err
+
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			select {
+			case <-locked:
+				window.Perform(system.ActionClose)
+			default:
+			}
+
+			select {
+			case importOperation := <-importChan:
+				tryingToImport = false
+				ResizeWindowImport(window)
+				window.Perform(system.ActionCenter)
+
+				switch err := importOperation.error; {
+				case err == nil:
+					summary := importOperation.summary
+
+					if summary.DryRun {
+						info.text = fmt.Sprintf("Preview: %d of %d entries would be stored. Nothing was changed yet.", summary.Imported+summary.Renamed+summary.Overwritten, len(summary.Entries))
+					} else {
+						info.text = fmt.Sprintf("Stored %d of %d entries.", summary.Imported+summary.Renamed+summary.Overwritten, len(summary.Entries))
+						password.SetText("")
+
+						// Main window may not have picked up previous refresh yet
+						select {
+						case refreshChan <- true:
+						default:
+						}
+					}
+
+					info.color = purple
+					importView.summary = importSummaryLines(summary)
+				case importOperation.msg != "":
+					info.text = importOperation.msg
+					info.color = red
+				case errors.Is(err, server.SessionLocked):
+					window.Perform(system.ActionClose)
+				default:
+					return err
+				}
+			default:
+			}
+
+			if importView.sourceWidget.Clicked(gtx) {
+				importView.source = (importView.source + 1) % len(importView.sources)
+				importView.summary = []string{}
+			}
+
+			if importView.collisionPolicyWidget.Clicked(gtx) {
+				switch importView.collisionPolicy {
+				case server.CollisionRename:
+					importView.collisionPolicy = server.CollisionSkip
+				case server.CollisionSkip:
+					importView.collisionPolicy = server.CollisionOverwrite
+				default:
+					importView.collisionPolicy = server.CollisionRename
+				}
+			}
+
+			if importView.showHideWidget.Clicked(gtx) {
+				if password.Mask == rune(0) {
+					password.Mask = '*'
+				} else {
+					password.Mask = rune(0)
+				}
+			}
+
+			if importView.closeBtnWidget.Clicked(gtx) {
+				window.Perform(system.ActionClose)
+			}
+
+			importClicked := importView.importBtnWidget.Clicked(gtx)
+			previewClicked := importView.previewBtnWidget.Clicked(gtx)
+
+			if importClicked || previewClicked {
+				source := importView.sources[importView.source]
+
+				switch {
+				case len(strings.TrimSpace(path.Text())) == 0:
+					info.text = "Path to import from is empty."
+					info.color = red
+				case source.isArchive() && password.Len() == 0:
+					info.text = server.EmptyArchivePassphrase.Error()
+					info.color = red
+				case source.isKeePass() && password.Len() == 0 && len(strings.TrimSpace(keyFilePath.Text())) == 0:
+					info.text = kdbx.MissingCredentials.Error()
+					info.color = red
+				default:
+					request := importRequest{
+						source:          source,
+						path:            expandHomeDirectory(strings.TrimSpace(path.Text())),
+						password:        password.Text(),
+						keyFilePath:     expandHomeDirectory(strings.TrimSpace(keyFilePath.Text())),
+						collisionPolicy: importView.collisionPolicy,
+						dryRun:          previewClicked,
+					}
+
+					go func() {
+						importOperation := ImportOperation{}
+						importOperation.summary, importOperation.msg, importOperation.error = request.run(vaultSession)
+						importChan <- importOperation
+						window.Invalidate()
+					}()
+
+					tryingToImport = true
+					ResizeWindowLoad(window)
+					window.Perform(system.ActionCenter)
+				}
+			}
+
+			if tryingToImport {
+				LoadWidget(&gtx, theme)
+			} else {
+				ImportWidget(&gtx, theme, &importView, info)
+			}
+
+			vaultSession.trackActivity(gtx)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+type importRequest struct {
+	source          ImportSource
+	path            string
+	password        string // passphrase of archive or password of KeePass database
+	keyFilePath     string // of KeePass database, optional
+	collisionPolicy server.CollisionPolicy
+	dryRun          bool
+}
+
+// Reads entries from chosen source and imports or previews them. Returns summary of import and message for user
+// when source can't be read, e.g. because of wrong path, format or credentials.
+func (request importRequest) run(vaultSession *VaultSession) (server.ImportSummary, string, error) {
+	session := vaultSession.Get()
+
+	if session == nil {
+		return server.ImportSummary{}, "", server.SessionLocked
+	}
+
+	var passwordEntries []server.PasswordEntry
+
+	switch {
+	case request.source.isArchive():
+		archiveData, err := os.ReadFile(request.path)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during reading archive: %w", err)
+			slog.Error(errWrapped.Error())
+			return server.ImportSummary{}, "Could not read archive file.", errWrapped
+		}
+
+		archive, err := server.ReadArchive(bytes.NewReader(archiveData), request.password)
+
+		if err != nil {
+			// Errors of archive describe what is wrong with the file or passphrase
+			return server.ImportSummary{}, err.Error(), err
+		}
+
+		passwordEntries = archive.PasswordEntries
+	case request.source.isKeePass():
+		databaseData, err := os.ReadFile(request.path)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during reading KeePass database: %w", err)
+			slog.Error(errWrapped.Error())
+			return server.ImportSummary{}, "Could not read KeePass database file.", errWrapped
+		}
+
+		var keyFileData []byte
+
+		if request.keyFilePath != "" {
+			keyFileData, err = os.ReadFile(request.keyFilePath)
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Error during reading key file: %w", err)
+				slog.Error(errWrapped.Error())
+				return server.ImportSummary{}, "Could not read key file.", errWrapped
+			}
+		}
+
+		database, err := kdbx.Open(bytes.NewReader(databaseData), request.password, keyFileData)
+
+		if err != nil {
+			// Errors of kdbx describe what is wrong with the database or credentials
+			return server.ImportSummary{}, err.Error(), err
+		}
+
+		passwordEntries = database.PasswordEntries()
+	default:
+		var err error
+		passwordEntries, err = request.source.format.Read(request.path)
+
+		switch {
+		case errors.Is(err, importer.MalformedExport), errors.Is(err, importer.EncryptedExport), errors.Is(err, importer.GPGNotFound):
+			return server.ImportSummary{}, err.Error(), err
+		case err != nil:
+			return server.ImportSummary{}, "Could not read " + request.source.description + " export. Please check path and logs.", err
+		}
+	}
+
+	if request.dryRun {
+		summary, err := session.PreviewPasswordEntriesImport(passwordEntries, request.collisionPolicy)
+		return summary, "", err
+	}
+
+	summary, err := session.ImportPasswordEntries(passwordEntries, request.collisionPolicy)
+
+	return summary, "", err
+}
+
+// Writes all entries of unlocked vault to archive or KeePass database encrypted with password chosen by user, or to
+// unencrypted CSV / JSON file once user confirms it. Archive can be imported into this or any other vault.
+func ExportEntries(window *app.Window, ops *op.Ops, vaultSession *VaultSession, theme *material.Theme) error {
+	var centerWindow bool = true
+
+	path := new(widget.Editor)
+	path.SingleLine = true
+
+	password := new(widget.Editor)
+	password.SingleLine = true
+	password.Mask = '*'
+	password.Filter = input_filter
+
+	passwordRepeat := new(widget.Editor)
+	passwordRepeat.SingleLine = true
+	passwordRepeat.Mask = '*'
+	passwordRepeat.Filter = input_filter
+
+	targets := []ExportTarget{{description: "frosk archive"}, {description: "KeePass KDBX 4", keePass: true}}
+
+	for _, format := range exporter.Formats() {
+		targets = append(targets, ExportTarget{description: format.Description() + " (unencrypted)", format: format})
+	}
+
+	exportView := ExportView{
+		path:            path,
+		password:        password,
+		passwordRepeat:  passwordRepeat,
+		targetWidget:    new(widget.Clickable),
+		exportBtnWidget: new(widget.Clickable),
+		showHideWidget:  new(widget.Clickable),
+		closeBtnWidget:  new(widget.Clickable),
+		strengthMeter:   new(StrengthMeter),
+		targets:         targets,
+	}
+
+	defaultInfo := Information{"Archive holds all entries sealed with its own passphrase - master password is not needed to open it. Existing file is not replaced.", purple}
+	info := defaultInfo
+	tryingToExport := false
+	plaintextConfirmed := false // unencrypted export is written on second click of EXPORT
+
+	type ExportOperation struct {
+		error error
+		msg   string // set when error is caused by user input and can be corrected
+		count int
+		path  string
+	}
+
+	exportChan := make(chan ExportOperation)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
+
+	go func() {
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
+		return
+	}()
+
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return e.Err
+
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
+
+			select {
+			case <-locked:
+				window.Perform(system.ActionClose)
+			default:
+			}
+
+			select {
+			case exportOperation := <-exportChan:
+				tryingToExport = false
+				ResizeWindowExport(window)
+				window.Perform(system.ActionCenter)
+
+				switch err := exportOperation.error; {
+				case err == nil:
+					info.text = fmt.Sprintf("Exported %d entries to %s.", exportOperation.count, exportOperation.path)
+					info.color = purple
+
+					if exportView.targets[exportView.target].isPlaintext() {
+						info.text += " Delete the file once it is not needed - it holds passwords unencrypted."
+						info.color = red
+					}
+
+					password.SetText("")
+					passwordRepeat.SetText("")
+				case exportOperation.msg != "":
+					info.text = exportOperation.msg
+					info.color = red
+				case errors.Is(err, server.SessionLocked):
+					window.Perform(system.ActionClose)
+				default:
+					return err
+				}
+			default:
+			}
+
+			if exportView.targetWidget.Clicked(gtx) {
+				exportView.target = (exportView.target + 1) % len(exportView.targets)
+				plaintextConfirmed = false
+				info = defaultInfo
+
+				if exportView.targets[exportView.target].isPlaintext() {
+					info = Information{"File will hold all passwords unencrypted - anyone who can read it can read them.", red}
+				}
+			}
+
+			if exportView.showHideWidget.Clicked(gtx) {
+				if password.Mask == rune(0) {
+					password.Mask = '*'
+					passwordRepeat.Mask = '*'
+				} else {
+					password.Mask = rune(0)
+					passwordRepeat.Mask = rune(0)
+				}
+			}
+
+			if exportView.closeBtnWidget.Clicked(gtx) {
+				window.Perform(system.ActionClose)
+			}
+
+			if exportView.exportBtnWidget.Clicked(gtx) {
+				target := exportView.targets[exportView.target]
+
+				switch {
+				case len(strings.TrimSpace(path.Text())) == 0:
+					info.text = "Path of exported file is empty."
+					info.color = red
+				case target.isPlaintext() && !plaintextConfirmed:
+					plaintextConfirmed = true
+					info.text = "File will hold all passwords unencrypted. Press EXPORT again to confirm."
+					info.color = red
+				case !target.isPlaintext() && password.Len() == 0:
+					info.text = "Password of exported file is empty."
+					info.color = red
+				case !target.isPlaintext() && password.Text() != passwordRepeat.Text():
+					info.text = "Password of exported file does not match its repetition."
+					info.color = red
+				case target.isArchive() && exportView.strengthMeter.Result().Score < strength.MinMasterPasswordScore:
+					info.text = strength.ArchivePassphraseTooWeak.Error()
+					info.color = red
+				case target.isKeePass() && exportView.strengthMeter.Result().Score < strength.MinMasterPasswordScore:
+					info.text = strength.KeePassPasswordTooWeak.Error()
+					info.color = red
+				default:
+					request := exportRequest{
+						target:   target,
+						path:     expandHomeDirectory(strings.TrimSpace(path.Text())),
+						password: password.Text(),
+					}
+
+					plaintextConfirmed = false
+
+					go func() {
+						exportOperation := ExportOperation{path: request.path}
+						exportOperation.count, exportOperation.msg, exportOperation.error = request.run(vaultSession)
+						exportChan <- exportOperation
+						window.Invalidate()
+					}()
+
+					tryingToExport = true
+					ResizeWindowLoad(window)
+					window.Perform(system.ActionCenter)
+				}
+			}
+
+			exportView.strengthMeter.Update(password.Text(), "frosk")
+
+			if tryingToExport {
+				LoadWidget(&gtx, theme)
+			} else {
+				ExportWidget(&gtx, theme, &exportView, info)
+			}
+
+			vaultSession.trackActivity(gtx)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
+}
+
+type exportRequest struct {
+	target   ExportTarget
+	path     string
+	password string // passphrase of archive or password of KeePass database
+}
+
+// Writes export to new file. Returns number of exported entries and message for user when file can't be created.
+func (request exportRequest) run(vaultSession *VaultSession) (int, string, error) {
+	session := vaultSession.Get()
+
+	if session == nil {
+		return 0, "", server.SessionLocked
+	}
+
+	exportFile, err := os.OpenFile(request.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+
+	if errors.Is(err, os.ErrExist) {
+		return 0, "File already exists. Choose another path.", err
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during creating export: %w", err)
+		slog.Error(errWrapped.Error())
+		return 0, "Could not create exported file. Please check path.", errWrapped
+	}
+
+	var count int
+
+	if request.target.isArchive() {
+		count, err = session.WriteArchive(exportFile, request.password)
+	} else {
+		var passwordEntries []server.PasswordEntry
+		passwordEntries, err = session.ExportPasswordEntries()
+		count = len(passwordEntries)
+
+		switch {
+		case err != nil:
+		case request.target.isKeePass():
+			err = kdbx.Save(exportFile, kdbx.NewDatabase("frosk", passwordEntries), request.password, nil)
+		default:
+			err = request.target.format.Write(exportFile, passwordEntries)
+		}
+	}
+
+	if err == nil {
+		err = exportFile.Sync()
+	}
+
+	closeErr := exportFile.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	// Incomplete export can't be opened, so it is not left behind
+	if err != nil {
+		os.Remove(request.path)
+		return 0, "", err
+	}
+
+	return count, "", nil
+}
+
+// Lists backups of the vault and restores chosen one. Restore replaces all entries and settings of the vault, so it has
+// to be confirmed. Vault gets locked afterwards - restored vault may have other master password.
+func RestoreBackup(window *app.Window, ops *op.Ops, backups *vaults.Backups, vaultSession *VaultSession, theme *material.Theme) error {
+	var centerWindow bool = true
+
+	type BackupsOperation struct {
+		listings []BackupListing
+		info     Information
+		restored bool
+		error    error
+	}
+
+	backupsView := BackupsView{
+		list:               &widget.List{List: layout.List{Axis: layout.Vertical}},
+		backupNowBtnWidget: new(widget.Clickable),
+		closeBtnWidget:     new(widget.Clickable),
+	}
+
+	defaultInfo := Information{"Backups are taken when vault is opened and after its entries change.", purple}
+	info := defaultInfo
+	confirmRestore := -1 // index of backup user has to press RESTORE again for
+
+	backupsChan := make(chan BackupsOperation)
+
+	// Runs action in background and lists backups afterwards, also when action failed
+	runOperation := func(action func() BackupsOperation) {
+		go func() {
+			backupsOperation := action()
+
+			if !backupsOperation.restored {
+				backupsOperation.listings, backupsOperation.error = listBackups(backups)
+			}
+
+			backupsChan <- backupsOperation
+			window.Invalidate()
+		}()
+	}
+
+	busy := true
+	runOperation(func() BackupsOperation { return BackupsOperation{info: defaultInfo} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
 
 	go func() {
-		time.Sleep(time.Second / 20)
-		window.Invalidate()
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
 		return
 	}()
 
-	{ // Window loop
-		for {
-			switch e := window.Event().(type) {
-			case app.DestroyEvent:
-				// return e.Err
-				return
+	for {
+		switch e := window.Event().(type) {
+		case app.DestroyEvent:
+			return e.Err
 
-			case app.FrameEvent:
-				gtx := app.NewContext(ops, e)
+		case app.FrameEvent:
+			gtx := app.NewContext(ops, e)
 
-				{ // Choice whether to delete password or not
-					if confirm.Clicked(gtx) {
-						err := backend.DeletePasswordEntry(serviceName)
-						if err != nil {
-							errWrapped := fmt.Errorf("Error during deletion of password: %w", err)
-							slog.Error(errWrapped.Error())
-						}
-						refreshChan <- deletePasswordEntry
-						window.Perform(system.ActionClose)
-					}
+			select {
+			case <-locked:
+				window.Perform(system.ActionClose)
+			default:
+			}
 
-					if deny.Clicked(gtx) {
-						window.Perform(system.ActionClose)
-					}
+			select {
+			case backupsOperation := <-backupsChan:
+				busy = false
+
+				if backupsOperation.error != nil {
+					return backupsOperation.error
 				}
 
-				{ // Paint
-					paint.Fill(ops, black)
-					ConfirmPasswordDeletionWidget(&gtx, theme, serviceName, &confirm, &deny)
+				// Session key may not open restored entries, user has to unlock restored vault
+				if backupsOperation.restored {
+					vaultSession.Lock()
+					break
+				}
 
-					if centerWindow {
-						window.Perform(system.ActionCenter)
-						centerWindow = !centerWindow
+				info = backupsOperation.info
+				backupsView.listings = backupsOperation.listings
+				backupsView.restoreBtnWidgets = make([]widget.Clickable, len(backupsOperation.listings))
+			default:
+			}
+
+			if backupsView.closeBtnWidget.Clicked(gtx) {
+				window.Perform(system.ActionClose)
+			}
+
+			if backupsView.backupNowBtnWidget.Clicked(gtx) && !busy {
+				busy = true
+				confirmRestore = -1
+
+				runOperation(func() BackupsOperation {
+					backup, err := backups.Take()
+
+					if err != nil {
+						return BackupsOperation{info: Information{"Could not take backup. Please check logs.", red}}
 					}
 
-					e.Frame(gtx.Ops)
+					return BackupsOperation{info: Information{"Took backup " + backup.CreatedAt.Local().Format(time.DateTime) + ".", purple}}
+				})
+			}
+
+			for i, listing := range backupsView.listings {
+				if !backupsView.restoreBtnWidgets[i].Clicked(gtx) || busy || listing.damaged {
+					continue
+				}
+
+				if confirmRestore != i {
+					confirmRestore = i
+					info = Information{"Restore replaces all entries and settings with backup " + listing.backup.CreatedAt.Local().Format(time.DateTime) + ". Current state is backed up first. Press RESTORE again to confirm.", red}
+					continue
 				}
 
+				busy = true
+				confirmRestore = -1
+
+				runOperation(func() BackupsOperation {
+					err := backups.Restore(listing.backup)
+
+					if errors.Is(err, server.DatabaseFileDamaged) {
+						return BackupsOperation{info: Information{err.Error(), red}}
+					}
+
+					if err != nil {
+						return BackupsOperation{info: Information{"Could not restore backup. Please check logs.", red}}
+					}
+
+					return BackupsOperation{restored: true}
+				})
 			}
-		}
-	}
 
-}
+			if busy {
+				LoadWidget(&gtx, theme)
+			} else {
+				BackupsWidget(&gtx, theme, &backupsView, info)
+			}
 
-type Information struct {
-	text  string
-	color color.NRGBA
+			vaultSession.trackActivity(gtx)
+
+			if centerWindow {
+				window.Perform(system.ActionCenter)
+				centerWindow = !centerWindow
+			}
+
+			e.Frame(gtx.Ops)
+		}
+	}
 }
 
-func InputNewPassword(window *app.Window, ops *op.Ops, backend *server.Backend, theme *material.Theme, refreshChan chan bool) error {
+// Lists deleted password entries and restores or purges chosen one. Purge can't be undone, so it has to be confirmed.
+func Trash(window *app.Window, ops *op.Ops, vaultSession *VaultSession, theme *material.Theme, refreshChan chan bool) error {
 	var centerWindow bool = true
-	var inserted bool = true
-
-	masterPassword := new(widget.Editor)
-	masterPassword.SingleLine = true
-	masterPassword.Mask = '*'
-	masterPassword.Filter = input_filter
 
-	serviceName := new(widget.Editor)
-	serviceName.SingleLine = true
-	serviceName.Mask = '*'
-	serviceName.Filter = input_filter + " "
+	trashView := TrashView{
+		list:           &widget.List{List: layout.List{Axis: layout.Vertical}},
+		closeBtnWidget: new(widget.Clickable),
+	}
 
-	username := new(widget.Editor)
-	username.SingleLine = true
-	username.Mask = '*'
-	username.Filter = input_filter + " "
+	defaultInfo := Information{"Deleted entries are kept here until they are purged.", purple}
+	info := defaultInfo
+	confirmPurge := int64(-1) // id of entry user has to press PURGE again for
 
-	password := new(widget.Editor)
-	password.SingleLine = true
-	password.Mask = '*'
-	password.Filter = input_filter
+	loadTrash := func() error {
+		session := vaultSession.Get()
 
-	confirmBtnWidget := new(widget.Clickable)
-	showHideWidget := new(widget.Clickable)
-	smallRandWidget := new(widget.Clickable)
-	mediumRandWidget := new(widget.Clickable)
-	bigRandWidget := new(widget.Clickable)
-	specialCharsWidget := new(widget.Clickable)
-	specialCharsText := "Special Chars: ON"
-	specialCharsColor := orange
+		if session == nil {
+			return nil
+		}
 
-	newPasswordView := NewPasswordView{
-		masterPassword:           masterPassword,
-		serviceName:              serviceName,
-		username:                 username,
-		password:                 password,
-		confirmBtnWidget:         confirmBtnWidget,
-		showHidWidget:            showHideWidget,
-		smallRandWidget:          smallRandWidget,
-		mediumRandWidget:         mediumRandWidget,
-		bigRandWidget:            bigRandWidget,
-		specialCharsSwitchWidget: specialCharsWidget,
-		specialCharsSwitchText:   specialCharsText,
-		specialCharsSwitchColor:  specialCharsColor,
-		specialCharsFlag:         true,
-		borderColor:              black,
-	}
+		trash, err := session.GetTrash()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+		if errors.Is(err, server.SessionLocked) {
+			return nil
+		}
 
-	passwordLength := ""
-	countLetterChan := make(chan int)
+		if err != nil {
+			return err
+		}
 
-	go func(ctx context.Context) {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
+		trashView.trash = trash
+		trashView.restoreBtnWidgets = make([]widget.Clickable, len(trash))
+		trashView.purgeBtnWidgets = make([]widget.Clickable, len(trash))
 
-			countLetterChan <- newPasswordView.password.Len()
-			time.Sleep(time.Second / 8)
+		if len(trash) == 0 {
+			info = Information{"Trash is empty.", purple}
 		}
-	}(ctx)
 
-	info := Information{"Provide Master Password to authenticate. Fill out form to save credentials for a service.", purple}
-	tryingToInsertPassword := false
+		return nil
+	}
 
-	type InsertPasswordEntryOperation struct {
-		error     error
-		didInsert bool
-		msg       string
+	err := loadTrash()
+
+	if err != nil {
+		return err
 	}
 
-	insertPasswordOperationChan := make(chan InsertPasswordEntryOperation)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locked := vaultSession.invalidateOnLock(ctx, window)
+
+	go func() {
+		for range 3 {
+			time.Sleep(time.Second / 20)
+			window.Invalidate()
+		}
+		return
+	}()
 
-	// Draw
 	for {
 		switch e := window.Event().(type) {
 		case app.DestroyEvent:
@@ -909,153 +3457,71 @@ func InputNewPassword(window *app.Window, ops *op.Ops, backend *server.Backend,
 			gtx := app.NewContext(ops, e)
 
 			select {
-			case insertOperation := <-insertPasswordOperationChan:
-				if insertOperation.error != nil {
-					switch err := insertOperation.error; {
-					case errors.Is(err, server.ServiceNameAlreadyTaken), errors.Is(err, server.MasterPasswordDoNotMatch):
-						info.text = insertOperation.msg
-						info.color = red
-						tryingToInsertPassword = false
-						ResizeWindowNewPasswordInsert(window)
-						window.Perform(system.ActionCenter)
-					default:
-						return err
-					}
-				}
-				if insertOperation.didInsert {
-					refreshChan <- true
-					window.Perform(system.ActionClose)
-				}
+			case <-locked:
+				window.Perform(system.ActionClose)
 			default:
 			}
 
-			if smallRandWidget.Clicked(gtx) {
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				min := 20
-				max := 25
-				len := r.Intn(max-min+1) + min
-				randomString := helpers.RandString(len, newPasswordView.specialCharsFlag)
-				newPasswordView.password.SetText(randomString)
-			}
-			if mediumRandWidget.Clicked(gtx) {
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				min := 25
-				max := 40
-				len := r.Intn(max-min+1) + min
-				randomString := helpers.RandString(len, newPasswordView.specialCharsFlag)
-				newPasswordView.password.SetText(randomString)
-			}
-			if bigRandWidget.Clicked(gtx) {
-				r := rand.New(rand.NewSource(time.Now().UnixNano()))
-				min := 40
-				max := 100
-				len := r.Intn(max-min+1) + min
-				randomString := helpers.RandString(len, newPasswordView.specialCharsFlag)
-				newPasswordView.password.SetText(randomString)
-			}
-
-			if specialCharsWidget.Clicked(gtx) {
-				if newPasswordView.specialCharsFlag == true {
-					newPasswordView.specialCharsFlag = !newPasswordView.specialCharsFlag
-					newPasswordView.specialCharsSwitchText = "Special Chars: OFF"
-					newPasswordView.specialCharsSwitchColor = grey
-				} else {
-					newPasswordView.specialCharsFlag = !newPasswordView.specialCharsFlag
-					newPasswordView.specialCharsSwitchText = "Special Chars: ON"
-					newPasswordView.specialCharsSwitchColor = orange
-				}
+			if trashView.closeBtnWidget.Clicked(gtx) {
+				window.Perform(system.ActionClose)
 			}
 
-		CheckConfirmButtonClickMarker:
-			if confirmBtnWidget.Clicked(gtx) {
-				info.text = ""
-				inputProblem := false
+			session := vaultSession.Get()
+			changed := false
 
-				if len(newPasswordView.masterPassword.Text()) == 0 {
-					info.text += "Master Password is empty. "
-					info.color = red
-					inputProblem = true
-				}
-				if len(newPasswordView.username.Text()) == 0 {
-					info.text += "Username is empty. "
-					info.color = red
-					inputProblem = true
-				}
-				if len(newPasswordView.serviceName.Text()) == 0 {
-					info.text += "Service name is empty. "
-					info.color = red
-					inputProblem = true
-				}
-				if len(newPasswordView.password.Text()) == 0 {
-					info.text += "Password is empty. "
-					info.color = red
-					inputProblem = true
-				}
+			for i, trashed := range trashView.trash {
+				if trashView.restoreBtnWidgets[i].Clicked(gtx) && session != nil {
+					confirmPurge = -1
+					err := session.RestorePasswordEntry(trashed.ID)
 
-				if inputProblem {
-					goto CheckConfirmButtonClickMarker
+					switch {
+					case errors.Is(err, server.ServiceNameAlreadyTaken):
+						info = Information{"Other entry is named " + trashed.ServiceName + " - rename or delete it first.", red}
+					case err != nil:
+						info = Information{"Could not restore entry. Please check logs.", red}
+					default:
+						info = Information{"Restored " + trashed.ServiceName + ".", purple}
+						changed = true
+					}
 				}
 
-				go func() {
-					masterPasswordMatch, err := backend.CmpMasterPassword(newPasswordView.masterPassword.Text())
+				if !trashView.purgeBtnWidgets[i].Clicked(gtx) || session == nil {
+					continue
+				}
 
-					if !masterPasswordMatch && errors.Is(err, server.MasterPasswordDoNotMatch) {
-						insertPasswordOperationChan <- InsertPasswordEntryOperation{server.MasterPasswordDoNotMatch, !inserted, "Master Password is incorrect."}
-						return
-					}
+				if confirmPurge != trashed.ID {
+					confirmPurge = trashed.ID
+					info = Information{"Purged entry " + trashed.ServiceName + " can't be restored. Press PURGE again to confirm.", red}
+					continue
+				}
 
-					err = backend.EncryptPasswordEntry(
-						newPasswordView.serviceName.Text(),
-						newPasswordView.password.Text(),
-						newPasswordView.username.Text(),
-						newPasswordView.masterPassword.Text(),
-					)
+				confirmPurge = -1
+				err := session.PurgePasswordEntry(trashed.ID)
 
-					if err != nil {
-						if errors.Is(err, server.ServiceNameAlreadyTaken) {
-							insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Service name is already taken. Choose another name."}
-						} else {
-							insertPasswordOperationChan <- InsertPasswordEntryOperation{err, !inserted, "Unspecified error occured. Check error description."}
-						}
-						return
-					}
+				if err != nil {
+					info = Information{"Could not purge entry. Please check logs.", red}
+				} else {
+					info = Information{"Purged " + trashed.ServiceName + ".", purple}
+					changed = true
+				}
+			}
 
-					insertPasswordOperationChan <- InsertPasswordEntryOperation{nil, inserted, ""}
-				}()
+			if changed {
+				err := loadTrash()
 
-				tryingToInsertPassword = true
-				ResizeWindowLoad(window)
-				window.Perform(system.ActionCenter)
-			}
+				if err != nil {
+					return err
+				}
 
-			if showHideWidget.Clicked(gtx) {
-				switch {
-				case newPasswordView.masterPassword.Mask == rune(0):
-					newPasswordView.masterPassword.Mask = '*'
-					newPasswordView.serviceName.Mask = '*'
-					newPasswordView.username.Mask = '*'
-					newPasswordView.password.Mask = '*'
+				// Main window shows restored entry
+				select {
+				case refreshChan <- true:
 				default:
-					newPasswordView.masterPassword.Mask = rune(0)
-					newPasswordView.serviceName.Mask = rune(0)
-					newPasswordView.username.Mask = rune(0)
-					newPasswordView.password.Mask = rune(0)
 				}
 			}
 
-			select {
-			case count := <-countLetterChan:
-				passwordLength = strconv.Itoa(count)
-			default:
-			}
-
-			window.Invalidate()
-
-			if tryingToInsertPassword {
-				LoadWidget(&gtx, theme)
-			} else {
-				InsertNewPasswordWidget(&gtx, theme, &newPasswordView, passwordLength, info)
-			}
+			TrashWidget(&gtx, theme, &trashView, info)
+			vaultSession.trackActivity(gtx)
 
 			if centerWindow {
 				window.Perform(system.ActionCenter)
@@ -1066,3 +3532,74 @@ func InputNewPassword(window *app.Window, ops *op.Ops, backend *server.Backend,
 		}
 	}
 }
+
+// Lists backups of the vault with number of their entries. Backup failing integrity check is listed as damaged.
+func listBackups(backups *vaults.Backups) ([]BackupListing, error) {
+	list, err := backups.List()
+
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]BackupListing, 0, len(list))
+
+	for _, backup := range list {
+		entries, err := server.InspectBackup(backup.Path)
+		listings = append(listings, BackupListing{backup: backup, entries: entries, damaged: err != nil})
+	}
+
+	return listings, nil
+}
+
+// Describes outcome of import - counts followed by one line per entry
+func importSummaryLines(summary server.ImportSummary) []string {
+	lines := []string{
+		fmt.Sprintf("Imported:    %d", summary.Imported),
+		fmt.Sprintf("Renamed:     %d", summary.Renamed),
+		fmt.Sprintf("Overwritten: %d", summary.Overwritten),
+		fmt.Sprintf("Duplicates:  %d", summary.Duplicates),
+		fmt.Sprintf("Skipped:     %d", summary.Skipped),
+		"",
+	}
+
+	for _, importedEntry := range summary.Entries {
+		line := fmt.Sprintf("%-11s %s", importedEntry.Outcome, importedEntry.ServiceName)
+
+		switch {
+		case importedEntry.Outcome == server.ImportSkipped:
+			line += " - " + importedEntry.Reason
+		case importedEntry.StoredAs != importedEntry.ServiceName:
+			line += " -> " + importedEntry.StoredAs
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// Replaces leading ~ of path with home directory of user
+func expandHomeDirectory(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	homeDirectory, err := os.UserHomeDir()
+
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(homeDirectory, path[1:])
+}
+
+// Returns option following current one, wrapping around. First option is returned when current one is not on the list.
+func nextOption[T comparable](options []T, current T) T {
+	for i, option := range options {
+		if option == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+
+	return options[0]
+}
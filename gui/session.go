@@ -0,0 +1,209 @@
+package gui
+
+import (
+	"context"
+	"image"
+	"sync"
+	"time"
+
+	server "github.com/mszalewicz/frosk/backend"
+
+	"gioui.org/app"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+)
+
+// Unlocked vault session shared by all windows. Main window is shown only while session is unlocked.
+// Session is locked after configured time without user input, on minimize of main window or with LOCK button -
+// user secret key is wiped and windows showing decrypted credentials are closed.
+type VaultSession struct {
+	mutex        sync.Mutex
+	session      *server.Session
+	locked       chan struct{} // closed when session gets locked
+	lastActivity time.Time
+
+	autoLockTimeout time.Duration // zero disables automatic lock
+	lockOnMinimize  bool
+}
+
+func NewVaultSession(autoLockTimeout time.Duration, lockOnMinimize bool) *VaultSession {
+	return &VaultSession{autoLockTimeout: autoLockTimeout, lockOnMinimize: lockOnMinimize}
+}
+
+// Returns unlocked session or nil when master password has to be provided
+func (vaultSession *VaultSession) Get() *server.Session {
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	if vaultSession.session == nil || vaultSession.session.IsLocked() {
+		return nil
+	}
+
+	return vaultSession.session
+}
+
+// Returns unlocked session, unlocking the vault with given master password when it is locked
+func (vaultSession *VaultSession) Unlock(backend *server.Backend, masterPassword string) (*server.Session, error) {
+	if session := vaultSession.Get(); session != nil {
+		return session, nil
+	}
+
+	// Key derivation takes seconds - mutex is not held, so windows can keep drawing in the meantime
+	session, err := backend.Unlock(masterPassword)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	// Other window unlocked the vault in the meantime
+	if vaultSession.session != nil && !vaultSession.session.IsLocked() {
+		session.Lock()
+		return vaultSession.session, nil
+	}
+
+	vaultSession.session = session
+	vaultSession.locked = make(chan struct{})
+	vaultSession.lastActivity = time.Now()
+
+	return session, nil
+}
+
+// Wipes user secret key of the session and notifies windows waiting for lock
+func (vaultSession *VaultSession) Lock() {
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	if vaultSession.session != nil {
+		vaultSession.session.Lock()
+		vaultSession.session = nil
+	}
+
+	if vaultSession.locked != nil {
+		close(vaultSession.locked)
+		vaultSession.locked = nil
+	}
+}
+
+// Returns channel closed when current session gets locked. Channel is already closed when vault is locked.
+func (vaultSession *VaultSession) Locked() <-chan struct{} {
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	if vaultSession.locked == nil {
+		locked := make(chan struct{})
+		close(locked)
+		return locked
+	}
+
+	return vaultSession.locked
+}
+
+// Redraws window once session gets locked, so its event loop can react. Returns channel closed on lock.
+func (vaultSession *VaultSession) invalidateOnLock(ctx context.Context, window *app.Window) <-chan struct{} {
+	locked := vaultSession.Locked()
+
+	go func() {
+		select {
+		case <-locked:
+			window.Invalidate()
+		case <-ctx.Done():
+		}
+	}()
+
+	return locked
+}
+
+// Postpones automatic lock
+func (vaultSession *VaultSession) Touch() {
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	vaultSession.lastActivity = time.Now()
+}
+
+func (vaultSession *VaultSession) AutoLockTimeout() time.Duration {
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	return vaultSession.autoLockTimeout
+}
+
+func (vaultSession *VaultSession) SetAutoLockTimeout(autoLockTimeout time.Duration) {
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	vaultSession.autoLockTimeout = autoLockTimeout
+	vaultSession.lastActivity = time.Now()
+}
+
+func (vaultSession *VaultSession) LockOnMinimize() bool {
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	return vaultSession.lockOnMinimize
+}
+
+func (vaultSession *VaultSession) SetLockOnMinimize(lockOnMinimize bool) {
+	vaultSession.mutex.Lock()
+	defer vaultSession.mutex.Unlock()
+
+	vaultSession.lockOnMinimize = lockOnMinimize
+}
+
+// Checks idle time every second and locks the vault after configured timeout. Main window is redrawn to show unlock screen.
+func (vaultSession *VaultSession) watchIdle(ctx context.Context, window *app.Window) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		vaultSession.mutex.Lock()
+		idleTimeoutReached := vaultSession.session != nil && vaultSession.autoLockTimeout > 0 && time.Since(vaultSession.lastActivity) >= vaultSession.autoLockTimeout
+		vaultSession.mutex.Unlock()
+
+		if idleTimeoutReached {
+			vaultSession.Lock()
+			window.Invalidate()
+		}
+	}
+}
+
+// Registers input area over whole window which lets events pass through to widgets below and reports pointer and key
+// events to the session as user activity. Has to be called after window content is laid out.
+func (vaultSession *VaultSession) trackActivity(gtx layout.Context) {
+	passThrough := pointer.PassOp{}.Push(gtx.Ops)
+	area := clip.Rect(image.Rectangle{Max: gtx.Constraints.Max}).Push(gtx.Ops)
+	event.Op(gtx.Ops, vaultSession)
+	area.Pop()
+	passThrough.Pop()
+
+	activity := false
+
+	for {
+		_, ok := gtx.Event(
+			pointer.Filter{Target: vaultSession, Kinds: pointer.Press | pointer.Release | pointer.Move},
+			key.Filter{Name: ""},
+		)
+
+		if !ok {
+			break
+		}
+
+		activity = true
+	}
+
+	if activity {
+		vaultSession.Touch()
+	}
+}
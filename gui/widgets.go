@@ -1,17 +1,30 @@
 package gui
 
 import (
+	"fmt"
 	"image"
 	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gioui.org/app"
 	"gioui.org/font"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+
+	server "github.com/mszalewicz/frosk/backend"
+	"github.com/mszalewicz/frosk/exporter"
+	"github.com/mszalewicz/frosk/generator"
+	"github.com/mszalewicz/frosk/importer"
+	"github.com/mszalewicz/frosk/strength"
+	"github.com/mszalewicz/frosk/vaults"
 )
 
 var (
@@ -32,7 +45,8 @@ var (
 	appName      = "Vault"
 )
 
-const input_filter = "abcdefghijklmnopqrstuvwxyz" + "ABCDEFGHIJKLMNOPQRSTUVWXYZ" + "0123456789" + "^!#$%&'~`(){}[]*+,-./:;<=>?@_|\"\\"
+const symbol_filter = "^!#$%&'~`(){}[]*+,-./:;<=>?@_|\"\\"
+const input_filter = "abcdefghijklmnopqrstuvwxyz" + "ABCDEFGHIJKLMNOPQRSTUVWXYZ" + "0123456789" + symbol_filter
 
 func ResizeWindowInfo(window *app.Window) {
 	window.Option(app.Decorated(true))
@@ -78,16 +92,21 @@ func ResizeWindowInitialSetup(window *app.Window) {
 	window.Option(app.Decorated(true))
 	window.Option(app.MinSize(unit.Dp(300), unit.Dp(300)))
 	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
-	window.Option(app.Size(unit.Dp(1_000), unit.Dp(850)))
+	window.Option(app.Size(unit.Dp(1_000), unit.Dp(950)))
 	window.Option(app.Title(appName))
 }
 
 type InitialSetup struct {
+	vaultName string
+
 	passwordInput       *widget.Editor
 	passwordInputRepeat *widget.Editor
 
 	confirmBtnWidget *widget.Clickable
 	showHidWidget    *widget.Clickable
+	vaultsBtnWidget  *widget.Clickable
+
+	strengthMeter *StrengthMeter
 
 	borderColor color.NRGBA
 }
@@ -114,6 +133,19 @@ func InitialSetupWidget(gtx *layout.Context, theme *material.Theme, initialSetup
 						)
 					},
 				),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, unit.Sp(20), "New vault: "+initialSetup.vaultName)
+								label.Font.Weight = font.Bold
+								label.Font.Typeface = "Verdana"
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
 
 				layout.Rigid(
 					func(gtx layout.Context) layout.Dimensions {
@@ -150,6 +182,14 @@ func InitialSetupWidget(gtx *layout.Context, theme *material.Theme, initialSetup
 						},
 					)
 				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return elementMargin.Layout(
+						gtx,
+						func(gtx layout.Context) layout.Dimensions {
+							return StrengthMeterWidget(gtx, theme, initialSetup.strengthMeter, unit.Sp(16))
+						},
+					)
+				}),
 				horizontalDivider(),
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 					return elementMargin.Layout(
@@ -211,6 +251,22 @@ func InitialSetupWidget(gtx *layout.Context, theme *material.Theme, initialSetup
 											)
 										},
 									),
+									layout.Rigid(
+										func(gtx layout.Context) layout.Dimensions {
+											return elementMargin.Layout(
+												gtx,
+												func(gtx layout.Context) layout.Dimensions {
+													vaultsBtn := material.Button(theme, initialSetup.vaultsBtnWidget, "other vault")
+													vaultsBtn.Background = grey_light
+													vaultsBtn.Color = black
+													vaultsBtn.TextSize = unit.Sp(20)
+													vaultsBtn.Font.Weight = font.Medium
+													vaultsBtn.Font.Typeface = "Verdana"
+													return vaultsBtn.Layout(gtx)
+												},
+											)
+										},
+									),
 								)
 							},
 						)
@@ -256,30 +312,70 @@ func ResizeWindowNewPasswordInsert(window *app.Window) {
 	window.Option(app.Decorated(true))
 	window.Option(app.MinSize(unit.Dp(500), unit.Dp(800)))
 	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
-	window.Option(app.Size(unit.Dp(750), unit.Dp(900)))
+	window.Option(app.Size(unit.Dp(750), unit.Dp(1050)))
 	window.Option(app.Title(appName))
 }
 
 type NewPasswordView struct {
+	header string
+
 	masterPassword *widget.Editor
 	password       *widget.Editor
 	serviceName    *widget.Editor
 	username       *widget.Editor
 
-	confirmBtnWidget         *widget.Clickable
-	showHidWidget            *widget.Clickable
-	smallRandWidget          *widget.Clickable
-	mediumRandWidget         *widget.Clickable
-	bigRandWidget            *widget.Clickable
-	specialCharsSwitchWidget *widget.Clickable
+	confirmBtnWidget *widget.Clickable
+	showHidWidget    *widget.Clickable
+	loadBtnWidget    *widget.Clickable // present only when editing existing entry
+
+	policyEditor  *PolicyEditor
+	strengthMeter *StrengthMeter
 
-	specialCharsSwitchText  string
-	specialCharsSwitchColor color.NRGBA
-	specialCharsFlag        bool
+	url            *widget.Editor
+	notes          *widget.Editor
+	totp           *widget.Editor
+	tags           *widget.Editor // comma separated
+	customFields   []*CustomFieldInput
+	addFieldWidget *widget.Clickable
+
+	list *widget.List
+
+	unlocked bool // vault session is unlocked - master password is not asked for
 
 	borderColor color.NRGBA
 }
 
+// Row of password form holding one custom field of the entry
+type CustomFieldInput struct {
+	name  *widget.Editor
+	value *widget.Editor
+
+	secret bool // value is masked when entry is opened
+
+	secretWidget *widget.Clickable
+	removeWidget *widget.Clickable
+}
+
+func NewCustomFieldInput(name string, value string, secret bool, mask rune) *CustomFieldInput {
+	nameInput := new(widget.Editor)
+	nameInput.SingleLine = true
+	nameInput.Filter = input_filter + " "
+	nameInput.SetText(name)
+
+	valueInput := new(widget.Editor)
+	valueInput.SingleLine = true
+	valueInput.Mask = mask
+	valueInput.SetText(value)
+
+	return &CustomFieldInput{
+		name:         nameInput,
+		value:        valueInput,
+		secret:       secret,
+		secretWidget: new(widget.Clickable),
+		removeWidget: new(widget.Clickable),
+	}
+}
+
 func DrawSearchInput(gtx layout.Context, th *material.Theme, editor *widget.Editor, height int) layout.Dimensions {
 	gtx.Constraints.Min.Y = height
 	gtx.Constraints.Max.Y = height
@@ -317,260 +413,414 @@ func DrawSearchInput(gtx layout.Context, th *material.Theme, editor *widget.Edit
 	})
 }
 
+// Row of tags above password entries list. First button shows all entries.
+type TagFilter struct {
+	tags       []string
+	allWidget  *widget.Clickable
+	tagWidgets []*widget.Clickable
+	list       *layout.List
+}
+
+func NewTagFilter(tags []string) *TagFilter {
+	tagWidgets := make([]*widget.Clickable, len(tags))
+
+	for i := range tagWidgets {
+		tagWidgets[i] = new(widget.Clickable)
+	}
+
+	return &TagFilter{tags: tags, allWidget: new(widget.Clickable), tagWidgets: tagWidgets, list: &layout.List{Axis: layout.Horizontal}}
+}
+
+// Returns tag whose button was clicked, empty tag for ALL
+func (tagFilter *TagFilter) Clicked(gtx layout.Context) (string, bool) {
+	if tagFilter.allWidget.Clicked(gtx) {
+		return "", true
+	}
+
+	for i, tagWidget := range tagFilter.tagWidgets {
+		if tagWidget.Clicked(gtx) {
+			return tagFilter.tags[i], true
+		}
+	}
+
+	return "", false
+}
+
+// Draws tag filter, nothing when no entry is tagged. Selected tag is highlighted.
+func TagFilterWidget(gtx layout.Context, theme *material.Theme, tagFilter *TagFilter, selectedTag string) layout.Dimensions {
+	if len(tagFilter.tags) == 0 {
+		return layout.Dimensions{}
+	}
+
+	tagButton := func(gtx layout.Context, clickable *widget.Clickable, text string, selected bool) layout.Dimensions {
+		return layout.Inset{Right: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			button := material.Button(theme, clickable, text)
+			button.Color = black
+			button.Background = grey_light
+			button.TextSize = unit.Sp(12)
+			button.Font.Typeface = "Verdana, monospace"
+
+			if selected {
+				button.Background = purple_light
+				button.Font.Weight = font.Bold
+			}
+
+			return button.Layout(gtx)
+		})
+	}
+
+	return layout.Inset{Top: unit.Dp(10), Left: unit.Dp(20), Right: unit.Dp(20)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return tagFilter.list.Layout(gtx, len(tagFilter.tags)+1, func(gtx layout.Context, i int) layout.Dimensions {
+			if i == 0 {
+				return tagButton(gtx, tagFilter.allWidget, "ALL", selectedTag == "")
+			}
+
+			return tagButton(gtx, tagFilter.tagWidgets[i-1], tagFilter.tags[i-1], tagFilter.tags[i-1] == selectedTag)
+		})
+	})
+}
+
 func InsertNewPasswordWidget(gtx *layout.Context, theme *material.Theme, newPasswordView *NewPasswordView, passwordLength string, info Information) {
 	elementMargin := layout.Inset{Top: unit.Dp(13), Bottom: unit.Dp(13), Right: unit.Dp(10), Left: unit.Dp(10)}
 	btnsMargin := layout.Inset{Top: unit.Dp(20), Bottom: unit.Dp(20), Right: unit.Dp(10), Left: unit.Dp(10)}
 	randomBtnsMargin := layout.Inset{Top: unit.Dp(0), Bottom: unit.Dp(0), Right: unit.Dp(10), Left: unit.Dp(10)}
 	appTextSize := unit.Sp(15)
 
+	masterPasswordDivider := horizontalDivider()
+	if newPasswordView.unlocked {
+		masterPasswordDivider = layout.Rigid(func(gtx layout.Context) layout.Dimensions { return layout.Dimensions{} })
+	}
+
 	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(60), Right: unit.Dp(60)}.Layout(
 		*gtx,
 		func(gtx layout.Context) layout.Dimensions {
-			return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
-				gtx,
-				layout.Rigid(
-					func(gtx layout.Context) layout.Dimensions {
+			// Custom fields can make the form longer than the window, so whole form scrolls
+			return material.List(theme, newPasswordView.list).Layout(gtx, 1, func(gtx layout.Context, _ int) layout.Dimensions {
+				gtx.Constraints.Min.X = gtx.Constraints.Max.X
+
+				return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+					gtx,
+					layout.Rigid(
+						func(gtx layout.Context) layout.Dimensions {
+							return elementMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceSides}.Layout(
+										gtx,
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											header := material.H3(theme, newPasswordView.header)
+											header.Font.Typeface = "Verdana, monospace"
+											return header.Layout(gtx)
+										}),
+									)
+								},
+							)
+						},
+					),
+					horizontalDivider(),
+					layout.Rigid(
+						func(gtx layout.Context) layout.Dimensions {
+							return elementMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									label := material.Label(theme, appTextSize, info.text)
+									label.Color = info.color
+									label.Font.Weight = font.Bold
+									return label.Layout(gtx)
+								},
+							)
+						},
+					),
+					horizontalDivider(),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if newPasswordView.unlocked {
+							return layout.Dimensions{}
+						}
+
 						return elementMargin.Layout(
 							gtx,
 							func(gtx layout.Context) layout.Dimensions {
-								return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceSides}.Layout(
+								return material.H6(theme, "Master Password:").Layout(gtx)
+							},
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if newPasswordView.unlocked {
+							return layout.Dimensions{}
+						}
+
+						masterPasswordInput := layout.Flexed(
+							1,
+							func(gtx layout.Context) layout.Dimensions {
+								return elementMargin.Layout(
 									gtx,
-									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-										header := material.H3(theme, "New Password")
-										header.Font.Typeface = "Verdana, monospace"
-										return header.Layout(gtx)
-									}),
+									func(gtx layout.Context) layout.Dimensions {
+										inputMasterPassword := material.Editor(theme, newPasswordView.masterPassword, "Enter master Password...")
+										inputMasterPassword.TextSize = appTextSize
+										inputMasterPassword.SelectionColor = blue
+										// border := widget.Border{Color: newPasswordView.borderColor, CornerRadius: unit.Dp(8), Width: unit.Dp(2)}
+
+										// return border.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+										return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPassword.Layout)
+										// })
+									},
 								)
 							},
 						)
-					},
-				),
-				horizontalDivider(),
-				layout.Rigid(
-					func(gtx layout.Context) layout.Dimensions {
+
+						if newPasswordView.loadBtnWidget == nil {
+							return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, masterPasswordInput)
+						}
+
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+							gtx,
+							masterPasswordInput,
+							layout.Rigid(
+								func(gtx layout.Context) layout.Dimensions {
+									return randomBtnsMargin.Layout(
+										gtx,
+										func(gtx layout.Context) layout.Dimensions {
+											loadBtn := material.Button(theme, newPasswordView.loadBtnWidget, "LOAD")
+											loadBtn.Background = blue
+											loadBtn.Color = black
+											loadBtn.TextSize = appTextSize
+											loadBtn.Font.Weight = font.Normal
+											loadBtn.Font.Typeface = "Verdana, monospace"
+
+											return loadBtn.Layout(gtx)
+										},
+									)
+								},
+							),
+						)
+					}),
+					masterPasswordDivider,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 						return elementMargin.Layout(
 							gtx,
 							func(gtx layout.Context) layout.Dimensions {
-								label := material.Label(theme, appTextSize, info.text)
-								label.Color = info.color
-								label.Font.Weight = font.Bold
-								return label.Layout(gtx)
+								return material.H6(theme, "Service Name:").Layout(gtx)
 							},
 						)
-					},
-				),
-				horizontalDivider(),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return elementMargin.Layout(
-						gtx,
-						func(gtx layout.Context) layout.Dimensions {
-							return material.H6(theme, "Master Password:").Layout(gtx)
-						},
-					)
-				}),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return elementMargin.Layout(
-						gtx,
-						func(gtx layout.Context) layout.Dimensions {
-							inputMasterPassword := material.Editor(theme, newPasswordView.masterPassword, "Enter master Password...")
-							inputMasterPassword.TextSize = appTextSize
-							inputMasterPassword.SelectionColor = blue
-							// border := widget.Border{Color: newPasswordView.borderColor, CornerRadius: unit.Dp(8), Width: unit.Dp(2)}
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								inputMasterPasswordRepeat := material.Editor(theme, newPasswordView.serviceName, "Enter name of service...")
+								inputMasterPasswordRepeat.TextSize = appTextSize
+								inputMasterPasswordRepeat.SelectionColor = blue
 
-							// return border.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-							return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPassword.Layout)
-							// })
-						},
-					)
-				}),
-				horizontalDivider(),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return elementMargin.Layout(
-						gtx,
-						func(gtx layout.Context) layout.Dimensions {
-							return material.H6(theme, "Service Name:").Layout(gtx)
-						},
-					)
-				}),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return elementMargin.Layout(
-						gtx,
-						func(gtx layout.Context) layout.Dimensions {
-							inputMasterPasswordRepeat := material.Editor(theme, newPasswordView.serviceName, "Enter name of service...")
-							inputMasterPasswordRepeat.TextSize = appTextSize
-							inputMasterPasswordRepeat.SelectionColor = blue
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPasswordRepeat.Layout)
+							},
+						)
+					}),
+					horizontalDivider(),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return material.H6(theme, "Username:").Layout(gtx)
+							},
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								inputMasterPasswordRepeat := material.Editor(theme, newPasswordView.username, "Enter username...")
+								inputMasterPasswordRepeat.TextSize = appTextSize
+								inputMasterPasswordRepeat.SelectionColor = blue
 
-							return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPasswordRepeat.Layout)
-						},
-					)
-				}),
-				horizontalDivider(),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return elementMargin.Layout(
-						gtx,
-						func(gtx layout.Context) layout.Dimensions {
-							return material.H6(theme, "Username:").Layout(gtx)
-						},
-					)
-				}),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return elementMargin.Layout(
-						gtx,
-						func(gtx layout.Context) layout.Dimensions {
-							inputMasterPasswordRepeat := material.Editor(theme, newPasswordView.username, "Enter username...")
-							inputMasterPasswordRepeat.TextSize = appTextSize
-							inputMasterPasswordRepeat.SelectionColor = blue
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPasswordRepeat.Layout)
+							},
+						)
+					}),
+					horizontalDivider(),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return material.H6(theme, "Password ["+passwordLength+"]").Layout(gtx)
+							},
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								inputMasterPasswordRepeat := material.Editor(theme, newPasswordView.password, "Enter or generate random password...")
+								inputMasterPasswordRepeat.TextSize = appTextSize
+								inputMasterPasswordRepeat.SelectionColor = blue
 
-							return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPasswordRepeat.Layout)
-						},
-					)
-				}),
-				horizontalDivider(),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return elementMargin.Layout(
-						gtx,
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPasswordRepeat.Layout)
+							},
+						)
+					}),
+					layout.Rigid(
 						func(gtx layout.Context) layout.Dimensions {
-							return material.H6(theme, "Password ["+passwordLength+"]").Layout(gtx)
+							return randomBtnsMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									return StrengthMeterWidget(gtx, theme, newPasswordView.strengthMeter, appTextSize-2)
+								},
+							)
 						},
-					)
-				}),
-				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return elementMargin.Layout(
-						gtx,
+					),
+					layout.Rigid(
 						func(gtx layout.Context) layout.Dimensions {
-							inputMasterPasswordRepeat := material.Editor(theme, newPasswordView.password, "Enter or generate random password...")
-							inputMasterPasswordRepeat.TextSize = appTextSize
-							inputMasterPasswordRepeat.SelectionColor = blue
-
-							return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPasswordRepeat.Layout)
+							return randomBtnsMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									return PolicyEditorWidget(gtx, theme, newPasswordView.policyEditor, appTextSize-3)
+								},
+							)
 						},
-					)
-				}),
-				layout.Rigid(
-					func(gtx layout.Context) layout.Dimensions {
-						return randomBtnsMargin.Layout(
+					),
+					horizontalDivider(),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
 							gtx,
 							func(gtx layout.Context) layout.Dimensions {
-								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.End, Spacing: layout.SpaceAround}.Layout(
-									gtx,
-									layout.Flexed(1,
-										func(gtx layout.Context) layout.Dimensions {
-											return randomBtnsMargin.Layout(
-												gtx,
-												func(gtx layout.Context) layout.Dimensions {
-													confirmBtn := material.Button(theme, newPasswordView.smallRandWidget, "20 - 25")
-													confirmBtn.Background = grey_light
-													confirmBtn.Color = black
-													confirmBtn.TextSize = appTextSize - 5
-													confirmBtn.Font.Weight = font.Bold
+								return material.H6(theme, "URL:").Layout(gtx)
+							},
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								inputURL := material.Editor(theme, newPasswordView.url, "Enter website address...")
+								inputURL.TextSize = appTextSize
+								inputURL.SelectionColor = blue
 
-													return layout.UniformInset(unit.Dp(0)).Layout(gtx, confirmBtn.Layout)
-												},
-											)
-										},
-									),
-									layout.Flexed(1,
-										func(gtx layout.Context) layout.Dimensions {
-											return randomBtnsMargin.Layout(
-												gtx,
-												func(gtx layout.Context) layout.Dimensions {
-													confirmBtn := material.Button(theme, newPasswordView.mediumRandWidget, "25 - 40")
-													confirmBtn.Background = grey_light
-													confirmBtn.Color = black
-													confirmBtn.TextSize = appTextSize - 5
-													confirmBtn.Font.Weight = font.Bold
-
-													return layout.UniformInset(unit.Dp(0)).Layout(gtx, confirmBtn.Layout)
-												},
-											)
-										},
-									),
-									layout.Flexed(1,
-										func(gtx layout.Context) layout.Dimensions {
-											return randomBtnsMargin.Layout(
-												gtx,
-												func(gtx layout.Context) layout.Dimensions {
-													confirmBtn := material.Button(theme, newPasswordView.bigRandWidget, "40-100")
-													confirmBtn.Background = grey_light
-													confirmBtn.Color = black
-													confirmBtn.TextSize = appTextSize - 5
-													confirmBtn.Font.Weight = font.Bold
-
-													return layout.UniformInset(unit.Dp(0)).Layout(gtx, confirmBtn.Layout)
-												},
-											)
-										},
-									),
-									layout.Flexed(1,
-										func(gtx layout.Context) layout.Dimensions {
-											return randomBtnsMargin.Layout(
-												gtx,
-												func(gtx layout.Context) layout.Dimensions {
-													confirmBtn := material.Button(theme, newPasswordView.specialCharsSwitchWidget, newPasswordView.specialCharsSwitchText)
-													confirmBtn.Background = newPasswordView.specialCharsSwitchColor
-													confirmBtn.Color = black
-													confirmBtn.TextSize = appTextSize - 5
-													confirmBtn.Font.Weight = font.Bold
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputURL.Layout)
+							},
+						)
+					}),
+					horizontalDivider(),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return material.H6(theme, "Two-factor secret:").Layout(gtx)
+							},
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								inputTOTP := material.Editor(theme, newPasswordView.totp, "Enter otpauth://totp/ URI or base32 key...")
+								inputTOTP.TextSize = appTextSize
+								inputTOTP.SelectionColor = blue
 
-													return layout.UniformInset(unit.Dp(0)).Layout(gtx, confirmBtn.Layout)
-												},
-											)
-										},
-									),
-								)
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputTOTP.Layout)
 							},
 						)
-					},
-				),
-				emptyDivider(),
-				horizontalDivider(),
-				layout.Rigid(
-					func(gtx layout.Context) layout.Dimensions {
-						return btnsMargin.Layout(
+					}),
+					horizontalDivider(),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
 							gtx,
 							func(gtx layout.Context) layout.Dimensions {
-								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
-									gtx,
-									layout.Rigid(
-										func(gtx layout.Context) layout.Dimensions {
-											return elementMargin.Layout(
-												gtx,
-												func(gtx layout.Context) layout.Dimensions {
-													confirmBtn := material.Button(theme, newPasswordView.confirmBtnWidget, "            SAVE            ")
-													confirmBtn.Background = purple_light
-													confirmBtn.TextSize = appTextSize
-													confirmBtn.Font.Weight = font.Normal
-													confirmBtn.Color = black
-													confirmBtn.Font.Typeface = "Verdana, monospace"
+								return material.H6(theme, "Notes:").Layout(gtx)
+							},
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								inputNotes := material.Editor(theme, newPasswordView.notes, "Enter notes...")
+								inputNotes.TextSize = appTextSize
+								inputNotes.SelectionColor = blue
+								gtx.Constraints.Min.Y = gtx.Dp(unit.Dp(60))
 
-													return layout.UniformInset(unit.Dp(0)).Layout(gtx, confirmBtn.Layout)
-												},
-											)
-										},
-									),
-									layout.Rigid(
-										func(gtx layout.Context) layout.Dimensions {
-											return elementMargin.Layout(
-												gtx,
-												func(gtx layout.Context) layout.Dimensions {
-													confirmBtn := material.Button(theme, newPasswordView.showHidWidget, "SHOW/HIDE")
-													confirmBtn.Background = grey_light
-													confirmBtn.TextSize = appTextSize
-													confirmBtn.Font.Weight = font.Normal
-													confirmBtn.Color = black
-													confirmBtn.Font.Typeface = "Verdana, monospace"
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputNotes.Layout)
+							},
+						)
+					}),
+					horizontalDivider(),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return material.H6(theme, "Tags:").Layout(gtx)
+							},
+						)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								inputTags := material.Editor(theme, newPasswordView.tags, "Enter tags separated by commas, nest with / like work/servers...")
+								inputTags.TextSize = appTextSize
+								inputTags.SelectionColor = blue
 
-													return confirmBtn.Layout(gtx)
-												},
-											)
-										},
-									),
-								)
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputTags.Layout)
 							},
 						)
-					},
-				),
-			)
+					}),
+					horizontalDivider(),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return CustomFieldInputsWidget(gtx, theme, newPasswordView, appTextSize)
+							},
+						)
+					}),
+					emptyDivider(),
+					horizontalDivider(),
+					layout.Rigid(
+						func(gtx layout.Context) layout.Dimensions {
+							return btnsMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+										gtx,
+										layout.Rigid(
+											func(gtx layout.Context) layout.Dimensions {
+												return elementMargin.Layout(
+													gtx,
+													func(gtx layout.Context) layout.Dimensions {
+														confirmBtn := material.Button(theme, newPasswordView.confirmBtnWidget, "            SAVE            ")
+														confirmBtn.Background = purple_light
+														confirmBtn.TextSize = appTextSize
+														confirmBtn.Font.Weight = font.Normal
+														confirmBtn.Color = black
+														confirmBtn.Font.Typeface = "Verdana, monospace"
+
+														return layout.UniformInset(unit.Dp(0)).Layout(gtx, confirmBtn.Layout)
+													},
+												)
+											},
+										),
+										layout.Rigid(
+											func(gtx layout.Context) layout.Dimensions {
+												return elementMargin.Layout(
+													gtx,
+													func(gtx layout.Context) layout.Dimensions {
+														confirmBtn := material.Button(theme, newPasswordView.showHidWidget, "SHOW/HIDE")
+														confirmBtn.Background = grey_light
+														confirmBtn.TextSize = appTextSize
+														confirmBtn.Font.Weight = font.Normal
+														confirmBtn.Color = black
+														confirmBtn.Font.Typeface = "Verdana, monospace"
+
+														return confirmBtn.Layout(gtx)
+													},
+												)
+											},
+										),
+									)
+								},
+							)
+						},
+					),
+				)
+			})
 		},
 	)
 }
@@ -589,7 +839,7 @@ func ConfirmPasswordDeletionWidget(gtx *layout.Context, theme *material.Theme, s
 				return labelMargin.Layout(
 					gtx,
 					func(gtx layout.Context) layout.Dimensions {
-						label := material.Label(theme, textSize, "Do you want to delete all information about service "+serviceName+"? This action can't be reversed.")
+						label := material.Label(theme, textSize, "Do you want to move service "+serviceName+" to trash? It can be restored from Settings until trash gets purged.")
 						label.Color = red
 						label.Font.Typeface = "Verdana, monospace"
 						return label.Layout(gtx)
@@ -640,21 +890,112 @@ func ConfirmPasswordDeletionWidget(gtx *layout.Context, theme *material.Theme, s
 	)
 }
 
+func ResizeWindowIntegrityWarning(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(600), unit.Dp(450)))
+	window.Option(app.MaxSize(unit.Dp(600), unit.Dp(450)))
+	window.Option(app.Size(unit.Dp(600), unit.Dp(450)))
+	window.Option(app.Title(appName))
+}
+
+type IntegrityWarningView struct {
+	warning         string
+	acceptBtnWidget *widget.Clickable
+	laterBtnWidget  *widget.Clickable
+}
+
+func IntegrityWarningWidget(gtx *layout.Context, theme *material.Theme, integrityWarningView *IntegrityWarningView, info Information) {
+	var (
+		textSize    unit.Sp      = 20
+		btnMargin   layout.Inset = layout.Inset{Top: unit.Dp(20), Bottom: unit.Dp(20), Right: unit.Dp(25), Left: unit.Dp(25)}
+		labelMargin layout.Inset = layout.Inset{Top: unit.Dp(25), Bottom: unit.Dp(10), Right: unit.Dp(25), Left: unit.Dp(25)}
+	)
+
+	layout.Flex{Axis: layout.Vertical, Spacing: layout.SpaceSides}.Layout(
+		*gtx,
+		layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return labelMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						label := material.Label(theme, textSize, integrityWarningView.warning)
+						label.Color = red
+						label.Font.Typeface = "Verdana, monospace"
+						return label.Layout(gtx)
+					},
+				)
+			},
+		),
+		layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return labelMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						label := material.Label(theme, unit.Sp(16), info.text)
+						label.Color = info.color
+						label.Font.Typeface = "Verdana, monospace"
+						return label.Layout(gtx)
+					},
+				)
+			},
+		),
+		layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal}.Layout(
+					gtx,
+					layout.Flexed(1,
+						func(gtx layout.Context) layout.Dimensions {
+							return btnMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									acceptBtn := material.Button(theme, integrityWarningView.acceptBtnWidget, "ACCEPT")
+									acceptBtn.Font.Weight = font.Bold
+									acceptBtn.Background = red
+									acceptBtn.Color = black
+									acceptBtn.Font.Typeface = "Verdana, monospace"
+
+									return acceptBtn.Layout(gtx)
+								},
+							)
+						},
+					),
+					layout.Flexed(1,
+						func(gtx layout.Context) layout.Dimensions {
+							return btnMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									laterBtn := material.Button(theme, integrityWarningView.laterBtnWidget, "LATER")
+									laterBtn.Font.Weight = font.Bold
+									laterBtn.Background = grey_light
+									laterBtn.Color = black
+									laterBtn.Font.Typeface = "Verdana, monospace"
+
+									return laterBtn.Layout(gtx)
+								},
+							)
+						},
+					),
+				)
+			},
+		),
+	)
+}
+
 func ResizeDecryptionWindow(window *app.Window) {
 	var (
 		maxW unit.Dp = 850
-		maxH unit.Dp = 800
+		maxH unit.Dp = 1000
 	)
 	window.Option(app.Decorated(true))
-	window.Option(app.MinSize(unit.Dp(maxW), unit.Dp(maxH)))
-	window.Option(app.MaxSize(unit.Dp(maxW*2), unit.Dp(maxH)))
+	window.Option(app.MinSize(unit.Dp(maxW), unit.Dp(800)))
+	window.Option(app.MaxSize(unit.Dp(maxW*2), unit.Dp(maxH*2)))
 	window.Option(app.Size(unit.Dp(maxW), unit.Dp(maxH)))
 	window.Option(app.Title(appName))
 
 	return
 }
 
-func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme, serviceName *string, textCheckMsg *string, authenticate *widget.Clickable, cancel *widget.Clickable, showHideUsername *widget.Clickable, showHidePassword *widget.Clickable, masterPasswordGUI *widget.Editor, usernameGUI *widget.Editor, passwordGUI *widget.Editor, passwordEditorBackgroundColor *color.NRGBA) {
+func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme, serviceName *string, textCheckMsg *string, authenticate *widget.Clickable, cancel *widget.Clickable, showHideUsername *widget.Clickable, showHidePassword *widget.Clickable, copyUsername *widget.Clickable, copyPassword *widget.Clickable, masterPasswordGUI *widget.Editor, usernameGUI *widget.Editor, passwordGUI *widget.Editor, passwordEditorBackgroundColor *color.NRGBA, entryDetails *EntryDetails) {
 	var (
 		appTextSize       unit.Sp      = 20
 		btnMargin         layout.Inset = layout.Inset{Top: unit.Dp(20), Bottom: unit.Dp(20), Right: unit.Dp(25), Left: unit.Dp(25)}
@@ -662,6 +1003,35 @@ func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme,
 		elementMargin     layout.Inset = layout.Inset{Top: unit.Dp(10), Bottom: unit.Dp(10), Right: unit.Dp(20), Left: unit.Dp(20)}
 	)
 
+	copyButton := func(clickable *widget.Clickable) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return showHideBtnMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						copyBtn := material.Button(theme, clickable, "copy")
+						copyBtn.Inset = layout.Inset{Top: unit.Dp(12), Bottom: unit.Dp(12), Left: unit.Dp(23), Right: unit.Dp(23)}
+						copyBtn.TextSize = appTextSize
+						copyBtn.Background = blue
+						copyBtn.Font.Typeface = "Verdana, monospace"
+						copyBtn.Font.Weight = font.Normal
+						copyBtn.Color = black
+
+						return copyBtn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	// Master password is not asked for when vault session is already unlocked
+	masterPasswordRequired := masterPasswordGUI != nil
+
+	masterPasswordDivider := horizontalDivider()
+	if !masterPasswordRequired {
+		masterPasswordDivider = layout.Rigid(func(gtx layout.Context) layout.Dimensions { return layout.Dimensions{} })
+	}
+
 	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(60), Right: unit.Dp(60)}.Layout(
 		*gtx,
 		func(gtx layout.Context) layout.Dimensions {
@@ -689,6 +1059,10 @@ func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme,
 				horizontalDivider(),
 				layout.Rigid(
 					func(gtx layout.Context) layout.Dimensions {
+						if !masterPasswordRequired {
+							return layout.Dimensions{}
+						}
+
 						return elementMargin.Layout(
 							gtx,
 							func(gtx layout.Context) layout.Dimensions {
@@ -706,6 +1080,10 @@ func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme,
 				),
 				layout.Rigid(
 					func(gtx layout.Context) layout.Dimensions {
+						if !masterPasswordRequired {
+							return layout.Dimensions{}
+						}
+
 						return elementMargin.Layout(
 							gtx,
 							func(gtx layout.Context) layout.Dimensions {
@@ -719,7 +1097,7 @@ func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme,
 						)
 					},
 				),
-				horizontalDivider(),
+				masterPasswordDivider,
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 					return elementMargin.Layout(
 						gtx,
@@ -767,6 +1145,7 @@ func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme,
 									)
 								},
 							),
+							copyButton(copyUsername),
 						)
 					},
 				),
@@ -820,16 +1199,27 @@ func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme,
 									)
 								},
 							),
+							copyButton(copyPassword),
 						)
 					},
 				),
 				horizontalDivider(),
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return EntryDetailsWidget(gtx, theme, entryDetails, appTextSize, copyButton)
+					},
+				),
 				layout.Rigid(
 					func(gtx layout.Context) layout.Dimensions {
 						return layout.Flex{Axis: layout.Horizontal}.Layout(
 							gtx,
 							layout.Flexed(1,
 								func(gtx layout.Context) layout.Dimensions {
+									if !masterPasswordRequired {
+										return layout.Dimensions{}
+									}
+
 									return btnMargin.Layout(
 										gtx,
 										func(gtx layout.Context) layout.Dimensions {
@@ -870,3 +1260,2096 @@ func ManagePasswordDecryptionWidget(gtx *layout.Context, theme *material.Theme,
 		},
 	)
 }
+
+// URL, notes and custom fields of decrypted entry shown under its password
+type EntryDetails struct {
+	url          *widget.Editor
+	notes        *widget.Editor
+	copyURL      *widget.Clickable
+	customFields []*CustomFieldDetails
+
+	passwordHistory []*CustomFieldDetails // replaced passwords, shown like secret custom fields named after time of replacement
+
+	totp     *server.TOTP // nil when entry has no two-factor secret
+	copyTOTP *widget.Clickable
+
+	list *widget.List
+}
+
+type CustomFieldDetails struct {
+	name   string
+	value  *widget.Editor
+	secret bool
+
+	showHideWidget *widget.Clickable
+	copyWidget     *widget.Clickable
+}
+
+func NewEntryDetails() *EntryDetails {
+	url := new(widget.Editor)
+	url.SingleLine = true
+	url.ReadOnly = true
+
+	notes := new(widget.Editor)
+	notes.ReadOnly = true
+
+	return &EntryDetails{
+		url:      url,
+		notes:    notes,
+		copyURL:  new(widget.Clickable),
+		copyTOTP: new(widget.Clickable),
+		list:     &widget.List{List: layout.List{Axis: layout.Vertical}},
+	}
+}
+
+func NewCustomFieldDetails(name string, value string, secret bool) *CustomFieldDetails {
+	valueGUI := new(widget.Editor)
+	valueGUI.SingleLine = true
+	valueGUI.ReadOnly = true
+	valueGUI.SetText(value)
+
+	if secret {
+		valueGUI.Mask = '*'
+	}
+
+	return &CustomFieldDetails{
+		name:           name,
+		value:          valueGUI,
+		secret:         secret,
+		showHideWidget: new(widget.Clickable),
+		copyWidget:     new(widget.Clickable),
+	}
+}
+
+// Draws entry details which are present - nothing is drawn before entry gets decrypted. Details scroll when they don't fit the window.
+func EntryDetailsWidget(gtx layout.Context, theme *material.Theme, entryDetails *EntryDetails, textSize unit.Sp, copyButton func(*widget.Clickable) layout.FlexChild) layout.Dimensions {
+	elementMargin := layout.Inset{Top: unit.Dp(10), Bottom: unit.Dp(10), Right: unit.Dp(20), Left: unit.Dp(20)}
+	showHideBtnMargin := layout.Inset{Top: unit.Dp(10), Bottom: unit.Dp(10), Right: unit.Dp(20), Left: unit.Dp(0)}
+
+	heading := func(text string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return elementMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					return material.H6(theme, text).Layout(gtx)
+				},
+			)
+		})
+	}
+
+	value := func(editor *widget.Editor, buttons ...layout.FlexChild) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				children := []layout.FlexChild{
+					layout.Flexed(
+						1,
+						func(gtx layout.Context) layout.Dimensions {
+							return elementMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									valueEditor := material.Editor(theme, editor, "")
+									valueEditor.TextSize = textSize
+									valueEditor.SelectionColor = blue
+									valueEditor.Font.Typeface = "Verdana, monospace"
+									valueEditor.Font.Weight = font.Medium
+
+									return layout.UniformInset(unit.Dp(10)).Layout(gtx, valueEditor.Layout)
+								},
+							)
+						},
+					),
+				}
+
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, append(children, buttons...)...)
+			},
+		)
+	}
+
+	showHideButton := func(clickable *widget.Clickable) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return showHideBtnMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						showHideBtn := material.Button(theme, clickable, "show")
+						showHideBtn.Inset = layout.Inset{Top: unit.Dp(12), Bottom: unit.Dp(12), Left: unit.Dp(23), Right: unit.Dp(23)}
+						showHideBtn.TextSize = textSize
+						showHideBtn.Background = grey_light
+						showHideBtn.Font.Typeface = "Verdana, monospace"
+						showHideBtn.Font.Weight = font.Normal
+						showHideBtn.Color = black
+
+						return showHideBtn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	rows := []layout.FlexChild{}
+
+	if entryDetails.totp != nil {
+		rows = append(rows, heading("Two-factor code"), totpCode(gtx, theme, entryDetails, textSize, copyButton), horizontalDivider())
+	}
+
+	if entryDetails.url.Len() > 0 {
+		rows = append(rows, heading("URL"), value(entryDetails.url, copyButton(entryDetails.copyURL)), horizontalDivider())
+	}
+
+	if entryDetails.notes.Len() > 0 {
+		rows = append(rows, heading("Notes"), value(entryDetails.notes), horizontalDivider())
+	}
+
+	for _, customField := range entryDetails.customFields {
+		buttons := []layout.FlexChild{}
+
+		if customField.secret {
+			buttons = append(buttons, showHideButton(customField.showHideWidget))
+		}
+
+		rows = append(rows, heading(customField.name), value(customField.value, append(buttons, copyButton(customField.copyWidget))...), horizontalDivider())
+	}
+
+	if len(entryDetails.passwordHistory) > 0 {
+		rows = append(rows, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return elementMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					return material.H5(theme, "Password history").Layout(gtx)
+				},
+			)
+		}))
+	}
+
+	for _, historyEntry := range entryDetails.passwordHistory {
+		rows = append(rows, heading(historyEntry.name), value(historyEntry.value, showHideButton(historyEntry.showHideWidget), copyButton(historyEntry.copyWidget)), horizontalDivider())
+	}
+
+	if len(rows) == 0 {
+		return layout.Dimensions{Size: gtx.Constraints.Min}
+	}
+
+	return material.List(theme, entryDetails.list).Layout(gtx, 1, func(gtx layout.Context, _ int) layout.Dimensions {
+		gtx.Constraints.Min.X = gtx.Constraints.Max.X
+
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
+	})
+}
+
+// Current two-factor code with time left until it changes. Window is redrawn every second to keep both up to date.
+func totpCode(gtx layout.Context, theme *material.Theme, entryDetails *EntryDetails, textSize unit.Sp, copyButton func(*widget.Clickable) layout.FlexChild) layout.FlexChild {
+	elementMargin := layout.Inset{Top: unit.Dp(10), Bottom: unit.Dp(10), Right: unit.Dp(20), Left: unit.Dp(20)}
+
+	code := entryDetails.totp.Code(gtx.Now)
+	remaining := entryDetails.totp.Remaining(gtx.Now)
+
+	gtx.Execute(op.InvalidateCmd{At: gtx.Now.Truncate(time.Second).Add(time.Second)})
+
+	// Digits are grouped for readability, e.g. "123 456"
+	grouped := code[:len(code)/2] + " " + code[len(code)/2:]
+
+	return layout.Rigid(
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+				gtx,
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return layout.Flex{Axis: layout.Vertical}.Layout(
+									gtx,
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										codeLabel := material.H4(theme, grouped)
+										codeLabel.Font.Typeface = "Verdana, monospace"
+										codeLabel.Color = black
+
+										return codeLabel.Layout(gtx)
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										seconds := int(remaining.Round(time.Second) / time.Second)
+
+										countdown := material.Label(theme, textSize-4, fmt.Sprintf("changes in %ds", seconds))
+										countdown.Color = charcoal2
+										if remaining <= 5*time.Second {
+											countdown.Color = red
+										}
+
+										return countdown.Layout(gtx)
+									}),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+											size := image.Pt(gtx.Constraints.Max.X, gtx.Dp(unit.Dp(4)))
+											left := int(float64(size.X) * remaining.Seconds() / entryDetails.totp.Period.Seconds())
+
+											paint.FillShape(gtx.Ops, grey_light, clip.Rect{Max: size}.Op())
+											paint.FillShape(gtx.Ops, blue, clip.Rect{Max: image.Pt(left, size.Y)}.Op())
+
+											return layout.Dimensions{Size: size}
+										})
+									}),
+								)
+							},
+						)
+					},
+				),
+				copyButton(entryDetails.copyTOTP),
+			)
+		},
+	)
+}
+
+func ResizeWindowChangeMasterPassword(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(500), unit.Dp(800)))
+	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
+	window.Option(app.Size(unit.Dp(750), unit.Dp(1050)))
+	window.Option(app.Title(appName))
+}
+
+type ChangeMasterPasswordView struct {
+	currentMasterPassword   *widget.Editor
+	newMasterPassword       *widget.Editor
+	newMasterPasswordRepeat *widget.Editor
+
+	confirmBtnWidget *widget.Clickable
+	cancelBtnWidget  *widget.Clickable
+	showHidWidget    *widget.Clickable
+	rehashBtnWidget  *widget.Clickable
+	unlockTimeWidget *widget.Clickable
+
+	strengthMeter *StrengthMeter
+
+	unlockTime time.Duration // target time of single key derivation used when rehashing
+	kdfInfo    string
+}
+
+func ChangeMasterPasswordWidget(gtx *layout.Context, theme *material.Theme, changeMasterPasswordView *ChangeMasterPasswordView, info Information) {
+	elementMargin := layout.Inset{Top: unit.Dp(13), Bottom: unit.Dp(13), Right: unit.Dp(10), Left: unit.Dp(10)}
+	btnsMargin := layout.Inset{Top: unit.Dp(20), Bottom: unit.Dp(20), Right: unit.Dp(10), Left: unit.Dp(10)}
+	appTextSize := unit.Sp(15)
+
+	heading := func(text string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return elementMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					return material.H6(theme, text).Layout(gtx)
+				},
+			)
+		})
+	}
+
+	input := func(editor *widget.Editor, hint string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return elementMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					inputPassword := material.Editor(theme, editor, hint)
+					inputPassword.TextSize = appTextSize
+					inputPassword.SelectionColor = blue
+
+					return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputPassword.Layout)
+				},
+			)
+		})
+	}
+
+	button := func(clickable *widget.Clickable, text string, background color.NRGBA) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return elementMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(theme, clickable, text)
+						btn.Background = background
+						btn.TextSize = appTextSize
+						btn.Font.Weight = font.Normal
+						btn.Color = black
+						btn.Font.Typeface = "Verdana, monospace"
+
+						return btn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(60), Right: unit.Dp(60)}.Layout(
+		*gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+				gtx,
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								header := material.H3(theme, "Master Password")
+								header.Font.Typeface = "Verdana, monospace"
+								return header.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, info.text)
+								label.Color = info.color
+								label.Font.Weight = font.Bold
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				heading("Current Master Password:"),
+				input(changeMasterPasswordView.currentMasterPassword, "Enter current master password..."),
+				horizontalDivider(),
+				heading("New Master Password:"),
+				input(changeMasterPasswordView.newMasterPassword, "Enter new master password..."),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return elementMargin.Layout(
+						gtx,
+						func(gtx layout.Context) layout.Dimensions {
+							return StrengthMeterWidget(gtx, theme, changeMasterPasswordView.strengthMeter, appTextSize-2)
+						},
+					)
+				}),
+				horizontalDivider(),
+				heading("Repeat New Master Password:"),
+				input(changeMasterPasswordView.newMasterPasswordRepeat, "Repeat new master password..."),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return btnsMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+									gtx,
+									button(changeMasterPasswordView.confirmBtnWidget, "CHANGE", purple_light),
+									button(changeMasterPasswordView.showHidWidget, "SHOW/HIDE", grey_light),
+									button(changeMasterPasswordView.cancelBtnWidget, "CANCEL", grey_light),
+								)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, "Key derivation: "+changeMasterPasswordView.kdfInfo)
+								label.Color = charcoal2
+								label.Font.Typeface = "Verdana, monospace"
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+							gtx,
+							button(changeMasterPasswordView.unlockTimeWidget, "Unlock time: "+changeMasterPasswordView.unlockTime.String(), grey_light),
+							button(changeMasterPasswordView.rehashBtnWidget, "REHASH", blue),
+						)
+					},
+				),
+			)
+		},
+	)
+}
+
+func ResizeWindowUnlock(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(300), unit.Dp(300)))
+	window.Option(app.Size(unit.Dp(500), unit.Dp(800)))
+	window.Option(app.Title(appName))
+}
+
+type UnlockView struct {
+	vaultName string
+
+	masterPassword *widget.Editor
+
+	unlockBtnWidget *widget.Clickable
+	showHidWidget   *widget.Clickable
+	vaultsBtnWidget *widget.Clickable
+}
+
+func UnlockWidget(gtx *layout.Context, theme *material.Theme, unlockView *UnlockView, info Information) {
+	elementMargin := layout.Inset{Top: unit.Dp(13), Bottom: unit.Dp(13), Right: unit.Dp(10), Left: unit.Dp(10)}
+	appTextSize := unit.Sp(18)
+
+	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(30), Right: unit.Dp(30)}.Layout(
+		*gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+				gtx,
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								header := material.H3(theme, "Vault Locked")
+								header.Font.Typeface = "Verdana, monospace"
+								return header.Layout(gtx)
+							},
+						)
+					},
+				),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.H6(theme, unlockView.vaultName)
+								label.Font.Typeface = "Verdana, monospace"
+								label.MaxLines = 1
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, info.text)
+								label.Color = info.color
+								label.Font.Weight = font.Bold
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								inputMasterPassword := material.Editor(theme, unlockView.masterPassword, "Enter master password...")
+								inputMasterPassword.TextSize = appTextSize
+								inputMasterPassword.SelectionColor = blue
+
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputMasterPassword.Layout)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+									gtx,
+									layout.Rigid(
+										func(gtx layout.Context) layout.Dimensions {
+											return elementMargin.Layout(
+												gtx,
+												func(gtx layout.Context) layout.Dimensions {
+													unlockBtn := material.Button(theme, unlockView.unlockBtnWidget, "UNLOCK")
+													unlockBtn.Background = blue
+													unlockBtn.Color = black
+													unlockBtn.TextSize = appTextSize
+													unlockBtn.Font.Weight = font.Medium
+													unlockBtn.Font.Typeface = "Verdana, monospace"
+													return unlockBtn.Layout(gtx)
+												},
+											)
+										},
+									),
+									layout.Rigid(
+										func(gtx layout.Context) layout.Dimensions {
+											return elementMargin.Layout(
+												gtx,
+												func(gtx layout.Context) layout.Dimensions {
+													showHideBtn := material.Button(theme, unlockView.showHidWidget, "show/hide")
+													showHideBtn.Background = grey_light
+													showHideBtn.Color = black
+													showHideBtn.TextSize = appTextSize
+													showHideBtn.Font.Weight = font.Medium
+													showHideBtn.Font.Typeface = "Verdana, monospace"
+													return showHideBtn.Layout(gtx)
+												},
+											)
+										},
+									),
+									layout.Rigid(
+										func(gtx layout.Context) layout.Dimensions {
+											return elementMargin.Layout(
+												gtx,
+												func(gtx layout.Context) layout.Dimensions {
+													vaultsBtn := material.Button(theme, unlockView.vaultsBtnWidget, "VAULTS")
+													vaultsBtn.Background = grey_light
+													vaultsBtn.Color = black
+													vaultsBtn.TextSize = appTextSize
+													vaultsBtn.Font.Weight = font.Medium
+													vaultsBtn.Font.Typeface = "Verdana, monospace"
+													return vaultsBtn.Layout(gtx)
+												},
+											)
+										},
+									),
+								)
+							},
+						)
+					},
+				),
+			)
+		},
+	)
+}
+
+func ResizeWindowSettings(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(500), unit.Dp(500)))
+	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
+	window.Option(app.Size(unit.Dp(650), unit.Dp(1060)))
+	window.Option(app.Title(appName))
+}
+
+type SettingsView struct {
+	autoLockWidget        *widget.Clickable
+	lockOnMinimizeWidget  *widget.Clickable
+	clipboardClearWidget  *widget.Clickable
+	passwordHistoryWidget *widget.Clickable
+	importWidget          *widget.Clickable
+	exportWidget          *widget.Clickable
+	backupRetentionWidget *widget.Clickable
+	backupsWidget         *widget.Clickable
+	trashRetentionWidget  *widget.Clickable
+	trashWidget           *widget.Clickable
+	closeBtnWidget        *widget.Clickable
+
+	autoLockTimeout       time.Duration
+	lockOnMinimize        bool
+	clipboardClearTimeout time.Duration
+	passwordHistoryLength int
+	backupRetention       server.BackupRetention
+	trashRetention        time.Duration
+}
+
+func SettingsWidget(gtx *layout.Context, theme *material.Theme, settingsView *SettingsView, info Information) {
+	elementMargin := layout.Inset{Top: unit.Dp(13), Bottom: unit.Dp(13), Right: unit.Dp(10), Left: unit.Dp(10)}
+	appTextSize := unit.Sp(15)
+
+	autoLockText := "never"
+	if settingsView.autoLockTimeout > 0 {
+		autoLockText = settingsView.autoLockTimeout.String()
+	}
+
+	clipboardClearText := "never"
+	if settingsView.clipboardClearTimeout > 0 {
+		clipboardClearText = settingsView.clipboardClearTimeout.String()
+	}
+
+	passwordHistoryText := "off"
+	if settingsView.passwordHistoryLength > 0 {
+		passwordHistoryText = fmt.Sprintf("last %d", settingsView.passwordHistoryLength)
+	}
+
+	trashRetentionText := "never"
+	if settingsView.trashRetention > 0 {
+		trashRetentionText = fmt.Sprintf("%d days", int(settingsView.trashRetention.Hours()/24))
+	}
+
+	lockOnMinimizeText := "OFF"
+	lockOnMinimizeColor := grey
+	if settingsView.lockOnMinimize {
+		lockOnMinimizeText = "ON"
+		lockOnMinimizeColor = orange
+	}
+
+	setting := func(description string, clickable *widget.Clickable, text string, background color.NRGBA) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+				gtx,
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return material.H6(theme, description).Layout(gtx)
+							},
+						)
+					},
+				),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								btn := material.Button(theme, clickable, text)
+								btn.Background = background
+								btn.TextSize = appTextSize
+								btn.Font.Weight = font.Normal
+								btn.Color = black
+								btn.Font.Typeface = "Verdana, monospace"
+
+								return btn.Layout(gtx)
+							},
+						)
+					},
+				),
+			)
+		})
+	}
+
+	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(40), Right: unit.Dp(40)}.Layout(
+		*gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical, Spacing: layout.SpaceSides}.Layout(
+				gtx,
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								header := material.H3(theme, "Settings")
+								header.Font.Typeface = "Verdana, monospace"
+								return header.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, info.text)
+								label.Color = info.color
+								label.Font.Weight = font.Bold
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				setting("Lock after inactivity:", settingsView.autoLockWidget, autoLockText, grey_light),
+				setting("Lock on minimize:", settingsView.lockOnMinimizeWidget, lockOnMinimizeText, lockOnMinimizeColor),
+				setting("Clear copied secret from clipboard after:", settingsView.clipboardClearWidget, clipboardClearText, grey_light),
+				setting("Replaced passwords kept:", settingsView.passwordHistoryWidget, passwordHistoryText, grey_light),
+				horizontalDivider(),
+				setting("Import from archive or other password managers:", settingsView.importWidget, "IMPORT", purple_light),
+				setting("Export to archive, KeePass or CSV / JSON:", settingsView.exportWidget, "EXPORT", purple_light),
+				horizontalDivider(),
+				setting("Backups kept:", settingsView.backupRetentionWidget, settingsView.backupRetention.String(), grey_light),
+				setting("Restore vault from backup:", settingsView.backupsWidget, "BACKUPS", purple_light),
+				horizontalDivider(),
+				setting("Purge deleted entries after:", settingsView.trashRetentionWidget, trashRetentionText, grey_light),
+				setting("Restore or purge deleted entries:", settingsView.trashWidget, "TRASH", purple_light),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								closeBtn := material.Button(theme, settingsView.closeBtnWidget, "CLOSE")
+								closeBtn.Background = grey_light
+								closeBtn.TextSize = appTextSize
+								closeBtn.Font.Weight = font.Normal
+								closeBtn.Color = black
+								closeBtn.Font.Typeface = "Verdana, monospace"
+
+								return closeBtn.Layout(gtx)
+							},
+						)
+					},
+				),
+			)
+		},
+	)
+}
+
+func ResizeWindowImport(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(500), unit.Dp(700)))
+	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
+	window.Option(app.Size(unit.Dp(750), unit.Dp(950)))
+	window.Option(app.Title(appName))
+}
+
+// Source of imported entries - frosk archive, KeePass database or export read by one of importer formats
+type ImportSource struct {
+	description string
+	format      importer.Format // nil for frosk archive and KeePass database, which need password to be read
+	archive     bool
+}
+
+func (importSource ImportSource) isArchive() bool {
+	return importSource.format == nil && importSource.archive
+}
+
+func (importSource ImportSource) isKeePass() bool {
+	return importSource.format == nil && !importSource.archive
+}
+
+type ImportView struct {
+	path        *widget.Editor // file or directory, depending on source
+	password    *widget.Editor
+	keyFilePath *widget.Editor
+
+	sourceWidget          *widget.Clickable
+	collisionPolicyWidget *widget.Clickable
+	importBtnWidget       *widget.Clickable
+	previewBtnWidget      *widget.Clickable
+	showHideWidget        *widget.Clickable
+	closeBtnWidget        *widget.Clickable
+
+	sources         []ImportSource
+	source          int // index of chosen source
+	collisionPolicy server.CollisionPolicy
+
+	summary     []string // lines describing outcome of last import
+	summaryList *widget.List
+}
+
+func ImportWidget(gtx *layout.Context, theme *material.Theme, importView *ImportView, info Information) {
+	elementMargin := layout.Inset{Top: unit.Dp(10), Bottom: unit.Dp(10), Right: unit.Dp(10), Left: unit.Dp(10)}
+	appTextSize := unit.Sp(15)
+
+	heading := func(text string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return elementMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					return material.H6(theme, text).Layout(gtx)
+				},
+			)
+		})
+	}
+
+	input := func(editor *widget.Editor, hint string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return elementMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					inputEditor := material.Editor(theme, editor, hint)
+					inputEditor.TextSize = appTextSize
+					inputEditor.SelectionColor = blue
+
+					return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputEditor.Layout)
+				},
+			)
+		})
+	}
+
+	button := func(clickable *widget.Clickable, text string, background color.NRGBA) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return elementMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(theme, clickable, text)
+						btn.Background = background
+						btn.TextSize = appTextSize
+						btn.Font.Weight = font.Normal
+						btn.Color = black
+						btn.Font.Typeface = "Verdana, monospace"
+
+						return btn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	// Row with description and button cycling through options
+	choice := func(description string, clickable *widget.Clickable, text string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+				gtx,
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return material.H6(theme, description).Layout(gtx)
+							},
+						)
+					},
+				),
+				button(clickable, text, grey_light),
+			)
+		})
+	}
+
+	source := importView.sources[importView.source]
+
+	pathHeading, pathHint := "Exported file:", "Path to exported file..."
+
+	switch {
+	case source.isArchive():
+		pathHeading, pathHint = "Archive file:", "Path to archive written by export..."
+	case source.isKeePass():
+		pathHeading, pathHint = "KeePass database:", "Path to .kdbx file..."
+	case source.format.Name() == "pass":
+		pathHeading, pathHint = "Password store directory:", "Path to password store, e.g. ~/.password-store..."
+	}
+
+	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(40), Right: unit.Dp(40)}.Layout(
+		*gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			children := make([]layout.FlexChild, 0, 16)
+
+			children = append(children,
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								header := material.H3(theme, "Import")
+								header.Font.Typeface = "Verdana, monospace"
+								return header.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, info.text)
+								label.Color = info.color
+								label.Font.Weight = font.Bold
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				choice("Import from:", importView.sourceWidget, source.description),
+				heading(pathHeading),
+				input(importView.path, pathHint),
+			)
+
+			// Only archives and KeePass databases are encrypted with password given by user
+			if source.isArchive() {
+				children = append(children,
+					heading("Archive passphrase:"),
+					input(importView.password, "Enter passphrase of archive..."),
+				)
+			}
+
+			if source.isKeePass() {
+				children = append(children,
+					heading("KeePass password:"),
+					input(importView.password, "Enter password of KeePass database..."),
+					heading("Key file (optional):"),
+					input(importView.keyFilePath, "Path to key file..."),
+				)
+			}
+
+			children = append(children,
+				choice("Service name already present:", importView.collisionPolicyWidget, strings.ToUpper(importView.collisionPolicy.String())),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+							gtx,
+							button(importView.importBtnWidget, "IMPORT", purple_light),
+							button(importView.previewBtnWidget, "PREVIEW", grey_light),
+							button(importView.showHideWidget, "SHOW/HIDE", grey_light),
+							button(importView.closeBtnWidget, "CLOSE", grey_light),
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return material.List(theme, importView.summaryList).Layout(
+									gtx,
+									len(importView.summary),
+									func(gtx layout.Context, index int) layout.Dimensions {
+										label := material.Label(theme, appTextSize, importView.summary[index])
+										label.Font.Typeface = "Verdana, monospace"
+										return label.Layout(gtx)
+									},
+								)
+							},
+						)
+					},
+				),
+			)
+
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		},
+	)
+}
+
+func ResizeWindowExport(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(500), unit.Dp(700)))
+	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
+	window.Option(app.Size(unit.Dp(750), unit.Dp(900)))
+	window.Option(app.Title(appName))
+}
+
+// Format of exported file - frosk archive, KeePass database or unencrypted file written by one of exporter formats
+type ExportTarget struct {
+	description string
+	format      exporter.Format // nil for frosk archive and KeePass database, which are encrypted with password
+	keePass     bool
+}
+
+func (exportTarget ExportTarget) isArchive() bool {
+	return exportTarget.format == nil && !exportTarget.keePass
+}
+
+func (exportTarget ExportTarget) isKeePass() bool {
+	return exportTarget.format == nil && exportTarget.keePass
+}
+
+func (exportTarget ExportTarget) isPlaintext() bool {
+	return exportTarget.format != nil
+}
+
+type ExportView struct {
+	path           *widget.Editor
+	password       *widget.Editor // passphrase of archive or password of KeePass database
+	passwordRepeat *widget.Editor
+
+	targetWidget    *widget.Clickable
+	exportBtnWidget *widget.Clickable
+	showHideWidget  *widget.Clickable
+	closeBtnWidget  *widget.Clickable
+
+	strengthMeter *StrengthMeter
+
+	targets []ExportTarget
+	target  int // index of chosen target
+}
+
+func ExportWidget(gtx *layout.Context, theme *material.Theme, exportView *ExportView, info Information) {
+	elementMargin := layout.Inset{Top: unit.Dp(13), Bottom: unit.Dp(13), Right: unit.Dp(10), Left: unit.Dp(10)}
+	btnsMargin := layout.Inset{Top: unit.Dp(20), Bottom: unit.Dp(20), Right: unit.Dp(10), Left: unit.Dp(10)}
+	appTextSize := unit.Sp(15)
+
+	heading := func(text string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return elementMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					return material.H6(theme, text).Layout(gtx)
+				},
+			)
+		})
+	}
+
+	input := func(editor *widget.Editor, hint string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return elementMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					inputEditor := material.Editor(theme, editor, hint)
+					inputEditor.TextSize = appTextSize
+					inputEditor.SelectionColor = blue
+
+					return layout.UniformInset(unit.Dp(10)).Layout(gtx, inputEditor.Layout)
+				},
+			)
+		})
+	}
+
+	button := func(clickable *widget.Clickable, text string, background color.NRGBA) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return elementMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(theme, clickable, text)
+						btn.Background = background
+						btn.TextSize = appTextSize
+						btn.Font.Weight = font.Normal
+						btn.Color = black
+						btn.Font.Typeface = "Verdana, monospace"
+
+						return btn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	// Row with description and button cycling through options
+	choice := func(description string, clickable *widget.Clickable, text string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+				gtx,
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return material.H6(theme, description).Layout(gtx)
+							},
+						)
+					},
+				),
+				button(clickable, text, grey_light),
+			)
+		})
+	}
+
+	target := exportView.targets[exportView.target]
+
+	pathHeading, pathHint, passwordHeading := "Archive file:", "Path of new archive, e.g. ~/vault.frosk...", "Archive passphrase"
+
+	switch {
+	case target.isKeePass():
+		pathHeading, pathHint, passwordHeading = "KeePass database:", "Path of new .kdbx file...", "KeePass password"
+	case target.isPlaintext():
+		pathHeading, pathHint = "Exported file:", "Path of new "+target.format.Description()+" file..."
+	}
+
+	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(60), Right: unit.Dp(60)}.Layout(
+		*gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			children := make([]layout.FlexChild, 0, 16)
+
+			children = append(children,
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								header := material.H3(theme, "Export")
+								header.Font.Typeface = "Verdana, monospace"
+								return header.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, info.text)
+								label.Color = info.color
+								label.Font.Weight = font.Bold
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				choice("Export to:", exportView.targetWidget, target.description),
+				heading(pathHeading),
+				input(exportView.path, pathHint),
+			)
+
+			// Unencrypted exports have no password
+			if !target.isPlaintext() {
+				children = append(children,
+					horizontalDivider(),
+					heading(passwordHeading+":"),
+					input(exportView.password, "Enter "+strings.ToLower(passwordHeading)+"..."),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return StrengthMeterWidget(gtx, theme, exportView.strengthMeter, appTextSize-2)
+							},
+						)
+					}),
+					heading("Repeat "+strings.ToLower(passwordHeading)+":"),
+					input(exportView.passwordRepeat, "Repeat "+strings.ToLower(passwordHeading)+"..."),
+				)
+			}
+
+			children = append(children,
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return btnsMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+									gtx,
+									button(exportView.exportBtnWidget, "EXPORT", purple_light),
+									button(exportView.showHideWidget, "SHOW/HIDE", grey_light),
+									button(exportView.closeBtnWidget, "CLOSE", grey_light),
+								)
+							},
+						)
+					},
+				),
+			)
+
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		},
+	)
+}
+
+func ResizeWindowBackups(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(500), unit.Dp(500)))
+	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
+	window.Option(app.Size(unit.Dp(750), unit.Dp(800)))
+	window.Option(app.Title(appName))
+}
+
+// Backup of the vault together with result of its integrity check
+type BackupListing struct {
+	backup  vaults.Backup
+	entries int
+	damaged bool
+}
+
+type BackupsView struct {
+	listings          []BackupListing
+	restoreBtnWidgets []widget.Clickable // one per listing
+	list              *widget.List
+
+	backupNowBtnWidget *widget.Clickable
+	closeBtnWidget     *widget.Clickable
+}
+
+func BackupsWidget(gtx *layout.Context, theme *material.Theme, backupsView *BackupsView, info Information) {
+	elementMargin := layout.Inset{Top: unit.Dp(13), Bottom: unit.Dp(13), Right: unit.Dp(10), Left: unit.Dp(10)}
+	rowMargin := layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Right: unit.Dp(10), Left: unit.Dp(10)}
+	appTextSize := unit.Sp(15)
+
+	button := func(clickable *widget.Clickable, text string, background color.NRGBA) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return elementMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(theme, clickable, text)
+						btn.Background = background
+						btn.Color = black
+						btn.TextSize = appTextSize
+						btn.Font.Weight = font.Medium
+						btn.Font.Typeface = "Verdana, monospace"
+						return btn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	backupRow := func(gtx layout.Context, index int) layout.Dimensions {
+		listing := backupsView.listings[index]
+
+		details := fmt.Sprintf("%d entries, %.1f KB", listing.entries, float64(listing.backup.Size)/1024)
+		detailsColor := charcoal2
+
+		if listing.damaged {
+			details = "damaged - can not be restored"
+			detailsColor = red
+		}
+
+		return layout.Flex{Axis: layout.Vertical}.Layout(
+			gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+					gtx,
+					layout.Flexed(
+						1,
+						func(gtx layout.Context) layout.Dimensions {
+							return rowMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									return layout.Flex{Axis: layout.Vertical}.Layout(
+										gtx,
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											createdAt := material.Label(theme, unit.Sp(22), listing.backup.CreatedAt.Local().Format(time.DateTime))
+											createdAt.Font.Typeface = "Verdana, monospace"
+											createdAt.MaxLines = 1
+											return createdAt.Layout(gtx)
+										}),
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											label := material.Label(theme, unit.Sp(12), details)
+											label.Color = detailsColor
+											label.MaxLines = 1
+											return label.Layout(gtx)
+										}),
+									)
+								},
+							)
+						},
+					),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if listing.damaged {
+							return layout.Dimensions{}
+						}
+
+						return rowMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								restoreBtn := material.Button(theme, &backupsView.restoreBtnWidgets[index], "RESTORE")
+								restoreBtn.Background = grey_light
+								restoreBtn.Color = black
+								restoreBtn.TextSize = unit.Sp(12)
+								restoreBtn.Font.Weight = font.Medium
+								restoreBtn.Font.Typeface = "Verdana, monospace"
+								return restoreBtn.Layout(gtx)
+							},
+						)
+					}),
+				)
+			}),
+			horizontalDivider(),
+		)
+	}
+
+	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(30), Right: unit.Dp(30)}.Layout(
+		*gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(
+				gtx,
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								header := material.H3(theme, "Backups")
+								header.Font.Typeface = "Verdana, monospace"
+								return header.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, info.text)
+								label.Color = info.color
+								label.Font.Weight = font.Bold
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return backupsView.list.Layout(gtx, len(backupsView.listings), backupRow)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+							gtx,
+							button(backupsView.backupNowBtnWidget, "BACKUP NOW", blue),
+							button(backupsView.closeBtnWidget, "CLOSE", grey_light),
+						)
+					},
+				),
+			)
+		},
+	)
+}
+
+func ResizeWindowTrash(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(500), unit.Dp(500)))
+	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
+	window.Option(app.Size(unit.Dp(750), unit.Dp(800)))
+	window.Option(app.Title(appName))
+}
+
+type TrashView struct {
+	trash             []server.TrashedPasswordEntry
+	restoreBtnWidgets []widget.Clickable // one per entry in trash
+	purgeBtnWidgets   []widget.Clickable // one per entry in trash
+	list              *widget.List
+
+	closeBtnWidget *widget.Clickable
+}
+
+func TrashWidget(gtx *layout.Context, theme *material.Theme, trashView *TrashView, info Information) {
+	elementMargin := layout.Inset{Top: unit.Dp(13), Bottom: unit.Dp(13), Right: unit.Dp(10), Left: unit.Dp(10)}
+	rowMargin := layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Right: unit.Dp(10), Left: unit.Dp(10)}
+	appTextSize := unit.Sp(15)
+
+	rowButton := func(clickable *widget.Clickable, text string, background color.NRGBA) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return rowMargin.Layout(
+				gtx,
+				func(gtx layout.Context) layout.Dimensions {
+					btn := material.Button(theme, clickable, text)
+					btn.Background = background
+					btn.Color = black
+					btn.TextSize = unit.Sp(12)
+					btn.Font.Weight = font.Medium
+					btn.Font.Typeface = "Verdana, monospace"
+					return btn.Layout(gtx)
+				},
+			)
+		})
+	}
+
+	trashRow := func(gtx layout.Context, index int) layout.Dimensions {
+		trashed := trashView.trash[index]
+
+		return layout.Flex{Axis: layout.Vertical}.Layout(
+			gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+					gtx,
+					layout.Flexed(
+						1,
+						func(gtx layout.Context) layout.Dimensions {
+							return rowMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									return layout.Flex{Axis: layout.Vertical}.Layout(
+										gtx,
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											serviceName := material.Label(theme, unit.Sp(22), trashed.ServiceName)
+											serviceName.Font.Typeface = "Verdana, monospace"
+											serviceName.MaxLines = 1
+											return serviceName.Layout(gtx)
+										}),
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											label := material.Label(theme, unit.Sp(12), "deleted "+trashed.DeletedAt)
+											label.Color = charcoal2
+											label.MaxLines = 1
+											return label.Layout(gtx)
+										}),
+									)
+								},
+							)
+						},
+					),
+					rowButton(&trashView.restoreBtnWidgets[index], "RESTORE", grey_light),
+					rowButton(&trashView.purgeBtnWidgets[index], "PURGE", red),
+				)
+			}),
+			horizontalDivider(),
+		)
+	}
+
+	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(30), Right: unit.Dp(30)}.Layout(
+		*gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(
+				gtx,
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								header := material.H3(theme, "Trash")
+								header.Font.Typeface = "Verdana, monospace"
+								return header.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, info.text)
+								label.Color = info.color
+								label.Font.Weight = font.Bold
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return trashView.list.Layout(gtx, len(trashView.trash), trashRow)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								closeBtn := material.Button(theme, trashView.closeBtnWidget, "CLOSE")
+								closeBtn.Background = grey_light
+								closeBtn.Color = black
+								closeBtn.TextSize = appTextSize
+								closeBtn.Font.Weight = font.Medium
+								closeBtn.Font.Typeface = "Verdana, monospace"
+								return closeBtn.Layout(gtx)
+							},
+						)
+					},
+				),
+			)
+		},
+	)
+}
+
+func ResizeWindowVaults(window *app.Window) {
+	window.Option(app.Decorated(true))
+	window.Option(app.MinSize(unit.Dp(500), unit.Dp(500)))
+	window.Option(app.MaxSize(unit.Dp(2000), unit.Dp(2000)))
+	window.Option(app.Size(unit.Dp(750), unit.Dp(800)))
+	window.Option(app.Title(appName))
+}
+
+type VaultsView struct {
+	currentVaultPath string
+	vaultPaths       []string
+	openBtnWidgets   []widget.Clickable // one per vault path
+	list             *widget.List
+
+	vaultName *widget.Editor // name in data directory or path of vault to create or open
+
+	createBtnWidget *widget.Clickable
+	openBtnWidget   *widget.Clickable
+	backBtnWidget   *widget.Clickable
+}
+
+func VaultsWidget(gtx *layout.Context, theme *material.Theme, vaultsView *VaultsView, info Information) {
+	elementMargin := layout.Inset{Top: unit.Dp(13), Bottom: unit.Dp(13), Right: unit.Dp(10), Left: unit.Dp(10)}
+	rowMargin := layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Right: unit.Dp(10), Left: unit.Dp(10)}
+	appTextSize := unit.Sp(15)
+
+	button := func(clickable *widget.Clickable, text string, background color.NRGBA) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return elementMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(theme, clickable, text)
+						btn.Background = background
+						btn.Color = black
+						btn.TextSize = appTextSize
+						btn.Font.Weight = font.Medium
+						btn.Font.Typeface = "Verdana, monospace"
+						return btn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	vaultRow := func(gtx layout.Context, index int) layout.Dimensions {
+		vaultPath := vaultsView.vaultPaths[index]
+
+		return layout.Flex{Axis: layout.Vertical}.Layout(
+			gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+					gtx,
+					layout.Flexed(
+						1,
+						func(gtx layout.Context) layout.Dimensions {
+							return rowMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									return layout.Flex{Axis: layout.Vertical}.Layout(
+										gtx,
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											name := material.Label(theme, unit.Sp(22), vaults.Name(vaultPath))
+											name.Font.Typeface = "Verdana, monospace"
+											name.MaxLines = 1
+											return name.Layout(gtx)
+										}),
+										layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+											path := material.Label(theme, unit.Sp(12), vaultPath)
+											path.Color = charcoal2
+											path.MaxLines = 1
+											return path.Layout(gtx)
+										}),
+									)
+								},
+							)
+						},
+					),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if vaultPath == vaultsView.currentVaultPath {
+							return rowMargin.Layout(
+								gtx,
+								func(gtx layout.Context) layout.Dimensions {
+									current := material.Label(theme, appTextSize, "CURRENT")
+									current.Color = purple
+									current.Font.Weight = font.Bold
+									return current.Layout(gtx)
+								},
+							)
+						}
+
+						return rowMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								openBtn := material.Button(theme, &vaultsView.openBtnWidgets[index], "OPEN")
+								openBtn.Background = grey_light
+								openBtn.Color = black
+								openBtn.TextSize = unit.Sp(12)
+								openBtn.Font.Weight = font.Medium
+								openBtn.Font.Typeface = "Verdana, monospace"
+								return openBtn.Layout(gtx)
+							},
+						)
+					}),
+				)
+			}),
+			horizontalDivider(),
+		)
+	}
+
+	layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(30), Right: unit.Dp(30)}.Layout(
+		*gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(
+				gtx,
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								header := material.H3(theme, "Vaults")
+								header.Font.Typeface = "Verdana, monospace"
+								return header.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								label := material.Label(theme, appTextSize, info.text)
+								label.Color = info.color
+								label.Font.Weight = font.Bold
+								return label.Layout(gtx)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Flexed(
+					1,
+					func(gtx layout.Context) layout.Dimensions {
+						return vaultsView.list.Layout(gtx, len(vaultsView.vaultPaths), vaultRow)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return elementMargin.Layout(
+							gtx,
+							func(gtx layout.Context) layout.Dimensions {
+								vaultName := material.Editor(theme, vaultsView.vaultName, "Vault name or path...")
+								vaultName.TextSize = appTextSize
+								vaultName.SelectionColor = blue
+
+								return layout.UniformInset(unit.Dp(10)).Layout(gtx, vaultName.Layout)
+							},
+						)
+					},
+				),
+				horizontalDivider(),
+				layout.Rigid(
+					func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle, Spacing: layout.SpaceSides}.Layout(
+							gtx,
+							button(vaultsView.createBtnWidget, "CREATE", blue),
+							button(vaultsView.openBtnWidget, "OPEN", grey_light),
+							button(vaultsView.backBtnWidget, "BACK", grey_light),
+						)
+					},
+				),
+			)
+		},
+	)
+}
+
+// Password generator policy controls shown under password input of new and edit password forms.
+// Generates either random characters or passphrase of words.
+type PolicyEditor struct {
+	policy           generator.Policy
+	passphrasePolicy generator.PassphrasePolicy
+	passphraseMode   bool
+
+	length    *widget.Editor
+	symbolSet *widget.Editor
+	words     *widget.Editor
+	separator *widget.Editor
+
+	generateBtnWidget         *widget.Clickable
+	charactersModeWidget      *widget.Clickable
+	passphraseModeWidget      *widget.Clickable
+	shortWordlistWidget       *widget.Clickable
+	capitalizeWidget          *widget.Clickable
+	appendDigitWidget         *widget.Clickable
+	lowercaseWidget           *widget.Clickable
+	uppercaseWidget           *widget.Clickable
+	digitsWidget              *widget.Clickable
+	symbolsWidget             *widget.Clickable
+	requireEveryClassWidget   *widget.Clickable
+	excludeAmbiguousWidget    *widget.Clickable
+	noConsecutiveRepeatWidget *widget.Clickable
+	noDuplicatesWidget        *widget.Clickable
+}
+
+func NewPolicyEditor() *PolicyEditor {
+	policy := generator.DefaultPolicy()
+
+	length := new(widget.Editor)
+	length.SingleLine = true
+	length.Filter = "0123456789"
+	length.MaxLen = 3
+	length.SetText(strconv.Itoa(policy.Length))
+
+	symbolSet := new(widget.Editor)
+	symbolSet.SingleLine = true
+	symbolSet.Filter = symbol_filter
+	symbolSet.SetText(policy.SymbolSet)
+
+	passphrasePolicy := generator.DefaultPassphrasePolicy()
+
+	words := new(widget.Editor)
+	words.SingleLine = true
+	words.Filter = "0123456789"
+	words.MaxLen = 2
+	words.SetText(strconv.Itoa(passphrasePolicy.Words))
+
+	// Passphrase has to pass password input filter, so separator is limited to symbols
+	separator := new(widget.Editor)
+	separator.SingleLine = true
+	separator.Filter = symbol_filter
+	separator.MaxLen = generator.MaxSeparatorLength
+	separator.SetText(passphrasePolicy.Separator)
+
+	return &PolicyEditor{
+		policy:                    policy,
+		passphrasePolicy:          passphrasePolicy,
+		length:                    length,
+		symbolSet:                 symbolSet,
+		words:                     words,
+		separator:                 separator,
+		generateBtnWidget:         new(widget.Clickable),
+		charactersModeWidget:      new(widget.Clickable),
+		passphraseModeWidget:      new(widget.Clickable),
+		shortWordlistWidget:       new(widget.Clickable),
+		capitalizeWidget:          new(widget.Clickable),
+		appendDigitWidget:         new(widget.Clickable),
+		lowercaseWidget:           new(widget.Clickable),
+		uppercaseWidget:           new(widget.Clickable),
+		digitsWidget:              new(widget.Clickable),
+		symbolsWidget:             new(widget.Clickable),
+		requireEveryClassWidget:   new(widget.Clickable),
+		excludeAmbiguousWidget:    new(widget.Clickable),
+		noConsecutiveRepeatWidget: new(widget.Clickable),
+		noDuplicatesWidget:        new(widget.Clickable),
+	}
+}
+
+// Returns policy with length and symbol set typed into editors
+func (policyEditor *PolicyEditor) Policy() generator.Policy {
+	policy := policyEditor.policy
+	policy.Length, _ = strconv.Atoi(policyEditor.length.Text())
+	policy.SymbolSet = policyEditor.symbolSet.Text()
+
+	return policy
+}
+
+// Returns passphrase policy with number of words and separator typed into editors
+func (policyEditor *PolicyEditor) PassphrasePolicy() generator.PassphrasePolicy {
+	passphrasePolicy := policyEditor.passphrasePolicy
+	passphrasePolicy.Words, _ = strconv.Atoi(policyEditor.words.Text())
+	passphrasePolicy.Separator = policyEditor.separator.Text()
+
+	return passphrasePolicy
+}
+
+// Generates password or passphrase, depending on selected mode
+func (policyEditor *PolicyEditor) Generate() (string, error) {
+	if policyEditor.passphraseMode {
+		return generator.GeneratePassphrase(policyEditor.PassphrasePolicy())
+	}
+
+	return generator.Generate(policyEditor.Policy())
+}
+
+// Returns strength of password or passphrase generated with current settings
+func (policyEditor *PolicyEditor) Entropy() (float64, error) {
+	if policyEditor.passphraseMode {
+		return policyEditor.PassphrasePolicy().Entropy()
+	}
+
+	return policyEditor.Policy().Entropy()
+}
+
+func PolicyEditorWidget(gtx layout.Context, theme *material.Theme, policyEditor *PolicyEditor, textSize unit.Sp) layout.Dimensions {
+	rowMargin := layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4)}
+	btnMargin := layout.Inset{Right: unit.Dp(6), Left: unit.Dp(6)}
+
+	policy := policyEditor.Policy()
+	passphrasePolicy := policyEditor.PassphrasePolicy()
+
+	strengthText := ""
+	strengthColor := charcoal2
+	entropy, err := policyEditor.Entropy()
+
+	if err != nil {
+		strengthText = err.Error()
+		strengthColor = red
+	} else {
+		strengthText = fmt.Sprintf("Strength: ~%.0f bits", entropy)
+	}
+
+	toggle := func(clickable *widget.Clickable, text string, on bool) layout.FlexChild {
+		return layout.Flexed(
+			1,
+			func(gtx layout.Context) layout.Dimensions {
+				return btnMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(theme, clickable, text)
+						btn.Background = grey
+						if on {
+							btn.Background = orange
+						}
+						btn.Color = black
+						btn.TextSize = textSize
+						btn.Font.Weight = font.Bold
+
+						return btn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	labeledEditor := func(label string, editor *widget.Editor, hint string) layout.FlexChild {
+		return layout.Flexed(
+			1,
+			func(gtx layout.Context) layout.Dimensions {
+				return btnMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+							gtx,
+							layout.Rigid(material.Label(theme, textSize, label).Layout),
+							layout.Flexed(
+								1,
+								func(gtx layout.Context) layout.Dimensions {
+									input := material.Editor(theme, editor, hint)
+									input.TextSize = textSize
+									input.SelectionColor = blue
+
+									return layout.UniformInset(unit.Dp(6)).Layout(gtx, input.Layout)
+								},
+							),
+						)
+					},
+				)
+			},
+		)
+	}
+
+	row := func(children ...layout.FlexChild) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return rowMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, children...)
+					},
+				)
+			},
+		)
+	}
+
+	strengthRow := row(
+		layout.Flexed(
+			1,
+			func(gtx layout.Context) layout.Dimensions {
+				return btnMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						label := material.Label(theme, textSize, strengthText)
+						label.Color = strengthColor
+						label.Font.Weight = font.Bold
+						return label.Layout(gtx)
+					},
+				)
+			},
+		),
+		layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return btnMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						generateBtn := material.Button(theme, policyEditor.generateBtnWidget, "GENERATE")
+						generateBtn.Background = blue
+						generateBtn.Color = black
+						generateBtn.TextSize = textSize
+						generateBtn.Font.Weight = font.Bold
+						generateBtn.Font.Typeface = "Verdana, monospace"
+
+						return generateBtn.Layout(gtx)
+					},
+				)
+			},
+		),
+	)
+
+	modeRow := row(
+		toggle(policyEditor.charactersModeWidget, "Characters", !policyEditor.passphraseMode),
+		toggle(policyEditor.passphraseModeWidget, "Passphrase", policyEditor.passphraseMode),
+	)
+
+	if policyEditor.passphraseMode {
+		return layout.Flex{Axis: layout.Vertical}.Layout(
+			gtx,
+			modeRow,
+			row(
+				toggle(policyEditor.shortWordlistWidget, "Short words", passphrasePolicy.Wordlist == generator.EFFShort),
+				toggle(policyEditor.capitalizeWidget, "Capitalize", passphrasePolicy.Capitalize),
+				toggle(policyEditor.appendDigitWidget, "Add digit", passphrasePolicy.AppendDigit),
+			),
+			row(
+				labeledEditor("Words:", policyEditor.words, "words"),
+				labeledEditor("Separator:", policyEditor.separator, "none"),
+			),
+			strengthRow,
+		)
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(
+		gtx,
+		modeRow,
+		row(
+			toggle(policyEditor.lowercaseWidget, "a-z", policy.Lowercase),
+			toggle(policyEditor.uppercaseWidget, "A-Z", policy.Uppercase),
+			toggle(policyEditor.digitsWidget, "0-9", policy.Digits),
+			toggle(policyEditor.symbolsWidget, "Symbols", policy.Symbols),
+		),
+		row(
+			toggle(policyEditor.requireEveryClassWidget, "Every class", policy.RequireEveryClass),
+			toggle(policyEditor.excludeAmbiguousWidget, "No ambiguous", policy.ExcludeAmbiguous),
+			toggle(policyEditor.noConsecutiveRepeatWidget, "No repeats", policy.NoConsecutiveRepeats),
+			toggle(policyEditor.noDuplicatesWidget, "Unique", policy.NoDuplicates),
+		),
+		row(
+			labeledEditor("Length:", policyEditor.length, "length"),
+			labeledEditor("Symbols:", policyEditor.symbolSet, "symbol set"),
+		),
+		strengthRow,
+	)
+}
+
+// Estimated strength of password typed into a form. Estimation runs again only when password or inputs related to it change.
+type StrengthMeter struct {
+	password   string
+	userInputs []string
+	estimated  bool
+	result     strength.Result
+}
+
+// Estimates password again when it changed. User inputs - e.g. service name and username - are penalized when used in password.
+func (strengthMeter *StrengthMeter) Update(password string, userInputs ...string) {
+	if strengthMeter.estimated && password == strengthMeter.password && slices.Equal(userInputs, strengthMeter.userInputs) {
+		return
+	}
+
+	strengthMeter.password = password
+	strengthMeter.userInputs = userInputs
+	strengthMeter.estimated = true
+	strengthMeter.result = strength.Estimate(password, userInputs...)
+}
+
+func (strengthMeter *StrengthMeter) Result() strength.Result {
+	return strengthMeter.result
+}
+
+// Score bar with estimated crack time and feedback on how to improve the password. Nothing is shown while password is empty.
+func StrengthMeterWidget(gtx layout.Context, theme *material.Theme, strengthMeter *StrengthMeter, textSize unit.Sp) layout.Dimensions {
+	if strengthMeter.password == "" {
+		return layout.Dimensions{}
+	}
+
+	result := strengthMeter.result
+	scoreColor := [...]color.NRGBA{red, red, orange, blue, green}[result.Score]
+
+	feedback := strings.TrimSpace(result.Warning + " " + strings.Join(result.Suggestions, " "))
+
+	segments := make([]layout.FlexChild, 0, 5)
+
+	for index := range 5 {
+		segmentColor := grey_light
+		if index <= result.Score {
+			segmentColor = scoreColor
+		}
+
+		segments = append(segments, layout.Flexed(
+			1,
+			func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Right: unit.Dp(3), Left: unit.Dp(3)}.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						size := image.Pt(gtx.Constraints.Max.X, gtx.Dp(unit.Dp(8)))
+						paint.FillShape(gtx.Ops, segmentColor, clip.Rect{Max: size}.Op())
+						return layout.Dimensions{Size: size}
+					},
+				)
+			},
+		))
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(
+		gtx,
+		layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, segments...)
+			},
+		),
+		layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Top: unit.Dp(6), Right: unit.Dp(3), Left: unit.Dp(3)}.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						label := material.Label(theme, textSize, result.ScoreName()+" - "+result.CrackTime()+" to crack")
+						label.Color = charcoal
+						label.Font.Weight = font.Bold
+						return label.Layout(gtx)
+					},
+				)
+			},
+		),
+		layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				if feedback == "" {
+					return layout.Dimensions{}
+				}
+
+				return layout.Inset{Top: unit.Dp(4), Right: unit.Dp(3), Left: unit.Dp(3)}.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						label := material.Label(theme, textSize, feedback)
+						label.Color = charcoal2
+						return label.Layout(gtx)
+					},
+				)
+			},
+		),
+	)
+}
+
+// Draws custom fields of password form - every field in its own row with name, value, secret toggle and REMOVE button
+func CustomFieldInputsWidget(gtx layout.Context, theme *material.Theme, newPasswordView *NewPasswordView, textSize unit.Sp) layout.Dimensions {
+	rowMargin := layout.Inset{Top: unit.Dp(4), Bottom: unit.Dp(4)}
+	btnMargin := layout.Inset{Right: unit.Dp(6), Left: unit.Dp(6)}
+
+	editor := func(editor *widget.Editor, hint string, weight float32) layout.FlexChild {
+		return layout.Flexed(
+			weight,
+			func(gtx layout.Context) layout.Dimensions {
+				return btnMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						input := material.Editor(theme, editor, hint)
+						input.TextSize = textSize
+						input.SelectionColor = blue
+
+						return layout.UniformInset(unit.Dp(6)).Layout(gtx, input.Layout)
+					},
+				)
+			},
+		)
+	}
+
+	button := func(clickable *widget.Clickable, text string, background color.NRGBA) layout.FlexChild {
+		return layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return btnMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(theme, clickable, text)
+						btn.Background = background
+						btn.Color = black
+						btn.TextSize = textSize - 3
+						btn.Font.Weight = font.Bold
+						btn.Font.Typeface = "Verdana, monospace"
+
+						return btn.Layout(gtx)
+					},
+				)
+			},
+		)
+	}
+
+	rows := []layout.FlexChild{
+		layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return rowMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+							gtx,
+							layout.Flexed(1, material.H6(theme, "Custom fields:").Layout),
+							button(newPasswordView.addFieldWidget, "ADD FIELD", blue),
+						)
+					},
+				)
+			},
+		),
+	}
+
+	for _, customField := range newPasswordView.customFields {
+		secretBackground := grey
+		if customField.secret {
+			secretBackground = orange
+		}
+
+		rows = append(rows, layout.Rigid(
+			func(gtx layout.Context) layout.Dimensions {
+				return rowMargin.Layout(
+					gtx,
+					func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(
+							gtx,
+							editor(customField.name, "name", 1),
+							editor(customField.value, "value", 2),
+							button(customField.secretWidget, "Secret", secretBackground),
+							button(customField.removeWidget, "REMOVE", red),
+						)
+					},
+				)
+			},
+		))
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
+}
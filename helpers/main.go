@@ -5,7 +5,6 @@ package helpers
 import (
 	"fmt"
 	"log/slog"
-	"math/rand/v2"
 	"runtime/debug"
 	"time"
 
@@ -17,30 +16,6 @@ func TimeTo8601String(timeToParse time.Time) string {
 	return timeToParse.Format("2006-01-02 15:04:05")
 }
 
-// Returns random string of a given length.
-// You can choose whether to use special characters - disable option if given website for some reason does not allow them.
-func RandString(length int, allowSpecialChars bool) string {
-	const chars = "abcdefghijklmnopqrstuvwxyz" +
-		"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-		"0123456789"
-
-	const specialChars = "^!#$%&'()*+,-./:;<=>?@_|\"\\"
-
-	charsPool := []byte(chars)
-
-	if allowSpecialChars {
-		charsPool = append(charsPool, []byte(specialChars)...)
-	}
-
-	randString := make([]byte, length)
-
-	for i := range length {
-		randString[i] = charsPool[rand.Int64()%int64(len(charsPool))]
-	}
-
-	return string(randString)
-}
-
 // Asserting if two values are equall. If not, it stops execution of program and logs error to the slog logger and stdout.
 func Assert[T comparable](x T, y T) {
 	if x != y {
@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+// Bitwarden - Tools > Export vault > File format: .json (not encrypted)
+type bitwarden struct{}
+
+func (bitwarden) Name() string        { return "bitwarden" }
+func (bitwarden) Description() string { return "Bitwarden JSON" }
+
+// Types of Bitwarden items and custom fields
+const (
+	bitwardenLogin = 1
+	bitwardenCard  = 3
+
+	bitwardenHiddenField = 1
+)
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type     int    `json:"type"`
+	Name     string `json:"name"`
+	Notes    string `json:"notes"`
+	FolderID string `json:"folderId"`
+	Fields   []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Type  int    `json:"type"`
+	} `json:"fields"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+}
+
+func (bitwarden) Read(path string) ([]server.PasswordEntry, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading Bitwarden export: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	var export bitwardenExport
+	err = json.Unmarshal(data, &export)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w %v", MalformedExport, err)
+	}
+
+	if export.Encrypted {
+		return nil, EncryptedExport
+	}
+
+	folders := make(map[string]string, len(export.Folders))
+
+	for _, folder := range export.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	passwordEntries := make([]server.PasswordEntry, 0, len(export.Items))
+
+	for _, item := range export.Items {
+		passwordEntry := server.PasswordEntry{Notes: item.Notes, Tags: appendTag([]string{}, folders[item.FolderID]), CustomFields: []server.CustomField{}}
+
+		switch {
+		case item.Type == bitwardenLogin && item.Login != nil:
+			passwordEntry.Username = item.Login.Username
+			passwordEntry.Password = item.Login.Password
+			passwordEntry.TOTP = strings.TrimSpace(item.Login.TOTP)
+
+			if len(item.Login.URIs) != 0 {
+				passwordEntry.URL = item.Login.URIs[0].URI
+			}
+
+			// Login may be used on more websites
+			for i, uri := range item.Login.URIs[min(1, len(item.Login.URIs)):] {
+				passwordEntry.CustomFields = appendCustomField(passwordEntry.CustomFields, fmt.Sprintf("URL %d", i+2), uri.URI, false)
+			}
+
+		case item.Type == bitwardenCard && item.Card != nil:
+			passwordEntry.Username = item.Card.CardholderName
+			passwordEntry.Password = item.Card.Number
+			passwordEntry.CustomFields = appendCustomField(passwordEntry.CustomFields, "Brand", item.Card.Brand, false)
+			passwordEntry.CustomFields = appendCustomField(passwordEntry.CustomFields, "Expiration", strings.Trim(item.Card.ExpMonth+"/"+item.Card.ExpYear, "/"), false)
+			passwordEntry.CustomFields = appendCustomField(passwordEntry.CustomFields, "Security code", item.Card.Code, true)
+		}
+
+		for _, field := range item.Fields {
+			if field.Name == "" {
+				continue
+			}
+
+			passwordEntry.CustomFields = append(passwordEntry.CustomFields, server.CustomField{Name: field.Name, Value: field.Value, Secret: field.Type == bitwardenHiddenField})
+		}
+
+		// Secure notes and identities have no credentials - they are reported as skipped by import
+		passwordEntry.ServiceName = serviceName(item.Name, passwordEntry.URL)
+		passwordEntries = append(passwordEntries, passwordEntry)
+	}
+
+	return passwordEntries, nil
+}
@@ -0,0 +1,182 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+// Reads CSV export with header row. Rows are returned as maps of lowercase column name to value.
+// Export is rejected when any of required columns is missing.
+func readCSV(path string, required ...string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during opening CSV export: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w File is empty.", MalformedExport)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w %v", MalformedExport, err)
+	}
+
+	columns := make([]string, len(header))
+
+	for i, column := range header {
+		// Excel and some browsers start file with byte order mark
+		columns[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+	}
+
+	for _, requiredColumn := range required {
+		found := false
+
+		for _, column := range columns {
+			found = found || column == requiredColumn
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%w Column %q is missing.", MalformedExport, requiredColumn)
+		}
+	}
+
+	rows := make([]map[string]string, 0)
+
+	for {
+		record, err := reader.Read()
+
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w %v", MalformedExport, err)
+		}
+
+		row := make(map[string]string, len(columns))
+
+		for i, column := range columns {
+			row[column] = record[i]
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// Chrome, Edge, Brave and other Chromium based browsers - Settings > Passwords > Export. CSV export of frosk
+// starts with the same columns, its one-time password and tags are read too.
+type chromeCSV struct{}
+
+func (chromeCSV) Name() string        { return "chrome" }
+func (chromeCSV) Description() string { return "Chrome CSV" }
+
+func (chromeCSV) Read(path string) ([]server.PasswordEntry, error) {
+	rows, err := readCSV(path, "name", "url", "username", "password")
+
+	if err != nil {
+		return nil, err
+	}
+
+	passwordEntries := make([]server.PasswordEntry, 0, len(rows))
+
+	for _, row := range rows {
+		passwordEntries = append(passwordEntries, server.PasswordEntry{
+			ServiceName:  serviceName(row["name"], row["url"]),
+			Username:     row["username"],
+			Password:     row["password"],
+			URL:          row["url"],
+			Notes:        row["note"],
+			TOTP:         strings.TrimSpace(row["totp"]),
+			Tags:         splitTags(row["tags"]),
+			CustomFields: []server.CustomField{},
+		})
+	}
+
+	return passwordEntries, nil
+}
+
+// Firefox - about:logins > Export Logins
+type firefoxCSV struct{}
+
+func (firefoxCSV) Name() string        { return "firefox" }
+func (firefoxCSV) Description() string { return "Firefox CSV" }
+
+func (firefoxCSV) Read(path string) ([]server.PasswordEntry, error) {
+	rows, err := readCSV(path, "url", "username", "password")
+
+	if err != nil {
+		return nil, err
+	}
+
+	passwordEntries := make([]server.PasswordEntry, 0, len(rows))
+
+	for _, row := range rows {
+		// Logins of HTTP authentication are kept under realm of the server
+		customFields := appendCustomField([]server.CustomField{}, "HTTP realm", row["httprealm"], false)
+
+		passwordEntries = append(passwordEntries, server.PasswordEntry{
+			ServiceName:  serviceName("", row["url"]),
+			Username:     row["username"],
+			Password:     row["password"],
+			URL:          row["url"],
+			CustomFields: customFields,
+		})
+	}
+
+	return passwordEntries, nil
+}
+
+// LastPass - Advanced Options > Export
+type lastPassCSV struct{}
+
+func (lastPassCSV) Name() string        { return "lastpass" }
+func (lastPassCSV) Description() string { return "LastPass CSV" }
+
+// URL of LastPass secure notes, which have no website
+const lastPassSecureNoteURL = "http://sn"
+
+func (lastPassCSV) Read(path string) ([]server.PasswordEntry, error) {
+	rows, err := readCSV(path, "url", "username", "password", "extra", "name")
+
+	if err != nil {
+		return nil, err
+	}
+
+	passwordEntries := make([]server.PasswordEntry, 0, len(rows))
+
+	for _, row := range rows {
+		address := row["url"]
+
+		if address == lastPassSecureNoteURL {
+			address = ""
+		}
+
+		passwordEntries = append(passwordEntries, server.PasswordEntry{
+			ServiceName:  serviceName(row["name"], address),
+			Username:     row["username"],
+			Password:     row["password"],
+			URL:          address,
+			Notes:        row["extra"],
+			TOTP:         strings.TrimSpace(row["totp"]),
+			Tags:         appendTag([]string{}, strings.ReplaceAll(row["grouping"], "\\", server.TagSeparator)),
+			CustomFields: []server.CustomField{},
+		})
+	}
+
+	return passwordEntries, nil
+}
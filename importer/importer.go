@@ -0,0 +1,119 @@
+// Readers of passwords exported by browsers and other password managers. Every format turns its export into frosk
+// password entries, which are stored with Session.ImportPasswordEntries. New formats are added with Register.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+var UnknownFormat = errors.New("Import format is not known.")
+var MalformedExport = errors.New("Exported file does not match chosen format.")
+var EncryptedExport = errors.New("Export is encrypted. Export passwords again without encryption.")
+
+// Service name of entry without name nor URL
+const untitledServiceName = "Untitled"
+
+// Reader of one export format
+type Format interface {
+	Name() string        // short identifier used on command line, e.g. "chrome"
+	Description() string // shown to user, e.g. "Chrome CSV"
+
+	// Reads export at given path - file or directory, depending on format. Entries are returned in export order and
+	// their service names are not made unique.
+	Read(path string) ([]server.PasswordEntry, error)
+}
+
+var formats []Format
+
+// Makes format available through Formats and Lookup. Panics when format with the same name is already registered.
+func Register(format Format) {
+	if slices.ContainsFunc(formats, func(registered Format) bool { return registered.Name() == format.Name() }) {
+		panic("importer: format " + format.Name() + " registered twice")
+	}
+
+	formats = append(formats, format)
+}
+
+// Returns registered formats in order of registration
+func Formats() []Format {
+	return slices.Clone(formats)
+}
+
+func Lookup(name string) (Format, error) {
+	index := slices.IndexFunc(formats, func(format Format) bool { return format.Name() == name })
+
+	if index < 0 {
+		return nil, fmt.Errorf("%w %q", UnknownFormat, name)
+	}
+
+	return formats[index], nil
+}
+
+// Returns names of registered formats, e.g. for usage messages
+func Names() []string {
+	names := make([]string, 0, len(formats))
+
+	for _, format := range formats {
+		names = append(names, format.Name())
+	}
+
+	return names
+}
+
+func init() {
+	Register(chromeCSV{})
+	Register(firefoxCSV{})
+	Register(lastPassCSV{})
+	Register(bitwarden{})
+	Register(onePassword{})
+	Register(passwordStore{})
+	Register(froskJSON{})
+}
+
+// Picks service name of entry - given name, falling back to host of its URL
+func serviceName(name string, address string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return name
+	}
+
+	if parsed, err := url.Parse(strings.TrimSpace(address)); err == nil && parsed.Hostname() != "" {
+		return parsed.Hostname()
+	}
+
+	return untitledServiceName
+}
+
+// Appends custom field unless its value is empty
+func appendCustomField(customFields []server.CustomField, name string, value string, secret bool) []server.CustomField {
+	if strings.TrimSpace(value) == "" {
+		return customFields
+	}
+
+	return append(customFields, server.CustomField{Name: name, Value: value, Secret: secret})
+}
+
+// Splits comma separated tag list, as written by frosk CSV export, dropping empty tags
+func splitTags(value string) []string {
+	tags := make([]string, 0)
+
+	for _, tag := range strings.Split(value, ",") {
+		tags = appendTag(tags, tag)
+	}
+
+	return tags
+}
+
+// Appends tag unless it is empty. Folders and groups are kept as nested tags, e.g. "Work/Servers".
+func appendTag(tags []string, tag string) []string {
+	if strings.TrimSpace(tag) == "" {
+		return tags
+	}
+
+	return append(tags, strings.TrimSpace(tag))
+}
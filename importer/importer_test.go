@@ -0,0 +1,253 @@
+package importer
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	server "github.com/mszalewicz/frosk/backend"
+	"github.com/mszalewicz/frosk/exporter"
+)
+
+// Zips testdata/1password into 1PUX archive, which is binary and so is not kept in testdata as it is
+func onePasswordFixture(t *testing.T) string {
+	t.Helper()
+
+	document, err := os.ReadFile(filepath.Join("testdata", "1password", onePasswordDocument))
+
+	if err != nil {
+		t.Fatalf("reading 1PUX fixture: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "export.1pux")
+	file, err := os.Create(path)
+
+	if err != nil {
+		t.Fatalf("creating 1PUX fixture: %v", err)
+	}
+
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	writer, err := archive.Create(onePasswordDocument)
+
+	if err == nil {
+		_, err = writer.Write(document)
+	}
+
+	if err == nil {
+		err = archive.Close()
+	}
+
+	if err != nil {
+		t.Fatalf("writing 1PUX fixture: %v", err)
+	}
+
+	return path
+}
+
+func TestReadFixtures(t *testing.T) {
+	tests := []struct {
+		format string
+		path   string
+		want   []server.PasswordEntry
+	}{
+		{
+			format: "chrome",
+			path:   "chrome.csv",
+			want: []server.PasswordEntry{
+				{ServiceName: "github", Username: "octocat", Password: "hunter2", URL: "https://github.com/login", Notes: "first line\nsecond line", Tags: []string{}, CustomFields: []server.CustomField{}},
+				{ServiceName: "accounts.example.com", Username: "alice", Password: "s3cret", URL: "https://accounts.example.com/signin", Tags: []string{}, CustomFields: []server.CustomField{}},
+			},
+		},
+		{
+			format: "firefox",
+			path:   "firefox.csv",
+			want: []server.PasswordEntry{
+				{ServiceName: "github.com", Username: "octocat", Password: "hunter2", URL: "https://github.com", CustomFields: []server.CustomField{}},
+				{ServiceName: "router.local", Username: "admin", Password: "admin", URL: "https://router.local", CustomFields: []server.CustomField{{Name: "HTTP realm", Value: "Router login"}}},
+			},
+		},
+		{
+			format: "lastpass",
+			path:   "lastpass.csv",
+			want: []server.PasswordEntry{
+				{ServiceName: "GitHub", Username: "octocat", Password: "hunter2", URL: "https://github.com", Notes: "recovery codes in safe", TOTP: "JBSWY3DPEHPK3PXP", Tags: []string{"Work/Code"}, CustomFields: []server.CustomField{}},
+				{ServiceName: "Home network", Notes: "Wi-Fi password is on the router", Tags: []string{}, CustomFields: []server.CustomField{}},
+			},
+		},
+		{
+			format: "bitwarden",
+			path:   "bitwarden.json",
+			want: []server.PasswordEntry{
+				{
+					ServiceName: "GitHub", Username: "octocat", Password: "hunter2", URL: "https://github.com", Notes: "personal account", TOTP: "JBSWY3DPEHPK3PXP", Tags: []string{"Work/Code"},
+					CustomFields: []server.CustomField{{Name: "URL 2", Value: "https://gist.github.com"}, {Name: "PIN", Value: "1234", Secret: true}},
+				},
+				{
+					ServiceName: "Visa", Username: "Alice", Password: "4111111111111111", Tags: []string{},
+					CustomFields: []server.CustomField{{Name: "Brand", Value: "Visa"}, {Name: "Expiration", Value: "12/2030"}, {Name: "Security code", Value: "123", Secret: true}},
+				},
+			},
+		},
+		{
+			format: "1password",
+			want: []server.PasswordEntry{
+				{
+					ServiceName: "GitHub", Username: "octocat", Password: "hunter2", URL: "https://github.com", Notes: "personal account", TOTP: "otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP", Tags: []string{"Private", "work"},
+					CustomFields: []server.CustomField{{Name: "recovery e-mail", Value: "octocat@example.com"}},
+				},
+			},
+		},
+		{
+			format: "json",
+			path:   "frosk.json",
+			want: []server.PasswordEntry{
+				{
+					ServiceName: "github", Username: "octocat", Password: "hunter2", URL: "https://github.com", TOTP: "JBSWY3DPEHPK3PXP", Tags: []string{"work/code"},
+					CustomFields: []server.CustomField{{Name: "PIN", Value: "1234", Secret: true}},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			format, err := Lookup(test.format)
+
+			if err != nil {
+				t.Fatalf("Lookup(%q) = %v", test.format, err)
+			}
+
+			path := filepath.Join("testdata", test.path)
+
+			if test.format == "1password" {
+				path = onePasswordFixture(t)
+			}
+
+			got, err := format.Read(path)
+
+			if err != nil {
+				t.Fatalf("Read() = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Read() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadRejectsOtherExports(t *testing.T) {
+	emptyFile := filepath.Join(t.TempDir(), "empty.csv")
+
+	if err := os.WriteFile(emptyFile, nil, 0o600); err != nil {
+		t.Fatalf("writing empty file: %v", err)
+	}
+
+	tests := []struct {
+		format string
+		path   string
+		want   error
+	}{
+		{"chrome", filepath.Join("testdata", "firefox.csv"), MalformedExport},
+		{"lastpass", filepath.Join("testdata", "chrome.csv"), MalformedExport},
+		{"firefox", emptyFile, MalformedExport},
+		{"bitwarden", filepath.Join("testdata", "chrome.csv"), MalformedExport},
+		{"bitwarden", filepath.Join("testdata", "bitwarden-encrypted.json"), EncryptedExport},
+		{"json", filepath.Join("testdata", "lastpass.csv"), MalformedExport},
+	}
+
+	for _, test := range tests {
+		format, err := Lookup(test.format)
+
+		if err != nil {
+			t.Fatalf("Lookup(%q) = %v", test.format, err)
+		}
+
+		if _, err := format.Read(test.path); !errors.Is(err, test.want) {
+			t.Errorf("%s Read(%s) = %v, want %v", test.format, test.path, err, test.want)
+		}
+	}
+
+	if _, err := Lookup("keepass1"); !errors.Is(err, UnknownFormat) {
+		t.Errorf("Lookup() of unknown format = %v, want UnknownFormat", err)
+	}
+}
+
+func TestParsePassEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    server.PasswordEntry
+	}{
+		{
+			name:    "web/github.com",
+			content: "hunter2\nlogin: octocat\nurl: https://github.com\notpauth://totp/github?secret=JBSWY3DPEHPK3PXP\nPIN: 1234\nrecovery codes in safe\n",
+			want: server.PasswordEntry{
+				ServiceName: "web/github.com", Username: "octocat", Password: "hunter2", URL: "https://github.com", Notes: "recovery codes in safe",
+				TOTP: "otpauth://totp/github?secret=JBSWY3DPEHPK3PXP", CustomFields: []server.CustomField{{Name: "PIN", Value: "1234"}},
+			},
+		},
+		{
+			// Login is taken from name of the file
+			name:    "web/github.com/octocat",
+			content: "hunter2\r\n",
+			want:    server.PasswordEntry{ServiceName: "web/github.com/octocat", Username: "octocat", Password: "hunter2", CustomFields: []server.CustomField{}},
+		},
+	}
+
+	for _, test := range tests {
+		if got := parsePassEntry(test.name, test.content); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parsePassEntry(%q) = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestExportedEntriesAreReadBack(t *testing.T) {
+	passwordEntries := []server.PasswordEntry{
+		{
+			ServiceName: "github", Username: "octocat", Password: "hunter2", URL: "https://github.com", Notes: "first line\nsecond line",
+			TOTP: "JBSWY3DPEHPK3PXP", Tags: []string{"code", "work/servers"}, CustomFields: []server.CustomField{},
+		},
+	}
+
+	// CSV export is read by Chrome format, which takes no custom fields
+	for _, name := range []string{"csv", "json"} {
+		exportFormat, err := exporter.Lookup(name)
+
+		if err != nil {
+			t.Fatalf("exporter.Lookup(%q) = %v", name, err)
+		}
+
+		path := filepath.Join(t.TempDir(), "export."+name)
+		file, err := os.Create(path)
+
+		if err != nil {
+			t.Fatalf("creating export: %v", err)
+		}
+
+		err = exportFormat.Write(file, passwordEntries)
+		file.Close()
+
+		if err != nil {
+			t.Fatalf("%s Write() = %v", name, err)
+		}
+
+		importName := map[string]string{"csv": "chrome", "json": "json"}[name]
+		importFormat, err := Lookup(importName)
+
+		if err != nil {
+			t.Fatalf("Lookup(%q) = %v", importName, err)
+		}
+
+		got, err := importFormat.Read(path)
+
+		if err != nil || !reflect.DeepEqual(got, passwordEntries) {
+			t.Errorf("%s export read back = %+v, %v, want %+v", name, got, err, passwordEntries)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+// JSON export of frosk - Export > JSON
+type froskJSON struct{}
+
+func (froskJSON) Name() string        { return "json" }
+func (froskJSON) Description() string { return "frosk JSON" }
+
+type froskJSONExport struct {
+	Entries []struct {
+		ServiceName  string               `json:"service_name"`
+		Username     string               `json:"username"`
+		Password     string               `json:"password"`
+		URL          string               `json:"url"`
+		Notes        string               `json:"notes"`
+		TOTP         string               `json:"totp"`
+		Tags         []string             `json:"tags"`
+		CustomFields []server.CustomField `json:"custom_fields"`
+	} `json:"entries"`
+}
+
+func (froskJSON) Read(path string) ([]server.PasswordEntry, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading JSON export: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	var export froskJSONExport
+	err = json.Unmarshal(data, &export)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w %v", MalformedExport, err)
+	}
+
+	passwordEntries := make([]server.PasswordEntry, 0, len(export.Entries))
+
+	for _, entry := range export.Entries {
+		passwordEntry := server.PasswordEntry{
+			ServiceName:  serviceName(entry.ServiceName, entry.URL),
+			Username:     entry.Username,
+			Password:     entry.Password,
+			URL:          entry.URL,
+			Notes:        entry.Notes,
+			TOTP:         strings.TrimSpace(entry.TOTP),
+			Tags:         []string{},
+			CustomFields: append([]server.CustomField{}, entry.CustomFields...),
+		}
+
+		for _, tag := range entry.Tags {
+			passwordEntry.Tags = appendTag(passwordEntry.Tags, tag)
+		}
+
+		passwordEntries = append(passwordEntries, passwordEntry)
+	}
+
+	return passwordEntries, nil
+}
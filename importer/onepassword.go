@@ -0,0 +1,185 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+// 1Password 8 - File > Export > 1PUX. Archive holds export.data JSON document and attached files, which are not imported.
+type onePassword struct{}
+
+func (onePassword) Name() string        { return "1password" }
+func (onePassword) Description() string { return "1Password 1PUX" }
+
+const onePasswordDocument = "export.data"
+
+type onePasswordExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePasswordItem struct {
+	State    string `json:"state"` // "active" or "archived"
+	Overview struct {
+		Title string   `json:"title"`
+		URL   string   `json:"url"`
+		Tags  []string `json:"tags"`
+	} `json:"overview"`
+	Details struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Designation string `json:"designation"` // "username" or "password" for credentials of login
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"` // credentials of items of Password category
+		Sections   []struct {
+			Title  string `json:"title"`
+			Fields []struct {
+				Title string                     `json:"title"`
+				Value map[string]json.RawMessage `json:"value"` // single key naming type of value, e.g. {"concealed": "..."}
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+}
+
+func (onePassword) Read(path string) ([]server.PasswordEntry, error) {
+	archive, err := zip.OpenReader(path)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during opening 1PUX export: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	defer archive.Close()
+
+	document, err := archive.Open(onePasswordDocument)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w Archive has no %s.", MalformedExport, onePasswordDocument)
+	}
+
+	defer document.Close()
+
+	data, err := io.ReadAll(document)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading 1PUX export: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	var export onePasswordExport
+	err = json.Unmarshal(data, &export)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w %v", MalformedExport, err)
+	}
+
+	passwordEntries := make([]server.PasswordEntry, 0)
+
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				if item.State == "archived" {
+					continue
+				}
+
+				passwordEntries = append(passwordEntries, item.passwordEntry(vault.Attrs.Name))
+			}
+		}
+	}
+
+	return passwordEntries, nil
+}
+
+func (item onePasswordItem) passwordEntry(vaultName string) server.PasswordEntry {
+	passwordEntry := server.PasswordEntry{
+		ServiceName:  serviceName(item.Overview.Title, item.Overview.URL),
+		URL:          item.Overview.URL,
+		Notes:        item.Details.NotesPlain,
+		Password:     item.Details.Password,
+		CustomFields: []server.CustomField{},
+	}
+
+	for _, loginField := range item.Details.LoginFields {
+		switch loginField.Designation {
+		case "username":
+			passwordEntry.Username = loginField.Value
+		case "password":
+			passwordEntry.Password = loginField.Value
+		}
+	}
+
+	passwordEntry.Tags = appendTag([]string{}, vaultName)
+
+	for _, tag := range item.Overview.Tags {
+		passwordEntry.Tags = appendTag(passwordEntry.Tags, tag)
+	}
+
+	for _, section := range item.Details.Sections {
+		for _, field := range section.Fields {
+			for valueType, rawValue := range field.Value {
+				value := onePasswordValue(rawValue)
+
+				// Only the first one-time password is used for codes, others are kept as secret fields
+				if valueType == "totp" && passwordEntry.TOTP == "" {
+					passwordEntry.TOTP = strings.TrimSpace(value)
+					continue
+				}
+
+				name := field.Title
+
+				if name == "" {
+					name = section.Title
+				}
+
+				if name == "" {
+					name = valueType
+				}
+
+				secret := valueType == "concealed" || valueType == "totp" || valueType == "creditCardNumber"
+				passwordEntry.CustomFields = appendCustomField(passwordEntry.CustomFields, name, value, secret)
+			}
+		}
+	}
+
+	return passwordEntry
+}
+
+// Returns value of section field as text. Strings and numbers are taken as they are, e-mail addresses are objects.
+func onePasswordValue(rawValue json.RawMessage) string {
+	var text string
+
+	if json.Unmarshal(rawValue, &text) == nil {
+		return text
+	}
+
+	var number json.Number
+
+	if json.Unmarshal(rawValue, &number) == nil {
+		return number.String()
+	}
+
+	var email struct {
+		Address string `json:"email_address"`
+	}
+
+	if json.Unmarshal(rawValue, &email) == nil {
+		return email.Address
+	}
+
+	return ""
+}
@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+var GPGNotFound = errors.New("gpg program is needed to decrypt password store. Install GnuPG and try again.")
+
+// pass, the standard unix password manager - directory of GPG encrypted files, ~/.password-store by default.
+// Files are decrypted with local gpg program, which asks for passphrase of the key through gpg-agent.
+type passwordStore struct{}
+
+func (passwordStore) Name() string        { return "pass" }
+func (passwordStore) Description() string { return "pass (password store)" }
+
+// Keys of "key: value" lines holding username and URL, as used by browserpass and passff
+var (
+	passUsernameKeys = []string{"login", "username", "user", "email"}
+	passURLKeys      = []string{"url", "website", "site"}
+)
+
+func (passwordStore) Read(path string) ([]server.PasswordEntry, error) {
+	gpg, err := exec.LookPath("gpg")
+
+	if err != nil {
+		gpg, err = exec.LookPath("gpg2")
+	}
+
+	if err != nil {
+		return nil, GPGNotFound
+	}
+
+	files := make([]string, 0)
+
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Git repository of the store and other hidden directories hold no passwords
+		if entry.IsDir() && file != path && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".gpg") {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during listing password store: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	passwordEntries := make([]server.PasswordEntry, 0, len(files))
+
+	for _, file := range files {
+		var stderr bytes.Buffer
+		command := exec.Command(gpg, "--quiet", "--yes", "--decrypt", "--", file)
+		command.Stderr = &stderr
+
+		decrypted, err := command.Output()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during decryption of %s: %w %s", file, err, strings.TrimSpace(stderr.String()))
+			slog.Error(errWrapped.Error())
+			return nil, errWrapped
+		}
+
+		relative, err := filepath.Rel(path, file)
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Error during naming entry of password store: %w", err)
+			slog.Error(errWrapped.Error())
+			return nil, errWrapped
+		}
+
+		passwordEntries = append(passwordEntries, parsePassEntry(filepath.ToSlash(strings.TrimSuffix(relative, ".gpg")), string(decrypted)))
+		clear(decrypted)
+	}
+
+	return passwordEntries, nil
+}
+
+// Parses decrypted pass file - password on first line, then "key: value" lines and free text kept as notes.
+// Path of the file becomes service name. When no line holds username, name of the file is taken as username,
+// following convention of storing logins as site/username.
+func parsePassEntry(name string, content string) server.PasswordEntry {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	passwordEntry := server.PasswordEntry{
+		ServiceName:  name,
+		Password:     lines[0],
+		CustomFields: []server.CustomField{},
+	}
+
+	notes := make([]string, 0)
+
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(strings.TrimSpace(line), "otpauth://") && passwordEntry.TOTP == "" {
+			passwordEntry.TOTP = strings.TrimSpace(line)
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case !found || key == "":
+			notes = append(notes, line)
+		case slices.Contains(passUsernameKeys, strings.ToLower(key)) && passwordEntry.Username == "":
+			passwordEntry.Username = value
+		case slices.Contains(passURLKeys, strings.ToLower(key)) && passwordEntry.URL == "":
+			passwordEntry.URL = value
+		default:
+			passwordEntry.CustomFields = append(passwordEntry.CustomFields, server.CustomField{Name: key, Value: value})
+		}
+	}
+
+	if passwordEntry.Username == "" && strings.Contains(name, "/") {
+		passwordEntry.Username = name[strings.LastIndex(name, "/")+1:]
+	}
+
+	passwordEntry.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+
+	return passwordEntry
+}
@@ -0,0 +1,331 @@
+package kdbx
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Argon2 (RFC 9106) with secret key and associated data. golang.org/x/crypto/argon2 implements only Argon2i and
+// Argon2id without those inputs, while KeePass databases are mostly protected with Argon2d.
+
+const (
+	argon2d  = 0
+	argon2id = 2
+
+	argon2Version = 0x13
+
+	argon2BlockWords = 128 // 1 KiB block as 64-bit words
+	argon2SyncPoints = 4   // slices per pass
+)
+
+type argon2Block [argon2BlockWords]uint64
+
+type argon2Params struct {
+	variant     int
+	iterations  uint32
+	memory      uint32 // KiB
+	parallelism uint32
+	secret      []byte
+	associated  []byte
+}
+
+// Returns number of blocks actually allocated for memory given in KiB - memory is rounded down to multiple of
+// 4 * lanes blocks, with at least 8 blocks per lane
+func argon2MemoryBlocks(memory uint32, lanes uint32) uint32 {
+	memoryBlocks := max(memory, 2*argon2SyncPoints*lanes)
+	return memoryBlocks - memoryBlocks%(argon2SyncPoints*lanes)
+}
+
+func argon2Key(password []byte, salt []byte, params argon2Params, keyLength uint32) []byte {
+	lanes := params.parallelism
+	memoryBlocks := argon2MemoryBlocks(params.memory, lanes)
+
+	laneLength := memoryBlocks / lanes
+	segmentLength := laneLength / argon2SyncPoints
+
+	h0 := argon2InitialHash(password, salt, params, keyLength)
+	memory := make([]argon2Block, memoryBlocks)
+
+	for lane := range lanes {
+		input := make([]byte, 0, blake2b.Size+8)
+		input = append(input, h0...)
+		input = binary.LittleEndian.AppendUint32(input, 0)
+		input = binary.LittleEndian.AppendUint32(input, lane)
+
+		argon2LoadBlock(&memory[lane*laneLength], argon2Hash(input, 1024))
+
+		binary.LittleEndian.PutUint32(input[blake2b.Size:], 1)
+		argon2LoadBlock(&memory[lane*laneLength+1], argon2Hash(input, 1024))
+	}
+
+	position := argon2Position{
+		params:        params,
+		memoryBlocks:  memoryBlocks,
+		laneLength:    laneLength,
+		segmentLength: segmentLength,
+	}
+
+	for pass := range params.iterations {
+		for slice := range uint32(argon2SyncPoints) {
+			var waitGroup sync.WaitGroup
+
+			// Segments of one slice reference only blocks of earlier slices, so lanes are filled in parallel
+			for lane := range lanes {
+				waitGroup.Add(1)
+
+				go func(lane uint32) {
+					defer waitGroup.Done()
+					position.fillSegment(memory, pass, lane, slice)
+				}(lane)
+			}
+
+			waitGroup.Wait()
+		}
+	}
+
+	final := memory[laneLength-1]
+
+	for lane := uint32(1); lane < lanes; lane++ {
+		last := &memory[lane*laneLength+laneLength-1]
+
+		for i := range final {
+			final[i] ^= last[i]
+		}
+	}
+
+	finalBytes := make([]byte, 1024)
+
+	for i, word := range final {
+		binary.LittleEndian.PutUint64(finalBytes[i*8:], word)
+	}
+
+	return argon2Hash(finalBytes, keyLength)
+}
+
+func argon2InitialHash(password []byte, salt []byte, params argon2Params, keyLength uint32) []byte {
+	hash, _ := blake2b.New512(nil)
+
+	for _, value := range []uint32{params.parallelism, keyLength, params.memory, params.iterations, argon2Version, uint32(params.variant)} {
+		hash.Write(binary.LittleEndian.AppendUint32(nil, value))
+	}
+
+	for _, input := range [][]byte{password, salt, params.secret, params.associated} {
+		hash.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(input))))
+		hash.Write(input)
+	}
+
+	return hash.Sum(nil)
+}
+
+// Variable length hash H' built from BLAKE2b
+func argon2Hash(input []byte, length uint32) []byte {
+	prefixed := binary.LittleEndian.AppendUint32(nil, length)
+	prefixed = append(prefixed, input...)
+
+	if length <= blake2b.Size {
+		hash, _ := blake2b.New(int(length), nil)
+		hash.Write(prefixed)
+		return hash.Sum(nil)
+	}
+
+	output := make([]byte, 0, length)
+	sum := blake2b.Sum512(prefixed)
+	output = append(output, sum[:32]...)
+
+	for uint32(len(output))+blake2b.Size < length {
+		sum = blake2b.Sum512(sum[:])
+		output = append(output, sum[:32]...)
+	}
+
+	hash, _ := blake2b.New(int(length-uint32(len(output))), nil)
+	hash.Write(sum[:])
+
+	return hash.Sum(output)
+}
+
+func argon2LoadBlock(block *argon2Block, data []byte) {
+	for i := range block {
+		block[i] = binary.LittleEndian.Uint64(data[i*8:])
+	}
+}
+
+type argon2Position struct {
+	params        argon2Params
+	memoryBlocks  uint32
+	laneLength    uint32
+	segmentLength uint32
+}
+
+func (position argon2Position) fillSegment(memory []argon2Block, pass uint32, lane uint32, slice uint32) {
+	// Argon2id picks reference blocks independently of data in first half of first pass
+	dataIndependent := position.params.variant == argon2id && pass == 0 && slice < argon2SyncPoints/2
+
+	var addresses, input, zero argon2Block
+
+	if dataIndependent {
+		input[0] = uint64(pass)
+		input[1] = uint64(lane)
+		input[2] = uint64(slice)
+		input[3] = uint64(position.memoryBlocks)
+		input[4] = uint64(position.params.iterations)
+		input[5] = uint64(position.params.variant)
+	}
+
+	nextAddresses := func() {
+		input[6]++
+		argon2Compress(&addresses, &zero, &input, false)
+		argon2Compress(&addresses, &zero, &addresses, false)
+	}
+
+	start := uint32(0)
+
+	// First two blocks of every lane are already filled
+	if pass == 0 && slice == 0 {
+		start = 2
+
+		if dataIndependent {
+			nextAddresses()
+		}
+	}
+
+	offset := lane*position.laneLength + slice*position.segmentLength + start
+	previous := offset - 1
+
+	if offset%position.laneLength == 0 {
+		previous = offset + position.laneLength - 1
+	}
+
+	for index := start; index < position.segmentLength; index, offset, previous = index+1, offset+1, offset {
+		var random uint64
+
+		if dataIndependent {
+			if index%argon2BlockWords == 0 {
+				nextAddresses()
+			}
+
+			random = addresses[index%argon2BlockWords]
+		} else {
+			random = memory[previous][0]
+		}
+
+		referenceLane := uint32(random>>32) % position.params.parallelism
+
+		if pass == 0 && slice == 0 {
+			referenceLane = lane
+		}
+
+		reference := position.referenceIndex(pass, slice, index, uint32(random), referenceLane == lane)
+
+		argon2Compress(&memory[offset], &memory[previous], &memory[referenceLane*position.laneLength+reference], pass > 0)
+	}
+}
+
+// Maps pseudo-random value onto one of blocks available for reference, preferring recently filled ones
+func (position argon2Position) referenceIndex(pass uint32, slice uint32, index uint32, random uint32, sameLane bool) uint32 {
+	var areaSize uint32
+
+	switch {
+	case pass == 0 && sameLane:
+		areaSize = slice*position.segmentLength + index - 1
+	case pass == 0:
+		areaSize = slice * position.segmentLength
+	case sameLane:
+		areaSize = position.laneLength - position.segmentLength + index - 1
+	default:
+		areaSize = position.laneLength - position.segmentLength
+	}
+
+	if !sameLane && index == 0 {
+		areaSize--
+	}
+
+	relative := uint64(random) * uint64(random) >> 32
+	relative = uint64(areaSize) - 1 - (uint64(areaSize) * relative >> 32)
+
+	start := uint32(0)
+
+	if pass != 0 && slice != argon2SyncPoints-1 {
+		start = (slice + 1) * position.segmentLength
+	}
+
+	return uint32((uint64(start) + relative) % uint64(position.laneLength))
+}
+
+// Words permuted together by P - eight rows of 16 words followed by eight columns of 2 words in every row
+var argon2Permutations = func() (permutations [16][16]int) {
+	for row := range 8 {
+		for i := range 16 {
+			permutations[row][i] = row*16 + i
+		}
+	}
+
+	for column := range 8 {
+		for i := range 8 {
+			permutations[8+column][2*i] = i*16 + column*2
+			permutations[8+column][2*i+1] = i*16 + column*2 + 1
+		}
+	}
+
+	return permutations
+}()
+
+// Compression function G. With xor set result is xored into output instead of replacing it (passes after the first).
+func argon2Compress(output *argon2Block, x *argon2Block, y *argon2Block, xor bool) {
+	var r, z argon2Block
+
+	for i := range r {
+		r[i] = x[i] ^ y[i]
+	}
+
+	z = r
+
+	for _, indexes := range &argon2Permutations {
+		argon2Permute(&z, &indexes)
+	}
+
+	for i := range output {
+		if xor {
+			output[i] ^= z[i] ^ r[i]
+		} else {
+			output[i] = z[i] ^ r[i]
+		}
+	}
+}
+
+// Permutation P - BLAKE2b round with multiplications added to its additions
+func argon2Permute(block *argon2Block, indexes *[16]int) {
+	var v [16]uint64
+
+	for i, index := range indexes {
+		v[i] = block[index]
+	}
+
+	v[0], v[4], v[8], v[12] = argon2Mix(v[0], v[4], v[8], v[12])
+	v[1], v[5], v[9], v[13] = argon2Mix(v[1], v[5], v[9], v[13])
+	v[2], v[6], v[10], v[14] = argon2Mix(v[2], v[6], v[10], v[14])
+	v[3], v[7], v[11], v[15] = argon2Mix(v[3], v[7], v[11], v[15])
+	v[0], v[5], v[10], v[15] = argon2Mix(v[0], v[5], v[10], v[15])
+	v[1], v[6], v[11], v[12] = argon2Mix(v[1], v[6], v[11], v[12])
+	v[2], v[7], v[8], v[13] = argon2Mix(v[2], v[7], v[8], v[13])
+	v[3], v[4], v[9], v[14] = argon2Mix(v[3], v[4], v[9], v[14])
+
+	for i, index := range indexes {
+		block[index] = v[i]
+	}
+}
+
+func argon2Mix(a uint64, b uint64, c uint64, d uint64) (uint64, uint64, uint64, uint64) {
+	a = a + b + 2*uint64(uint32(a))*uint64(uint32(b))
+	d = bits.RotateLeft64(d^a, -32)
+	c = c + d + 2*uint64(uint32(c))*uint64(uint32(d))
+	b = bits.RotateLeft64(b^c, -24)
+	a = a + b + 2*uint64(uint32(a))*uint64(uint32(b))
+	d = bits.RotateLeft64(d^a, -16)
+	c = c + d + 2*uint64(uint32(c))*uint64(uint32(d))
+	b = bits.RotateLeft64(b^c, -63)
+
+	return a, b, c, d
+}
@@ -0,0 +1,58 @@
+package kdbx
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Test vectors of RFC 9106 section 5 - all of them use the same inputs, differing in variant only
+func TestArgon2KeyRFC9106(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+
+	tests := []struct {
+		name    string
+		variant int
+		tag     string
+	}{
+		{"Argon2d", argon2d, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb"},
+		{"Argon2id", argon2id, "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659"},
+	}
+
+	for _, test := range tests {
+		params := argon2Params{
+			variant:     test.variant,
+			iterations:  3,
+			memory:      32,
+			parallelism: 4,
+			secret:      bytes.Repeat([]byte{0x03}, 8),
+			associated:  bytes.Repeat([]byte{0x04}, 12),
+		}
+
+		if got := argon2Key(password, salt, params, 32); !bytes.Equal(got, mustDecodeHex(test.tag)) {
+			t.Errorf("%s tag = %x, want %s", test.name, got, test.tag)
+		}
+	}
+}
+
+// Without secret and associated data Argon2id has to match golang.org/x/crypto, including memory not divisible by lanes
+func TestArgon2KeyMatchesXCrypto(t *testing.T) {
+	password := []byte("correct horse battery staple")
+	salt := []byte("somesaltsomesalt")
+
+	tests := []argon2Params{
+		{variant: argon2id, iterations: 1, memory: 64, parallelism: 1},
+		{variant: argon2id, iterations: 2, memory: 1000, parallelism: 3},
+		{variant: argon2id, iterations: 3, memory: 8, parallelism: 4},
+	}
+
+	for _, params := range tests {
+		want := argon2.IDKey(password, salt, params.iterations, params.memory, uint8(params.parallelism), 64)
+
+		if got := argon2Key(password, salt, params, 64); !bytes.Equal(got, want) {
+			t.Errorf("argon2Key(%+v) = %x, want %x", params, got, want)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reads XML document of database. Protected values are encrypted with inner stream in document order - every one of
+// them, including those in skipped parts like entry history, has to be decrypted in turn to keep the stream in sync.
+type documentParser struct {
+	decoder    *xml.Decoder
+	stream     cipher.Stream
+	recycleBin string // UUID of group holding deleted entries, as written in document
+}
+
+// UUID written for missing group, e.g. recycle bin of database which never had one
+const emptyUUID = "AAAAAAAAAAAAAAAAAAAAAA=="
+
+func parseDocument(document []byte, stream cipher.Stream) (*Database, error) {
+	parser := documentParser{decoder: xml.NewDecoder(bytes.NewReader(document)), stream: stream}
+	database := &Database{Entries: []Entry{}}
+
+	for {
+		token, err := parser.decoder.Token()
+
+		if errors.Is(err, io.EOF) {
+			return database, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		start, isStart := token.(xml.StartElement)
+
+		if !isStart {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "KeePassFile":
+			// Meta and Root are its children
+		case "Meta":
+			err = parser.parseMeta(database)
+		case "Group":
+			var group parsedGroup
+			group, err = parser.parseGroup()
+
+			// Root group holds whole tree - its name is not part of group path
+			database.Entries = append(database.Entries, group.entries...)
+		case "Root":
+			// Holds root group and deleted objects
+		default:
+			err = parser.skip(start)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (parser *documentParser) parseMeta(database *Database) error {
+	for {
+		token, err := parser.decoder.Token()
+
+		if err != nil {
+			return fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "DatabaseName":
+				database.Name, err = parser.text(token)
+			case "RecycleBinUUID":
+				parser.recycleBin, err = parser.text(token)
+			default:
+				err = parser.skip(token)
+			}
+
+			if err != nil {
+				return err
+			}
+
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type parsedGroup struct {
+	uuid    string
+	name    string
+	entries []Entry // group paths relative to the group
+}
+
+func (parser *documentParser) parseGroup() (parsedGroup, error) {
+	group := parsedGroup{entries: []Entry{}}
+
+	for {
+		token, err := parser.decoder.Token()
+
+		if err != nil {
+			return parsedGroup{}, fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "UUID":
+				group.uuid, err = parser.text(token)
+			case "Name":
+				group.name, err = parser.text(token)
+			case "Entry":
+				var entry Entry
+				entry, err = parser.parseEntry()
+				group.entries = append(group.entries, entry)
+			case "Group":
+				var subgroup parsedGroup
+				subgroup, err = parser.parseGroup()
+
+				if subgroup.uuid != "" && subgroup.uuid != emptyUUID && subgroup.uuid == parser.recycleBin {
+					break
+				}
+
+				for _, entry := range subgroup.entries {
+					entry.Group = append([]string{subgroup.name}, entry.Group...)
+					group.entries = append(group.entries, entry)
+				}
+			default:
+				err = parser.skip(token)
+			}
+
+			if err != nil {
+				return parsedGroup{}, err
+			}
+
+		case xml.EndElement:
+			return group, nil
+		}
+	}
+}
+
+func (parser *documentParser) parseEntry() (Entry, error) {
+	entry := Entry{Group: []string{}, Fields: []Field{}, Tags: []string{}}
+
+	for {
+		token, err := parser.decoder.Token()
+
+		if err != nil {
+			return Entry{}, fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "String":
+				var field Field
+				field, err = parser.parseString()
+
+				switch field.Name {
+				case "Title":
+					entry.Title = field.Value
+				case "UserName":
+					entry.Username = field.Value
+				case "Password":
+					entry.Password = field.Value
+				case "URL":
+					entry.URL = field.Value
+				case "Notes":
+					entry.Notes = field.Value
+				default:
+					entry.Fields = append(entry.Fields, field)
+				}
+			case "Tags":
+				var tags string
+				tags, err = parser.text(token)
+
+				for _, tag := range strings.FieldsFunc(tags, func(char rune) bool { return char == ';' || char == ',' }) {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						entry.Tags = append(entry.Tags, tag)
+					}
+				}
+			default:
+				// History holds previous versions of the entry - its protected values are still decrypted by skip
+				err = parser.skip(token)
+			}
+
+			if err != nil {
+				return Entry{}, err
+			}
+
+		case xml.EndElement:
+			return entry, nil
+		}
+	}
+}
+
+func (parser *documentParser) parseString() (Field, error) {
+	field := Field{}
+
+	for {
+		token, err := parser.decoder.Token()
+
+		if err != nil {
+			return Field{}, fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "Key":
+				field.Name, err = parser.text(token)
+			case "Value":
+				field.Protected = isProtected(token)
+				field.Value, err = parser.text(token)
+			default:
+				err = parser.skip(token)
+			}
+
+			if err != nil {
+				return Field{}, err
+			}
+
+		case xml.EndElement:
+			return field, nil
+		}
+	}
+}
+
+// Reads text content of element which start was just read, decrypting it when it is protected
+func (parser *documentParser) text(start xml.StartElement) (string, error) {
+	var content strings.Builder
+
+	for {
+		token, err := parser.decoder.Token()
+
+		if err != nil {
+			return "", fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		switch token := token.(type) {
+		case xml.CharData:
+			content.Write(token)
+
+		case xml.StartElement:
+			err = parser.skip(token)
+
+			if err != nil {
+				return "", err
+			}
+
+		case xml.EndElement:
+			if !isProtected(start) {
+				return content.String(), nil
+			}
+
+			return parser.unprotect(content.String())
+		}
+	}
+}
+
+// Skips element which start was just read together with its children
+func (parser *documentParser) skip(start xml.StartElement) error {
+	if isProtected(start) {
+		_, err := parser.text(start)
+		return err
+	}
+
+	for {
+		token, err := parser.decoder.Token()
+
+		if err != nil {
+			return fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			err = parser.skip(token)
+
+			if err != nil {
+				return err
+			}
+
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (parser *documentParser) unprotect(value string) (string, error) {
+	if parser.stream == nil {
+		return "", fmt.Errorf("%w Protected value without inner stream.", CorruptedDatabase)
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+
+	if err != nil {
+		return "", fmt.Errorf("%w Protected value is not valid base64.", CorruptedDatabase)
+	}
+
+	decrypted := make([]byte, len(encrypted))
+	parser.stream.XORKeyStream(decrypted, encrypted)
+
+	return string(decrypted), nil
+}
+
+func isProtected(start xml.StartElement) bool {
+	for _, attribute := range start.Attr {
+		if attribute.Name.Local == "Protected" && strings.EqualFold(attribute.Value, "True") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,209 @@
+package kdbx
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+// Service name of entry without title nor URL
+const untitledServiceName = "Untitled"
+
+// Fields of KeePass 2.47+ one-time password settings, see https://keepass.info/help/base/placeholders.html#otp
+const (
+	timeOTPPrefix    = "TimeOtp-"
+	timeOTPAlgorithm = "TimeOtp-Algorithm"
+	timeOTPLength    = "TimeOtp-Length"
+	timeOTPPeriod    = "TimeOtp-Period"
+)
+
+// Converts entries to frosk password entries. Title becomes service name, falling back to host of URL. Tags are
+// kept and group path becomes one more tag nested like folders, e.g. "Banking/Cards". TOTP secret of KeePassXC ("otp" field) or KeePass (TimeOtp-* fields) becomes
+// TOTP of the entry and remaining fields become custom fields. Service names are not made unique - see
+// Session.ImportPasswordEntries.
+func (database *Database) PasswordEntries() []server.PasswordEntry {
+	passwordEntries := make([]server.PasswordEntry, 0, len(database.Entries))
+
+	for _, entry := range database.Entries {
+		passwordEntries = append(passwordEntries, entry.passwordEntry())
+	}
+
+	return passwordEntries
+}
+
+func (entry Entry) passwordEntry() server.PasswordEntry {
+	passwordEntry := server.PasswordEntry{
+		ServiceName:  entry.serviceName(),
+		Username:     entry.Username,
+		Password:     entry.Password,
+		URL:          entry.URL,
+		Notes:        entry.Notes,
+		Tags:         []string{},
+		CustomFields: []server.CustomField{},
+	}
+
+	totp, usedFields := entry.totp()
+
+	if _, err := server.ParseTOTP(totp); totp != "" && err == nil {
+		passwordEntry.TOTP = totp
+	} else {
+		// Secret frosk can't generate codes from is kept as custom field
+		usedFields = nil
+	}
+
+	groupPath := make([]string, 0, len(entry.Group))
+
+	for _, name := range entry.Group {
+		if name = strings.TrimSpace(name); name != "" {
+			groupPath = append(groupPath, name)
+		}
+	}
+
+	if len(groupPath) != 0 {
+		passwordEntry.Tags = append(passwordEntry.Tags, strings.Join(groupPath, server.TagSeparator))
+	}
+
+	for _, tag := range entry.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			passwordEntry.Tags = append(passwordEntry.Tags, tag)
+		}
+	}
+
+	for _, field := range entry.Fields {
+		if usedFields[field.Name] || field.Name == "" {
+			continue
+		}
+
+		passwordEntry.CustomFields = append(passwordEntry.CustomFields, server.CustomField{Name: field.Name, Value: field.Value, Secret: field.Protected})
+	}
+
+	return passwordEntry
+}
+
+func (entry Entry) serviceName() string {
+	if title := strings.TrimSpace(entry.Title); title != "" {
+		return title
+	}
+
+	if parsed, err := url.Parse(strings.TrimSpace(entry.URL)); err == nil && parsed.Hostname() != "" {
+		return parsed.Hostname()
+	}
+
+	return untitledServiceName
+}
+
+func (entry Entry) field(name string) (string, bool) {
+	for _, field := range entry.Fields {
+		if field.Name == name {
+			return field.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Returns TOTP secret of entry as accepted by server.ParseTOTP together with names of fields it was built from
+func (entry Entry) totp() (string, map[string]bool) {
+	// KeePassXC stores otpauth:// URI
+	if otp, found := entry.field("otp"); found {
+		return otp, map[string]bool{"otp": true}
+	}
+
+	// KeePass stores key in one of several encodings with settings in separate fields
+	for _, encoding := range []string{"Base32", "Hex", "Base64", ""} {
+		name := timeOTPPrefix + "Secret"
+
+		if encoding != "" {
+			name += "-" + encoding
+		}
+
+		value, found := entry.field(name)
+
+		if !found {
+			continue
+		}
+
+		var key []byte
+		var err error
+
+		switch encoding {
+		case "Base32":
+			return entry.keePassTOTP(value, name)
+		case "Hex":
+			key, err = hex.DecodeString(strings.Join(strings.Fields(value), ""))
+		case "Base64":
+			key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		default:
+			key = []byte(value)
+		}
+
+		if err != nil {
+			return "", nil
+		}
+
+		return entry.keePassTOTP(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key), name)
+	}
+
+	return "", nil
+}
+
+// Builds otpauth:// URI from base32 key and TimeOtp-* settings of KeePass entry
+func (entry Entry) keePassTOTP(key string, secretField string) (string, map[string]bool) {
+	usedFields := map[string]bool{secretField: true}
+	query := url.Values{}
+	query.Set("secret", strings.Join(strings.Fields(key), ""))
+
+	if algorithm, found := entry.field(timeOTPAlgorithm); found {
+		usedFields[timeOTPAlgorithm] = true
+		// KeePass names algorithms HMAC-SHA-1, HMAC-SHA-256 and HMAC-SHA-512
+		query.Set("algorithm", strings.ReplaceAll(strings.TrimPrefix(strings.ToUpper(algorithm), "HMAC-"), "-", ""))
+	}
+
+	for _, setting := range []struct{ field, parameter string }{{timeOTPLength, "digits"}, {timeOTPPeriod, "period"}} {
+		if value, found := entry.field(setting.field); found {
+			usedFields[setting.field] = true
+
+			if _, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				query.Set(setting.parameter, strings.TrimSpace(value))
+			}
+		}
+	}
+
+	return "otpauth://totp/" + url.PathEscape(entry.serviceName()) + "?" + query.Encode(), usedFields
+}
+
+// Converts frosk password entries to database entries - reverse of PasswordEntries. Entries are kept in root group
+// with their tags, TOTP is kept in "otp" field as KeePassXC does.
+func NewDatabase(name string, passwordEntries []server.PasswordEntry) *Database {
+	database := &Database{Name: name, Entries: make([]Entry, 0, len(passwordEntries))}
+
+	for _, passwordEntry := range passwordEntries {
+		entry := Entry{
+			Group:    []string{},
+			Title:    passwordEntry.ServiceName,
+			Username: passwordEntry.Username,
+			Password: passwordEntry.Password,
+			URL:      passwordEntry.URL,
+			Notes:    passwordEntry.Notes,
+			Fields:   []Field{},
+			Tags:     append([]string{}, passwordEntry.Tags...),
+		}
+
+		if passwordEntry.TOTP != "" {
+			entry.Fields = append(entry.Fields, Field{Name: "otp", Value: passwordEntry.TOTP, Protected: true})
+		}
+
+		for _, customField := range passwordEntry.CustomFields {
+			entry.Fields = append(entry.Fields, Field{Name: customField.Name, Value: customField.Value, Protected: customField.Secret})
+		}
+
+		database.Entries = append(database.Entries, entry)
+	}
+
+	return database
+}
@@ -0,0 +1,188 @@
+package kdbx
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	signature1 = 0x9AA2D903
+	signature2 = 0xB54BFB67
+
+	majorVersion = 4
+)
+
+// Fields of outer header
+const (
+	headerEnd            = 0
+	headerCipherID       = 2
+	headerCompression    = 3
+	headerMasterSeed     = 4
+	headerEncryptionIV   = 7
+	headerKDFParameters  = 11
+	headerFieldMaxLength = 1 << 20
+)
+
+type header struct {
+	length int // bytes of header covered by its hash and HMAC
+
+	cipher       string
+	compressed   bool
+	masterSeed   []byte
+	encryptionIV []byte
+	kdf          kdf
+}
+
+// Reads outer header - signature, version and unencrypted fields describing how payload is protected
+func readHeader(data []byte) (header, error) {
+	if len(data) < 12 || binary.LittleEndian.Uint32(data[0:4]) != signature1 || binary.LittleEndian.Uint32(data[4:8]) != signature2 {
+		return header{}, InvalidSignature
+	}
+
+	if binary.LittleEndian.Uint16(data[10:12]) != majorVersion {
+		return header{}, UnsupportedVersion
+	}
+
+	parsed := header{}
+	position := 12
+
+	for {
+		if len(data) < position+5 {
+			return header{}, CorruptedDatabase
+		}
+
+		fieldID := data[position]
+		fieldLength := int(binary.LittleEndian.Uint32(data[position+1 : position+5]))
+		position += 5
+
+		if fieldLength > headerFieldMaxLength || len(data) < position+fieldLength {
+			return header{}, CorruptedDatabase
+		}
+
+		value := data[position : position+fieldLength]
+		position += fieldLength
+
+		switch fieldID {
+		case headerEnd:
+			parsed.length = position
+
+			if parsed.cipher == "" || len(parsed.masterSeed) != 32 || parsed.kdf == nil {
+				return header{}, CorruptedDatabase
+			}
+
+			return parsed, nil
+
+		case headerCipherID:
+			if len(value) != 16 {
+				return header{}, CorruptedDatabase
+			}
+
+			parsed.cipher = fmt.Sprintf("%x", value)
+
+		case headerCompression:
+			if len(value) != 4 || binary.LittleEndian.Uint32(value) > 1 {
+				return header{}, CorruptedDatabase
+			}
+
+			parsed.compressed = binary.LittleEndian.Uint32(value) == 1
+
+		case headerMasterSeed:
+			parsed.masterSeed = value
+
+		case headerEncryptionIV:
+			parsed.encryptionIV = value
+
+		case headerKDFParameters:
+			parameters, err := readVariantDictionary(value)
+
+			if err != nil {
+				return header{}, err
+			}
+
+			parsed.kdf, err = newKDF(parameters)
+
+			if err != nil {
+				return header{}, err
+			}
+		}
+	}
+}
+
+// Derives key of payload cipher and base key of block HMACs
+func (header header) keys(transformedKey []byte) ([]byte, []byte) {
+	encryptionKey := sha256.New()
+	encryptionKey.Write(header.masterSeed)
+	encryptionKey.Write(transformedKey)
+
+	hmacKey := sha512.New()
+	hmacKey.Write(header.masterSeed)
+	hmacKey.Write(transformedKey)
+	hmacKey.Write([]byte{1})
+
+	return encryptionKey.Sum(nil), hmacKey.Sum(nil)
+}
+
+type variantDictionary map[string][]byte
+
+// Reads KeePass serialized dictionary of typed values, used for key derivation parameters. Values are kept
+// as raw little endian bytes - readers check their length.
+func readVariantDictionary(data []byte) (variantDictionary, error) {
+	if len(data) < 2 || data[1] != 1 {
+		return nil, CorruptedDatabase
+	}
+
+	dictionary := variantDictionary{}
+	position := 2
+
+	for {
+		if len(data) < position+1 {
+			return nil, CorruptedDatabase
+		}
+
+		valueType := data[position]
+		position++
+
+		if valueType == 0 {
+			return dictionary, nil
+		}
+
+		if len(data) < position+4 {
+			return nil, CorruptedDatabase
+		}
+
+		nameLength := int(binary.LittleEndian.Uint32(data[position:]))
+		position += 4
+
+		if nameLength < 0 || len(data) < position+nameLength+4 {
+			return nil, CorruptedDatabase
+		}
+
+		name := string(data[position : position+nameLength])
+		position += nameLength
+
+		valueLength := int(binary.LittleEndian.Uint32(data[position:]))
+		position += 4
+
+		if valueLength < 0 || len(data) < position+valueLength {
+			return nil, CorruptedDatabase
+		}
+
+		dictionary[name] = data[position : position+valueLength]
+		position += valueLength
+	}
+}
+
+func (dictionary variantDictionary) uint64(name string) (uint64, bool) {
+	value, found := dictionary[name]
+
+	switch {
+	case found && len(value) == 8:
+		return binary.LittleEndian.Uint64(value), true
+	case found && len(value) == 4:
+		return uint64(binary.LittleEndian.Uint32(value)), true
+	}
+
+	return 0, false
+}
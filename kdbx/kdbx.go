@@ -0,0 +1,148 @@
+// Package kdbx reads and writes KeePass databases in KDBX 4 format, as used by KeePass 2.35+ and KeePassXC
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+var InvalidSignature = errors.New("File is not a KeePass database.")
+var UnsupportedVersion = errors.New("Only KDBX 4 databases are supported. Save database in KeePass 2.35+ or KeePassXC 2.7+ and try again.")
+var InvalidCredentials = errors.New("Password or key file of KeePass database is incorrect.")
+var CorruptedDatabase = errors.New("KeePass database is corrupted.")
+var UnsupportedCipher = errors.New("KeePass database is encrypted with unsupported cipher.")
+var UnsupportedKDF = errors.New("KeePass database uses unsupported key derivation.")
+var MissingCredentials = errors.New("Password or key file of KeePass database is needed.")
+var InvalidKeyFile = errors.New("Key file of KeePass database is damaged.")
+
+// Limit of decompressed inner header and XML document - far above databases holding thousands of entries
+const maxDecompressedSize = 256 << 20
+
+// Entries of KeePass database. Entries in recycle bin and history of entries are left out.
+type Database struct {
+	Name    string
+	Entries []Entry
+}
+
+type Entry struct {
+	Group    []string // names of groups containing entry, outermost first, root group excluded
+	Title    string
+	Username string
+	Password string
+	URL      string
+	Notes    string
+	Fields   []Field // other fields of the entry in file order, e.g. "otp" of KeePassXC
+	Tags     []string
+}
+
+type Field struct {
+	Name      string
+	Value     string
+	Protected bool // field is kept encrypted in memory by KeePass - password or other secret
+}
+
+// Decrypts and parses KDBX 4 database. Key file is optional - pass its contents when database was created with one.
+func Open(reader io.Reader, password string, keyFile []byte) (*Database, error) {
+	if password == "" && len(keyFile) == 0 {
+		return nil, MissingCredentials
+	}
+
+	data, err := io.ReadAll(reader)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during reading KeePass database: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	header, err := readHeader(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	compositeKey, err := compositeKey(password, keyFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	transformedKey, err := header.kdf.transform(compositeKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	encryptionKey, hmacKey := header.keys(transformedKey)
+
+	payload := data[header.length:]
+
+	if len(payload) < 2*sha256.Size {
+		return nil, CorruptedDatabase
+	}
+
+	headerHash := sha256.Sum256(data[:header.length])
+
+	if !bytes.Equal(headerHash[:], payload[:sha256.Size]) {
+		return nil, CorruptedDatabase
+	}
+
+	// Header HMAC is the first thing depending on the key - mismatch means wrong password or key file
+	if !bytes.Equal(blockHMAC(hmacKey, headerHMACIndex, data[:header.length]), payload[sha256.Size:2*sha256.Size]) {
+		return nil, InvalidCredentials
+	}
+
+	encrypted, err := readBlocks(payload[2*sha256.Size:], hmacKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := decrypt(header.cipher, encryptionKey, header.encryptionIV, encrypted)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if header.compressed {
+		decrypted, err = decompress(decrypted, maxDecompressedSize)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	innerStream, document, err := readInnerHeader(decrypted)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDocument(document, innerStream)
+}
+
+// Decompresses gzip payload. Small payload can decompress to gigabytes, so reading stops one byte over the limit.
+func decompress(compressed []byte, limit int) ([]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(compressed))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w %v", CorruptedDatabase, err)
+	}
+
+	decompressed, err := io.ReadAll(io.LimitReader(gzipReader, int64(limit)+1))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w %v", CorruptedDatabase, err)
+	}
+
+	if len(decompressed) > limit {
+		return nil, fmt.Errorf("%w Decompressed content is larger than %d MiB.", CorruptedDatabase, limit>>20)
+	}
+
+	return decompressed, nil
+}
@@ -0,0 +1,181 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testDatabase() *Database {
+	return &Database{
+		Name: "frosk",
+		Entries: []Entry{
+			{
+				Group:    []string{},
+				Title:    "github",
+				Username: "octocat",
+				Password: "correct horse battery staple",
+				URL:      "https://github.com",
+				Notes:    "first line\nsecond line with <xml> & \"quotes\"",
+				Fields: []Field{
+					{Name: "otp", Value: "otpauth://totp/github?secret=GEZDGNBVGY3TQOJQ", Protected: true},
+					{Name: "Recovery code", Value: "1234-5678", Protected: true},
+					{Name: "Account", Value: "personal"},
+				},
+				Tags: []string{"work", "code"},
+			},
+			{
+				Group:    []string{"Banking", "Cards"},
+				Title:    "bank żółw",
+				Username: "użytkownik",
+				Password: "hasło ✓",
+				Fields:   []Field{},
+				Tags:     []string{},
+			},
+		},
+	}
+}
+
+// Cheapest Argon2id parameters accepted by newKDF, so tests do not spend seconds in key derivation
+var testArgon2 = argon2Params{variant: argon2id, iterations: 1, memory: 8, parallelism: 1}
+
+func saveDatabase(t *testing.T, database *Database, password string, keyFile []byte) []byte {
+	t.Helper()
+
+	var saved bytes.Buffer
+
+	if err := save(&saved, database, password, keyFile, testArgon2); err != nil {
+		t.Fatalf("save() = %v", err)
+	}
+
+	return saved.Bytes()
+}
+
+func TestSaveOpenRoundTrip(t *testing.T) {
+	keyFile := bytes.Repeat([]byte{0x42}, 32)
+
+	tests := []struct {
+		name     string
+		password string
+		keyFile  []byte
+	}{
+		{"password", "master password", nil},
+		{"password and key file", "master password", keyFile},
+		{"key file only", "", keyFile},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			saved := saveDatabase(t, testDatabase(), test.password, test.keyFile)
+
+			opened, err := Open(bytes.NewReader(saved), test.password, test.keyFile)
+
+			if err != nil {
+				t.Fatalf("Open() = %v", err)
+			}
+
+			if want := testDatabase(); !reflect.DeepEqual(opened, want) {
+				t.Fatalf("Open() = %+v, want %+v", opened, want)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsWrongCredentials(t *testing.T) {
+	keyFile := bytes.Repeat([]byte{0x42}, 32)
+	saved := saveDatabase(t, testDatabase(), "master password", keyFile)
+
+	tests := []struct {
+		name     string
+		password string
+		keyFile  []byte
+		want     error
+	}{
+		{"wrong password", "wrong password", keyFile, InvalidCredentials},
+		{"missing key file", "master password", nil, InvalidCredentials},
+		{"wrong key file", "master password", bytes.Repeat([]byte{0x43}, 32), InvalidCredentials},
+		{"no credentials", "", nil, MissingCredentials},
+	}
+
+	for _, test := range tests {
+		_, err := Open(bytes.NewReader(saved), test.password, test.keyFile)
+
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: Open() = %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestOpenRejectsDamagedFile(t *testing.T) {
+	saved := saveDatabase(t, testDatabase(), "master password", nil)
+
+	if _, err := Open(bytes.NewReader([]byte("not a database")), "master password", nil); !errors.Is(err, InvalidSignature) {
+		t.Errorf("Open() of other file = %v, want InvalidSignature", err)
+	}
+
+	// Last byte belongs to encrypted payload, covered by HMAC of its block
+	damaged := bytes.Clone(saved)
+	damaged[len(damaged)-1] ^= 0xff
+
+	if _, err := Open(bytes.NewReader(damaged), "master password", nil); !errors.Is(err, CorruptedDatabase) {
+		t.Errorf("Open() of damaged payload = %v, want CorruptedDatabase", err)
+	}
+}
+
+func TestPasswordEntriesRoundTrip(t *testing.T) {
+	database := testDatabase()
+	entries := database.PasswordEntries()
+
+	if !reflect.DeepEqual(entries[1].Tags, []string{"Banking/Cards"}) {
+		t.Fatalf("Tags of entry in group = %q, want group path", entries[1].Tags)
+	}
+
+	// Group path comes back as tag of entry in root group
+	want := testDatabase()
+	want.Entries[1].Group = []string{}
+	want.Entries[1].Tags = []string{"Banking/Cards"}
+
+	if got := NewDatabase(database.Name, entries); !reflect.DeepEqual(got, want) {
+		t.Fatalf("NewDatabase(PasswordEntries()) = %+v, want %+v", got, want)
+	}
+}
+
+func TestSaveWritesDefaultArgon2Parameters(t *testing.T) {
+	var saved bytes.Buffer
+
+	if err := Save(&saved, testDatabase(), "master password", nil); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+
+	header, err := readHeader(saved.Bytes())
+
+	if err != nil {
+		t.Fatalf("readHeader() = %v", err)
+	}
+
+	transform, ok := header.kdf.(argon2Transform)
+	want := argon2Params{variant: argon2id, iterations: writeArgon2Iterations, memory: writeArgon2Memory / 1024, parallelism: writeArgon2Parallelism}
+
+	if !ok || !reflect.DeepEqual(transform.params, want) {
+		t.Fatalf("KDF of saved database = %+v, want %+v", header.kdf, want)
+	}
+}
+
+func TestDecompressStopsAtLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(make([]byte, 4<<20))
+	writer.Close()
+
+	if _, err := decompress(compressed.Bytes(), 1<<20); !errors.Is(err, CorruptedDatabase) {
+		t.Errorf("decompress() over limit = %v, want CorruptedDatabase", err)
+	}
+
+	decompressed, err := decompress(compressed.Bytes(), 4<<20)
+
+	if err != nil || len(decompressed) != 4<<20 {
+		t.Errorf("decompress() at limit = %d bytes, %v", len(decompressed), err)
+	}
+}
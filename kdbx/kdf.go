@@ -0,0 +1,127 @@
+package kdbx
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Key derivation functions identified by UUID in KDF parameters
+const (
+	aesKDF       = "c9d9f39a628a4460bf740d08c18a4fea"
+	aesKDFLegacy = "7c02bb8279a74ac0927d114a00648238" // AES-KDF as identified in KDBX 3.1
+	argon2dKDF   = "ef636ddf8c29444b91f7a9a403e30a0c"
+	argon2idKDF  = "9e298b1960db4772bd3c5ce8c3efe2c6"
+)
+
+// Guard against databases asking for more memory or time than import can reasonably take. Memory is checked after
+// it is raised to minimum blocks per lane, passes are limited through total number of processed blocks.
+const (
+	argon2MaxMemory      = 4 << 30
+	argon2MaxParallelism = 256
+	argon2MaxWork        = 64 << 20 // blocks processed over all passes - 64 passes over 1 GiB
+
+	// About half a minute of AES-KDF, far more than KeePass picks for one second delay
+	aesMaxRounds = 1 << 30
+)
+
+type kdf interface {
+	transform(compositeKey []byte) ([]byte, error)
+}
+
+func newKDF(parameters variantDictionary) (kdf, error) {
+	switch fmt.Sprintf("%x", parameters["$UUID"]) {
+	case aesKDF, aesKDFLegacy:
+		rounds, found := parameters.uint64("R")
+
+		if !found || len(parameters["S"]) != 32 {
+			return nil, CorruptedDatabase
+		}
+
+		if rounds > aesMaxRounds {
+			return nil, fmt.Errorf("%w AES-KDF rounds are out of range.", UnsupportedKDF)
+		}
+
+		return aesTransform{seed: parameters["S"], rounds: rounds}, nil
+
+	case argon2dKDF, argon2idKDF:
+		variant := argon2d
+
+		if fmt.Sprintf("%x", parameters["$UUID"]) == argon2idKDF {
+			variant = argon2id
+		}
+
+		iterations, iterationsFound := parameters.uint64("I")
+		memory, memoryFound := parameters.uint64("M")
+		parallelism, parallelismFound := parameters.uint64("P")
+		version, versionFound := parameters.uint64("V")
+
+		if !iterationsFound || !memoryFound || !parallelismFound || !versionFound || len(parameters["S"]) == 0 {
+			return nil, CorruptedDatabase
+		}
+
+		// Argon2 1.0 differs in how passes after the first are combined - KeePass never wrote it by default
+		if version != argon2Version {
+			return nil, fmt.Errorf("%w Argon2 version 0x%x.", UnsupportedKDF, version)
+		}
+
+		if iterations < 1 || parallelism < 1 || parallelism > argon2MaxParallelism || memory < 8*1024 || memory > argon2MaxMemory {
+			return nil, fmt.Errorf("%w Argon2 parameters are out of range.", UnsupportedKDF)
+		}
+
+		memoryBlocks := argon2MemoryBlocks(uint32(memory/1024), uint32(parallelism))
+
+		if uint64(memoryBlocks)*1024 > argon2MaxMemory || iterations > argon2MaxWork/uint64(memoryBlocks) {
+			return nil, fmt.Errorf("%w Argon2 parameters are out of range.", UnsupportedKDF)
+		}
+
+		return argon2Transform{
+			salt: parameters["S"],
+			params: argon2Params{
+				variant:     variant,
+				iterations:  uint32(iterations),
+				memory:      uint32(memory / 1024),
+				parallelism: uint32(parallelism),
+				secret:      parameters["K"],
+				associated:  parameters["A"],
+			},
+		}, nil
+	}
+
+	return nil, UnsupportedKDF
+}
+
+type aesTransform struct {
+	seed   []byte
+	rounds uint64
+}
+
+// Encrypts both halves of composite key with AES-256 in ECB mode given number of rounds
+func (transform aesTransform) transform(compositeKey []byte) ([]byte, error) {
+	block, err := aes.NewCipher(transform.seed)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w %v", CorruptedDatabase, err)
+	}
+
+	key := make([]byte, 32)
+	copy(key, compositeKey)
+
+	for range transform.rounds {
+		block.Encrypt(key[:16], key[:16])
+		block.Encrypt(key[16:], key[16:])
+	}
+
+	transformed := sha256.Sum256(key)
+
+	return transformed[:], nil
+}
+
+type argon2Transform struct {
+	salt   []byte
+	params argon2Params
+}
+
+func (transform argon2Transform) transform(compositeKey []byte) ([]byte, error) {
+	return argon2Key(compositeKey, transform.salt, transform.params, 32), nil
+}
@@ -0,0 +1,110 @@
+package kdbx
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func argon2Parameters(iterations uint64, memory uint64, parallelism uint32) variantDictionary {
+	return variantDictionary{
+		"$UUID": mustDecodeHex(argon2dKDF),
+		"S":     make([]byte, 32),
+		"I":     binary.LittleEndian.AppendUint64(nil, iterations),
+		"M":     binary.LittleEndian.AppendUint64(nil, memory),
+		"P":     binary.LittleEndian.AppendUint32(nil, parallelism),
+		"V":     binary.LittleEndian.AppendUint32(nil, argon2Version),
+	}
+}
+
+func TestNewKDFAcceptsArgon2ParametersInRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		iterations  uint64
+		memory      uint64
+		parallelism uint32
+	}{
+		{"written by Save", writeArgon2Iterations, writeArgon2Memory, writeArgon2Parallelism},
+		{"KeePass defaults", 2, 1 << 20, 2},
+		{"maximal memory", 16, argon2MaxMemory, 4},
+		{"maximal lanes", 1, 8 * 1024, argon2MaxParallelism},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newKDF(argon2Parameters(test.iterations, test.memory, test.parallelism))
+
+			if err != nil {
+				t.Fatalf("newKDF() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// Parameters below would allocate or compute far more than import can take
+func TestNewKDFRejectsArgon2ParametersOutOfRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		iterations  uint64
+		memory      uint64
+		parallelism uint32
+	}{
+		{"no passes", 0, 1 << 20, 2},
+		{"no lanes", 2, 1 << 20, 0},
+		{"too little memory", 2, 1024, 2},
+		{"too much memory", 2, argon2MaxMemory + 1024, 2},
+		{"lanes raising memory", 1, 8 * 1024, 1<<24 - 1},
+		{"too many lanes", 1, 1 << 20, argon2MaxParallelism + 1},
+		{"too many passes", 1<<32 - 1, 1 << 20, 2},
+		{"too many passes over large memory", 65, 1 << 30, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newKDF(argon2Parameters(test.iterations, test.memory, test.parallelism))
+
+			if !errors.Is(err, UnsupportedKDF) {
+				t.Fatalf("newKDF() = %v, want UnsupportedKDF", err)
+			}
+		})
+	}
+}
+
+func aesParameters(rounds uint64) variantDictionary {
+	return variantDictionary{
+		"$UUID": mustDecodeHex(aesKDF),
+		"S":     make([]byte, 32),
+		"R":     binary.LittleEndian.AppendUint64(nil, rounds),
+	}
+}
+
+func TestNewKDFLimitsAESRounds(t *testing.T) {
+	for _, rounds := range []uint64{0, 60_000, aesMaxRounds} {
+		if _, err := newKDF(aesParameters(rounds)); err != nil {
+			t.Errorf("newKDF() with %d rounds = %v", rounds, err)
+		}
+	}
+
+	for _, rounds := range []uint64{aesMaxRounds + 1, 1 << 63} {
+		if _, err := newKDF(aesParameters(rounds)); !errors.Is(err, UnsupportedKDF) {
+			t.Errorf("newKDF() with %d rounds = %v, want UnsupportedKDF", rounds, err)
+		}
+	}
+}
+
+func TestArgon2MemoryBlocks(t *testing.T) {
+	tests := []struct {
+		memory, lanes, want uint32
+	}{
+		{memory: 32, lanes: 4, want: 32},
+		{memory: 35, lanes: 4, want: 32},
+		{memory: 8, lanes: 4, want: 32}, // at least 8 blocks per lane
+		{memory: 65536, lanes: 2, want: 65536},
+	}
+
+	for _, test := range tests {
+		if got := argon2MemoryBlocks(test.memory, test.lanes); got != test.want {
+			t.Errorf("argon2MemoryBlocks(%d, %d) = %d, want %d", test.memory, test.lanes, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Combines password and key file into key which KDF transforms. Password is left out when empty, as KeePass does
+// for databases protected only with key file.
+func compositeKey(password string, keyFile []byte) ([]byte, error) {
+	composite := sha256.New()
+
+	if password != "" {
+		passwordHash := sha256.Sum256([]byte(password))
+		composite.Write(passwordHash[:])
+	}
+
+	if len(keyFile) > 0 {
+		key, err := keyFileKey(keyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		composite.Write(key)
+	}
+
+	return composite.Sum(nil), nil
+}
+
+// Returns 32 byte key stored in key file. KeePass XML key files (version 1.0 and 2.0), raw 32 byte keys and keys
+// written as 64 hex digits are read as such, any other file is hashed as a whole.
+func keyFileKey(keyFile []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(keyFile)
+
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<KeyFile")) {
+		var document struct {
+			Version string `xml:"Meta>Version"`
+			Data    struct {
+				Hash  string `xml:"Hash,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"Key>Data"`
+		}
+
+		if xml.Unmarshal(trimmed, &document) == nil && document.Data.Value != "" {
+			return xmlKeyFileKey(document.Version, document.Data.Value, document.Data.Hash)
+		}
+	}
+
+	if len(keyFile) == 32 {
+		return keyFile, nil
+	}
+
+	if len(keyFile) == 64 {
+		key, err := hex.DecodeString(string(keyFile))
+
+		if err == nil {
+			return key, nil
+		}
+	}
+
+	hash := sha256.Sum256(keyFile)
+
+	return hash[:], nil
+}
+
+func xmlKeyFileKey(version string, data string, hash string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(version, "1."):
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+
+		if err != nil {
+			return nil, InvalidKeyFile
+		}
+
+		return key, nil
+
+	case strings.HasPrefix(version, "2."):
+		key, err := hex.DecodeString(strings.Join(strings.Fields(data), ""))
+
+		if err != nil || len(key) != 32 {
+			return nil, InvalidKeyFile
+		}
+
+		// Version 2.0 keeps first 4 bytes of key hash to detect typos in hand-copied keys
+		keyHash := sha256.Sum256(key)
+
+		if hash != "" && !strings.EqualFold(hex.EncodeToString(keyHash[:4]), hash) {
+			return nil, InvalidKeyFile
+		}
+
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w Key file version %q is not supported.", InvalidKeyFile, version)
+}
@@ -0,0 +1,236 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+	"golang.org/x/crypto/twofish"
+)
+
+// Ciphers of payload identified by UUID in outer header
+const (
+	aesCipher      = "31c1f2e6bf714350be5805216afc5aff"
+	chaCha20Cipher = "d6038a2b8b6f4cb5a524339a31dbb59a"
+	twofishCipher  = "ad68f29f576f4bb9a36ad47af965346c"
+)
+
+// Header HMAC is computed like HMAC of block with the highest index
+const headerHMACIndex = math.MaxUint64
+
+// Computes HMAC-SHA256 of block with key bound to block index
+func blockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	indexBytes := binary.LittleEndian.AppendUint64(nil, index)
+
+	blockKey := sha512.New()
+	blockKey.Write(indexBytes)
+	blockKey.Write(hmacKey)
+
+	mac := hmac.New(sha256.New, blockKey.Sum(nil))
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
+
+// Verifies HMAC of every block of encrypted payload and joins their contents. Block of zero length ends payload.
+func readBlocks(data []byte, hmacKey []byte) ([]byte, error) {
+	var payload bytes.Buffer
+
+	for index := uint64(0); ; index++ {
+		if len(data) < sha256.Size+4 {
+			return nil, CorruptedDatabase
+		}
+
+		storedHMAC := data[:sha256.Size]
+		blockLength := int(binary.LittleEndian.Uint32(data[sha256.Size : sha256.Size+4]))
+
+		if blockLength < 0 || len(data) < sha256.Size+4+blockLength {
+			return nil, CorruptedDatabase
+		}
+
+		// HMAC covers block index, length and contents
+		block := data[sha256.Size+4 : sha256.Size+4+blockLength]
+		authenticated := binary.LittleEndian.AppendUint64(nil, index)
+		authenticated = append(authenticated, data[sha256.Size:sha256.Size+4+blockLength]...)
+
+		if !hmac.Equal(storedHMAC, blockHMAC(hmacKey, index, authenticated)) {
+			return nil, fmt.Errorf("%w Block %d failed integrity check.", CorruptedDatabase, index)
+		}
+
+		if blockLength == 0 {
+			return payload.Bytes(), nil
+		}
+
+		payload.Write(block)
+		data = data[sha256.Size+4+blockLength:]
+	}
+}
+
+func decrypt(cipherID string, key []byte, initialVector []byte, encrypted []byte) ([]byte, error) {
+	switch cipherID {
+	case aesCipher, twofishCipher:
+		var block cipher.Block
+		var err error
+
+		if cipherID == aesCipher {
+			block, err = aes.NewCipher(key)
+		} else {
+			block, err = twofish.NewCipher(key)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		if len(initialVector) != block.BlockSize() || len(encrypted) == 0 || len(encrypted)%block.BlockSize() != 0 {
+			return nil, CorruptedDatabase
+		}
+
+		decrypted := make([]byte, len(encrypted))
+		cipher.NewCBCDecrypter(block, initialVector).CryptBlocks(decrypted, encrypted)
+
+		// PKCS#7 padding
+		padding := int(decrypted[len(decrypted)-1])
+
+		if padding == 0 || padding > block.BlockSize() || !bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+			return nil, CorruptedDatabase
+		}
+
+		return decrypted[:len(decrypted)-padding], nil
+
+	case chaCha20Cipher:
+		stream, err := chacha20.NewUnauthenticatedCipher(key, initialVector)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		decrypted := make([]byte, len(encrypted))
+		stream.XORKeyStream(decrypted, encrypted)
+
+		return decrypted, nil
+	}
+
+	return nil, UnsupportedCipher
+}
+
+// Fields of inner header, at the start of decrypted payload
+const (
+	innerHeaderEnd       = 0
+	innerHeaderStreamID  = 1
+	innerHeaderStreamKey = 2
+	innerHeaderBinary    = 3
+)
+
+// Ciphers of inner random stream protecting values marked as protected in XML document
+const (
+	innerStreamNone     = 0
+	innerStreamSalsa20  = 2
+	innerStreamChaCha20 = 3
+)
+
+// Reads inner header and returns stream decrypting protected values together with XML document following the header
+func readInnerHeader(data []byte) (cipher.Stream, []byte, error) {
+	streamID := uint32(innerStreamNone)
+	var streamKey []byte
+
+	position := 0
+
+	for {
+		if len(data) < position+5 {
+			return nil, nil, CorruptedDatabase
+		}
+
+		fieldID := data[position]
+		fieldLength := int(binary.LittleEndian.Uint32(data[position+1 : position+5]))
+		position += 5
+
+		if fieldLength < 0 || len(data) < position+fieldLength {
+			return nil, nil, CorruptedDatabase
+		}
+
+		value := data[position : position+fieldLength]
+		position += fieldLength
+
+		switch fieldID {
+		case innerHeaderEnd:
+			stream, err := newInnerStream(streamID, streamKey)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return stream, data[position:], nil
+
+		case innerHeaderStreamID:
+			if len(value) != 4 {
+				return nil, nil, CorruptedDatabase
+			}
+
+			streamID = binary.LittleEndian.Uint32(value)
+
+		case innerHeaderStreamKey:
+			streamKey = value
+
+		case innerHeaderBinary:
+			// Attachments are not imported
+		}
+	}
+}
+
+func newInnerStream(streamID uint32, key []byte) (cipher.Stream, error) {
+	switch streamID {
+	case innerStreamNone:
+		return nil, nil
+
+	case innerStreamChaCha20:
+		hash := sha512.Sum512(key)
+		stream, err := chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+
+		if err != nil {
+			return nil, fmt.Errorf("%w %v", CorruptedDatabase, err)
+		}
+
+		return stream, nil
+
+	case innerStreamSalsa20:
+		hash := sha256.Sum256(key)
+		return &salsa20Stream{key: hash, nonce: [8]byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}, used: 64}, nil
+	}
+
+	return nil, fmt.Errorf("%w Unknown inner stream %d.", UnsupportedCipher, streamID)
+}
+
+// Salsa20 key stream continuing across calls, which golang.org/x/crypto/salsa20 does not keep between them
+type salsa20Stream struct {
+	key     [32]byte
+	nonce   [8]byte
+	counter uint64
+	block   [64]byte
+	used    int // bytes of current key stream block already used, 64 when new block is needed
+}
+
+func (stream *salsa20Stream) XORKeyStream(destination []byte, source []byte) {
+	for i := range source {
+		if stream.used == len(stream.block) {
+			var counter [16]byte
+			copy(counter[:8], stream.nonce[:])
+			binary.LittleEndian.PutUint64(counter[8:], stream.counter)
+
+			salsa.XORKeyStream(stream.block[:], make([]byte, len(stream.block)), &counter, &stream.key)
+			stream.counter++
+			stream.used = 0
+		}
+
+		destination[i] = source[i] ^ stream.block[stream.used]
+		stream.used++
+	}
+}
@@ -0,0 +1,447 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// Databases are written the way KeePassXC creates new ones - ChaCha20 payload, Argon2id key derivation,
+// gzip compression and ChaCha20 inner stream protecting passwords and fields marked as protected.
+
+// Argon2id parameters of written databases - KeePassXC defaults for memory and lanes
+const (
+	writeArgon2Iterations  = 10
+	writeArgon2Memory      = 64 * 1024 * 1024 // bytes, as stored in KDF parameters
+	writeArgon2Parallelism = 2
+)
+
+// Payload is split into blocks of this size, each with its own HMAC
+const writeBlockSize = 1024 * 1024
+
+// Types of values in variant dictionary
+const (
+	variantUint32 = 0x04
+	variantUint64 = 0x05
+	variantBytes  = 0x42
+)
+
+const generator = "frosk"
+
+// Encrypts database with password and optional key file and writes it in KDBX 4 format
+func Save(writer io.Writer, database *Database, password string, keyFile []byte) error {
+	params := argon2Params{
+		variant:     argon2id,
+		iterations:  writeArgon2Iterations,
+		memory:      writeArgon2Memory / 1024,
+		parallelism: writeArgon2Parallelism,
+	}
+
+	return save(writer, database, password, keyFile, params)
+}
+
+// Writes database with key derived using given Argon2id parameters
+func save(writer io.Writer, database *Database, password string, keyFile []byte, params argon2Params) error {
+	if password == "" && len(keyFile) == 0 {
+		return MissingCredentials
+	}
+
+	compositeKey, err := compositeKey(password, keyFile)
+
+	if err != nil {
+		return err
+	}
+
+	masterSeed, err := randomBytes(32)
+
+	if err != nil {
+		return err
+	}
+
+	encryptionIV, err := randomBytes(12)
+
+	if err != nil {
+		return err
+	}
+
+	kdfSalt, err := randomBytes(32)
+
+	if err != nil {
+		return err
+	}
+
+	streamKey, err := randomBytes(64)
+
+	if err != nil {
+		return err
+	}
+
+	kdfParameters := writeVariantDictionary([]variantValue{
+		{variantBytes, "$UUID", mustDecodeHex(argon2idKDF)},
+		{variantUint64, "I", binary.LittleEndian.AppendUint64(nil, uint64(params.iterations))},
+		{variantUint64, "M", binary.LittleEndian.AppendUint64(nil, uint64(params.memory)*1024)},
+		{variantUint32, "P", binary.LittleEndian.AppendUint32(nil, params.parallelism)},
+		{variantUint32, "V", binary.LittleEndian.AppendUint32(nil, argon2Version)},
+		{variantBytes, "S", kdfSalt},
+	})
+
+	headerBytes := binary.LittleEndian.AppendUint32(nil, signature1)
+	headerBytes = binary.LittleEndian.AppendUint32(headerBytes, signature2)
+	headerBytes = binary.LittleEndian.AppendUint16(headerBytes, 1) // minor version
+	headerBytes = binary.LittleEndian.AppendUint16(headerBytes, majorVersion)
+	headerBytes = appendHeaderField(headerBytes, headerCipherID, mustDecodeHex(chaCha20Cipher))
+	headerBytes = appendHeaderField(headerBytes, headerCompression, binary.LittleEndian.AppendUint32(nil, 1))
+	headerBytes = appendHeaderField(headerBytes, headerMasterSeed, masterSeed)
+	headerBytes = appendHeaderField(headerBytes, headerEncryptionIV, encryptionIV)
+	headerBytes = appendHeaderField(headerBytes, headerKDFParameters, kdfParameters)
+	headerBytes = appendHeaderField(headerBytes, headerEnd, []byte("\r\n\r\n"))
+
+	writtenHeader := header{masterSeed: masterSeed}
+	transform := argon2Transform{salt: kdfSalt, params: params}
+
+	transformedKey, err := transform.transform(compositeKey)
+
+	if err != nil {
+		return err
+	}
+
+	encryptionKey, hmacKey := writtenHeader.keys(transformedKey)
+
+	document, err := writeDocument(database, streamKey)
+
+	if err != nil {
+		return err
+	}
+
+	innerHeader := appendHeaderField(nil, innerHeaderStreamID, binary.LittleEndian.AppendUint32(nil, innerStreamChaCha20))
+	innerHeader = appendHeaderField(innerHeader, innerHeaderStreamKey, streamKey)
+	innerHeader = appendHeaderField(innerHeader, innerHeaderEnd, nil)
+
+	var compressed bytes.Buffer
+	gzipWriter := gzip.NewWriter(&compressed)
+	gzipWriter.Write(innerHeader)
+	gzipWriter.Write(document)
+	err = gzipWriter.Close()
+	clear(document)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during compressing KeePass database: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	stream, err := chacha20.NewUnauthenticatedCipher(encryptionKey, encryptionIV)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during creating cipher of KeePass database: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	encrypted := compressed.Bytes()
+	stream.XORKeyStream(encrypted, encrypted)
+
+	headerHash := sha256.Sum256(headerBytes)
+
+	output := bytes.NewBuffer(headerBytes)
+	output.Write(headerHash[:])
+	output.Write(blockHMAC(hmacKey, headerHMACIndex, headerBytes))
+	writeBlocks(output, encrypted, hmacKey)
+
+	_, err = writer.Write(output.Bytes())
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during writing KeePass database: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Splits encrypted payload into blocks with HMAC, ending with empty block - reverse of readBlocks
+func writeBlocks(output *bytes.Buffer, payload []byte, hmacKey []byte) {
+	for index := uint64(0); ; index++ {
+		block := payload[:min(writeBlockSize, len(payload))]
+		payload = payload[len(block):]
+
+		blockLength := binary.LittleEndian.AppendUint32(nil, uint32(len(block)))
+		authenticated := binary.LittleEndian.AppendUint64(nil, index)
+		authenticated = append(authenticated, blockLength...)
+		authenticated = append(authenticated, block...)
+
+		output.Write(blockHMAC(hmacKey, index, authenticated))
+		output.Write(blockLength)
+		output.Write(block)
+
+		if len(block) == 0 {
+			return
+		}
+	}
+}
+
+func appendHeaderField(data []byte, fieldID byte, value []byte) []byte {
+	data = append(data, fieldID)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(value)))
+	return append(data, value...)
+}
+
+type variantValue struct {
+	valueType byte
+	name      string
+	value     []byte
+}
+
+// Serializes values in given order - reverse of readVariantDictionary
+func writeVariantDictionary(values []variantValue) []byte {
+	data := []byte{0x00, 0x01} // version 1.0
+
+	for _, value := range values {
+		data = append(data, value.valueType)
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(value.name)))
+		data = append(data, value.name...)
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(value.value)))
+		data = append(data, value.value...)
+	}
+
+	return append(data, 0x00)
+}
+
+func randomBytes(length int) ([]byte, error) {
+	data := make([]byte, length)
+	_, err := rand.Read(data)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Can't create random bytes for KeePass database: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	return data, nil
+}
+
+func mustDecodeHex(value string) []byte {
+	decoded, err := hex.DecodeString(value)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return decoded
+}
+
+// XML document as written by KeePass - only elements read by KeePass and KeePassXC without defaults are included
+type xmlDocument struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    xmlMeta  `xml:"Meta"`
+	Root    struct {
+		Group *xmlGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type xmlMeta struct {
+	Generator         string `xml:"Generator"`
+	DatabaseName      string `xml:"DatabaseName"`
+	RecycleBinEnabled string `xml:"RecycleBinEnabled"`
+}
+
+type xmlGroup struct {
+	UUID    string      `xml:"UUID"`
+	Name    string      `xml:"Name"`
+	Times   xmlTimes    `xml:"Times"`
+	Entries []*xmlEntry `xml:"Entry"`
+	Groups  []*xmlGroup `xml:"Group"`
+}
+
+type xmlEntry struct {
+	UUID    string      `xml:"UUID"`
+	Times   xmlTimes    `xml:"Times"`
+	Tags    string      `xml:"Tags,omitempty"`
+	Strings []xmlString `xml:"String"`
+}
+
+type xmlString struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Protected string `xml:"Protected,attr,omitempty"`
+		Text      string `xml:",chardata"`
+	} `xml:"Value"`
+}
+
+type xmlTimes struct {
+	CreationTime         string `xml:"CreationTime"`
+	LastModificationTime string `xml:"LastModificationTime"`
+	LastAccessTime       string `xml:"LastAccessTime"`
+	LocationChanged      string `xml:"LocationChanged"`
+	Expires              string `xml:"Expires"`
+}
+
+// Seconds between 0001-01-01 and Unix epoch - KDBX 4 stores times as base64 of seconds since year 1
+const kdbxEpochOffset = 62135596800
+
+func newXMLTimes(now time.Time) xmlTimes {
+	encoded := base64.StdEncoding.EncodeToString(binary.LittleEndian.AppendUint64(nil, uint64(now.Unix()+kdbxEpochOffset)))
+
+	return xmlTimes{
+		CreationTime:         encoded,
+		LastModificationTime: encoded,
+		LastAccessTime:       encoded,
+		LocationChanged:      encoded,
+		Expires:              "False",
+	}
+}
+
+// Builds XML document with group tree made of group paths of entries. Protected values are encrypted with inner
+// stream in document order - entries of a group first, then its subgroups - as parseDocument decrypts them.
+func writeDocument(database *Database, streamKey []byte) ([]byte, error) {
+	stream, err := newInnerStream(innerStreamChaCha20, streamKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	uuid := func() (string, error) {
+		value, err := randomBytes(16)
+		return base64.StdEncoding.EncodeToString(value), err
+	}
+
+	rootName := database.Name
+
+	if rootName == "" {
+		rootName = "Root"
+	}
+
+	rootUUID, err := uuid()
+
+	if err != nil {
+		return nil, err
+	}
+
+	root := &xmlGroup{UUID: rootUUID, Name: rootName, Times: newXMLTimes(now)}
+
+	for _, entry := range database.Entries {
+		group := root
+
+		for _, name := range entry.Group {
+			var subgroup *xmlGroup
+
+			for _, existing := range group.Groups {
+				if existing.Name == name {
+					subgroup = existing
+					break
+				}
+			}
+
+			if subgroup == nil {
+				groupUUID, err := uuid()
+
+				if err != nil {
+					return nil, err
+				}
+
+				subgroup = &xmlGroup{UUID: groupUUID, Name: name, Times: newXMLTimes(now)}
+				group.Groups = append(group.Groups, subgroup)
+			}
+
+			group = subgroup
+		}
+
+		entryUUID, err := uuid()
+
+		if err != nil {
+			return nil, err
+		}
+
+		written := &xmlEntry{UUID: entryUUID, Times: newXMLTimes(now), Strings: []xmlString{}}
+
+		for _, tag := range entry.Tags {
+			if written.Tags != "" {
+				written.Tags += ";"
+			}
+
+			written.Tags += tag
+		}
+
+		// KeePass requires keys of entry to be unique - standard fields come first, custom ones can't replace them
+		usedKeys := map[string]bool{}
+		addString := func(key string, value string, protected bool) {
+			name := key
+
+			for suffix := 2; usedKeys[name]; suffix++ {
+				name = fmt.Sprintf("%s (%d)", key, suffix)
+			}
+
+			usedKeys[name] = true
+
+			field := xmlString{Key: name}
+			field.Value.Text = value
+
+			if protected {
+				field.Value.Protected = "True"
+			}
+
+			written.Strings = append(written.Strings, field)
+		}
+
+		addString("Title", entry.Title, false)
+		addString("UserName", entry.Username, false)
+		addString("Password", entry.Password, true)
+		addString("URL", entry.URL, false)
+		addString("Notes", entry.Notes, false)
+
+		for _, field := range entry.Fields {
+			addString(field.Name, field.Value, field.Protected)
+		}
+
+		group.Entries = append(group.Entries, written)
+	}
+
+	protectGroup(root, stream)
+
+	document := xmlDocument{Meta: xmlMeta{Generator: generator, DatabaseName: database.Name, RecycleBinEnabled: "False"}}
+	document.Root.Group = root
+
+	encoded, err := xml.MarshalIndent(document, "", "\t")
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Error during encoding KeePass document: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	return append([]byte(xml.Header), encoded...), nil
+}
+
+// Encrypts protected values of group in order they are marshaled
+func protectGroup(group *xmlGroup, stream cipher.Stream) {
+	for _, entry := range group.Entries {
+		for i := range entry.Strings {
+			value := &entry.Strings[i].Value
+
+			if value.Protected == "" {
+				continue
+			}
+
+			encrypted := []byte(value.Text)
+			stream.XORKeyStream(encrypted, encrypted)
+			value.Text = base64.StdEncoding.EncodeToString(encrypted)
+		}
+	}
+
+	for _, subgroup := range group.Groups {
+		protectGroup(subgroup, stream)
+	}
+}
@@ -1,19 +1,70 @@
-CREATE TABLE IF NOT EXISTS passwords (
+-- Generated by `make schema` from migrations in backend/migrations.go. Do not edit by hand.
+-- Schema version: 11
+
+CREATE TABLE integrity (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    version INTEGER NOT NULL,
+    mac TEXT NOT NULL,
+    updated_at TEXT NULL
+) STRICT;
+
+CREATE TABLE master (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    password TEXT UNIQUE NOT NULL,
+    secret_key TEXT UNIQUE NOT NULL,
+    salt TEXT UNIQUE NOT NULL,
+    initial_vector TEXT UNIQUE NOT NULL,
+    created_at TEXT NULL,
+    updated_at TEXT NULL,
+    kdf_algorithm TEXT NOT NULL DEFAULT 'argon2id',
+    kdf_time INTEGER NOT NULL DEFAULT 3,
+    kdf_memory INTEGER NOT NULL DEFAULT 524288,
+    kdf_threads INTEGER NOT NULL DEFAULT 8
+) STRICT;
+
+CREATE TABLE password_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    password_id INTEGER NOT NULL REFERENCES passwords (id),
+    password TEXT NOT NULL,
+    replaced_at TEXT NOT NULL
+) STRICT;
+
+CREATE TABLE password_tags (
+    password_id INTEGER NOT NULL REFERENCES passwords (id),
+    tag_id INTEGER NOT NULL REFERENCES tags (id),
+    PRIMARY KEY (password_id, tag_id)
+) STRICT;
+
+CREATE TABLE "passwords" (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     service_name TEXT UNIQUE NOT NULL,
     username TEXT NOT NULL,
     password TEXT NOT NULL,
-    initial_vector TEXT UNIQUE NOT NULL,
     created_at TEXT NULL,
+    updated_at TEXT NULL,
+    legacy_initial_vector TEXT NULL,
+    url TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    custom_fields TEXT NOT NULL DEFAULT '',
+    totp TEXT NOT NULL DEFAULT '',
+    service_name_index TEXT NULL,
+    deleted_at TEXT NULL
+) STRICT;
+
+CREATE TABLE settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
     updated_at TEXT NULL
 ) STRICT;
 
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    name_index TEXT UNIQUE NOT NULL
+) STRICT;
+
+CREATE INDEX password_history_password_id ON password_history (password_id);
 
-CREATE TABLE IF NOT EXISTS master (
-   	id INTEGER PRIMARY KEY AUTOINCREMENT,
-    password TEXT UNIQUE NOT NULL,
-    salt TEXT UNIQUE NOT NULL,
-    initial_vector TEXT UNIQUE NOT NULL,
-    created_at TEXT NULL,
-    updated_at TEXT NULL
-) STRICT;
\ No newline at end of file
+CREATE INDEX password_tags_tag_id ON password_tags (tag_id);
+
+CREATE UNIQUE INDEX passwords_service_name_index ON passwords (service_name_index) WHERE deleted_at IS NULL;
@@ -0,0 +1,119 @@
+package strength
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Returns warning and suggestions explaining the score, based on the longest match of the sequence
+func feedback(score int, sequence []*match) (string, []string) {
+	if len(sequence) == 0 {
+		return "", []string{"Use a few words, avoid common phrases.", "No need for symbols, digits, or uppercase letters."}
+	}
+
+	if score > 2 {
+		return "", []string{}
+	}
+
+	longest := sequence[0]
+
+	for _, match := range sequence[1:] {
+		if len(match.token) > len(longest.token) {
+			longest = match
+		}
+	}
+
+	warning, suggestions := matchFeedback(longest, len(sequence) == 1)
+
+	return warning, append([]string{"Add another word or two. Uncommon words are better."}, suggestions...)
+}
+
+func matchFeedback(match *match, isSoleMatch bool) (string, []string) {
+	switch match.pattern {
+	case dictionaryPattern:
+		return dictionaryFeedback(match, isSoleMatch)
+
+	case spatialPattern:
+		warning := "Short keyboard patterns are easy to guess."
+
+		if match.turns == 1 {
+			warning = "Straight rows of keys are easy to guess."
+		}
+
+		return warning, []string{"Use a longer keyboard pattern with more turns."}
+
+	case repeatPattern:
+		warning := `Repeats like "abcabcabc" are only slightly harder to guess than "abc".`
+
+		if match.baseLength == 1 {
+			warning = `Repeats like "aaa" are easy to guess.`
+		}
+
+		return warning, []string{"Avoid repeated words and characters."}
+
+	case sequencePattern:
+		return "Sequences like abc or 6543 are easy to guess.", []string{"Avoid sequences."}
+
+	case yearPattern:
+		return "Recent years are easy to guess.", []string{"Avoid recent years.", "Avoid years that are associated with you."}
+
+	case datePattern:
+		return "Dates are often easy to guess.", []string{"Avoid dates and years that are associated with you."}
+	}
+
+	return "", []string{}
+}
+
+func dictionaryFeedback(match *match, isSoleMatch bool) (string, []string) {
+	warning := ""
+
+	switch match.dictionary {
+	case passwordsDictionary:
+		switch {
+		case isSoleMatch && !match.l33t && !match.reversed && match.rank <= 10:
+			warning = "This is a top-10 common password."
+		case isSoleMatch && !match.l33t && !match.reversed && match.rank <= 100:
+			warning = "This is a top-100 common password."
+		case isSoleMatch && !match.l33t && !match.reversed:
+			warning = "This is a very common password."
+		case math.Log10(match.guesses) <= 4:
+			warning = "This is similar to a commonly used password."
+		}
+
+	case englishDictionary:
+		if isSoleMatch {
+			warning = "A word by itself is easy to guess."
+		}
+
+	case namesDictionary, surnamesDictionary:
+		if isSoleMatch {
+			warning = "Names and surnames by themselves are easy to guess."
+		} else {
+			warning = "Common names and surnames are easy to guess."
+		}
+
+	case userInputsDictionary:
+		warning = "Avoid the service name, username and other details of the account."
+	}
+
+	suggestions := []string{}
+	token := []rune(match.token)
+
+	switch {
+	case unicode.IsUpper(token[0]) && strings.ToLower(string(token[1:])) == string(token[1:]):
+		suggestions = append(suggestions, "Capitalization doesn't help very much.")
+	case strings.ToUpper(match.token) == match.token && strings.ToLower(match.token) != match.token:
+		suggestions = append(suggestions, "All-uppercase is almost as easy to guess as all-lowercase.")
+	}
+
+	if match.reversed && len(token) >= 4 {
+		suggestions = append(suggestions, "Reversed words aren't much harder to guess.")
+	}
+
+	if match.l33t {
+		suggestions = append(suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much.")
+	}
+
+	return warning, suggestions
+}
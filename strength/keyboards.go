@@ -0,0 +1,119 @@
+package strength
+
+import (
+	"sync"
+)
+
+// Keyboard layouts as printed on keys. Rows of slanted keyboards are shifted by half a key, keypad keys are aligned.
+// Key is written as its character followed by character typed with shift.
+
+var qwertyLayout = []string{
+	"`~ 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+",
+	"    qQ wW eE rR tT yY uU iI oO pP [{ ]} \\|",
+	"     aA sS dD fF gG hH jJ kK lL ;: '\"",
+	"      zZ xX cC vV bB nN mM ,< .> /?",
+}
+
+var dvorakLayout = []string{
+	"`~ 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) [{ ]}",
+	"    '\" ,< .> pP yY fF gG cC rR lL /? =+ \\|",
+	"     aA oO eE uU iI dD hH tT nN sS -_",
+	"      ;: qQ jJ kK xX bB mM wW vV zZ",
+}
+
+var keypadLayout = []string{
+	"  / * -",
+	"7 8 9 +",
+	"4 5 6",
+	"1 2 3",
+	"  0 .",
+}
+
+type keyboard struct {
+	name string
+
+	// Keys around every character, in fixed order of directions - empty string where there is no key
+	adjacency map[rune][]string
+
+	shifted map[rune]bool // characters typed with shift
+
+	startingPositions float64
+	averageDegree     float64
+}
+
+var keyboards = sync.OnceValue(func() []*keyboard {
+	return []*keyboard{
+		newKeyboard("qwerty", qwertyLayout, true),
+		newKeyboard("dvorak", dvorakLayout, true),
+		newKeyboard("keypad", keypadLayout, false),
+	}
+})
+
+func newKeyboard(name string, layout []string, slanted bool) *keyboard {
+	type position struct{ x, y int }
+
+	keys := map[position]string{}
+
+	for y, line := range layout {
+		slant := 0
+
+		if slanted {
+			slant = y
+		}
+
+		for start := 0; start < len(line); {
+			if line[start] == ' ' {
+				start++
+				continue
+			}
+
+			end := start
+
+			for end < len(line) && line[end] != ' ' {
+				end++
+			}
+
+			keyWidth := end - start + 1
+			keys[position{(start - slant) / keyWidth, y}] = line[start:end]
+			start = end
+		}
+	}
+
+	keyboard := &keyboard{name: name, adjacency: map[rune][]string{}, shifted: map[rune]bool{}}
+	neighbourCount := 0
+
+	for at, key := range keys {
+		var around []position
+
+		if slanted {
+			around = []position{{at.x - 1, at.y}, {at.x, at.y - 1}, {at.x + 1, at.y - 1}, {at.x + 1, at.y}, {at.x, at.y + 1}, {at.x - 1, at.y + 1}}
+		} else {
+			around = []position{
+				{at.x - 1, at.y}, {at.x - 1, at.y - 1}, {at.x, at.y - 1}, {at.x + 1, at.y - 1},
+				{at.x + 1, at.y}, {at.x + 1, at.y + 1}, {at.x, at.y + 1}, {at.x - 1, at.y + 1},
+			}
+		}
+
+		neighbours := make([]string, len(around))
+
+		for index, neighbour := range around {
+			neighbours[index] = keys[neighbour]
+		}
+
+		for index, char := range []rune(key) {
+			keyboard.adjacency[char] = neighbours
+			keyboard.shifted[char] = index == 1
+
+			for _, neighbour := range neighbours {
+				if neighbour != "" {
+					neighbourCount++
+				}
+			}
+		}
+	}
+
+	keyboard.startingPositions = float64(len(keyboard.adjacency))
+	keyboard.averageDegree = float64(neighbourCount) / keyboard.startingPositions
+
+	return keyboard
+}
@@ -0,0 +1,686 @@
+package strength
+
+import (
+	"bufio"
+	_ "embed"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mszalewicz/frosk/generator"
+)
+
+// Most common passwords ordered from the most frequent one, collected from public password leaks
+
+//go:embed dictionaries/passwords.txt
+var commonPasswords string
+
+// Most frequent English words, ordered from the most frequent one. Lists of words, names and surnames come from
+// frequency lists of zxcvbn (MIT license).
+
+//go:embed dictionaries/english.txt
+var englishWords string
+
+// Most common first names, female and male ones taking turns from the most frequent one
+
+//go:embed dictionaries/names.txt
+var firstNames string
+
+// Most common surnames ordered from the most frequent one
+
+//go:embed dictionaries/surnames.txt
+var surnames string
+
+type pattern int
+
+const (
+	bruteforcePattern pattern = iota
+	dictionaryPattern
+	spatialPattern
+	repeatPattern
+	sequencePattern
+	yearPattern
+	datePattern
+)
+
+type dictionary int
+
+const (
+	passwordsDictionary dictionary = iota
+	englishDictionary
+	namesDictionary
+	surnamesDictionary
+	userInputsDictionary
+)
+
+// Part of password matching one of patterns, from i-th to j-th rune inclusive
+type match struct {
+	pattern pattern
+	i, j    int
+	token   string
+
+	// dictionary
+	dictionary    dictionary
+	rank          int
+	reversed      bool
+	l33t          bool
+	substitutions map[rune]rune // l33t character -> letter it stands for
+
+	// spatial
+	keyboard *keyboard
+	turns    int
+	shifted  int
+
+	// repeat
+	baseGuesses float64
+	repeatCount int
+	baseLength  int
+
+	// sequence
+	ascending bool
+
+	// year and date
+	year      int
+	separator string
+
+	guesses float64 // 0 until estimated
+}
+
+type rankedDictionary struct {
+	name  dictionary
+	ranks map[string]int
+}
+
+var builtInDictionaries = sync.OnceValue(func() []rankedDictionary {
+	english := rankedWords(englishWords)
+
+	// Passphrase wordlist is alphabetical, not ordered by frequency - its words missing from frequency list get rank of
+	// an average one
+	words, err := generator.EFFLarge.Words()
+
+	if err == nil {
+		for _, word := range words {
+			if _, seen := english[word]; !seen {
+				english[word] = len(words) / 2
+			}
+		}
+	}
+
+	return []rankedDictionary{
+		{passwordsDictionary, rankedWords(commonPasswords)},
+		{englishDictionary, english},
+		{namesDictionary, rankedWords(firstNames)},
+		{surnamesDictionary, rankedWords(surnames)},
+	}
+})
+
+// Ranks words of list with one word per line, ordered from the most frequent one
+func rankedWords(list string) map[string]int {
+	ranks := map[string]int{}
+	scanner := bufio.NewScanner(strings.NewReader(list))
+
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+
+		if _, seen := ranks[word]; word != "" && !seen {
+			ranks[word] = len(ranks) + 1
+		}
+	}
+
+	return ranks
+}
+
+type estimator struct {
+	dictionaries  []rankedDictionary
+	maxWordLength int
+	referenceYear int
+}
+
+func newEstimator(userInputs []string) *estimator {
+	userWords := map[string]int{}
+
+	add := func(word string) {
+		if _, seen := userWords[word]; len([]rune(word)) >= 3 && !seen {
+			userWords[word] = len(userWords) + 1
+		}
+	}
+
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		add(input)
+
+		// Parts of e.g. e-mail address are tried on their own as well
+		for _, part := range strings.FieldsFunc(input, func(char rune) bool { return !unicode.IsLetter(char) && !unicode.IsDigit(char) }) {
+			add(part)
+		}
+	}
+
+	dictionaries := append(slices.Clone(builtInDictionaries()), rankedDictionary{userInputsDictionary, userWords})
+	maxWordLength := 0
+
+	for _, dictionary := range dictionaries {
+		for word := range dictionary.ranks {
+			maxWordLength = max(maxWordLength, len([]rune(word)))
+		}
+	}
+
+	return &estimator{dictionaries: dictionaries, maxWordLength: maxWordLength, referenceYear: time.Now().Year()}
+}
+
+// Returns matches of every pattern found in password
+func (estimator *estimator) omnimatch(password []rune) []*match {
+	matches := estimator.dictionaryMatches(password)
+	matches = append(matches, estimator.reversedDictionaryMatches(password)...)
+	matches = append(matches, estimator.l33tMatches(password)...)
+	matches = append(matches, spatialMatches(password)...)
+	matches = append(matches, estimator.repeatMatches(password)...)
+	matches = append(matches, sequenceMatches(password)...)
+	matches = append(matches, yearMatches(password)...)
+	matches = append(matches, dateMatches(password, estimator.referenceYear)...)
+
+	return matches
+}
+
+func (estimator *estimator) dictionaryMatches(password []rune) []*match {
+	lower := make([]rune, len(password))
+
+	for index, char := range password {
+		lower[index] = unicode.ToLower(char)
+	}
+
+	matches := []*match{}
+
+	for i := range lower {
+		for j := i; j < len(lower) && j-i < estimator.maxWordLength; j++ {
+			word := string(lower[i : j+1])
+
+			for _, dictionary := range estimator.dictionaries {
+				rank, found := dictionary.ranks[word]
+
+				if found {
+					matches = append(matches, &match{
+						pattern:    dictionaryPattern,
+						i:          i,
+						j:          j,
+						token:      string(password[i : j+1]),
+						dictionary: dictionary.name,
+						rank:       rank,
+					})
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+func (estimator *estimator) reversedDictionaryMatches(password []rune) []*match {
+	reversed := slices.Clone(password)
+	slices.Reverse(reversed)
+
+	matches := estimator.dictionaryMatches(reversed)
+
+	for _, match := range matches {
+		token := []rune(match.token)
+		slices.Reverse(token)
+
+		match.token = string(token)
+		match.reversed = true
+		match.i, match.j = len(password)-1-match.j, len(password)-1-match.i
+	}
+
+	return matches
+}
+
+// Letters and characters commonly substituted for them
+var l33tTable = map[rune][]rune{
+	'a': {'4', '@'},
+	'b': {'8'},
+	'c': {'(', '{', '[', '<'},
+	'e': {'3'},
+	'g': {'6', '9'},
+	'i': {'1', '!', '|'},
+	'l': {'1', '|', '7'},
+	'o': {'0'},
+	's': {'$', '5'},
+	't': {'+', '7'},
+	'x': {'%'},
+	'z': {'2'},
+}
+
+// Bound for number of ways l33t characters of a single password are translated back to letters
+const maxL33tSubstitutions = 64
+
+// Finds dictionary words hidden behind l33t substitutions, e.g. "p4$$w0rd"
+func (estimator *estimator) l33tMatches(password []rune) []*match {
+	candidates := map[rune][]rune{}
+
+	for letter, substitutes := range l33tTable {
+		for _, substitute := range substitutes {
+			if slices.Contains(password, substitute) {
+				candidates[substitute] = append(candidates[substitute], letter)
+			}
+		}
+	}
+
+	substituted := make([]rune, 0, len(candidates))
+
+	for substitute, letters := range candidates {
+		substituted = append(substituted, substitute)
+		slices.Sort(letters)
+	}
+
+	slices.Sort(substituted)
+
+	// Every l33t character stands for one letter within a single translation
+	translations := []map[rune]rune{{}}
+
+	for _, substitute := range substituted {
+		extended := []map[rune]rune{}
+
+		for _, translation := range translations {
+			for _, letter := range candidates[substitute] {
+				if len(extended) == maxL33tSubstitutions {
+					break
+				}
+
+				next := map[rune]rune{substitute: letter}
+
+				for key, value := range translation {
+					next[key] = value
+				}
+
+				extended = append(extended, next)
+			}
+		}
+
+		translations = extended
+	}
+
+	matches := []*match{}
+	seen := map[string]bool{}
+
+	for _, translation := range translations {
+		if len(translation) == 0 {
+			continue
+		}
+
+		translated := make([]rune, len(password))
+
+		for index, char := range password {
+			letter, found := translation[char]
+
+			if !found {
+				letter = char
+			}
+
+			translated[index] = letter
+		}
+
+		for _, match := range estimator.dictionaryMatches(translated) {
+			token := password[match.i : match.j+1]
+			used := map[rune]rune{}
+
+			for _, char := range token {
+				if letter, found := translation[char]; found {
+					used[char] = letter
+				}
+			}
+
+			// Single l33t characters like "4" standing for "a" are not words
+			if len(used) == 0 || len(token) < 2 {
+				continue
+			}
+
+			key := strconv.Itoa(match.i) + "|" + strconv.Itoa(match.j) + "|" + string(translated[match.i:match.j+1]) + "|" + strconv.Itoa(int(match.dictionary))
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			match.token = string(token)
+			match.l33t = true
+			match.substitutions = used
+			matches = append(matches, match)
+		}
+	}
+
+	return matches
+}
+
+// Finds runs of at least three keys next to each other on one of keyboards, e.g. "qwerty" or "zaq1"
+func spatialMatches(password []rune) []*match {
+	matches := []*match{}
+
+	for _, keyboard := range keyboards() {
+		i := 0
+
+		for i < len(password)-1 {
+			j := i + 1
+			lastDirection := -1
+			turns := 0
+			shifted := 0
+
+			if keyboard.shifted[password[i]] {
+				shifted = 1
+			}
+
+			for {
+				found := false
+
+				if j < len(password) {
+					for direction, neighbour := range keyboard.adjacency[password[j-1]] {
+						position := strings.IndexRune(neighbour, password[j])
+
+						if neighbour == "" || position < 0 {
+							continue
+						}
+
+						found = true
+
+						if position == 1 {
+							shifted++
+						}
+
+						if direction != lastDirection {
+							turns++
+							lastDirection = direction
+						}
+
+						break
+					}
+				}
+
+				if found {
+					j++
+					continue
+				}
+
+				if j-i > 2 {
+					matches = append(matches, &match{
+						pattern:  spatialPattern,
+						i:        i,
+						j:        j - 1,
+						token:    string(password[i:j]),
+						keyboard: keyboard,
+						turns:    turns,
+						shifted:  shifted,
+					})
+				}
+
+				i = j
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// Finds the same text repeated at least twice in a row, e.g. "aaa" or "abcabc". Shortest repeated part is preferred.
+func (estimator *estimator) repeatMatches(password []rune) []*match {
+	matches := []*match{}
+	i := 0
+
+	for i < len(password) {
+		bestLength, bestCount := 0, 0
+
+		for length := 1; i+2*length <= len(password); length++ {
+			base := password[i : i+length]
+			count := 1
+
+			for i+(count+1)*length <= len(password) && slices.Equal(password[i+count*length:i+(count+1)*length], base) {
+				count++
+			}
+
+			if count >= 2 && length*count > bestLength*bestCount {
+				bestLength, bestCount = length, count
+			}
+		}
+
+		if bestCount == 0 {
+			i++
+			continue
+		}
+
+		_, baseGuesses := estimator.mostGuessableSequence(password[i : i+bestLength])
+		end := i + bestLength*bestCount
+
+		matches = append(matches, &match{
+			pattern:     repeatPattern,
+			i:           i,
+			j:           end - 1,
+			token:       string(password[i:end]),
+			baseGuesses: baseGuesses,
+			repeatCount: bestCount,
+			baseLength:  bestLength,
+		})
+
+		i = end
+	}
+
+	return matches
+}
+
+// Largest step between consecutive characters still considered a sequence, e.g. "aceg" or "9630"
+const maxSequenceDelta = 5
+
+// Finds characters following each other with the same step, e.g. "abcd", "1357" or "zyx"
+func sequenceMatches(password []rune) []*match {
+	if len(password) < 2 {
+		return []*match{}
+	}
+
+	matches := []*match{}
+
+	add := func(i int, j int, delta int) {
+		if (j-i > 1 || delta == 1 || delta == -1) && delta != 0 && max(delta, -delta) <= maxSequenceDelta {
+			matches = append(matches, &match{
+				pattern:   sequencePattern,
+				i:         i,
+				j:         j,
+				token:     string(password[i : j+1]),
+				ascending: delta > 0,
+			})
+		}
+	}
+
+	i := 0
+	lastDelta := int(password[1] - password[0])
+
+	for k := 2; k < len(password); k++ {
+		delta := int(password[k] - password[k-1])
+
+		if delta == lastDelta {
+			continue
+		}
+
+		add(i, k-1, lastDelta)
+		i = k - 1
+		lastDelta = delta
+	}
+
+	add(i, len(password)-1, lastDelta)
+
+	return matches
+}
+
+var yearExpression = regexp.MustCompile(`^(19|20)\d\d$`)
+
+func yearMatches(password []rune) []*match {
+	matches := []*match{}
+
+	for i := 0; i+4 <= len(password); i++ {
+		token := string(password[i : i+4])
+
+		if yearExpression.MatchString(token) {
+			year, _ := strconv.Atoi(token)
+			matches = append(matches, &match{pattern: yearPattern, i: i, j: i + 3, token: token, year: year})
+		}
+	}
+
+	return matches
+}
+
+var dateWithSeparatorExpression = regexp.MustCompile(`^(\d{1,4})([\s/\\_.-])(\d{1,2})([\s/\\_.-])(\d{1,4})$`)
+
+// Ways of splitting date without separators into day, month and year, by its length
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},         // 1 1 91, 91 1 1
+	5: {{1, 3}, {2, 3}},         // 1 11 91, 11 1 91
+	6: {{1, 2}, {2, 4}, {4, 5}}, // 1 1 1991, 11 11 91, 1991 1 1
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}}, // 11 11 1991, 1991 11 11
+}
+
+// Finds dates in day/month/year order and its variants, with or without separators, e.g. "13.05.1991" or "910513"
+func dateMatches(password []rune, referenceYear int) []*match {
+	matches := []*match{}
+
+	closest := func(candidates []int) (int, bool) {
+		if len(candidates) == 0 {
+			return 0, false
+		}
+
+		return slices.MinFunc(candidates, func(a int, b int) int { return abs(a-referenceYear) - abs(b-referenceYear) }), true
+	}
+
+	for i := range password {
+		for j := i + 3; j < len(password) && j-i < 8; j++ {
+			token := string(password[i : j+1])
+
+			if !isDigits(token) {
+				continue
+			}
+
+			candidates := []int{}
+
+			for _, split := range dateSplits[len(token)] {
+				first, _ := strconv.Atoi(token[:split[0]])
+				second, _ := strconv.Atoi(token[split[0]:split[1]])
+				third, _ := strconv.Atoi(token[split[1]:])
+
+				if year, valid := dateYear(first, second, third); valid {
+					candidates = append(candidates, year)
+				}
+			}
+
+			if year, found := closest(candidates); found {
+				matches = append(matches, &match{pattern: datePattern, i: i, j: j, token: token, year: year})
+			}
+		}
+	}
+
+	for i := range password {
+		for j := i + 5; j < len(password) && j-i < 10; j++ {
+			token := string(password[i : j+1])
+			parts := dateWithSeparatorExpression.FindStringSubmatch(token)
+
+			if parts == nil || parts[2] != parts[4] {
+				continue
+			}
+
+			first, _ := strconv.Atoi(parts[1])
+			second, _ := strconv.Atoi(parts[3])
+			third, _ := strconv.Atoi(parts[5])
+
+			if year, valid := dateYear(first, second, third); valid {
+				matches = append(matches, &match{pattern: datePattern, i: i, j: j, token: token, year: year, separator: parts[2]})
+			}
+		}
+	}
+
+	// Dates inside other dates, e.g. "1/1/91" in "1/1/1991", add nothing
+	return slices.DeleteFunc(matches, func(candidate *match) bool {
+		return slices.ContainsFunc(matches, func(other *match) bool {
+			return other != candidate && other.i <= candidate.i && other.j >= candidate.j && other.j-other.i > candidate.j-candidate.i
+		})
+	})
+}
+
+const (
+	minDateYear = 1000
+	maxDateYear = 2050
+)
+
+// Interprets three numbers as date with year at the beginning or at the end and returns the year
+func dateYear(first int, second int, third int) (int, bool) {
+	if second > 31 || second <= 0 {
+		return 0, false
+	}
+
+	over12, over31, under1 := 0, 0, 0
+
+	for _, number := range []int{first, second, third} {
+		if (number > 99 && number < minDateYear) || number > maxDateYear {
+			return 0, false
+		}
+
+		if number > 31 {
+			over31++
+		}
+
+		if number > 12 {
+			over12++
+		}
+
+		if number <= 0 {
+			under1++
+		}
+	}
+
+	if over31 >= 2 || over12 == 3 || under1 >= 2 {
+		return 0, false
+	}
+
+	orders := [][3]int{{third, first, second}, {first, second, third}}
+
+	for _, order := range orders {
+		if order[0] >= minDateYear && order[0] <= maxDateYear {
+			return order[0], isDayMonth(order[1], order[2])
+		}
+	}
+
+	for _, order := range orders {
+		if isDayMonth(order[1], order[2]) {
+			year := order[0]
+
+			switch {
+			case year > 99:
+			case year > 50:
+				year += 1900
+			default:
+				year += 2000
+			}
+
+			return year, true
+		}
+	}
+
+	return 0, false
+}
+
+func isDayMonth(first int, second int) bool {
+	valid := func(day int, month int) bool { return day >= 1 && day <= 31 && month >= 1 && month <= 12 }
+	return valid(first, second) || valid(second, first)
+}
+
+func isDigits(token string) bool {
+	for _, char := range token {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func abs(value int) int {
+	return max(value, -value)
+}
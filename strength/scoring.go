@@ -0,0 +1,331 @@
+package strength
+
+import (
+	"math"
+	"unicode"
+)
+
+const (
+	// Every additional match in a sequence multiplies guesses at least by this, so passwords are not explained by many tiny matches
+	minGuessesBeforeGrowingSequence = 10_000
+
+	// Lowest number of guesses of a match covering only part of password
+	minSubmatchGuessesSingleChar = 10
+	minSubmatchGuessesMultiChar  = 50
+
+	// Cardinality assumed for characters not covered by any pattern
+	bruteforceCardinality = 10
+
+	// Years further than this from current year are not more unusual
+	minYearSpace = 20
+)
+
+// Finds sequence of non-overlapping matches and brute forced parts covering whole password that needs the least guesses.
+//
+// Dynamic programming over password prefixes: for every prefix and every number of matches covering it, the best
+// sequence ending there is kept. Sequence of l matches takes l! * product of match guesses - the matches can come in
+// any order - plus penalty growing with l.
+func (estimator *estimator) mostGuessableSequence(password []rune) ([]*match, float64) {
+	length := len(password)
+
+	if length == 0 {
+		return []*match{}, 1
+	}
+
+	matchesByEnd := make([][]*match, length)
+
+	for _, match := range estimator.omnimatch(password) {
+		matchesByEnd[match.j] = append(matchesByEnd[match.j], match)
+	}
+
+	type step struct {
+		match   *match
+		product float64 // product of guesses of matches in the sequence
+		guesses float64 // guesses of the whole sequence
+	}
+
+	// optimal[k][l] is the best sequence of l matches covering password up to k-th rune
+	optimal := make([][]step, length)
+
+	for k := range optimal {
+		optimal[k] = make([]step, length+2)
+	}
+
+	update := func(match *match, count int) {
+		k := match.j
+		product := estimator.matchGuesses(match, length)
+
+		if count > 1 {
+			product *= optimal[match.i-1][count-1].product
+		}
+
+		guesses := factorial(count)*product + math.Pow(minGuessesBeforeGrowingSequence, float64(count-1))
+
+		// Longer sequence is worth keeping only when it is less guessable than every shorter one
+		for shorter := 1; shorter <= count; shorter++ {
+			competing := optimal[k][shorter]
+
+			if competing.match != nil && competing.guesses <= guesses {
+				return
+			}
+		}
+
+		optimal[k][count] = step{match: match, product: product, guesses: guesses}
+	}
+
+	bruteforceUpdate := func(k int) {
+		update(bruteforceMatch(password, 0, k), 1)
+
+		for i := 1; i <= k; i++ {
+			match := bruteforceMatch(password, i, k)
+
+			// Two brute forced parts next to each other are always worse than one covering both
+			for count, last := range optimal[i-1] {
+				if last.match != nil && last.match.pattern != bruteforcePattern {
+					update(match, count+1)
+				}
+			}
+		}
+	}
+
+	for k := range length {
+		for _, match := range matchesByEnd[k] {
+			if match.i == 0 {
+				update(match, 1)
+				continue
+			}
+
+			for count, last := range optimal[match.i-1] {
+				if last.match != nil {
+					update(match, count+1)
+				}
+			}
+		}
+
+		bruteforceUpdate(k)
+	}
+
+	bestCount := 0
+
+	for count, candidate := range optimal[length-1] {
+		if candidate.match != nil && (bestCount == 0 || candidate.guesses < optimal[length-1][bestCount].guesses) {
+			bestCount = count
+		}
+	}
+
+	guesses := optimal[length-1][bestCount].guesses
+	sequence := make([]*match, bestCount)
+
+	for k, count := length-1, bestCount; k >= 0; count-- {
+		match := optimal[k][count].match
+		sequence[count-1] = match
+		k = match.i - 1
+	}
+
+	return sequence, guesses
+}
+
+func bruteforceMatch(password []rune, i int, j int) *match {
+	return &match{pattern: bruteforcePattern, i: i, j: j, token: string(password[i : j+1])}
+}
+
+// Returns number of guesses needed to find match, counted once and kept in the match
+func (estimator *estimator) matchGuesses(match *match, passwordLength int) float64 {
+	if match.guesses != 0 {
+		return match.guesses
+	}
+
+	tokenLength := match.j - match.i + 1
+	minGuesses := 1.0
+
+	if tokenLength < passwordLength {
+		minGuesses = minSubmatchGuessesMultiChar
+
+		if tokenLength == 1 {
+			minGuesses = minSubmatchGuessesSingleChar
+		}
+	}
+
+	var guesses float64
+
+	switch match.pattern {
+	case bruteforcePattern:
+		guesses = math.Pow(bruteforceCardinality, float64(tokenLength))
+		minGuesses = minSubmatchGuessesMultiChar + 1
+
+		if tokenLength == 1 {
+			minGuesses = minSubmatchGuessesSingleChar + 1
+		}
+
+	case dictionaryPattern:
+		guesses = float64(match.rank) * uppercaseVariations([]rune(match.token)) * l33tVariations(match)
+
+		if match.reversed {
+			guesses *= 2
+		}
+
+	case spatialPattern:
+		guesses = spatialGuesses(match, tokenLength)
+
+	case repeatPattern:
+		guesses = match.baseGuesses * float64(match.repeatCount)
+
+	case sequencePattern:
+		guesses = sequenceGuesses(match, tokenLength)
+
+	case yearPattern:
+		guesses = float64(max(abs(match.year-estimator.referenceYear), minYearSpace))
+
+	case datePattern:
+		guesses = float64(max(abs(match.year-estimator.referenceYear), minYearSpace)) * 365
+
+		if match.separator != "" {
+			guesses *= 4
+		}
+	}
+
+	match.guesses = max(guesses, minGuesses)
+
+	return match.guesses
+}
+
+// Capitalized, all uppercase and last letter uppercase words are tried first, other mixes of case need more guesses
+func uppercaseVariations(token []rune) float64 {
+	upper, lower := 0, 0
+
+	for _, char := range token {
+		switch {
+		case unicode.IsUpper(char):
+			upper++
+		case unicode.IsLower(char):
+			lower++
+		}
+	}
+
+	if upper == 0 {
+		return 1
+	}
+
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1]))) {
+		return 2
+	}
+
+	variations := 0.0
+
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += binomial(upper+lower, i)
+	}
+
+	return variations
+}
+
+func l33tVariations(match *match) float64 {
+	if !match.l33t {
+		return 1
+	}
+
+	variations := 1.0
+
+	for substitute, letter := range match.substitutions {
+		substituted, unsubstituted := 0, 0
+
+		for _, char := range match.token {
+			switch {
+			case char == substitute:
+				substituted++
+			case unicode.ToLower(char) == letter:
+				unsubstituted++
+			}
+		}
+
+		if substituted == 0 || unsubstituted == 0 {
+			// All occurrences substituted - attacker tries fully substituted word or the original
+			variations *= 2
+			continue
+		}
+
+		possibilities := 0.0
+
+		for i := 1; i <= min(substituted, unsubstituted); i++ {
+			possibilities += binomial(substituted+unsubstituted, i)
+		}
+
+		variations *= possibilities
+	}
+
+	return variations
+}
+
+func spatialGuesses(match *match, tokenLength int) float64 {
+	startingPositions := match.keyboard.startingPositions
+	averageDegree := match.keyboard.averageDegree
+	guesses := 0.0
+
+	// Keyboard walks of every length up to token length with up to its number of turns
+	for i := 2; i <= tokenLength; i++ {
+		for j := 1; j <= min(match.turns, i-1); j++ {
+			guesses += binomial(i-1, j-1) * startingPositions * math.Pow(averageDegree, float64(j))
+		}
+	}
+
+	if match.shifted > 0 {
+		unshifted := tokenLength - match.shifted
+
+		if unshifted == 0 {
+			guesses *= 2
+		} else {
+			variations := 0.0
+
+			for i := 1; i <= min(match.shifted, unshifted); i++ {
+				variations += binomial(match.shifted+unshifted, i)
+			}
+
+			guesses *= variations
+		}
+	}
+
+	return guesses
+}
+
+func sequenceGuesses(match *match, tokenLength int) float64 {
+	first := []rune(match.token)[0]
+	base := 26.0
+
+	switch {
+	// Obvious starting points
+	case first == 'a' || first == 'A' || first == 'z' || first == 'Z' || first == '0' || first == '1' || first == '9':
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	}
+
+	if !match.ascending {
+		base *= 2
+	}
+
+	return base * float64(tokenLength)
+}
+
+func binomial(n int, k int) float64 {
+	if k > n {
+		return 0
+	}
+
+	result := 1.0
+
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+
+	return result
+}
+
+func factorial(n int) float64 {
+	result := 1.0
+
+	for i := 2; i <= n; i++ {
+		result *= float64(i)
+	}
+
+	return result
+}
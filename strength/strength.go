@@ -0,0 +1,124 @@
+// Password strength estimation in the spirit of zxcvbn.
+//
+// Password is matched against patterns attackers try first - common passwords, dictionary words (also reversed and
+// with l33t substitutions), keyboard walks, repeats, sequences, years and dates. Then the least guessable way of
+// covering the whole password with those matches and brute force is searched for, and its number of guesses becomes
+// the estimate. Score and feedback are derived from it.
+package strength
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// Lowest score accepted for master password and passwords of encrypted exports
+	MinMasterPasswordScore = 3
+
+	// Guesses per second of an attacker holding the vault file - master password goes through slow Argon2id hashing
+	OfflineGuessesPerSecond = 1e4
+
+	// Only beginning of very long password is analyzed - the rest can only make it stronger
+	maxAnalyzedLength = 128
+)
+
+var MasterPasswordTooWeak = errors.New("Master password is too easy to guess - make it longer or add a few uncommon words.")
+var ArchivePassphraseTooWeak = errors.New("Archive passphrase is too easy to guess - make it longer or add a few uncommon words.")
+var KeePassPasswordTooWeak = errors.New("KeePass password is too easy to guess - make it longer or add a few uncommon words.")
+
+type Result struct {
+	Score            int     // 0 (too guessable) to 4 (very unguessable)
+	Guesses          float64 // estimated number of guesses needed to find the password
+	CrackTimeSeconds float64 // time needed for the guesses at OfflineGuessesPerSecond
+
+	Warning     string   // main weakness, empty when there is nothing specific to warn about
+	Suggestions []string // how to make the password stronger
+}
+
+// Estimates strength of password. User inputs - e.g. service name or username - are treated as a dictionary of their own.
+func Estimate(password string, userInputs ...string) Result {
+	runes := []rune(password)
+
+	if len(runes) > maxAnalyzedLength {
+		runes = runes[:maxAnalyzedLength]
+	}
+
+	estimator := newEstimator(userInputs)
+	sequence, guesses := estimator.mostGuessableSequence(runes)
+	score := scoreFromGuesses(guesses)
+	warning, suggestions := feedback(score, sequence)
+
+	return Result{
+		Score:            score,
+		Guesses:          guesses,
+		CrackTimeSeconds: guesses / OfflineGuessesPerSecond,
+		Warning:          warning,
+		Suggestions:      suggestions,
+	}
+}
+
+// Margin keeps passwords right at the threshold - e.g. guessed as 1000th - in the lower score
+const scoreMargin = 5
+
+func scoreFromGuesses(guesses float64) int {
+	switch {
+	case guesses < 1e3+scoreMargin:
+		return 0 // risky password
+	case guesses < 1e6+scoreMargin:
+		return 1 // protects only from throttled online attacks
+	case guesses < 1e8+scoreMargin:
+		return 2 // protects from unthrottled online attacks
+	case guesses < 1e10+scoreMargin:
+		return 3 // moderate protection from offline attack
+	default:
+		return 4 // strong protection from offline attack
+	}
+}
+
+func (result Result) ScoreName() string {
+	return [...]string{"Very weak", "Weak", "Fair", "Strong", "Very strong"}[result.Score]
+}
+
+// Returns human readable time needed to crack the password, e.g. "3 hours" or "centuries"
+func (result Result) CrackTime() string {
+	const (
+		minute  = 60.0
+		hour    = 60 * minute
+		day     = 24 * hour
+		month   = 31 * day
+		year    = 12 * month
+		century = 100 * year
+	)
+
+	seconds := result.CrackTimeSeconds
+
+	switch {
+	case seconds < 1:
+		return "less than a second"
+	case seconds < minute:
+		return countOf(seconds, "second")
+	case seconds < hour:
+		return countOf(seconds/minute, "minute")
+	case seconds < day:
+		return countOf(seconds/hour, "hour")
+	case seconds < month:
+		return countOf(seconds/day, "day")
+	case seconds < year:
+		return countOf(seconds/month, "month")
+	case seconds < century:
+		return countOf(seconds/year, "year")
+	default:
+		return "centuries"
+	}
+}
+
+func countOf(value float64, unit string) string {
+	count := int(math.Round(value))
+
+	if count == 1 {
+		return "1 " + unit
+	}
+
+	return fmt.Sprintf("%d %ss", count, unit)
+}
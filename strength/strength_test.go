@@ -0,0 +1,61 @@
+package strength
+
+import "testing"
+
+func TestEstimateScoresWeakPasswords(t *testing.T) {
+	tests := []struct {
+		password string
+		maxScore int
+	}{
+		{"password", 0},
+		{"123456", 0},
+		{"qwertyuiop", 0},
+		{"michael", 0},
+		{"elizabeth", 0},
+		{"password1", 0},
+		{"troubadour", 1},
+		{"P4ssw0rd!", 1},
+		{"M1ch43l&3", 1},
+		{"J0hns0n&5", 1},
+		{"Summer2023", 1},
+		{"monkeybusiness", 1},
+		{"Tr0ub4dour&3", 2},
+		{"Michael2020!", 2},
+	}
+
+	for _, test := range tests {
+		if result := Estimate(test.password); result.Score > test.maxScore {
+			t.Errorf("Estimate(%q).Score = %d, want at most %d", test.password, result.Score, test.maxScore)
+		}
+	}
+}
+
+func TestEstimateScoresStrongPasswords(t *testing.T) {
+	tests := []string{
+		"correct horse battery staple",
+		"correcthorsebatterystaple",
+		"rWibMFACxAUGZmxhVncy",
+		"Xk9#mP2$vL7q",
+	}
+
+	for _, password := range tests {
+		if result := Estimate(password); result.Score != 4 {
+			t.Errorf("Estimate(%q).Score = %d, want 4", password, result.Score)
+		}
+	}
+}
+
+func TestEstimateWarnsAboutNames(t *testing.T) {
+	if warning := Estimate("elizabeth").Warning; warning != "Names and surnames by themselves are easy to guess." {
+		t.Errorf("Estimate(elizabeth).Warning = %q", warning)
+	}
+}
+
+func TestEstimateTreatsUserInputsAsDictionary(t *testing.T) {
+	without := Estimate("frosksecretvault")
+	with := Estimate("frosksecretvault", "frosksecretvault")
+
+	if with.Score != 0 || with.Guesses >= without.Guesses {
+		t.Errorf("Estimate with user input = %d (%g guesses), without = %d (%g guesses)", with.Score, with.Guesses, without.Score, without.Guesses)
+	}
+}
@@ -0,0 +1,378 @@
+package vaults
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+// Backups of a vault are consistent copies of its database, taken when vault is opened and shortly after its entries
+// change. They are kept next to the vault in backups/<vault name>, one file per backup named after its UTC time,
+// and thinned out by retention stored in vault settings. Backup taken before service names of older vault got encrypted
+// at its first unlock holds them in plain text - such backups are replaced by a new one once the names are encrypted.
+
+const (
+	backupsDirectory = "backups"
+	backupTimeLayout = "2006-01-02T15-04-05.000000Z"
+
+	// Changes made in quick succession end up in one backup
+	backupDelay = 10 * time.Second
+)
+
+type Backup struct {
+	Path      string
+	CreatedAt time.Time
+	Size      int64 // bytes
+}
+
+// Takes, lists, prunes and restores backups of one vault
+type Backups struct {
+	directory string
+	backend   *server.Backend
+	witness   versionWitness
+
+	mutex sync.Mutex // one backup or restore at a time
+
+	scheduleMutex sync.Mutex
+	scheduled     *time.Timer
+	running       sync.WaitGroup // scheduled backup not finished yet
+}
+
+// Directory holding backups of vault
+func BackupsDirectory(vaultPath string) string {
+	return filepath.Join(filepath.Dir(vaultPath), backupsDirectory, Name(vaultPath))
+}
+
+func newBackups(vaultPath string, backend *server.Backend, witness versionWitness) *Backups {
+	backups := &Backups{directory: BackupsDirectory(vaultPath), backend: backend, witness: witness}
+	backend.OnChange(backups.schedule)
+	backend.OnServiceNamesEncrypted(backups.replacePlainTextBackups)
+	return backups
+}
+
+// Takes backup after backupDelay, postponing already scheduled one
+func (backups *Backups) schedule() {
+	backups.scheduleMutex.Lock()
+	defer backups.scheduleMutex.Unlock()
+
+	// Stopped timer did not run, so it is still counted as running
+	if backups.scheduled == nil || !backups.scheduled.Stop() {
+		backups.running.Add(1)
+	}
+
+	backups.scheduled = time.AfterFunc(backupDelay, func() {
+		defer backups.running.Done()
+
+		backups.scheduleMutex.Lock()
+		backups.scheduled = nil
+		backups.scheduleMutex.Unlock()
+
+		// Error is logged, there is no one to show it to
+		backups.Take()
+	})
+}
+
+// Takes scheduled backup right away and waits for backup which already started
+func (backups *Backups) Flush() {
+	backups.scheduleMutex.Lock()
+
+	if backups.scheduled != nil && backups.scheduled.Stop() {
+		backups.scheduled = nil
+		backups.scheduleMutex.Unlock()
+
+		backups.Take()
+		backups.running.Done()
+	} else {
+		backups.scheduleMutex.Unlock()
+	}
+
+	backups.running.Wait()
+}
+
+// Writes new backup of the vault, verifies it and prunes old backups according to retention setting
+func (backups *Backups) Take() (Backup, error) {
+	backups.mutex.Lock()
+	defer backups.mutex.Unlock()
+
+	backup, err := backups.take()
+
+	if err != nil {
+		return Backup{}, err
+	}
+
+	backups.prune()
+
+	return backup, nil
+}
+
+// Takes backup unless vault file did not change since the newest backup. Used when vault is opened,
+// so it runs before the vault gets migrated to the latest schema.
+func (backups *Backups) TakeIfChanged(vaultPath string) error {
+	info, err := os.Stat(vaultPath)
+
+	// New vault has nothing to back up yet
+	if err != nil || info.Size() == 0 {
+		return nil
+	}
+
+	backups.mutex.Lock()
+	defer backups.mutex.Unlock()
+
+	existing, err := backups.list()
+
+	if err != nil {
+		return err
+	}
+
+	if len(existing) > 0 && !info.ModTime().After(existing[0].CreatedAt) {
+		return nil
+	}
+
+	_, err = backups.take()
+
+	if err != nil {
+		return err
+	}
+
+	backups.prune()
+
+	return nil
+}
+
+func (backups *Backups) take() (Backup, error) {
+	err := os.MkdirAll(backups.directory, 0o700)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not create backups directory %s: %w", backups.directory, err)
+		slog.Error(errWrapped.Error())
+		return Backup{}, errWrapped
+	}
+
+	createdAt := time.Now().UTC()
+	backupPath := filepath.Join(backups.directory, createdAt.Format(backupTimeLayout)+Extension)
+
+	// Backup gets its name only once it is complete and verified
+	partialPath := backupPath + ".partial"
+	os.Remove(partialPath)
+
+	err = backups.backend.BackupInto(partialPath)
+
+	if err == nil {
+		_, err = server.InspectBackup(partialPath)
+	}
+
+	if err == nil {
+		err = os.Chmod(partialPath, 0o600)
+	}
+
+	if err == nil {
+		err = os.Rename(partialPath, backupPath)
+	}
+
+	if err != nil {
+		os.Remove(partialPath)
+		errWrapped := fmt.Errorf("Could not take backup of vault: %w", err)
+		slog.Error(errWrapped.Error())
+		return Backup{}, errWrapped
+	}
+
+	info, err := os.Stat(backupPath)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not read taken backup %s: %w", backupPath, err)
+		slog.Error(errWrapped.Error())
+		return Backup{}, errWrapped
+	}
+
+	slog.Info("Took backup of vault.", "path", backupPath)
+
+	return Backup{Path: backupPath, CreatedAt: createdAt, Size: info.Size()}, nil
+}
+
+// Takes backup of vault with encrypted service names and removes backups holding them in plain text. When new backup
+// can't be taken, older ones are kept, so vault is not left without backup.
+func (backups *Backups) replacePlainTextBackups() {
+	backups.mutex.Lock()
+	defer backups.mutex.Unlock()
+
+	list, err := backups.list()
+
+	if err != nil {
+		return
+	}
+
+	plainText := make([]Backup, 0)
+
+	for _, backup := range list {
+		holdsPlainText, err := server.HoldsPlainTextServiceNames(backup.Path)
+
+		// Backup which can't be read is left to pruning
+		if err == nil && holdsPlainText {
+			plainText = append(plainText, backup)
+		}
+	}
+
+	if len(plainText) == 0 {
+		return
+	}
+
+	_, err = backups.take()
+
+	if err != nil {
+		slog.Error("Backups with plain text service names are kept, new backup could not be taken.", "backups", len(plainText))
+		return
+	}
+
+	for _, backup := range plainText {
+		err = os.Remove(backup.Path)
+
+		if err != nil {
+			slog.Error("Could not remove backup with plain text service names.", "path", backup.Path, "error", err)
+		}
+	}
+
+	slog.Info("Removed backups with plain text service names.", "backups", len(plainText))
+}
+
+// Returns backups of the vault, newest first
+func (backups *Backups) List() ([]Backup, error) {
+	backups.mutex.Lock()
+	defer backups.mutex.Unlock()
+
+	return backups.list()
+}
+
+func (backups *Backups) list() ([]Backup, error) {
+	entries, err := os.ReadDir(backups.directory)
+
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not list backups in %s: %w", backups.directory, err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	list := []Backup{}
+
+	for _, entry := range entries {
+		name, isBackup := strings.CutSuffix(entry.Name(), Extension)
+
+		if !isBackup || !entry.Type().IsRegular() {
+			continue
+		}
+
+		createdAt, err := time.Parse(backupTimeLayout, name)
+
+		// Files not named by frosk are left alone
+		if err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			continue
+		}
+
+		list = append(list, Backup{Path: filepath.Join(backups.directory, entry.Name()), CreatedAt: createdAt, Size: info.Size()})
+	}
+
+	slices.SortFunc(list, func(a, b Backup) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	return list, nil
+}
+
+// Removes backups not kept by retention setting. Days and weeks are counted in local time.
+func (backups *Backups) prune() {
+	retention, _ := backups.backend.GetBackupRetention()
+
+	list, err := backups.list()
+
+	if err != nil {
+		return
+	}
+
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+
+	for i, backup := range list {
+		createdAt := backup.CreatedAt.Local()
+		day := createdAt.Format(time.DateOnly)
+		year, week := createdAt.ISOWeek()
+		yearWeek := fmt.Sprintf("%d-%d", year, week)
+
+		keep := i < retention.Last
+
+		if !days[day] && len(days) < retention.Daily {
+			days[day] = true
+			keep = true
+		}
+
+		if !weeks[yearWeek] && len(weeks) < retention.Weekly {
+			weeks[yearWeek] = true
+			keep = true
+		}
+
+		if keep {
+			continue
+		}
+
+		err = os.Remove(backup.Path)
+
+		if err != nil {
+			slog.Error("Could not remove pruned backup.", "path", backup.Path, "error", err)
+		}
+	}
+}
+
+// Replaces content of the vault with backup. Current state of the vault is backed up first, so restore can be undone.
+func (backups *Backups) Restore(backup Backup) error {
+	backups.Flush()
+
+	backups.mutex.Lock()
+	defer backups.mutex.Unlock()
+
+	_, err := server.InspectBackup(backup.Path)
+
+	if err != nil {
+		return err
+	}
+
+	// Not pruned before restore, restored backup could be removed otherwise
+	_, err = backups.take()
+
+	if err != nil {
+		return err
+	}
+
+	err = backups.backend.RestoreFrom(backup.Path)
+
+	if err != nil {
+		return err
+	}
+
+	// Restored vault is older on purpose, it must not be reported as rolled back
+	version, _, err := backups.backend.VaultVersion()
+
+	if err == nil {
+		err = backups.witness.reset(version)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	backups.prune()
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package vaults
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+// Opens new vault in temporary directory, which is also its data directory. Vault is closed when test ends.
+func openTestVault(t *testing.T) (*Vault, string) {
+	t.Helper()
+
+	dataDirectory := t.TempDir()
+	vault, err := Open(DefaultVaultPath(dataDirectory), dataDirectory)
+
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	t.Cleanup(func() { vault.Close() })
+
+	return vault, dataDirectory
+}
+
+func TestTakeListsVerifiedBackup(t *testing.T) {
+	vault, _ := openTestVault(t)
+
+	backup, err := vault.Backups.Take()
+
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+
+	if _, err := server.InspectBackup(backup.Path); err != nil {
+		t.Fatalf("InspectBackup() of taken backup = %v", err)
+	}
+
+	if filepath.Dir(backup.Path) != BackupsDirectory(vault.Path) {
+		t.Errorf("backup taken into %s, want %s", filepath.Dir(backup.Path), BackupsDirectory(vault.Path))
+	}
+
+	// Files not named by frosk are not listed
+	os.WriteFile(filepath.Join(BackupsDirectory(vault.Path), "copy"+Extension), nil, 0o600)
+
+	list, err := vault.Backups.List()
+
+	if err != nil || len(list) != 1 || list[0].Path != backup.Path {
+		t.Fatalf("List() = %+v, %v, want taken backup only", list, err)
+	}
+
+	// Vault did not change since the backup
+	if err := vault.Backups.TakeIfChanged(vault.Path); err != nil {
+		t.Fatalf("TakeIfChanged: %v", err)
+	}
+
+	if list, _ := vault.Backups.List(); len(list) != 1 {
+		t.Fatalf("List() after TakeIfChanged of unchanged vault has %d backups, want 1", len(list))
+	}
+}
+
+// Creates empty files named like backups taken at given times
+func createTestBackups(t *testing.T, directory string, times []time.Time) {
+	t.Helper()
+
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		t.Fatalf("creating backups directory: %v", err)
+	}
+
+	for _, createdAt := range times {
+		path := filepath.Join(directory, createdAt.UTC().Format(backupTimeLayout)+Extension)
+
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("creating backup %s: %v", path, err)
+		}
+	}
+}
+
+func TestPruneKeepsLastDailyAndWeekly(t *testing.T) {
+	vault, _ := openTestVault(t)
+
+	err := vault.Backend.SetBackupRetention(server.BackupRetention{Last: 2, Daily: 3, Weekly: 2})
+
+	if err != nil {
+		t.Fatalf("SetBackupRetention: %v", err)
+	}
+
+	// Wednesday noon, so neither days nor weeks depend on time zone of the test
+	now := time.Date(2026, time.October, 14, 12, 0, 0, 0, time.Local)
+	day := 24 * time.Hour
+
+	times := []time.Time{
+		now,                       // last
+		now.Add(-time.Hour),       // last
+		now.Add(-2 * time.Hour),   // the same day and week as newer ones
+		now.Add(-day),             // daily, Tuesday
+		now.Add(-day - time.Hour), // older one of Tuesday
+		now.Add(-2 * day),         // daily, Monday
+		now.Add(-3 * day),         // weekly, Sunday of previous week
+		now.Add(-4 * day),         // previous week again
+		now.Add(-14 * day),        // third week
+	}
+
+	createTestBackups(t, BackupsDirectory(vault.Path), times)
+	vault.Backups.prune()
+
+	list, err := vault.Backups.List()
+
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	kept := make([]time.Time, 0, len(list))
+
+	for _, backup := range list {
+		kept = append(kept, backup.CreatedAt)
+	}
+
+	want := []time.Time{times[0], times[1], times[3], times[5], times[6]}
+
+	if !slices.EqualFunc(kept, want, time.Time.Equal) {
+		t.Fatalf("kept backups %v, want %v", kept, want)
+	}
+}
+
+func TestRestoreResetsWitnessedVersion(t *testing.T) {
+	vault, dataDirectory := openTestVault(t)
+
+	backup, err := vault.Backups.Take()
+
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+
+	// Vault changes after the backup and its newer version gets witnessed
+	err = vault.Backend.SetBackupRetention(server.BackupRetention{Last: 5, Daily: 0, Weekly: 0})
+
+	if err != nil {
+		t.Fatalf("SetBackupRetention: %v", err)
+	}
+
+	witness := versionWitness{dataDirectory: dataDirectory, vaultPath: vault.Path}
+
+	if err := witness.SawVersion(5); err != nil {
+		t.Fatalf("SawVersion: %v", err)
+	}
+
+	err = vault.Backups.Restore(backup)
+
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	retention, err := vault.Backend.GetBackupRetention()
+
+	if err != nil || retention != server.DefaultBackupRetention {
+		t.Fatalf("GetBackupRetention() after restore = %v, %v, want default of backed up vault", retention, err)
+	}
+
+	version, _, err := vault.Backend.VaultVersion()
+
+	if err != nil {
+		t.Fatalf("VaultVersion: %v", err)
+	}
+
+	// Restored vault is not reported as rolled back
+	if lastVersion, err := witness.LastVersion(); err != nil || lastVersion != version {
+		t.Fatalf("LastVersion() after restore = %d, %v, want version %d of restored vault", lastVersion, err, version)
+	}
+
+	// State before restore is backed up, so restore can be undone
+	if list, _ := vault.Backups.List(); len(list) != 2 {
+		t.Fatalf("List() after restore has %d backups, want 2", len(list))
+	}
+}
+
+func TestPlainTextBackupsAreReplaced(t *testing.T) {
+	vault, _ := openTestVault(t)
+
+	backup, err := vault.Backups.Take()
+
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+
+	// Backup of vault written before service names were encrypted
+	plainTextPath := filepath.Join(BackupsDirectory(vault.Path), backup.CreatedAt.Add(-time.Hour).Format(backupTimeLayout)+Extension)
+	db, err := sql.Open("sqlite3", plainTextPath)
+
+	if err == nil {
+		_, err = db.Exec("CREATE TABLE passwords (id INTEGER PRIMARY KEY, service_name TEXT); INSERT INTO passwords (service_name) VALUES ('github')")
+		db.Close()
+	}
+
+	if err != nil {
+		t.Fatalf("creating plain text backup: %v", err)
+	}
+
+	vault.Backups.replacePlainTextBackups()
+
+	list, err := vault.Backups.List()
+
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	if len(list) != 2 || list[1].Path != backup.Path {
+		t.Fatalf("List() = %+v, want new backup and backup with encrypted service names", list)
+	}
+
+	if _, err := os.Stat(plainTextPath); !os.IsNotExist(err) {
+		t.Fatalf("backup with plain text service names is still present: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || windows
+
+package vaults
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFileKeepsOutOtherOpens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), versionsLockFile)
+	files := make([]*os.File, 2)
+
+	for i := range files {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+
+		if err != nil {
+			t.Fatalf("opening lock file: %v", err)
+		}
+
+		defer file.Close()
+		files[i] = file
+	}
+
+	if err := lockFile(files[0]); err != nil {
+		t.Fatalf("lockFile: %v", err)
+	}
+
+	locked := make(chan error, 1)
+
+	go func() { locked <- lockFile(files[1]) }()
+
+	select {
+	case <-locked:
+		t.Fatalf("lockFile() of other open returned while file was locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := unlockFile(files[0]); err != nil {
+		t.Fatalf("unlockFile: %v", err)
+	}
+
+	select {
+	case err := <-locked:
+		if err != nil {
+			t.Fatalf("lockFile() of other open = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("lockFile() of other open did not return after unlock")
+	}
+
+	unlockFile(files[1])
+}
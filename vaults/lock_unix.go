@@ -0,0 +1,25 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package vaults
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Blocks until exclusive lock of the file is acquired. Lock is held by open file, so it also keeps out other
+// processes and other opens of the file in this process.
+func lockFile(file *os.File) error {
+	for {
+		err := unix.Flock(int(file.Fd()), unix.LOCK_EX)
+
+		if err != unix.EINTR {
+			return err
+		}
+	}
+}
+
+func unlockFile(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
@@ -0,0 +1,17 @@
+package vaults
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Blocks until exclusive lock of the file is acquired. Lock is held by open file, so it also keeps out other
+// processes and other opens of the file in this process.
+func lockFile(file *os.File) error {
+	return windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &windows.Overlapped{})
+}
+
+func unlockFile(file *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
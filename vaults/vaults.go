@@ -0,0 +1,331 @@
+// Location of vault files and the list of vaults known to the application.
+//
+// Vault used is chosen, in order, by --vault flag, FROSK_VAULT environment variable and the default vault in data directory:
+//
+//	Linux:     $XDG_DATA_HOME/frosk (~/.local/share/frosk when not set)
+//	Mac:       ~/Library/Application Support/frosk
+//	Windows:   %LOCALAPPDATA%\frosk
+package vaults
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	server "github.com/mszalewicz/frosk/backend"
+)
+
+const (
+	EnvironmentVariable = "FROSK_VAULT"
+	Extension           = ".sqlite"
+
+	defaultVaultName = "application"
+	knownVaultsFile  = "vaults"
+	maxKnownVaults   = 20
+)
+
+// Directory used by versions which stored the vault under /var/lib - shown among known vaults when it is still there
+const legacyLinuxDirectory = "/var/lib/frosk"
+
+var EmptyVaultName = errors.New("Vault name is empty.")
+var VaultAlreadyExists = errors.New("Vault file already exists.")
+var VaultDoesNotExist = errors.New("Vault file does not exist.")
+
+// Returns per user directory holding default vault, log and list of known vaults
+func DataDirectory() (string, error) {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "netbsd", "openbsd", "dragonfly":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+
+		// Relative paths are invalid according to XDG base directory specification and have to be ignored
+		if dataHome == "" || !filepath.IsAbs(dataHome) {
+			homeDirectory, err := os.UserHomeDir()
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Could not determine home directory: %w", err)
+				slog.Error(errWrapped.Error())
+				return "", errWrapped
+			}
+
+			dataHome = filepath.Join(homeDirectory, ".local", "share")
+		}
+
+		return filepath.Join(dataHome, "frosk"), nil
+
+	case "windows":
+		localAppData := os.Getenv("LOCALAPPDATA")
+
+		if localAppData == "" {
+			homeDirectory, err := os.UserHomeDir()
+
+			if err != nil {
+				errWrapped := fmt.Errorf("Could not determine home directory: %w", err)
+				slog.Error(errWrapped.Error())
+				return "", errWrapped
+			}
+
+			localAppData = filepath.Join(homeDirectory, "AppData", "Local")
+		}
+
+		return filepath.Join(localAppData, "frosk"), nil
+
+	default:
+		// Application Support on Mac
+		configDirectory, err := os.UserConfigDir()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Could not determine user configuration directory: %w", err)
+			slog.Error(errWrapped.Error())
+			return "", errWrapped
+		}
+
+		return filepath.Join(configDirectory, "frosk"), nil
+	}
+}
+
+func DefaultVaultPath(dataDirectory string) string {
+	return filepath.Join(dataDirectory, defaultVaultName+Extension)
+}
+
+// Returns absolute path of vault to open: flag value when given, then FROSK_VAULT, then default vault
+func Resolve(flagValue string, dataDirectory string) (string, error) {
+	vaultPath := flagValue
+
+	if vaultPath == "" {
+		vaultPath = os.Getenv(EnvironmentVariable)
+	}
+
+	if vaultPath == "" {
+		return DefaultVaultPath(dataDirectory), nil
+	}
+
+	return absolutePath(vaultPath)
+}
+
+// Turns name typed in vault picker into vault path. Plain name means vault file in data directory, anything looking like path is used as is.
+func PathFromName(dataDirectory string, name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return "", EmptyVaultName
+	}
+
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, "~") {
+		return absolutePath(name)
+	}
+
+	if !strings.HasSuffix(name, Extension) {
+		name += Extension
+	}
+
+	return filepath.Join(dataDirectory, name), nil
+}
+
+// Vault name shown to the user - file name without extension
+func Name(vaultPath string) string {
+	return strings.TrimSuffix(filepath.Base(vaultPath), Extension)
+}
+
+func absolutePath(vaultPath string) (string, error) {
+	if vaultPath == "~" || strings.HasPrefix(vaultPath, "~/") {
+		homeDirectory, err := os.UserHomeDir()
+
+		if err != nil {
+			errWrapped := fmt.Errorf("Could not determine home directory: %w", err)
+			slog.Error(errWrapped.Error())
+			return "", errWrapped
+		}
+
+		vaultPath = filepath.Join(homeDirectory, vaultPath[1:])
+	}
+
+	absolute, err := filepath.Abs(vaultPath)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not resolve vault path %s: %w", vaultPath, err)
+		slog.Error(errWrapped.Error())
+		return "", errWrapped
+	}
+
+	return absolute, nil
+}
+
+// Creates directory vault file is stored in. sqlite creates the file itself, but not missing directories.
+func EnsureDirectory(vaultPath string) error {
+	err := os.MkdirAll(filepath.Dir(vaultPath), 0o700)
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not create directory for vault %s: %w", vaultPath, err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+func Exists(vaultPath string) bool {
+	info, err := os.Stat(vaultPath)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Returns existing vaults known to the application: recently opened ones first, then other vault files in data directory
+func Known(dataDirectory string) ([]string, error) {
+	remembered, err := readKnownVaults(dataDirectory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	known := make([]string, 0, len(remembered))
+
+	add := func(vaultPath string) {
+		if Exists(vaultPath) && !slices.Contains(known, vaultPath) {
+			known = append(known, vaultPath)
+		}
+	}
+
+	for _, vaultPath := range remembered {
+		add(vaultPath)
+	}
+
+	inDataDirectory, err := filepath.Glob(filepath.Join(dataDirectory, "*"+Extension))
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not list vaults in data directory: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	slices.Sort(inDataDirectory)
+
+	for _, vaultPath := range inDataDirectory {
+		add(vaultPath)
+	}
+
+	if runtime.GOOS == "linux" {
+		add(filepath.Join(legacyLinuxDirectory, defaultVaultName+Extension))
+	}
+
+	return known, nil
+}
+
+// Moves vault to the top of recently opened vaults
+func Remember(dataDirectory string, vaultPath string) error {
+	remembered, err := readKnownVaults(dataDirectory)
+
+	if err != nil {
+		return err
+	}
+
+	remembered = slices.DeleteFunc(remembered, func(path string) bool { return path == vaultPath })
+	remembered = slices.Insert(remembered, 0, vaultPath)
+
+	if len(remembered) > maxKnownVaults {
+		remembered = remembered[:maxKnownVaults]
+	}
+
+	err = os.MkdirAll(dataDirectory, 0o700)
+
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dataDirectory, knownVaultsFile), []byte(strings.Join(remembered, "\n")+"\n"), 0o600)
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not save list of known vaults: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
+
+// Known vaults are stored one path per line
+func readKnownVaults(dataDirectory string) ([]string, error) {
+	file, err := os.Open(filepath.Join(dataDirectory, knownVaultsFile))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not open list of known vaults: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	defer file.Close()
+
+	remembered := []string{}
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line != "" {
+			remembered = append(remembered, line)
+		}
+	}
+
+	err = scanner.Err()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not read list of known vaults: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	return remembered, nil
+}
+
+// Vault file together with its opened database
+type Vault struct {
+	Path    string
+	Backend *server.Backend
+	Backups *Backups
+}
+
+// Opens vault file, creating it when it does not exist, and migrates it to the latest schema. Vault changed since
+// its newest backup is backed up before migration - such backup of older vault holds service names in plain text
+// until they are encrypted at unlock, see Backups. Versions of the vault are witnessed in data directory.
+func Open(vaultPath string, dataDirectory string) (*Vault, error) {
+	err := EnsureDirectory(vaultPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := server.Initialize(vaultPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	witness := versionWitness{dataDirectory: dataDirectory, vaultPath: vaultPath}
+	backend.SetVersionWitness(witness)
+
+	backups := newBackups(vaultPath, backend, witness)
+
+	// Failed backup does not keep vault from opening, it is logged and retried on next change
+	backups.TakeIfChanged(vaultPath)
+
+	err = backend.CreateStructure()
+
+	if err != nil {
+		backend.DB.Close()
+		return nil, err
+	}
+
+	return &Vault{Path: vaultPath, Backend: backend, Backups: backups}, nil
+}
+
+// Takes pending backup and closes vault database
+func (vault *Vault) Close() error {
+	vault.Backups.Flush()
+	return vault.Backend.DB.Close()
+}
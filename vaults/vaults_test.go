@@ -0,0 +1,180 @@
+package vaults
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestResolvePrefersFlagThenEnvironment(t *testing.T) {
+	dataDirectory := t.TempDir()
+	flagVault := filepath.Join(t.TempDir(), "flag.sqlite")
+	environmentVault := filepath.Join(t.TempDir(), "environment.sqlite")
+
+	tests := []struct {
+		name        string
+		flagValue   string
+		environment string
+		want        string
+	}{
+		{"flag over environment", flagVault, environmentVault, flagVault},
+		{"environment", "", environmentVault, environmentVault},
+		{"default", "", "", DefaultVaultPath(dataDirectory)},
+	}
+
+	for _, test := range tests {
+		t.Setenv(EnvironmentVariable, test.environment)
+
+		if got, err := Resolve(test.flagValue, dataDirectory); err != nil || got != test.want {
+			t.Errorf("%s: Resolve() = %q, %v, want %q", test.name, got, err, test.want)
+		}
+	}
+
+	// Relative path is resolved against working directory
+	t.Setenv(EnvironmentVariable, "")
+	workingDirectory, _ := os.Getwd()
+
+	if got, err := Resolve("vault.sqlite", dataDirectory); err != nil || got != filepath.Join(workingDirectory, "vault.sqlite") {
+		t.Errorf("Resolve() of relative path = %q, %v, want it in working directory", got, err)
+	}
+}
+
+func TestResolveExpandsHomeDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(EnvironmentVariable, "")
+
+	if got, err := Resolve("~/vaults/work.sqlite", t.TempDir()); err != nil || got != filepath.Join(home, "vaults", "work.sqlite") {
+		t.Errorf("Resolve() = %q, %v, want vault in home directory", got, err)
+	}
+
+	// Only leading ~ is home directory
+	if got, err := Resolve("/tmp/~work.sqlite", t.TempDir()); err != nil || got != filepath.Clean("/tmp/~work.sqlite") {
+		t.Errorf("Resolve() = %q, %v, want path as it is", got, err)
+	}
+}
+
+func TestDataDirectoryIgnoresRelativeXDGDataHome(t *testing.T) {
+	if !slices.Contains([]string{"linux", "freebsd", "netbsd", "openbsd", "dragonfly"}, runtime.GOOS) {
+		t.Skip("XDG_DATA_HOME is used on Linux and BSD only")
+	}
+
+	home := t.TempDir()
+	dataHome := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		xdgDataHome string
+		want        string
+	}{
+		{dataHome, filepath.Join(dataHome, "frosk")},
+		{"relative/share", filepath.Join(home, ".local", "share", "frosk")},
+		{"", filepath.Join(home, ".local", "share", "frosk")},
+	}
+
+	for _, test := range tests {
+		t.Setenv("XDG_DATA_HOME", test.xdgDataHome)
+
+		if got, err := DataDirectory(); err != nil || got != test.want {
+			t.Errorf("DataDirectory() with XDG_DATA_HOME=%q = %q, %v, want %q", test.xdgDataHome, got, err, test.want)
+		}
+	}
+}
+
+func TestPathFromName(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	dataDirectory := t.TempDir()
+	external := filepath.Join(t.TempDir(), "external")
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"work", filepath.Join(dataDirectory, "work.sqlite")},
+		{" work.sqlite ", filepath.Join(dataDirectory, "work.sqlite")},
+		{external, external},
+		{"~/work", filepath.Join(home, "work")},
+	}
+
+	for _, test := range tests {
+		if got, err := PathFromName(dataDirectory, test.name); err != nil || got != test.want {
+			t.Errorf("PathFromName(%q) = %q, %v, want %q", test.name, got, err, test.want)
+		}
+	}
+
+	if _, err := PathFromName(dataDirectory, "  "); !errors.Is(err, EmptyVaultName) {
+		t.Errorf("PathFromName() of blank name = %v, want EmptyVaultName", err)
+	}
+}
+
+func createVaultFiles(t *testing.T, paths ...string) {
+	t.Helper()
+
+	for _, path := range paths {
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("creating vault %s: %v", path, err)
+		}
+	}
+}
+
+func TestKnownListsRememberedVaultsFirst(t *testing.T) {
+	dataDirectory := t.TempDir()
+	external := filepath.Join(t.TempDir(), "external.sqlite")
+	first := filepath.Join(dataDirectory, "first.sqlite")
+	second := filepath.Join(dataDirectory, "second.sqlite")
+	createVaultFiles(t, external, first, second)
+
+	for _, vaultPath := range []string{external, second, filepath.Join(dataDirectory, "removed.sqlite")} {
+		if err := Remember(dataDirectory, vaultPath); err != nil {
+			t.Fatalf("Remember: %v", err)
+		}
+	}
+
+	known, err := Known(dataDirectory)
+
+	if err != nil {
+		t.Fatalf("Known: %v", err)
+	}
+
+	// Legacy vault is listed when it is present on the machine
+	known = slices.DeleteFunc(known, func(vaultPath string) bool { return filepath.Dir(vaultPath) == legacyLinuxDirectory })
+
+	// Removed vault is left out, vaults in data directory follow remembered ones
+	want := []string{second, external, first}
+
+	if !slices.Equal(known, want) {
+		t.Fatalf("Known() = %q, want %q", known, want)
+	}
+}
+
+func TestRememberKeepsRecentVaults(t *testing.T) {
+	dataDirectory := t.TempDir()
+
+	for i := range maxKnownVaults + 5 {
+		if err := Remember(dataDirectory, filepath.Join(dataDirectory, string(rune('a'+i))+Extension)); err != nil {
+			t.Fatalf("Remember: %v", err)
+		}
+	}
+
+	// Remembering vault again moves it to the top
+	if err := Remember(dataDirectory, filepath.Join(dataDirectory, "k"+Extension)); err != nil {
+		t.Fatalf("Remember: %v", err)
+	}
+
+	remembered, err := readKnownVaults(dataDirectory)
+
+	if err != nil {
+		t.Fatalf("readKnownVaults: %v", err)
+	}
+
+	if len(remembered) != maxKnownVaults || remembered[0] != filepath.Join(dataDirectory, "k"+Extension) || remembered[1] != filepath.Join(dataDirectory, "y"+Extension) {
+		t.Fatalf("remembered vaults = %q, want %d most recent with k first", remembered, maxKnownVaults)
+	}
+}
@@ -0,0 +1,188 @@
+package vaults
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Highest version of every vault opened by frosk is kept in data directory, outside of vault files, so older copy
+// of a vault put in place of the current one is detected at unlock. See server.VersionWitness.
+//
+// Versions are stored one vault per line as "<version> <vault path>".
+
+const (
+	versionsFile     = "versions"
+	versionsLockFile = "versions.lock"
+)
+
+// Versions file is rewritten as a whole, so windows of one process do not overwrite each other's versions. Other
+// frosk processes are kept out by lock of versionsLockFile - versions file itself is replaced on every write.
+var versionsMutex sync.Mutex
+
+// Runs read, change and write of versions file while no other window nor frosk process does the same
+func withVersionsLock(dataDirectory string, change func() error) error {
+	versionsMutex.Lock()
+	defer versionsMutex.Unlock()
+
+	err := os.MkdirAll(dataDirectory, 0o700)
+
+	var lock *os.File
+
+	if err == nil {
+		lock, err = os.OpenFile(filepath.Join(dataDirectory, versionsLockFile), os.O_RDWR|os.O_CREATE, 0o600)
+	}
+
+	if err == nil {
+		defer lock.Close()
+		err = lockFile(lock)
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not lock versions of vaults: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	defer unlockFile(lock)
+
+	return change()
+}
+
+type versionWitness struct {
+	dataDirectory string
+	vaultPath     string
+}
+
+func (witness versionWitness) LastVersion() (int64, error) {
+	versionsMutex.Lock()
+	defer versionsMutex.Unlock()
+
+	versions, err := readVersions(witness.dataDirectory)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return versions[witness.vaultPath], nil
+}
+
+func (witness versionWitness) SawVersion(version int64) error {
+	return withVersionsLock(witness.dataDirectory, func() error {
+		versions, err := readVersions(witness.dataDirectory)
+
+		if err != nil {
+			return err
+		}
+
+		if versions[witness.vaultPath] >= version {
+			return nil
+		}
+
+		versions[witness.vaultPath] = version
+
+		return writeVersions(witness.dataDirectory, versions)
+	})
+}
+
+// Sets version of the vault, also when it is lower than the last one - used when user restores backup on purpose
+func (witness versionWitness) reset(version int64) error {
+	return withVersionsLock(witness.dataDirectory, func() error {
+		versions, err := readVersions(witness.dataDirectory)
+
+		if err != nil {
+			return err
+		}
+
+		versions[witness.vaultPath] = version
+
+		return writeVersions(witness.dataDirectory, versions)
+	})
+}
+
+func readVersions(dataDirectory string) (map[string]int64, error) {
+	versions := map[string]int64{}
+
+	file, err := os.Open(filepath.Join(dataDirectory, versionsFile))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return versions, nil
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not open versions of vaults: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		versionText, vaultPath, found := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		version, err := strconv.ParseInt(versionText, 10, 64)
+
+		if !found || err != nil {
+			slog.Error("Skipping invalid line in versions of vaults.", "line", scanner.Text())
+			continue
+		}
+
+		versions[vaultPath] = version
+	}
+
+	err = scanner.Err()
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not read versions of vaults: %w", err)
+		slog.Error(errWrapped.Error())
+		return nil, errWrapped
+	}
+
+	return versions, nil
+}
+
+// Writes versions to temporary file first, so interrupted write does not lose them
+func writeVersions(dataDirectory string, versions map[string]int64) error {
+	vaultPaths := make([]string, 0, len(versions))
+
+	for vaultPath := range versions {
+		vaultPaths = append(vaultPaths, vaultPath)
+	}
+
+	slices.Sort(vaultPaths)
+
+	var content strings.Builder
+
+	for _, vaultPath := range vaultPaths {
+		fmt.Fprintf(&content, "%d %s\n", versions[vaultPath], vaultPath)
+	}
+
+	versionsPath := filepath.Join(dataDirectory, versionsFile)
+	temporaryPath := versionsPath + ".partial"
+
+	err := os.MkdirAll(dataDirectory, 0o700)
+
+	if err == nil {
+		err = os.WriteFile(temporaryPath, []byte(content.String()), 0o600)
+	}
+
+	if err == nil {
+		err = os.Rename(temporaryPath, versionsPath)
+	}
+
+	if err != nil {
+		errWrapped := fmt.Errorf("Could not save versions of vaults: %w", err)
+		slog.Error(errWrapped.Error())
+		return errWrapped
+	}
+
+	return nil
+}
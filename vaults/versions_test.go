@@ -0,0 +1,65 @@
+package vaults
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSawVersionOnlyRaisesVersion(t *testing.T) {
+	dataDirectory := t.TempDir()
+	witness := versionWitness{dataDirectory: dataDirectory, vaultPath: DefaultVaultPath(dataDirectory)}
+
+	for _, version := range []int64{3, 7, 5} {
+		if err := witness.SawVersion(version); err != nil {
+			t.Fatalf("SawVersion(%d): %v", version, err)
+		}
+	}
+
+	if version, err := witness.LastVersion(); err != nil || version != 7 {
+		t.Fatalf("LastVersion() = %d, %v, want 7", version, err)
+	}
+
+	// Restored backup brings vault back on purpose
+	if err := witness.reset(2); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	if version, err := witness.LastVersion(); err != nil || version != 2 {
+		t.Fatalf("LastVersion() after reset = %d, %v, want 2", version, err)
+	}
+}
+
+func TestSawVersionKeepsVersionsOfOtherVaults(t *testing.T) {
+	dataDirectory := t.TempDir()
+	witnesses := make([]versionWitness, 10)
+
+	for i := range witnesses {
+		witnesses[i] = versionWitness{dataDirectory: dataDirectory, vaultPath: filepath.Join(dataDirectory, fmt.Sprintf("vault%d%s", i, Extension))}
+	}
+
+	var wait sync.WaitGroup
+
+	for _, witness := range witnesses {
+		wait.Add(1)
+
+		go func() {
+			defer wait.Done()
+
+			for version := range int64(20) {
+				if err := witness.SawVersion(version + 1); err != nil {
+					t.Errorf("SawVersion: %v", err)
+				}
+			}
+		}()
+	}
+
+	wait.Wait()
+
+	for _, witness := range witnesses {
+		if version, err := witness.LastVersion(); err != nil || version != 20 {
+			t.Errorf("LastVersion() of %s = %d, %v, want 20", filepath.Base(witness.vaultPath), version, err)
+		}
+	}
+}